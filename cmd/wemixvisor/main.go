@@ -6,6 +6,7 @@ import (
 
 	"github.com/wemix/wemixvisor/internal/cli"
 	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/node"
 	"github.com/wemix/wemixvisor/pkg/logger"
 )
 
@@ -13,6 +14,15 @@ func main() {
 	// Parse command-line arguments
 	args := os.Args[1:]
 
+	// Internal helper the node is started through when process limits are
+	// configured; it replaces itself with the node
+	if len(args) > 0 && args[0] == node.ExecNodeCommand {
+		if err := node.ExecNode(args[1:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to execute node: %v\n", err)
+		}
+		os.Exit(1)
+	}
+
 	// Handle help flag
 	if len(args) > 0 && (args[0] == "--help" || args[0] == "-h" || args[0] == "help") {
 		printHelp()
@@ -55,6 +65,9 @@ func applyEnvVars(cfg *config.Config) {
 	if val := os.Getenv("DAEMON_NAME"); val != "" {
 		cfg.Name = val
 	}
+	if val := os.Getenv("DAEMON_INSTANCES_FILE"); val != "" {
+		cfg.InstancesFile = val
+	}
 
 	// Upgrade settings
 	if val := os.Getenv("DAEMON_ALLOW_DOWNLOAD_BINARIES"); val == "true" {
@@ -110,6 +123,7 @@ Phase 7 - Advanced Monitoring Commands:
 
 Wemixvisor Flags:
   --home <path>      Set the home directory (default: ~/.wemixd)
+  --instance <name>  Operate on one named node instance (multi-instance mode)
   --network <name>   Set the network (mainnet/testnet)
   --debug            Enable debug mode
   --json             Output in JSON format
@@ -307,6 +307,26 @@ func (am *AlertManager) getMetricValue(metric string, snapshot *metrics.MetricsS
 		if snapshot.Application != nil {
 			return float64(snapshot.Application.NodeHeight)
 		}
+	case "node_memory_bytes":
+		if snapshot.Process != nil {
+			return float64(snapshot.Process.RSSBytes)
+		}
+	case "node_cpu_seconds":
+		if snapshot.Process != nil {
+			return snapshot.Process.CPUSeconds
+		}
+	case "node_open_fds":
+		if snapshot.Process != nil {
+			return float64(snapshot.Process.OpenFDs)
+		}
+	case "node_fd_usage":
+		if snapshot.Process != nil {
+			return snapshot.Process.FDUsage() * 100
+		}
+	case "node_threads":
+		if snapshot.Process != nil {
+			return float64(snapshot.Process.Threads)
+		}
 	case "proposals_voting":
 		if snapshot.Governance != nil {
 			return float64(snapshot.Governance.ProposalVoting)
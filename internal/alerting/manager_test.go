@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/wemix/wemixvisor/internal/metrics"
+	"github.com/wemix/wemixvisor/internal/procstat"
 	"github.com/wemix/wemixvisor/pkg/logger"
 )
 
@@ -243,6 +244,15 @@ func TestGetMetricValue(t *testing.T) {
 		Application: &metrics.ApplicationMetrics{
 			NodeHeight: 12345,
 		},
+		Process: &metrics.ProcessMetrics{
+			Stats: procstat.Stats{
+				RSSBytes:   2 << 30,
+				CPUSeconds: 12.5,
+				OpenFDs:    512,
+				MaxFDs:     1024,
+				Threads:    40,
+			},
+		},
 		Governance: &metrics.GovernanceMetrics{
 			ProposalVoting: 5,
 		},
@@ -257,6 +267,11 @@ func TestGetMetricValue(t *testing.T) {
 		{"memory_usage", "memory_usage", 85.2},
 		{"disk_usage", "disk_usage", 60.1},
 		{"node_height", "node_height", 12345.0},
+		{"node_memory_bytes", "node_memory_bytes", float64(2 << 30)},
+		{"node_cpu_seconds", "node_cpu_seconds", 12.5},
+		{"node_open_fds", "node_open_fds", 512.0},
+		{"node_fd_usage", "node_fd_usage", 50.0},
+		{"node_threads", "node_threads", 40.0},
 		{"proposals_voting", "proposals_voting", 5.0},
 		{"unknown_metric", "unknown", 0.0},
 	}
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"sync"
@@ -12,8 +13,10 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/websocket"
 	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/control"
 	"github.com/wemix/wemixvisor/internal/governance"
 	"github.com/wemix/wemixvisor/internal/metrics"
+	"github.com/wemix/wemixvisor/internal/node"
 	"github.com/wemix/wemixvisor/pkg/logger"
 )
 
@@ -129,6 +132,10 @@ func (s *Server) setupRoutes() {
 	v1.GET("/status", s.getStatus)
 	v1.GET("/version", s.getVersion)
 
+	// Node instance routes
+	v1.GET("/instances", s.getInstances)
+	v1.GET("/instances/:name", s.getInstance)
+
 	// Protected routes (auth required)
 	// Note: Auth middleware would be added here in production
 	// v1.Use(s.auth.Authenticate())
@@ -139,6 +146,7 @@ func (s *Server) setupRoutes() {
 
 	// Upgrade routes
 	v1.GET("/upgrades", s.getUpgrades)
+	v1.GET("/upgrades/staging", s.getStaging)
 	v1.GET("/upgrades/:id", s.getUpgrade)
 	v1.POST("/upgrades", s.scheduleUpgrade)
 	v1.DELETE("/upgrades/:id", s.cancelUpgrade)
@@ -252,6 +260,68 @@ func (s *Server) getVersion(c *gin.Context) {
 	})
 }
 
+// instanceStatus returns the node status of one instance, reporting a
+// stopped node when its supervisor is not running
+func instanceStatus(cfg *config.Config) (*node.Status, error) {
+	status, err := control.NewClient(cfg).Status()
+	if errors.Is(err, control.ErrNotRunning) {
+		return &node.Status{
+			Instance:    cfg.Instance,
+			State:       node.StateStopped,
+			StateString: node.StateStopped.String(),
+			Binary:      cfg.CurrentBin(),
+		}, nil
+	}
+	return status, err
+}
+
+// getInstances returns the status of every named node instance
+func (s *Server) getInstances(c *gin.Context) {
+	instances := make([]gin.H, 0, len(s.config.Instances))
+	for _, instCfg := range s.config.InstanceConfigs() {
+		entry := gin.H{
+			"name": instCfg.Instance,
+			"home": instCfg.Home,
+		}
+		if status, err := instanceStatus(instCfg); err != nil {
+			entry["error"] = err.Error()
+		} else {
+			entry["status"] = status
+		}
+		instances = append(instances, entry)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"instances": instances,
+		"count":     len(instances),
+	})
+}
+
+// getInstance returns the status of one named node instance
+func (s *Server) getInstance(c *gin.Context) {
+	instCfg, err := s.config.ForInstance(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	status, err := instanceStatus(instCfg)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"name":   instCfg.Instance,
+		"home":   instCfg.Home,
+		"status": status,
+	})
+}
+
 // getMetrics returns current metrics
 func (s *Server) getMetrics(c *gin.Context) {
 	if s.collector == nil {
@@ -308,6 +378,27 @@ func (s *Server) getUpgrades(c *gin.Context) {
 	})
 }
 
+// getStaging returns the staging of upgrade binaries by the supervisor of
+// the configured node
+func (s *Server) getStaging(c *gin.Context) {
+	statuses, err := control.NewClient(s.config).UpgradeStaging()
+	if err != nil {
+		code := http.StatusBadGateway
+		if errors.Is(err, control.ErrNotRunning) {
+			code = http.StatusServiceUnavailable
+		}
+		c.JSON(code, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"staging": statuses,
+		"count":   len(statuses),
+	})
+}
+
 // getUpgrade returns a specific upgrade
 func (s *Server) getUpgrade(c *gin.Context) {
 	upgradeID := c.Param("id")
@@ -442,6 +533,8 @@ func (s *Server) getConfig(c *gin.Context) {
 		"home":        s.config.Home,
 		"rpc_address": s.config.RPCAddress,
 		"debug":       s.config.Debug,
+		"environment": s.config.RedactedEnvironment(),
+		"env_files":   s.config.EnvFiles,
 	}
 
 	c.JSON(http.StatusOK, config)
@@ -294,6 +294,26 @@ func TestGetUpgrades(t *testing.T) {
 	})
 }
 
+// TestGetStaging tests the staging status without a running supervisor
+func TestGetStaging(t *testing.T) {
+	// Arrange
+	server := setupTestServer(t, false, false)
+	server.config.Home = t.TempDir()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/upgrades/staging", nil)
+
+	// Act
+	server.router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+	var response map[string]interface{}
+	err := json.Unmarshal(w.Body.Bytes(), &response)
+	require.NoError(t, err)
+	assert.NotNil(t, response["error"])
+}
+
 // TestGetUpgrade tests getting a specific upgrade
 func TestGetUpgrade(t *testing.T) {
 	// Arrange
@@ -436,6 +456,35 @@ func TestGetConfig(t *testing.T) {
 	assert.NotNil(t, response["debug"])
 }
 
+// TestGetConfig_RedactsSecrets tests that secret environment values are not exposed
+func TestGetConfig_RedactsSecrets(t *testing.T) {
+	// Arrange
+	server := setupTestServer(t, false, false)
+	server.config.Environment = map[string]string{
+		"LOG_LEVEL":         "info",
+		"KEYSTORE_PASSWORD": "hunter2",
+		"NODE_SECRET":       "file:/etc/wemix/secret",
+	}
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/config", nil)
+
+	// Act
+	server.router.ServeHTTP(w, req)
+
+	// Assert
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "hunter2")
+	assert.NotContains(t, w.Body.String(), "/etc/wemix/secret")
+
+	var response struct {
+		Environment map[string]string `json:"environment"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "info", response.Environment["LOG_LEVEL"])
+	assert.Equal(t, config.RedactedValue, response.Environment["KEYSTORE_PASSWORD"])
+	assert.Equal(t, config.RedactedValue, response.Environment["NODE_SECRET"])
+}
+
 // TestUpdateConfig tests updating configuration
 func TestUpdateConfig(t *testing.T) {
 	tests := []struct {
@@ -536,3 +585,56 @@ func TestGinLogger(t *testing.T) {
 	// Assert
 	assert.Equal(t, http.StatusOK, w.Code)
 }
+
+// TestInstanceHandlers tests the named node instance endpoints
+func TestInstanceHandlers(t *testing.T) {
+	server := setupTestServer(t, false, false)
+	base := t.TempDir()
+	server.config.Home = base
+	server.config.Instances = []config.InstanceConfig{
+		{Name: "validator", Home: base + "/validator"},
+		{Name: "sentry", Home: base + "/sentry"},
+	}
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{"list instances", "/api/v1/instances", http.StatusOK},
+		{"known instance", "/api/v1/instances/sentry", http.StatusOK},
+		{"unknown instance", "/api/v1/instances/archive", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tt.path, nil)
+
+			server.router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+
+	// Instances whose supervisor is not running are reported as stopped
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v1/instances", nil)
+	server.router.ServeHTTP(w, req)
+
+	var response struct {
+		Count     int `json:"count"`
+		Instances []struct {
+			Name   string `json:"name"`
+			Status struct {
+				Instance    string `json:"instance"`
+				StateString string `json:"state_string"`
+			} `json:"status"`
+		} `json:"instances"`
+	}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Equal(t, 2, response.Count)
+	assert.Equal(t, "validator", response.Instances[0].Name)
+	assert.Equal(t, "sentry", response.Instances[1].Status.Instance)
+	assert.Equal(t, "stopped", response.Instances[1].Status.StateString)
+}
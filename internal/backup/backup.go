@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"time"
 
+	"github.com/shirou/gopsutil/v3/disk"
 	"github.com/wemix/wemixvisor/internal/config"
 	"github.com/wemix/wemixvisor/pkg/logger"
 	"go.uber.org/zap"
@@ -55,6 +56,57 @@ func (m *Manager) CreateBackup(name string) (string, error) {
 	return backupPath, nil
 }
 
+// SpaceEstimate compares the size of the data directory, an upper bound for
+// its compressed backup, with the space free in the backup directory
+type SpaceEstimate struct {
+	DataBytes uint64
+	FreeBytes uint64
+}
+
+// Sufficient reports whether a backup fits in the free space
+func (e *SpaceEstimate) Sufficient() bool {
+	return e.DataBytes <= e.FreeBytes
+}
+
+// EstimateSpace measures the data directory and the space free for backups,
+// without writing anything
+func (m *Manager) EstimateSpace() (*SpaceEstimate, error) {
+	estimate := &SpaceEstimate{}
+
+	dataDir := filepath.Join(m.cfg.Home, "data")
+	err := filepath.Walk(dataDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if filepath.HasPrefix(path, m.cfg.DataBackupPath) {
+			return nil
+		}
+		if info.Mode().IsRegular() {
+			estimate.DataBytes += uint64(info.Size())
+		}
+		return nil
+	})
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to measure data directory: %w", err)
+	}
+
+	// The backup directory is created on the first backup
+	dir := m.cfg.DataBackupPath
+	for {
+		if _, err := os.Stat(dir); err == nil || filepath.Dir(dir) == dir {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+	usage, err := disk.Usage(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get free space of %s: %w", dir, err)
+	}
+	estimate.FreeBytes = usage.Free
+
+	return estimate, nil
+}
+
 // createArchive creates a tar.gz archive of the data directory
 func (m *Manager) createArchive(destPath string) error {
 	dataDir := filepath.Join(m.cfg.Home, "data")
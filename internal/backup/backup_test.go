@@ -255,4 +255,37 @@ func TestListBackupsEmptyDir(t *testing.T) {
 	if len(backups) != 0 {
 		t.Errorf("expected empty list, got %d backups", len(backups))
 	}
-}
\ No newline at end of file
+}
+func TestEstimateSpace(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &config.Config{
+		Home:           tmpDir,
+		DataBackupPath: filepath.Join(tmpDir, "backups", "pre-upgrade"),
+	}
+	logger, _ := logger.New(false, true, "")
+	manager := NewManager(cfg, logger)
+
+	dataDir := filepath.Join(tmpDir, "data")
+	os.MkdirAll(filepath.Join(dataDir, "chaindata"), 0755)
+	os.WriteFile(filepath.Join(dataDir, "chaindata", "000001.ldb"), make([]byte, 1000), 0644)
+	os.WriteFile(filepath.Join(dataDir, "nodekey"), make([]byte, 24), 0644)
+
+	estimate, err := manager.EstimateSpace()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if estimate.DataBytes != 1024 {
+		t.Errorf("expected 1024 data bytes, got %d", estimate.DataBytes)
+	}
+	if estimate.FreeBytes == 0 {
+		t.Error("expected free space of the nearest existing backup parent")
+	}
+	if _, err := os.Stat(cfg.DataBackupPath); !os.IsNotExist(err) {
+		t.Error("expected backup directory not to be created")
+	}
+
+	estimate.FreeBytes = estimate.DataBytes - 1
+	if estimate.Sufficient() {
+		t.Error("expected a backup larger than the free space not to fit")
+	}
+}
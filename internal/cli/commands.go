@@ -4,16 +4,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
-	"os/signal"
 	"path/filepath"
 	"strconv"
 	"strings"
-	"syscall"
 	"time"
 
 	"go.uber.org/zap"
 	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/control"
 	"github.com/wemix/wemixvisor/internal/node"
+	"github.com/wemix/wemixvisor/internal/supervisor"
 	"github.com/wemix/wemixvisor/pkg/logger"
 )
 
@@ -25,16 +25,32 @@ type CommandHandler struct {
 	parser  *Parser
 }
 
-// NewCommandHandler creates a new command handler
+// NewCommandHandler creates a new command handler.
+// If a supervisor is already running, commands are forwarded to it over the
+// control socket; otherwise start and run launch a supervisor.
 func NewCommandHandler(cfg *config.Config, logger *logger.Logger) *CommandHandler {
 	return &CommandHandler{
-		config:  cfg,
-		logger:  logger,
-		parser:  NewParser(),
-		manager: node.NewManager(cfg, logger),
+		config: cfg,
+		logger: logger,
+		parser: NewParser(),
 	}
 }
 
+// connect returns a manager forwarding to the supervisor running for the
+// configured home, or an offline manager if none answers
+func (h *CommandHandler) connect() NodeManager {
+	if client := control.NewClient(h.config); client.IsAvailable() {
+		return newRemoteManager(client)
+	}
+	return &offlineManager{cfg: h.config}
+}
+
+// isOffline returns true if no supervisor is running
+func (h *CommandHandler) isOffline() bool {
+	_, ok := h.manager.(*offlineManager)
+	return ok
+}
+
 // Execute executes a CLI command
 func (h *CommandHandler) Execute(args []string) error {
 	// Parse arguments
@@ -46,6 +62,12 @@ func (h *CommandHandler) Execute(args []string) error {
 	// Apply wemixvisor options to config
 	h.applyOptions(parsed.WemixvisorOpts)
 
+	// Look for a running supervisor only once --home is applied, so that
+	// its control socket is the one used
+	if h.manager == nil {
+		h.manager = h.connect()
+	}
+
 	// Execute command
 	switch parsed.Command {
 	case "init":
@@ -74,8 +96,17 @@ func (h *CommandHandler) Execute(args []string) error {
 func (h *CommandHandler) handleStart(parsed *ParsedArgs) error {
 	h.logger.Info("starting node", zap.Strings("args", parsed.NodeArgs))
 
-	// Check if already running
-	if h.manager.GetState() == node.StateRunning {
+	// Without a running supervisor, become one or launch one in the
+	// background
+	if h.isOffline() {
+		if h.config.Daemon {
+			return startDetached(h.config, parsed.NodeArgs)
+		}
+		return supervisor.New(h.config, h.logger).Run(parsed.NodeArgs)
+	}
+
+	// Check if already running or still starting
+	if h.manager.GetState().IsActive() {
 		return fmt.Errorf("node is already running")
 	}
 
@@ -85,12 +116,6 @@ func (h *CommandHandler) handleStart(parsed *ParsedArgs) error {
 	}
 
 	h.logger.Info("node started successfully", zap.Int("pid", h.manager.GetPID()))
-
-	// If running in foreground mode, wait for signals
-	if !h.config.Daemon {
-		return h.waitForSignal()
-	}
-
 	return nil
 }
 
@@ -98,7 +123,7 @@ func (h *CommandHandler) handleStart(parsed *ParsedArgs) error {
 func (h *CommandHandler) handleStop() error {
 	h.logger.Info("stopping node")
 
-	if h.manager.GetState() != node.StateRunning {
+	if !h.manager.GetState().IsActive() {
 		return fmt.Errorf("node is not running")
 	}
 
@@ -130,10 +155,13 @@ func (h *CommandHandler) handleRestart(parsed *ParsedArgs) error {
 
 // handleStatus handles the status command
 func (h *CommandHandler) handleStatus() error {
-	status := h.manager.GetStatus()
+	return printStatus(h.manager.GetStatus(), h.config.JSONOutput)
+}
 
+// printStatus prints node status as JSON or human-readable text
+func printStatus(status *node.Status, jsonOutput bool) error {
 	// Check output format
-	if h.config.JSONOutput {
+	if jsonOutput {
 		// JSON output
 		data, err := json.MarshalIndent(status, "", "  ")
 		if err != nil {
@@ -142,20 +170,43 @@ func (h *CommandHandler) handleStatus() error {
 		fmt.Println(string(data))
 	} else {
 		// Human-readable output
+		if status.Instance != "" {
+			fmt.Printf("Instance: %s\n", status.Instance)
+		}
 		fmt.Printf("Node Status: %s\n", status.StateString)
 		if status.PID > 0 {
 			fmt.Printf("PID: %d\n", status.PID)
 			fmt.Printf("Uptime: %s\n", formatDuration(status.Uptime))
+			if status.Adopted {
+				fmt.Printf("Adopted: yes (started by a previous supervisor)\n")
+			}
 		}
 		fmt.Printf("Network: %s\n", status.Network)
 		if status.Version != "" {
 			fmt.Printf("Version: %s\n", status.Version)
 		}
 		fmt.Printf("Restart Count: %d\n", status.RestartCount)
+		if status.LastRestart != nil {
+			fmt.Printf("Last Restart Decision: %s (%s)\n", status.LastRestart.Action, status.LastRestart.Reason)
+		}
 		if status.Binary != "" {
 			fmt.Printf("Binary: %s\n", status.Binary)
 		}
 
+		// Display chain progress if the stall watchdog is running
+		if stall := status.Stall; stall != nil {
+			fmt.Printf("\nChain Height: %d", stall.Height)
+			if stall.Stalled {
+				fmt.Printf(" (stalled for %s, ~%d blocks missed)", formatDuration(stall.StallDuration), stall.MissedBlocks)
+			}
+			fmt.Printf("\nStall Watchdog: %s after %s\n", stall.Action, formatDuration(stall.Timeout))
+			if n := len(stall.History); n > 0 {
+				last := stall.History[n-1]
+				fmt.Printf("Last Stall Action: %s at height %d (%s)\n",
+					last.Action, last.Height, last.Time.Format(time.RFC3339))
+			}
+		}
+
 		// Display health status if available
 		if status.Health != nil {
 			fmt.Printf("\nHealth Status: ")
@@ -201,7 +252,7 @@ func (h *CommandHandler) handleLogs(parsed *ParsedArgs) error {
 	}
 
 	// Get log file path
-	logFile := h.config.LogFile
+	logFile := h.config.NodeLogPath()
 	if logFile == "" {
 		logFile = filepath.Join(h.config.Home, "logs", "node.log")
 	}
@@ -235,38 +286,15 @@ func (h *CommandHandler) handleVersion() error {
 	return nil
 }
 
-// handleRun handles the run command (starts and waits)
+// handleRun handles the run command, supervising the node in the
+// foreground until interrupted
 func (h *CommandHandler) handleRun(parsed *ParsedArgs) error {
-	h.logger.Info("running node in foreground")
-
-	// Start the node
-	if err := h.manager.Start(parsed.NodeArgs); err != nil {
-		return fmt.Errorf("failed to start node: %w", err)
+	if !h.isOffline() {
+		return fmt.Errorf("wemixvisor is already running for %s", h.config.Home)
 	}
 
-	h.logger.Info("node started successfully", zap.Int("pid", h.manager.GetPID()))
-
-	// Wait for signals
-	return h.waitForSignal()
-}
-
-// waitForSignal waits for interrupt signals
-func (h *CommandHandler) waitForSignal() error {
-	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
-
-	// Wait for signal
-	sig := <-sigCh
-	h.logger.Info("received signal", zap.String("signal", sig.String()))
-
-	// Stop the node
-	if h.manager.GetState() == node.StateRunning {
-		if err := h.manager.Stop(); err != nil {
-			return fmt.Errorf("failed to stop node: %w", err)
-		}
-	}
-
-	return nil
+	h.logger.Info("running node in foreground")
+	return supervisor.New(h.config, h.logger).Run(parsed.NodeArgs)
 }
 
 // applyOptions applies wemixvisor options to config
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/control"
 	"github.com/wemix/wemixvisor/internal/node"
 	"github.com/wemix/wemixvisor/pkg/logger"
 	"go.uber.org/zap"
@@ -317,4 +318,76 @@ func TestCommandHandler_RestartWithNewArgs(t *testing.T) {
 			break
 		}
 	}
-}
\ No newline at end of file
+}
+func TestCommandHandler_WithoutSupervisor(t *testing.T) {
+	testLogger := &logger.Logger{
+		Logger: zap.NewNop(),
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Home = t.TempDir()
+
+	// No supervisor listens on the control socket of an empty home
+	handler := NewCommandHandler(cfg, testLogger)
+	if err := handler.Execute([]string{"status"}); err != nil {
+		t.Errorf("status should report a stopped node: %v", err)
+	}
+	if !handler.isOffline() {
+		t.Fatalf("expected an offline manager, got %T", handler.manager)
+	}
+	if err := handler.Execute([]string{"stop"}); err == nil {
+		t.Error("stop should fail without a supervisor")
+	}
+}
+
+func TestCommandHandler_HomeSelectsSupervisor(t *testing.T) {
+	testLogger := &logger.Logger{
+		Logger: zap.NewNop(),
+	}
+
+	// A supervisor runs for another home than the default one
+	home := t.TempDir()
+	server := control.NewServer(&config.Config{Home: home}, &MockManager{state: node.StateRunning, pid: 4242}, testLogger)
+	if err := server.Start(); err != nil {
+		t.Fatalf("failed to start control server: %v", err)
+	}
+	defer server.Stop()
+
+	cfg := config.DefaultConfig()
+	cfg.Home = t.TempDir()
+
+	handler := NewCommandHandler(cfg, testLogger)
+	if err := handler.Execute([]string{"status", "--home", home}); err != nil {
+		t.Fatalf("status failed: %v", err)
+	}
+	if handler.isOffline() {
+		t.Fatal("expected the supervisor running for --home to be used")
+	}
+	if pid := handler.manager.GetPID(); pid != 4242 {
+		t.Errorf("expected PID 4242 from the supervisor, got %d", pid)
+	}
+}
+
+func TestCommandHandler_StartingNodeIsActive(t *testing.T) {
+	testLogger := &logger.Logger{
+		Logger: zap.NewNop(),
+	}
+
+	mockManager := &MockManager{state: node.StateStarting, pid: 1234}
+	handler := &CommandHandler{
+		config:  config.DefaultConfig(),
+		logger:  testLogger,
+		parser:  NewParser(),
+		manager: mockManager,
+	}
+
+	if err := handler.Execute([]string{"start"}); err == nil {
+		t.Error("start should fail while the node is still starting")
+	}
+	if err := handler.Execute([]string{"stop"}); err != nil {
+		t.Errorf("stop should stop a node that is still starting: %v", err)
+	}
+	if mockManager.state != node.StateStopped {
+		t.Errorf("expected stopped state, got %v", mockManager.state)
+	}
+}
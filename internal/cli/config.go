@@ -54,7 +54,9 @@ func NewConfigShowCommand() *cobra.Command {
 			// Display based on format
 			switch format {
 			case "json":
-				data, err := json.MarshalIndent(cfg, "", "  ")
+				shown := *cfg
+				shown.Environment = cfg.RedactedEnvironment()
+				data, err := json.MarshalIndent(&shown, "", "  ")
 				if err != nil {
 					return fmt.Errorf("failed to marshal config: %w", err)
 				}
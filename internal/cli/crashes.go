@@ -0,0 +1,193 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/crash"
+	"github.com/wemix/wemixvisor/pkg/logger"
+)
+
+// DefaultCrashOutputLines is the number of output lines shown by crashes show
+const DefaultCrashOutputLines = 50
+
+// NewCrashesCommand creates the crashes command
+func NewCrashesCommand(cfg *config.Config, logger *logger.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "crashes",
+		Short: "Inspect node crash records",
+		Long: `Inspect the forensic records written whenever the node exits unexpectedly.
+
+Records are stored under <home>/wemixvisor/crashes.`,
+	}
+
+	cmd.AddCommand(newCrashesListCommand(cfg, logger))
+	cmd.AddCommand(newCrashesShowCommand(cfg, logger))
+
+	return cmd
+}
+
+func newCrashesListCommand(cfg *config.Config, logger *logger.Logger) *cobra.Command {
+	var jsonOutput bool
+
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "List crash records",
+		Long:  `List recorded node crashes, newest first.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			records, err := crash.NewStore(cfg).List()
+			if err != nil {
+				return fmt.Errorf("failed to list crash records: %w", err)
+			}
+
+			if jsonOutput {
+				data, err := json.MarshalIndent(records, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to marshal crash records: %w", err)
+				}
+				fmt.Println(string(data))
+				return nil
+			}
+
+			if len(records) == 0 {
+				fmt.Println("No crash records found")
+				return nil
+			}
+
+			fmt.Printf("%-24s  %-20s  %-8s  %-12s  %s\n", "ID", "TIME", "EXIT", "UPTIME", "REASON")
+			for _, record := range records {
+				fmt.Printf("%-24s  %-20s  %-8s  %-12s  %s\n",
+					record.ID,
+					record.Time.Local().Format("2006-01-02 15:04:05"),
+					formatExit(record),
+					formatDuration(record.Uptime),
+					record.Reason)
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output in JSON format")
+
+	return cmd
+}
+
+func newCrashesShowCommand(cfg *config.Config, logger *logger.Logger) *cobra.Command {
+	var (
+		jsonOutput bool
+		lines      int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "show [id|latest]",
+		Short: "Show a crash record",
+		Long:  `Show the details of a crash record. Without an ID the latest record is shown.`,
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id := "latest"
+			if len(args) == 1 {
+				id = args[0]
+			}
+
+			record, err := crash.NewStore(cfg).Load(id)
+			if err != nil {
+				return err
+			}
+
+			if jsonOutput {
+				data, err := json.MarshalIndent(record, "", "  ")
+				if err != nil {
+					return fmt.Errorf("failed to marshal crash record: %w", err)
+				}
+				fmt.Println(string(data))
+				return nil
+			}
+
+			printCrashRecord(record, lines)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOutput, "json", false, "Output in JSON format")
+	cmd.Flags().IntVar(&lines, "lines", DefaultCrashOutputLines, "Number of node output lines to show (0 for all)")
+
+	return cmd
+}
+
+// printCrashRecord prints a human-readable crash record
+func printCrashRecord(record *crash.Record, lines int) {
+	fmt.Printf("Crash: %s\n", record.ID)
+	fmt.Printf("Time: %s\n", record.Time.Local().Format(time.RFC3339))
+	fmt.Printf("PID: %d\n", record.PID)
+	fmt.Printf("Exit: %s\n", formatExit(record))
+	fmt.Printf("Reason: %s\n", record.Reason)
+	fmt.Printf("Uptime: %s\n", formatDuration(record.Uptime))
+	fmt.Printf("Restart Count: %d\n", record.RestartCount)
+	fmt.Printf("Binary: %s\n", record.Binary)
+	if record.Upgrade != "" {
+		fmt.Printf("Upgrade: %s\n", record.Upgrade)
+	}
+	if record.Version != "" {
+		fmt.Printf("Version: %s\n", record.Version)
+	}
+	if len(record.Args) > 0 {
+		fmt.Printf("Args: %s\n", strings.Join(record.Args, " "))
+	}
+
+	if usage := record.Rusage; usage != nil {
+		fmt.Printf("\nResource Usage:\n")
+		fmt.Printf("  User CPU: %s\n", usage.UserTime)
+		fmt.Printf("  System CPU: %s\n", usage.SystemTime)
+		fmt.Printf("  Max RSS: %d KB\n", usage.MaxRSSKB)
+		fmt.Printf("  Page Faults: %d minor, %d major\n", usage.MinorFaults, usage.MajorFaults)
+		fmt.Printf("  Block I/O: %d in, %d out\n", usage.BlockInputs, usage.BlockOutputs)
+		fmt.Printf("  Context Switches: %d voluntary, %d involuntary\n", usage.VoluntaryCtxSw, usage.InvoluntaryCtxSw)
+	}
+
+	if health := record.Health; health != nil {
+		fmt.Printf("\nLast Health Status: ")
+		if health.Healthy {
+			fmt.Printf("Healthy\n")
+		} else {
+			fmt.Printf("Unhealthy\n")
+		}
+		for name, check := range health.Checks {
+			if check.Healthy {
+				fmt.Printf("  %s: OK\n", name)
+			} else {
+				fmt.Printf("  %s: %s\n", name, check.Error)
+			}
+		}
+	}
+
+	if snapshot := record.Metrics; snapshot != nil && snapshot.System != nil {
+		fmt.Printf("\nSystem Metrics (%s):\n", snapshot.Timestamp.Local().Format(time.RFC3339))
+		fmt.Printf("  CPU: %.1f%%\n", snapshot.System.CPUUsage)
+		fmt.Printf("  Memory: %.1f%%\n", snapshot.System.MemoryUsage)
+		fmt.Printf("  Disk: %.1f%%\n", snapshot.System.DiskUsage)
+	}
+
+	output := record.Output
+	if lines > 0 && len(output) > lines {
+		output = output[len(output)-lines:]
+	}
+	if len(output) > 0 {
+		fmt.Printf("\nLast %d lines of node output:\n", len(output))
+		for _, line := range output {
+			fmt.Println(line)
+		}
+	}
+}
+
+// formatExit describes how the process exited
+func formatExit(record *crash.Record) string {
+	if record.Signal != "" {
+		return record.Signal
+	}
+	return fmt.Sprintf("%d", record.ExitCode)
+}
@@ -0,0 +1,171 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/control"
+	"github.com/wemix/wemixvisor/internal/node"
+	"github.com/wemix/wemixvisor/pkg/logger"
+)
+
+// InstanceStatus is the status of one named node instance
+type InstanceStatus struct {
+	Name   string       `json:"name"`
+	Home   string       `json:"home"`
+	Status *node.Status `json:"status"`
+}
+
+// instanceScoped lists the commands that act on a single node and so need
+// an instance selected in multi-instance mode
+var instanceScoped = map[string]bool{
+	"stop":    true,
+	"restart": true,
+	"upgrade": true,
+	"crashes": true,
+	"backup":  true,
+}
+
+// selectInstance loads the instance definitions and, if an instance is
+// selected, narrows cfg to that instance so every command operates on it
+func selectInstance(cmd *cobra.Command, cfg *config.Config, name string) error {
+	if err := cfg.LoadInstances(); err != nil {
+		return err
+	}
+	if name == "" {
+		if cfg.IsMultiInstance() && instanceScoped[topLevelName(cmd)] {
+			return fmt.Errorf("%s needs an instance in multi-instance mode: use --instance (one of %s)",
+				topLevelName(cmd), strings.Join(cfg.InstanceNames(), ", "))
+		}
+		return nil
+	}
+
+	if !cfg.IsMultiInstance() {
+		return fmt.Errorf("instance %q selected but no instances are defined in %s",
+			name, cfg.InstancesFilePath())
+	}
+
+	instCfg, err := cfg.ForInstance(name)
+	if err != nil {
+		return err
+	}
+	*cfg = *instCfg
+	return nil
+}
+
+// topLevelName returns the name of the root subcommand cmd belongs to
+func topLevelName(cmd *cobra.Command) string {
+	for cmd.HasParent() && cmd.Parent().HasParent() {
+		cmd = cmd.Parent()
+	}
+	return cmd.Name()
+}
+
+// isGroup reports whether commands act on all instances at once, which is
+// the case in multi-instance mode when no instance is selected
+func isGroup(cfg *config.Config) bool {
+	return cfg.IsMultiInstance() && cfg.Instance == ""
+}
+
+// runningInstance returns the name of an instance whose supervisor is
+// already running, if any
+func runningInstance(cfg *config.Config) string {
+	for _, instCfg := range cfg.InstanceConfigs() {
+		if control.NewClient(instCfg).IsAvailable() {
+			return instCfg.Instance
+		}
+	}
+	return ""
+}
+
+// instanceStatuses queries the status of every instance
+func instanceStatuses(cfg *config.Config) ([]InstanceStatus, error) {
+	statuses := make([]InstanceStatus, 0, len(cfg.Instances))
+	for _, instCfg := range cfg.InstanceConfigs() {
+		status, err := control.NewClient(instCfg).Status()
+		if errors.Is(err, control.ErrNotRunning) {
+			status = &node.Status{
+				Instance:    instCfg.Instance,
+				State:       node.StateStopped,
+				StateString: node.StateStopped.String(),
+				Binary:      instCfg.CurrentBin(),
+			}
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to get status of instance %s: %w", instCfg.Instance, err)
+		}
+
+		statuses = append(statuses, InstanceStatus{
+			Name:   instCfg.Instance,
+			Home:   instCfg.Home,
+			Status: status,
+		})
+	}
+	return statuses, nil
+}
+
+// NewInstancesCommand creates the instances command
+func NewInstancesCommand(cfg *config.Config, logger *logger.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "instances",
+		Short: "List named node instances",
+		Long: `List the named node instances defined in the instances file and their state.
+
+Instances are defined in <home>/wemixvisor/instances.toml, for example:
+
+  [[instance]]
+  name = "validator"
+  home = "/data/validator"
+  args = ["--syncmode", "full"]
+
+  [[instance]]
+  name = "sentry"
+  home = "/data/sentry"
+  rpc_address = "localhost:8546"
+
+Use --instance <name> with any command to operate on a single instance.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !cfg.IsMultiInstance() {
+				return fmt.Errorf("no instances are defined in %s", cfg.InstancesFilePath())
+			}
+			return printInstances(cfg)
+		},
+	}
+
+	cmd.Flags().BoolVar(&cfg.JSONOutput, "json", cfg.JSONOutput, "Output in JSON format")
+
+	return cmd
+}
+
+// printInstances prints the state of every instance
+func printInstances(cfg *config.Config) error {
+	statuses, err := instanceStatuses(cfg)
+	if err != nil {
+		return err
+	}
+
+	if cfg.JSONOutput {
+		data, err := json.MarshalIndent(statuses, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal instances: %w", err)
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATE\tPID\tHOME")
+	for _, inst := range statuses {
+		pid := "-"
+		if inst.Status.PID > 0 {
+			pid = fmt.Sprintf("%d", inst.Status.PID)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", inst.Name, inst.Status.StateString, pid, inst.Home)
+	}
+	return w.Flush()
+}
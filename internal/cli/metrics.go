@@ -267,6 +267,19 @@ func printMetricsSnapshot(snapshot *metrics.MetricsSnapshot) {
 		fmt.Fprintf(w, "Process Restarts:\t%d\n", snapshot.Application.ProcessRestarts)
 	}
 
+	if snapshot.Process != nil {
+		fmt.Fprintf(w, "\n=== Node Process Metrics ===\n")
+		fmt.Fprintf(w, "Processes:\t%d\n", snapshot.Process.Processes)
+		fmt.Fprintf(w, "Resident Memory:\t%d MB\n", snapshot.Process.RSSBytes/(1024*1024))
+		fmt.Fprintf(w, "CPU Time:\t%.2f seconds\n", snapshot.Process.CPUSeconds)
+		fmt.Fprintf(w, "Open FDs:\t%d / %d\n", snapshot.Process.OpenFDs, snapshot.Process.MaxFDs)
+		fmt.Fprintf(w, "Threads:\t%d\n", snapshot.Process.Threads)
+		fmt.Fprintf(w, "I/O Read:\t%d MB\n", snapshot.Process.ReadBytes/(1024*1024))
+		fmt.Fprintf(w, "I/O Written:\t%d MB\n", snapshot.Process.WriteBytes/(1024*1024))
+		fmt.Fprintf(w, "Context Switches:\t%d voluntary, %d involuntary\n",
+			snapshot.Process.VoluntarySwitches, snapshot.Process.InvoluntarySwitches)
+	}
+
 	if snapshot.Governance != nil {
 		fmt.Fprintf(w, "\n=== Governance Metrics ===\n")
 		fmt.Fprintf(w, "Proposal Total:\t%d\n", snapshot.Governance.ProposalTotal)
@@ -0,0 +1,154 @@
+package cli
+
+import (
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/control"
+	"github.com/wemix/wemixvisor/internal/node"
+)
+
+// remoteManager implements NodeManager by forwarding every call to a
+// running supervisor over the control socket
+type remoteManager struct {
+	client   *control.Client
+	nodeArgs []string
+}
+
+// newRemoteManager creates a NodeManager backed by the control client
+func newRemoteManager(client *control.Client) *remoteManager {
+	return &remoteManager{client: client}
+}
+
+// Start starts the node in the running supervisor
+func (r *remoteManager) Start(args []string) error {
+	_, err := r.client.Start(args)
+	return err
+}
+
+// Stop stops the node in the running supervisor
+func (r *remoteManager) Stop() error {
+	_, err := r.client.Stop()
+	return err
+}
+
+// Restart restarts the node in the running supervisor
+func (r *remoteManager) Restart() error {
+	_, err := r.client.Restart(r.nodeArgs)
+	return err
+}
+
+// GetState returns the live node state, or StateStopped if unreachable
+func (r *remoteManager) GetState() node.NodeState {
+	return r.GetStatus().State
+}
+
+// GetStatus returns the live node status
+func (r *remoteManager) GetStatus() *node.Status {
+	status, err := r.client.Status()
+	if err != nil || status == nil {
+		return &node.Status{State: node.StateStopped, StateString: node.StateStopped.String()}
+	}
+	return status
+}
+
+// GetVersion returns the version reported by the running supervisor
+func (r *remoteManager) GetVersion() string {
+	return r.GetStatus().Version
+}
+
+// GetPID returns the PID of the supervised node
+func (r *remoteManager) GetPID() int {
+	return r.GetStatus().PID
+}
+
+// SetNodeArgs sets the arguments sent with the next restart
+func (r *remoteManager) SetNodeArgs(args []string) {
+	r.nodeArgs = args
+}
+
+// Wait is not supported remotely and returns a closed channel
+func (r *remoteManager) Wait() <-chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
+
+// IsHealthy returns true if the supervised node reports itself healthy
+func (r *remoteManager) IsHealthy() bool {
+	status := r.GetStatus()
+	if status.Health != nil {
+		return status.Health.Healthy
+	}
+	return status.State == node.StateRunning
+}
+
+// Close releases nothing; the supervisor keeps running
+func (r *remoteManager) Close() error {
+	return nil
+}
+
+// offlineManager implements NodeManager when no supervisor is running.
+// It reports the node as stopped; the node itself is only ever started
+// through a supervisor.
+type offlineManager struct {
+	cfg *config.Config
+}
+
+// Start fails; the node is started by running a supervisor
+func (o *offlineManager) Start(args []string) error {
+	return control.ErrNotRunning
+}
+
+// Stop fails, there is no supervised node to stop
+func (o *offlineManager) Stop() error {
+	return control.ErrNotRunning
+}
+
+// Restart fails, there is no supervised node to restart
+func (o *offlineManager) Restart() error {
+	return control.ErrNotRunning
+}
+
+// GetState returns StateStopped
+func (o *offlineManager) GetState() node.NodeState {
+	return node.StateStopped
+}
+
+// GetStatus returns the status of a stopped node
+func (o *offlineManager) GetStatus() *node.Status {
+	return &node.Status{
+		State:       node.StateStopped,
+		StateString: node.StateStopped.String(),
+		Network:     o.cfg.Network,
+		Binary:      o.cfg.CurrentBin(),
+	}
+}
+
+// GetVersion returns an empty version
+func (o *offlineManager) GetVersion() string {
+	return ""
+}
+
+// GetPID returns 0
+func (o *offlineManager) GetPID() int {
+	return 0
+}
+
+// SetNodeArgs does nothing
+func (o *offlineManager) SetNodeArgs(args []string) {}
+
+// Wait returns a closed channel
+func (o *offlineManager) Wait() <-chan struct{} {
+	ch := make(chan struct{})
+	close(ch)
+	return ch
+}
+
+// IsHealthy returns false
+func (o *offlineManager) IsHealthy() bool {
+	return false
+}
+
+// Close releases nothing
+func (o *offlineManager) Close() error {
+	return nil
+}
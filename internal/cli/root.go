@@ -8,6 +8,8 @@ import (
 
 // NewRootCommand creates the root command for wemixvisor
 func NewRootCommand(cfg *config.Config, logger *logger.Logger) *cobra.Command {
+	var instance string
+
 	cmd := &cobra.Command{
 		Use:   "wemixvisor",
 		Short: "WBFT Node Lifecycle Manager",
@@ -15,6 +17,9 @@ func NewRootCommand(cfg *config.Config, logger *logger.Logger) *cobra.Command {
 It manages the lifecycle of the node binary, handling upgrades seamlessly.`,
 		SilenceUsage:  true,
 		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return selectInstance(cmd, cfg, instance)
+		},
 	}
 
 	// Add global flags
@@ -23,6 +28,8 @@ It manages the lifecycle of the node binary, handling upgrades seamlessly.`,
 	cmd.PersistentFlags().BoolVar(&cfg.RestartAfterUpgrade, "restart-after-upgrade", cfg.RestartAfterUpgrade, "Restart after upgrade")
 	cmd.PersistentFlags().BoolVar(&cfg.AllowDownloadBinaries, "allow-download-binaries", cfg.AllowDownloadBinaries, "Allow automatic binary downloads")
 	cmd.PersistentFlags().BoolVar(&cfg.UnsafeSkipBackup, "unsafe-skip-backup", cfg.UnsafeSkipBackup, "Skip backup during upgrade")
+	cmd.PersistentFlags().StringVar(&instance, "instance", "", "Named node instance to operate on (multi-instance mode)")
+	cmd.PersistentFlags().StringVar(&cfg.InstancesFile, "instances-file", cfg.InstancesFile, "File defining named node instances (default <home>/wemixvisor/instances.toml)")
 
 	// Add subcommands
 	cmd.AddCommand(NewStartCommand(cfg, logger))
@@ -34,6 +41,8 @@ It manages the lifecycle of the node binary, handling upgrades seamlessly.`,
 	cmd.AddCommand(NewStatusCommand(cfg, logger))
 	cmd.AddCommand(NewStopCommand(cfg, logger))
 	cmd.AddCommand(NewRestartCommand(cfg, logger))
+	cmd.AddCommand(NewCrashesCommand(cfg, logger))
+	cmd.AddCommand(NewInstancesCommand(cfg, logger))
 
 	// Phase 7: Advanced monitoring and management commands
 	cmd.AddCommand(NewAPICommand(cfg, logger))
@@ -2,37 +2,70 @@ package cli
 
 import (
 	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/control"
+	"github.com/wemix/wemixvisor/internal/node"
+	"github.com/wemix/wemixvisor/internal/supervisor"
 	"github.com/wemix/wemixvisor/pkg/logger"
 )
 
+// Default values for daemonized start
+const (
+	DaemonStartupTimeout  = 10 * time.Second
+	DaemonStartupInterval = 200 * time.Millisecond
+)
+
 // NewStartCommand creates the start command
 func NewStartCommand(cfg *config.Config, logger *logger.Logger) *cobra.Command {
-	var daemon bool
+	var daemonMode bool
 
 	cmd := &cobra.Command{
 		Use:   "start [flags] [node-args]",
 		Short: "Start the node",
-		Long:  `Start the managed node process.`,
+		Long: `Start the managed node process.
+
+If a wemixvisor supervisor is already running for this home directory, the
+node is started inside it. Otherwise a new supervisor is started, either in
+the foreground or, with --daemon, in the background.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			logger.Info("Starting node...")
+			if isGroup(cfg) {
+				if err := checkGroupStart(cfg, args); err != nil {
+					return err
+				}
+				if daemonMode {
+					return startDetached(cfg, args)
+				}
+				logger.Info("Starting node instances...")
+				return supervisor.NewGroup(cfg, logger).Run()
+			}
 
-			// TODO: Implement actual start logic
-			fmt.Printf("Starting node at %s\n", cfg.CurrentBin())
-			fmt.Printf("Home: %s\n", cfg.Home)
-			fmt.Printf("Args: %v\n", args)
+			client := control.NewClient(cfg)
+			if client.IsAvailable() {
+				status, err := client.Start(args)
+				if err != nil {
+					return fmt.Errorf("failed to start node: %w", err)
+				}
+				fmt.Printf("Node started (PID: %d)\n", status.PID)
+				return nil
+			}
 
-			if daemon {
-				fmt.Println("Running in daemon mode")
+			if daemonMode {
+				return startDetached(cfg, args)
 			}
 
-			return nil
+			logger.Info("Starting node...")
+			return supervisor.New(cfg, logger).Run(args)
 		},
 	}
 
-	cmd.Flags().BoolVar(&daemon, "daemon", false, "Run in background")
+	cmd.Flags().BoolVar(&daemonMode, "daemon", false, "Run in background")
 
 	return cmd
 }
@@ -44,16 +77,98 @@ func NewRunCommand(cfg *config.Config, logger *logger.Logger) *cobra.Command {
 		Short: "Start node in foreground",
 		Long:  `Start the managed node process in foreground mode.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			logger.Info("Running node in foreground...")
+			if isGroup(cfg) {
+				if err := checkGroupStart(cfg, args); err != nil {
+					return err
+				}
+				logger.Info("Running node instances in foreground...")
+				return supervisor.NewGroup(cfg, logger).Run()
+			}
 
-			// TODO: Implement actual run logic
-			fmt.Printf("Running node at %s\n", cfg.CurrentBin())
-			fmt.Printf("Home: %s\n", cfg.Home)
-			fmt.Printf("Args: %v\n", args)
+			if control.NewClient(cfg).IsAvailable() {
+				return fmt.Errorf("wemixvisor is already running for %s", cfg.Home)
+			}
 
-			return nil
+			logger.Info("Running node in foreground...")
+			return supervisor.New(cfg, logger).Run(args)
 		},
 	}
 
 	return cmd
-}
\ No newline at end of file
+}
+
+// checkGroupStart verifies that all instances can be started together
+func checkGroupStart(cfg *config.Config, args []string) error {
+	if len(args) > 0 {
+		return fmt.Errorf("node arguments apply to a single instance: set args in %s or select one with --instance",
+			cfg.InstancesFilePath())
+	}
+	if name := runningInstance(cfg); name != "" {
+		return fmt.Errorf("wemixvisor is already running for instance %s", name)
+	}
+	return nil
+}
+
+// startDetached re-executes wemixvisor in a new session and waits until its
+// control sockets answer
+func startDetached(cfg *config.Config, args []string) error {
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate wemixvisor executable: %w", err)
+	}
+
+	cmdArgs := []string{"start", "--home", cfg.Home, "--name", cfg.Name}
+	if cfg.IsMultiInstance() || cfg.Instance != "" {
+		cmdArgs = append(cmdArgs, "--instances-file", cfg.InstancesFilePath())
+	}
+	if cfg.Instance != "" {
+		cmdArgs = append(cmdArgs, "--instance", cfg.Instance)
+	}
+	cmdArgs = append(append(cmdArgs, "--"), args...)
+
+	child := exec.Command(self, cmdArgs...)
+	child.Env = os.Environ()
+	child.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	if err := child.Start(); err != nil {
+		return fmt.Errorf("failed to start background supervisor: %w", err)
+	}
+	child.Process.Release()
+
+	clients := []*control.Client{control.NewClient(cfg)}
+	if isGroup(cfg) {
+		clients = clients[:0]
+		for _, instCfg := range cfg.InstanceConfigs() {
+			clients = append(clients, control.NewClient(instCfg))
+		}
+	}
+
+	deadline := time.Now().Add(DaemonStartupTimeout)
+	for time.Now().Before(deadline) {
+		if status, ok := allAnswer(clients); ok {
+			if isGroup(cfg) {
+				fmt.Printf("wemixvisor started in background (instances: %s)\n",
+					strings.Join(cfg.InstanceNames(), ", "))
+			} else {
+				fmt.Printf("wemixvisor started in background (node PID: %d)\n", status.PID)
+			}
+			return nil
+		}
+		time.Sleep(DaemonStartupInterval)
+	}
+
+	return fmt.Errorf("background supervisor did not become ready within %v", DaemonStartupTimeout)
+}
+
+// allAnswer reports whether every client's supervisor answers, returning
+// the last status received
+func allAnswer(clients []*control.Client) (*node.Status, bool) {
+	var status *node.Status
+	for _, client := range clients {
+		var err error
+		if status, err = client.Status(); err != nil {
+			return nil, false
+		}
+	}
+	return status, true
+}
@@ -1,11 +1,14 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
 	"github.com/spf13/cobra"
 	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/control"
+	"github.com/wemix/wemixvisor/internal/node"
 	"github.com/wemix/wemixvisor/pkg/logger"
 )
 
@@ -16,40 +19,43 @@ func NewStatusCommand(cfg *config.Config, logger *logger.Logger) *cobra.Command
 		Short: "Show node status",
 		Long:  `Display the current status of the managed node process.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			logger.Info("Checking node status...")
+			logger.Debug("Checking node status...")
 
-			// Check if process is running
-			pidFile := fmt.Sprintf("%s/wemixvisor.pid", cfg.Home)
-			if _, err := os.Stat(pidFile); err != nil {
-				if os.IsNotExist(err) {
-					fmt.Println("Node is not running")
-					return nil
-				}
-				return fmt.Errorf("failed to check status: %w", err)
+			if isGroup(cfg) {
+				return printInstances(cfg)
 			}
 
-			// Read PID from file
-			data, err := os.ReadFile(pidFile)
-			if err != nil {
-				return fmt.Errorf("failed to read PID file: %w", err)
+			status, err := control.NewClient(cfg).Status()
+			if errors.Is(err, control.ErrNotRunning) {
+				status = &node.Status{
+					State:       node.StateStopped,
+					StateString: node.StateStopped.String(),
+					Network:     cfg.Network,
+					Binary:      cfg.CurrentBin(),
+				}
+			} else if err != nil {
+				return fmt.Errorf("failed to get status: %w", err)
 			}
 
-			fmt.Printf("Node is running (PID: %s)\n", string(data))
-			fmt.Printf("Binary: %s\n", cfg.CurrentBin())
-			fmt.Printf("Home: %s\n", cfg.Home)
+			if err := printStatus(status, cfg.JSONOutput); err != nil {
+				return err
+			}
 
-			// Check for pending upgrades
-			upgradeInfo := cfg.UpgradeInfoFilePath()
-			if _, err := os.Stat(upgradeInfo); err == nil {
-				fmt.Println("Upgrade pending: Yes")
-			} else {
-				fmt.Println("Upgrade pending: No")
+			if !cfg.JSONOutput {
+				// Check for pending upgrades
+				if _, err := os.Stat(cfg.UpgradeInfoFilePath()); err == nil {
+					fmt.Println("Upgrade pending: Yes")
+				} else {
+					fmt.Println("Upgrade pending: No")
+				}
 			}
 
 			return nil
 		},
 	}
 
+	cmd.Flags().BoolVar(&cfg.JSONOutput, "json", cfg.JSONOutput, "Output in JSON format")
+
 	return cmd
 }
 
@@ -62,18 +68,15 @@ func NewStopCommand(cfg *config.Config, logger *logger.Logger) *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			logger.Info("Stopping node...")
 
-			// Check if process is running
-			pidFile := fmt.Sprintf("%s/wemixvisor.pid", cfg.Home)
-			if _, err := os.Stat(pidFile); err != nil {
-				if os.IsNotExist(err) {
+			if _, err := control.NewClient(cfg).Stop(); err != nil {
+				if errors.Is(err, control.ErrNotRunning) {
 					fmt.Println("Node is not running")
 					return nil
 				}
-				return fmt.Errorf("failed to check status: %w", err)
+				return fmt.Errorf("failed to stop node: %w", err)
 			}
 
-			// TODO: Implement actual stop logic
-			fmt.Println("Node stop command is not yet implemented")
+			fmt.Println("Node stopped")
 			return nil
 		},
 	}
@@ -84,17 +87,23 @@ func NewStopCommand(cfg *config.Config, logger *logger.Logger) *cobra.Command {
 // NewRestartCommand creates the restart command
 func NewRestartCommand(cfg *config.Config, logger *logger.Logger) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "restart",
+		Use:   "restart [node-args]",
 		Short: "Restart the node",
-		Long:  `Restart the managed node process.`,
+		Long: `Restart the managed node process.
+
+If node arguments are given, they replace the arguments of the running node.`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			logger.Info("Restarting node...")
 
-			// TODO: Implement actual restart logic
-			fmt.Println("Node restart command is not yet implemented")
+			status, err := control.NewClient(cfg).Restart(args)
+			if err != nil {
+				return fmt.Errorf("failed to restart node: %w", err)
+			}
+
+			fmt.Printf("Node restarted (PID: %d)\n", status.PID)
 			return nil
 		},
 	}
 
 	return cmd
-}
\ No newline at end of file
+}
@@ -6,9 +6,15 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/control"
+	"github.com/wemix/wemixvisor/internal/orchestrator"
+	"github.com/wemix/wemixvisor/internal/staging"
+	"github.com/wemix/wemixvisor/internal/state"
+	"github.com/wemix/wemixvisor/internal/supervisor"
 	"github.com/wemix/wemixvisor/pkg/logger"
 	"github.com/wemix/wemixvisor/pkg/types"
 )
@@ -25,6 +31,9 @@ func NewUpgradeCommand(cfg *config.Config, log *logger.Logger) *cobra.Command {
 	cmd.AddCommand(newScheduleCommand(cfg, log))
 	cmd.AddCommand(newUpgradeStatusCommand(cfg, log))
 	cmd.AddCommand(newCancelCommand(cfg, log))
+	cmd.AddCommand(newRollbackCommand(cfg, log))
+	cmd.AddCommand(newRehearseCommand(cfg, log))
+	cmd.AddCommand(newStagingCommand(cfg, log))
 
 	return cmd
 }
@@ -32,42 +41,62 @@ func NewUpgradeCommand(cfg *config.Config, log *logger.Logger) *cobra.Command {
 // newScheduleCommand creates the schedule subcommand
 func newScheduleCommand(cfg *config.Config, log *logger.Logger) *cobra.Command {
 	var (
-		binaries string
-		checksum string
-		info     string
+		binaries   string
+		checksum   string
+		expected   string
+		info       string
+		activateAt string
+		blockTime  bool
 	)
 
 	cmd := &cobra.Command{
-		Use:   "schedule <name> <height>",
-		Short: "Schedule an upgrade at a specific block height",
-		Long: `Schedule an upgrade to be executed at a specific block height.
+		Use:   "schedule <name> [height]",
+		Short: "Schedule an upgrade at a block height or time",
+		Long: `Schedule an upgrade to be executed at a block height, a time, or
+whichever of the two is reached first.
 
 The upgrade will be triggered automatically when the blockchain reaches
-the specified height. The upgrade name should match the directory name
-under the upgrades folder.
+the specified height or the --time passes. By default the time is read
+from the host clock; with --block-time it is compared with the timestamp
+of the latest block instead, so that every node upgrades on the same
+block. The upgrade name should match the directory name under the
+upgrades folder.
 
 Examples:
   # Schedule upgrade "v1.2.0" at height 1000000
   wemixvisor upgrade schedule v1.2.0 1000000
 
+  # Schedule upgrade "v1.2.0" at a time on the host clock
+  wemixvisor upgrade schedule v1.2.0 --time 2026-11-01T12:00:00Z
+
+  # Schedule upgrade "v1.2.0" at the first block at or after a time
+  wemixvisor upgrade schedule v1.2.0 --time 2026-11-01T12:00:00Z --block-time
+
   # Schedule with binary download URLs
   wemixvisor upgrade schedule v1.2.0 1000000 --binaries '{"linux/amd64":"https://..."}'
 
   # Schedule with checksum verification
-  wemixvisor upgrade schedule v1.2.0 1000000 --checksum abc123...`,
-		Args: cobra.ExactArgs(2),
+  wemixvisor upgrade schedule v1.2.0 1000000 --checksum abc123...
+
+  # Schedule with the version the upgrade binary must report
+  wemixvisor upgrade schedule v1.2.0 1000000 --expect-version 1.2.0`,
+		Args: cobra.RangeArgs(1, 2),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			name := args[0]
-			heightStr := args[1]
 
 			// Parse height
-			height, err := strconv.ParseInt(heightStr, 10, 64)
-			if err != nil {
-				return fmt.Errorf("invalid height '%s': must be a positive integer", heightStr)
-			}
+			var height int64
+			if len(args) > 1 {
+				heightStr := args[1]
+				var err error
+				height, err = strconv.ParseInt(heightStr, 10, 64)
+				if err != nil {
+					return fmt.Errorf("invalid height '%s': must be a positive integer", heightStr)
+				}
 
-			if height <= 0 {
-				return fmt.Errorf("height must be positive, got %d", height)
+				if height <= 0 {
+					return fmt.Errorf("height must be positive, got %d", height)
+				}
 			}
 
 			// Create upgrade info
@@ -77,6 +106,25 @@ Examples:
 				Info:   make(map[string]interface{}),
 			}
 
+			// Parse activation time
+			if activateAt != "" {
+				t, err := time.Parse(time.RFC3339, activateAt)
+				if err != nil {
+					return fmt.Errorf("invalid time '%s': must be RFC3339, e.g. 2026-11-01T12:00:00Z", activateAt)
+				}
+				if blockTime {
+					upgradeInfo.BlockTime = &t
+				} else {
+					upgradeInfo.Time = &t
+				}
+			} else if blockTime {
+				return fmt.Errorf("--block-time requires --time")
+			}
+
+			if height == 0 && activateAt == "" {
+				return fmt.Errorf("a height or --time is required")
+			}
+
 			// Add optional metadata
 			if binaries != "" {
 				var binMap map[string]string
@@ -90,6 +138,10 @@ Examples:
 				upgradeInfo.Info["checksum"] = checksum
 			}
 
+			if expected != "" {
+				upgradeInfo.Info["version"] = expected
+			}
+
 			if info != "" {
 				upgradeInfo.Info["description"] = info
 			}
@@ -109,19 +161,26 @@ Examples:
 
 			log.Info("upgrade scheduled successfully",
 				"name", name,
-				"height", height,
+				"condition", upgradeInfo.Condition(),
 				"file", upgradeInfoPath)
 
+			// Hand the plan to a running supervisor right away
+			if client := control.NewClient(cfg); client.IsAvailable() {
+				if _, err := client.ScheduleUpgrade(upgradeInfo); err != nil {
+					log.Warn("running supervisor did not accept upgrade", "error", err)
+				}
+			}
+
 			// Print confirmation
 			if cfg.JSONOutput {
 				output, _ := json.MarshalIndent(upgradeInfo, "", "  ")
 				fmt.Println(string(output))
 			} else {
 				fmt.Printf("✓ Upgrade scheduled successfully\n")
-				fmt.Printf("  Name:   %s\n", name)
-				fmt.Printf("  Height: %d\n", height)
-				fmt.Printf("  File:   %s\n", upgradeInfoPath)
-				fmt.Printf("\nThe upgrade will be triggered automatically when the blockchain reaches height %d.\n", height)
+				fmt.Printf("  Name:      %s\n", name)
+				fmt.Printf("  Activates: %s\n", upgradeInfo.Condition())
+				fmt.Printf("  File:      %s\n", upgradeInfoPath)
+				fmt.Printf("\nThe upgrade will be triggered automatically at %s.\n", upgradeInfo.Condition())
 			}
 
 			return nil
@@ -130,7 +189,10 @@ Examples:
 
 	cmd.Flags().StringVar(&binaries, "binaries", "", "Binary download URLs (JSON format)")
 	cmd.Flags().StringVar(&checksum, "checksum", "", "Binary checksum for verification")
+	cmd.Flags().StringVar(&expected, "expect-version", "", "Version the upgrade binary must report")
 	cmd.Flags().StringVar(&info, "info", "", "Additional upgrade information")
+	cmd.Flags().StringVar(&activateAt, "time", "", "Activation time (RFC3339)")
+	cmd.Flags().BoolVar(&blockTime, "block-time", false, "Compare --time with the latest block timestamp instead of the host clock")
 
 	return cmd
 }
@@ -164,17 +226,23 @@ func newUpgradeStatusCommand(cfg *config.Config, log *logger.Logger) *cobra.Comm
 				return fmt.Errorf("failed to read upgrade info: %w", err)
 			}
 
+			// Query the running supervisor for live progress
+			liveStatus, _ := control.NewClient(cfg).UpgradeStatus()
+
 			if cfg.JSONOutput {
 				output := map[string]interface{}{
 					"status":  "scheduled",
 					"upgrade": upgradeInfo,
 				}
+				if liveStatus != nil {
+					output["live"] = liveStatus
+				}
 				data, _ := json.MarshalIndent(output, "", "  ")
 				fmt.Println(string(data))
 			} else {
 				fmt.Printf("Upgrade Status: SCHEDULED\n\n")
-				fmt.Printf("  Name:   %s\n", upgradeInfo.Name)
-				fmt.Printf("  Height: %d\n", upgradeInfo.Height)
+				fmt.Printf("  Name:      %s\n", upgradeInfo.Name)
+				fmt.Printf("  Activates: %s\n", upgradeInfo.Condition())
 
 				if len(upgradeInfo.Info) > 0 {
 					fmt.Printf("\nAdditional Info:\n")
@@ -183,7 +251,14 @@ func newUpgradeStatusCommand(cfg *config.Config, log *logger.Logger) *cobra.Comm
 					}
 				}
 
-				fmt.Printf("\nThe upgrade will trigger automatically when the blockchain reaches height %d.\n", upgradeInfo.Height)
+				if liveStatus != nil {
+					fmt.Printf("\nSupervisor:\n")
+					fmt.Printf("  Current Height: %d\n", liveStatus.CurrentHeight)
+					fmt.Printf("  Node State:     %s\n", liveStatus.NodeState)
+					fmt.Printf("  Upgrading:      %t\n", liveStatus.Upgrading)
+				}
+
+				fmt.Printf("\nThe upgrade will trigger automatically at %s.\n", upgradeInfo.Condition())
 			}
 
 			return nil
@@ -227,7 +302,7 @@ func newCancelCommand(cfg *config.Config, log *logger.Logger) *cobra.Command {
 
 			// Confirm cancellation
 			if !force && !cfg.Quiet {
-				fmt.Printf("Cancel upgrade '%s' at height %d? (y/N): ", upgradeInfo.Name, upgradeInfo.Height)
+				fmt.Printf("Cancel upgrade '%s' at %s? (y/N): ", upgradeInfo.Name, upgradeInfo.Condition())
 				var response string
 				fmt.Scanln(&response)
 				if response != "y" && response != "Y" && response != "yes" {
@@ -243,7 +318,7 @@ func newCancelCommand(cfg *config.Config, log *logger.Logger) *cobra.Command {
 
 			log.Info("upgrade cancelled",
 				"name", upgradeInfo.Name,
-				"height", upgradeInfo.Height)
+				"condition", upgradeInfo.Condition())
 
 			if cfg.JSONOutput {
 				output := map[string]interface{}{
@@ -254,15 +329,245 @@ func newCancelCommand(cfg *config.Config, log *logger.Logger) *cobra.Command {
 				fmt.Println(string(data))
 			} else {
 				fmt.Printf("✓ Upgrade cancelled successfully\n")
-				fmt.Printf("  Name:   %s\n", upgradeInfo.Name)
-				fmt.Printf("  Height: %d\n", upgradeInfo.Height)
+				fmt.Printf("  Name:      %s\n", upgradeInfo.Name)
+				fmt.Printf("  Activates: %s\n", upgradeInfo.Condition())
+			}
+
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVarP(&force, "force", "f", false, "Skip confirmation prompt")
+
+	return cmd
+}
+
+// newRollbackCommand creates the rollback subcommand
+func newRollbackCommand(cfg *config.Config, log *logger.Logger) *cobra.Command {
+	var (
+		to    string
+		force bool
+	)
+
+	cmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "Roll the node back to an earlier binary",
+		Long: `Roll the node back to the last known-good upgrade in the upgrade history,
+or to the upgrade given with --to.
+
+Upgrades applied after the rollback target are marked as rolled back in the
+history. The target binary must still match the hash recorded when it was
+applied. Use --to genesis to return to the genesis binary.
+
+If a supervisor is running, it stops the node, switches the binary and starts
+the node again. Otherwise only the current symlink is switched.
+
+Examples:
+  # Roll back to the last known-good upgrade
+  wemixvisor upgrade rollback
+
+  # Roll back to a specific upgrade
+  wemixvisor upgrade rollback --to v1.1.0`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			target := to
+			if target == "" {
+				target = "the last known-good upgrade"
+			}
+
+			// Confirm rollback
+			if !force && !cfg.Quiet {
+				fmt.Printf("Roll the node back to %s? (y/N): ", target)
+				var response string
+				fmt.Scanln(&response)
+				if response != "y" && response != "Y" && response != "yes" {
+					fmt.Println("Rollback aborted")
+					return nil
+				}
+			}
+
+			var (
+				result *orchestrator.RollbackResult
+				err    error
+			)
+			live := false
+			if client := control.NewClient(cfg); client.IsAvailable() {
+				live = true
+				result, err = client.RollbackUpgrade(to)
+			} else {
+				result, err = orchestrator.RollbackBinary(cfg, state.NewStore(cfg), log, to)
+			}
+			if err != nil {
+				return fmt.Errorf("rollback failed: %w", err)
+			}
+
+			log.Info("upgrade rolled back",
+				"from", result.From,
+				"to", result.To)
+
+			if cfg.JSONOutput {
+				output := map[string]interface{}{
+					"status":   "rolled_back",
+					"rollback": result,
+					"live":     live,
+				}
+				data, _ := json.MarshalIndent(output, "", "  ")
+				fmt.Println(string(data))
+			} else {
+				fmt.Printf("✓ Rolled back successfully\n")
+				fmt.Printf("  From: %s\n", result.From)
+				fmt.Printf("  To:   %s\n", result.To)
+				if !live {
+					fmt.Printf("\nNo supervisor is running; the node will use %s when it is next started.\n", result.To)
+				}
 			}
 
 			return nil
 		},
 	}
 
+	cmd.Flags().StringVar(&to, "to", "", "Upgrade to roll back to (\"genesis\" for the genesis binary)")
 	cmd.Flags().BoolVarP(&force, "force", "f", false, "Skip confirmation prompt")
 
 	return cmd
 }
+
+// newRehearseCommand creates the rehearse subcommand
+func newRehearseCommand(cfg *config.Config, log *logger.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rehearse <name>",
+		Short: "Dry-run an upgrade without touching the node",
+		Long: `Walk through the steps of an upgrade and report which of them would fail,
+without stopping the node or changing its home.
+
+The upgrade binary is resolved, downloading it into a scratch directory if it
+is not installed, and verified against its checksum. The pre-upgrade hook runs
+with DAEMON_HOME pointing at a scratch directory and UPGRADE_DRY_RUN=true. The
+free space for the pre-upgrade backup is checked, and the symlink switch is
+tried on a scratch link.
+
+If the upgrade is scheduled in upgrade-info.json, its height is validated
+against the height of the running supervisor.
+
+Examples:
+  # Rehearse the upgrade to v1.2.0
+  wemixvisor upgrade rehearse v1.2.0`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			// Rehearse the scheduled plan if it is for this upgrade
+			upgradeInfo := &types.UpgradeInfo{Name: name}
+			if scheduled, err := types.ParseUpgradeInfoFile(cfg.UpgradeInfoFilePath()); err == nil && scheduled.Name == name {
+				upgradeInfo = scheduled
+			}
+
+			var currentHeight int64
+			if status, err := control.NewClient(cfg).UpgradeStatus(); err == nil && status != nil {
+				currentHeight = status.CurrentHeight
+			}
+
+			rehearsal, err := supervisor.NewUpgrader(cfg, log).Rehearse(upgradeInfo, currentHeight)
+			if err != nil {
+				return fmt.Errorf("rehearsal failed: %w", err)
+			}
+
+			if cfg.JSONOutput {
+				output := map[string]interface{}{
+					"rehearsal": rehearsal,
+					"failed":    rehearsal.Failed(),
+				}
+				data, _ := json.MarshalIndent(output, "", "  ")
+				fmt.Println(string(data))
+			} else {
+				fmt.Printf("Upgrade Rehearsal: %s\n\n", name)
+				for i, step := range rehearsal.Steps {
+					mark := "✓"
+					switch step.Status {
+					case supervisor.RehearsalFailed:
+						mark = "✗"
+					case supervisor.RehearsalSkipped:
+						mark = "-"
+					}
+					fmt.Printf("  %d. %s %s: %s\n", i+1, mark, step.Name, step.Detail)
+				}
+			}
+
+			if rehearsal.Failed() {
+				return fmt.Errorf("upgrade %s would fail", name)
+			}
+			if !cfg.JSONOutput {
+				fmt.Printf("\nAll steps would succeed.\n")
+			}
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+// newStagingCommand creates the upgrade staging command
+func newStagingCommand(cfg *config.Config, log *logger.Logger) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "staging",
+		Short: "Show the staging of upgrade binaries",
+		Long: `Show the upgrade binaries the running supervisor has staged ahead of their
+upgrade.
+
+As soon as an upgrade is scheduled, or appears in a batch plan, its binary is
+downloaded if it is not installed, verified against its checksum and run once
+for its version. An alert is raised for an upgrade whose binary is not staged
+DAEMON_STAGING_ALERT_BLOCKS blocks before its height.
+
+Examples:
+  # Show staging status
+  wemixvisor upgrade staging`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			statuses, err := control.NewClient(cfg).UpgradeStaging()
+			if err != nil {
+				return fmt.Errorf("failed to get staging status: %w", err)
+			}
+
+			if cfg.JSONOutput {
+				output := map[string]interface{}{
+					"staging": statuses,
+				}
+				data, _ := json.MarshalIndent(output, "", "  ")
+				fmt.Println(string(data))
+				return nil
+			}
+
+			if len(statuses) == 0 {
+				fmt.Println("No upgrade binaries staged")
+				return nil
+			}
+
+			fmt.Printf("Upgrade Staging:\n\n")
+			for _, st := range statuses {
+				mark := "…"
+				switch st.State {
+				case staging.StateStaged:
+					mark = "✓"
+				case staging.StateFailed:
+					mark = "✗"
+				}
+
+				fmt.Printf("  %s %s", mark, st.Name)
+				if st.Height > 0 {
+					fmt.Printf(" (height %d)", st.Height)
+				}
+				fmt.Printf(": %s\n", st.State)
+				if st.Version != "" {
+					fmt.Printf("      Version: %s\n", st.Version)
+				}
+				if st.Error != "" {
+					fmt.Printf("      Error:   %s\n", st.Error)
+				}
+			}
+			return nil
+		},
+	}
+
+	return cmd
+}
@@ -4,10 +4,12 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/state"
 	"github.com/wemix/wemixvisor/pkg/logger"
 	"github.com/wemix/wemixvisor/pkg/types"
 )
@@ -125,6 +127,107 @@ func TestScheduleCommand_NegativeHeight(t *testing.T) {
 	assert.Contains(t, err.Error(), "must be positive")
 }
 
+func TestScheduleCommand_WithTime(t *testing.T) {
+	tests := []struct {
+		name          string
+		args          []string
+		wantTime      bool
+		wantBlockTime bool
+	}{
+		{
+			name:     "host clock",
+			args:     []string{"v1.2.0", "--time", "2026-11-01T12:00:00Z"},
+			wantTime: true,
+		},
+		{
+			name:          "block time with height",
+			args:          []string{"v1.2.0", "1000000", "--time", "2026-11-01T12:00:00Z", "--block-time"},
+			wantBlockTime: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			cfg := &config.Config{
+				Home: t.TempDir(),
+				Name: "wemixd",
+			}
+
+			log, err := logger.New(false, false, "")
+			require.NoError(t, err)
+
+			cmd := newScheduleCommand(cfg, log)
+
+			// Act
+			cmd.SetArgs(tt.args)
+			err = cmd.Execute()
+
+			// Assert
+			require.NoError(t, err)
+
+			upgradeInfo, err := types.ParseUpgradeInfoFile(cfg.UpgradeInfoFilePath())
+			require.NoError(t, err)
+			activation := time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC)
+			if tt.wantTime {
+				require.NotNil(t, upgradeInfo.Time)
+				assert.True(t, activation.Equal(*upgradeInfo.Time))
+			} else {
+				assert.Nil(t, upgradeInfo.Time)
+			}
+			if tt.wantBlockTime {
+				require.NotNil(t, upgradeInfo.BlockTime)
+				assert.True(t, activation.Equal(*upgradeInfo.BlockTime))
+			} else {
+				assert.Nil(t, upgradeInfo.BlockTime)
+			}
+		})
+	}
+}
+
+func TestScheduleCommand_RequiresHeightOrTime(t *testing.T) {
+	// Arrange
+	cfg := &config.Config{
+		Home: t.TempDir(),
+		Name: "wemixd",
+	}
+
+	log, err := logger.New(false, false, "")
+	require.NoError(t, err)
+
+	cmd := newScheduleCommand(cfg, log)
+
+	// Act
+	cmd.SetArgs([]string{"v1.2.0"})
+	err = cmd.Execute()
+
+	// Assert
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "a height or --time is required")
+	assert.NoFileExists(t, cfg.UpgradeInfoFilePath())
+}
+
+func TestScheduleCommand_InvalidTime(t *testing.T) {
+	// Arrange
+	cfg := &config.Config{
+		Home: t.TempDir(),
+		Name: "wemixd",
+	}
+
+	log, err := logger.New(false, false, "")
+	require.NoError(t, err)
+
+	cmd := newScheduleCommand(cfg, log)
+
+	// Act
+	cmd.SetArgs([]string{"v1.2.0", "--time", "tomorrow"})
+	err = cmd.Execute()
+
+	// Assert
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid time")
+}
+
 func TestUpgradeStatusCommand_NoUpgrade(t *testing.T) {
 	// Arrange
 	tmpDir := t.TempDir()
@@ -298,3 +401,83 @@ func TestUpgradeCommand_Integration(t *testing.T) {
 	err = statusCmd2.Execute()
 	require.NoError(t, err, "status should succeed after cancel")
 }
+
+func TestRollbackCommand_WithoutSupervisor(t *testing.T) {
+	// Arrange
+	tmpDir := t.TempDir()
+	cfg := &config.Config{
+		Home: tmpDir,
+		Name: "wemixd",
+	}
+
+	log, err := logger.New(false, false, "")
+	require.NoError(t, err)
+
+	for _, dir := range []string{cfg.GenesisDir(), cfg.UpgradeDir("v1.1.0"), cfg.UpgradeDir("v1.2.0")} {
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "bin"), 0755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "bin", cfg.Name), []byte("#!/bin/sh\n"), 0755))
+	}
+	require.NoError(t, config.NewSymlinkManager(cfg).LinkToUpgrade("v1.2.0"))
+
+	store := state.NewStore(cfg)
+	require.NoError(t, store.Update(func(st *state.State) {
+		st.Upgrade.RecordApplied("v1.1.0", 100, "", time.Now())
+		st.Upgrade.RecordApplied("v1.2.0", 200, "", time.Now())
+	}))
+
+	cmd := newRollbackCommand(cfg, log)
+
+	// Act
+	cmd.SetArgs([]string{"--force"})
+	err = cmd.Execute()
+
+	// Assert
+	require.NoError(t, err)
+	target, err := config.NewSymlinkManager(cfg).CurrentTarget()
+	require.NoError(t, err)
+	assert.Equal(t, cfg.UpgradeDir("v1.1.0"), target)
+
+	// The rolled back upgrade is no longer a rollback target
+	cmd = newRollbackCommand(cfg, log)
+	cmd.SetArgs([]string{"--force", "--to", "v1.2.0"})
+	assert.Error(t, cmd.Execute())
+}
+
+func TestRehearseCommand_ReportsFailure(t *testing.T) {
+	// Arrange
+	tmpDir := t.TempDir()
+	cfg := &config.Config{
+		Home:             tmpDir,
+		Name:             "wemixd",
+		UnsafeSkipBackup: true,
+	}
+
+	log, err := logger.New(false, false, "")
+	require.NoError(t, err)
+
+	require.NoError(t, os.MkdirAll(filepath.Join(cfg.GenesisDir(), "bin"), 0755))
+	require.NoError(t, os.WriteFile(cfg.GenesisBin(), []byte("#!/bin/sh\n"), 0755))
+	require.NoError(t, config.NewSymlinkManager(cfg).LinkToGenesis())
+
+	// Act - the upgrade binary is not installed
+	cmd := newRehearseCommand(cfg, log)
+	cmd.SetArgs([]string{"v1.2.0"})
+	err = cmd.Execute()
+
+	// Assert
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "would fail")
+
+	// Act - once it is, every step succeeds
+	require.NoError(t, os.MkdirAll(filepath.Dir(cfg.UpgradeBin("v1.2.0")), 0755))
+	require.NoError(t, os.WriteFile(cfg.UpgradeBin("v1.2.0"), []byte("#!/bin/sh\n"), 0755))
+	cmd = newRehearseCommand(cfg, log)
+	cmd.SetArgs([]string{"v1.2.0"})
+	err = cmd.Execute()
+
+	// Assert
+	require.NoError(t, err)
+	target, err := config.NewSymlinkManager(cfg).CurrentTarget()
+	require.NoError(t, err)
+	assert.Equal(t, cfg.GenesisDir(), target, "rehearsal should not switch the binary")
+}
@@ -1,27 +0,0 @@
-package commands
-
-import (
-	"fmt"
-
-	"github.com/spf13/cobra"
-)
-
-// Version information
-var (
-	Version   = "0.1.0"
-	GitCommit = "unknown"
-	BuildDate = "unknown"
-)
-
-// VersionCmd creates the version command
-func VersionCmd() *cobra.Command {
-	return &cobra.Command{
-		Use:   "version",
-		Short: "Print version information",
-		Run: func(cmd *cobra.Command, args []string) {
-			fmt.Printf("Wemixvisor %s\n", Version)
-			fmt.Printf("Git Commit: %s\n", GitCommit)
-			fmt.Printf("Build Date: %s\n", BuildDate)
-		},
-	}
-}
\ No newline at end of file
@@ -33,10 +33,45 @@ const (
 	DefaultGCPercent             = 100
 	DefaultProfileInterval       = 30 * time.Second
 	DefaultHeightPollInterval    = 5 * time.Second
+	DefaultUpgradeVerifyTimeout  = 10 * time.Minute
+	DefaultStagingAlertBlocks    = 1000
+	DefaultSmokeTimeout          = 30 * time.Second
+	DefaultVerifyCommandTimeout  = time.Minute
+	DefaultRestartBackoffInitial = 5 * time.Second
+	DefaultRestartBackoffMax     = 5 * time.Minute
+	DefaultRestartBackoffFactor  = 2.0
+	DefaultRestartBackoffJitter  = 0.2
+	DefaultRestartResetAfter     = 10 * time.Minute
+	DefaultCrashLoopWindow       = 10 * time.Minute
+	DefaultCrashLoopThreshold    = 5
+	DefaultCrashRecordsMax       = 50
+	DefaultStartupTimeout        = 5 * time.Minute
+	DefaultReadinessInterval     = 2 * time.Second
+	DefaultExpectedBlockInterval = time.Second
+	DefaultLogMaxSizeMB          = 100
+	DefaultLogMaxBackups         = 10
+	DefaultLogMaxAge             = 7 * 24 * time.Hour
+	DefaultLogRotateInterval     = 24 * time.Hour
+	DefaultLogTailLines          = 1000
 	DefaultConfigVersion         = "0.8.0"
 	MinPollInterval              = 100 * time.Millisecond
 )
 
+// Readiness conditions a started node must pass before it counts as running
+const (
+	ReadinessNone    = "none"
+	ReadinessRPC     = "rpc"
+	ReadinessHeight  = "height"
+	ReadinessCommand = "command"
+)
+
+// Actions the stalled-chain watchdog can take once the height stops advancing
+const (
+	StallActionAlert           = "alert"
+	StallActionRestart         = "restart"
+	StallActionRestartWithArgs = "restart_with_args"
+)
+
 // Config holds all configuration for Wemixvisor
 type Config struct {
 	// Core settings
@@ -56,15 +91,66 @@ type Config struct {
 	RestartOnFailure bool          `mapstructure:"daemon_restart_on_failure"`
 	MaxRestarts      int           `mapstructure:"daemon_max_restarts"`
 
+	// Stop sequence, e.g. ["rpc:10s", "SIGINT:30s", "SIGTERM:30s", "SIGKILL:5s"]
+	StopSequence    []string `mapstructure:"daemon_stop_sequence"`
+	StopRPCMethod   string   `mapstructure:"daemon_stop_rpc_method"`
+	StopRPCEndpoint string   `mapstructure:"daemon_stop_rpc_endpoint"`
+
+	// Restart policy
+	RestartBackoffInitial time.Duration `mapstructure:"daemon_restart_backoff_initial"`
+	RestartBackoffMax     time.Duration `mapstructure:"daemon_restart_backoff_max"`
+	RestartBackoffFactor  float64       `mapstructure:"daemon_restart_backoff_factor"`
+	RestartBackoffJitter  float64       `mapstructure:"daemon_restart_backoff_jitter"`
+	RestartResetAfter     time.Duration `mapstructure:"daemon_restart_reset_after"`
+	CrashLoopWindow       time.Duration `mapstructure:"daemon_crash_loop_window"`
+	CrashLoopThreshold    int           `mapstructure:"daemon_crash_loop_threshold"`
+	CrashRecordsMax       int           `mapstructure:"daemon_crash_records_max"`
+
+	// Startup readiness
+	ReadinessCheck    string        `mapstructure:"daemon_readiness_check"`
+	ReadinessCommand  string        `mapstructure:"daemon_readiness_command"`
+	ReadinessInterval time.Duration `mapstructure:"daemon_readiness_interval"`
+	StartupTimeout    time.Duration `mapstructure:"daemon_startup_timeout"`
+
+	// Stalled-chain watchdog, disabled while StallTimeout is zero
+	StallTimeout          time.Duration `mapstructure:"daemon_stall_timeout"`
+	StallAction           string        `mapstructure:"daemon_stall_action"`
+	StallRestartArgs      []string      `mapstructure:"daemon_stall_restart_args"`
+	ExpectedBlockInterval time.Duration `mapstructure:"daemon_expected_block_interval"`
+
+	// Halt markers: regular expressions matched against node output. A node
+	// exiting after printing one has halted for the upgrade it names.
+	HaltMarkers []string `mapstructure:"daemon_halt_markers"`
+
 	// Health and monitoring
 	HealthCheckInterval time.Duration     `mapstructure:"daemon_health_check_interval"`
 	MetricsInterval     time.Duration     `mapstructure:"daemon_metrics_interval"`
 	RPCPort             int               `mapstructure:"daemon_rpc_port"`
+	MaxMemoryMB         int64             `mapstructure:"daemon_max_memory_mb"`
 	LogFile             string            `mapstructure:"daemon_log_file"`
 	Environment         map[string]string `mapstructure:"daemon_environment"`
+	EnvFiles            []string          `mapstructure:"daemon_env_files"`
 	Network             string            `mapstructure:"daemon_network"`
 	Debug               bool              `mapstructure:"daemon_debug"`
 
+	// Node process privileges and limits, applied on every start. Unset
+	// values keep what the node inherits from wemixvisor.
+	RunAsUser           string   `mapstructure:"daemon_run_as_user"`
+	RunAsGroup          string   `mapstructure:"daemon_run_as_group"`
+	SupplementaryGroups []string `mapstructure:"daemon_supplementary_groups"`
+	RlimitNoFile        uint64   `mapstructure:"daemon_rlimit_nofile"`
+	RlimitCore          string   `mapstructure:"daemon_rlimit_core"`
+	Nice                int      `mapstructure:"daemon_nice"`
+	OOMScoreAdj         *int     `mapstructure:"daemon_oom_score_adj"`
+
+	// Node output capture
+	LogMaxSizeMB      int           `mapstructure:"daemon_log_max_size_mb"`
+	LogMaxBackups     int           `mapstructure:"daemon_log_max_backups"`
+	LogMaxAge         time.Duration `mapstructure:"daemon_log_max_age"`
+	LogRotateInterval time.Duration `mapstructure:"daemon_log_rotate_interval"`
+	LogCompress       bool          `mapstructure:"daemon_log_compress"`
+	LogTailLines      int           `mapstructure:"daemon_log_tail_lines"`
+
 	// Backup settings
 	UnsafeSkipBackup bool   `mapstructure:"unsafe_skip_backup"`
 	DataBackupPath   string `mapstructure:"daemon_data_backup_dir"`
@@ -136,6 +222,36 @@ type Config struct {
 	UpgradeEnabled     bool          `mapstructure:"upgrade_enabled"`
 	HeightPollInterval time.Duration `mapstructure:"height_poll_interval"`
 
+	// Post-upgrade verification: the upgraded node must advance
+	// UpgradeVerifyBlocks past the upgrade height within UpgradeVerifyTimeout,
+	// or the upgrade is rolled back, together with the pre-upgrade backup
+	// when UpgradeRestoreBackup is set
+	UpgradeVerifyBlocks  int64         `mapstructure:"daemon_upgrade_verify_blocks"`
+	UpgradeVerifyTimeout time.Duration `mapstructure:"daemon_upgrade_verify_timeout"`
+	UpgradeRestoreBackup bool          `mapstructure:"daemon_upgrade_restore_backup"`
+
+	// UpgradeVerifyCommand, if set, runs with /bin/sh -c once the upgraded
+	// node is ready and must succeed within UpgradeVerifyCommandTimeout for
+	// the upgrade to be verified
+	UpgradeVerifyCommand        string        `mapstructure:"daemon_upgrade_verify_command"`
+	UpgradeVerifyCommandTimeout time.Duration `mapstructure:"daemon_upgrade_verify_command_timeout"`
+
+	// Upgrade binaries are staged ahead of their upgrade; an alert is raised
+	// for an upgrade not staged StagingAlertBlocks before its height
+	StagingAlertBlocks int64 `mapstructure:"daemon_staging_alert_blocks"`
+
+	// Pre-switch validation: SmokeCommand, if set, runs with /bin/sh -c and
+	// UPGRADE_BINARY pointing at the upgrade binary, and must succeed within
+	// SmokeTimeout before the node is switched to it
+	SmokeCommand string        `mapstructure:"daemon_smoke_command"`
+	SmokeTimeout time.Duration `mapstructure:"daemon_smoke_timeout"`
+
+	// Multi-instance mode: named nodes supervised side by side. Instance is
+	// the name of the instance this configuration belongs to, if any.
+	Instances     []InstanceConfig `mapstructure:"instances"`
+	InstancesFile string           `mapstructure:"daemon_instances_file"`
+	Instance      string           `mapstructure:"-"`
+
 	// CLI options
 	Daemon     bool `mapstructure:"daemon"`
 	JSONOutput bool `mapstructure:"json_output"`
@@ -157,6 +273,24 @@ func DefaultConfig() *Config {
 		PollInterval:         DefaultPollInterval,
 		RestartOnFailure:     true,
 		MaxRestarts:          DefaultMaxRestarts,
+
+		// Restart policy defaults
+		RestartBackoffInitial: DefaultRestartBackoffInitial,
+		RestartBackoffMax:     DefaultRestartBackoffMax,
+		RestartBackoffFactor:  DefaultRestartBackoffFactor,
+		RestartBackoffJitter:  DefaultRestartBackoffJitter,
+		RestartResetAfter:     DefaultRestartResetAfter,
+		CrashLoopWindow:       DefaultCrashLoopWindow,
+		CrashLoopThreshold:    DefaultCrashLoopThreshold,
+		CrashRecordsMax:       DefaultCrashRecordsMax,
+
+		ReadinessCheck:    ReadinessNone,
+		ReadinessInterval: DefaultReadinessInterval,
+		StartupTimeout:    DefaultStartupTimeout,
+
+		StallAction:           StallActionAlert,
+		ExpectedBlockInterval: DefaultExpectedBlockInterval,
+
 		HealthCheckInterval:  DefaultHealthCheckInterval,
 		RPCPort:              DefaultRPCPort,
 		Environment:          make(map[string]string),
@@ -166,6 +300,14 @@ func DefaultConfig() *Config {
 		ColorLogs:            true,
 		TimeFormatLogs:       DefaultTimeFormatLogs,
 
+		// Node output capture defaults
+		LogMaxSizeMB:      DefaultLogMaxSizeMB,
+		LogMaxBackups:     DefaultLogMaxBackups,
+		LogMaxAge:         DefaultLogMaxAge,
+		LogRotateInterval: DefaultLogRotateInterval,
+		LogCompress:       true,
+		LogTailLines:      DefaultLogTailLines,
+
 		// Metrics defaults
 		MetricsPort:               DefaultMetricsPort,
 		MetricsPath:               DefaultMetricsPath,
@@ -200,6 +342,11 @@ func DefaultConfig() *Config {
 		UpgradeEnabled:     true,
 		HeightPollInterval: DefaultHeightPollInterval,
 
+		UpgradeVerifyTimeout:        DefaultUpgradeVerifyTimeout,
+		UpgradeVerifyCommandTimeout: DefaultVerifyCommandTimeout,
+		StagingAlertBlocks:          DefaultStagingAlertBlocks,
+		SmokeTimeout:                DefaultSmokeTimeout,
+
 		ConfigVersion: DefaultConfigVersion,
 	}
 }
@@ -0,0 +1,236 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Prefixes of node environment values that reference a secret instead of
+// holding it. References are resolved every time the node is started.
+const (
+	// EnvFileRefPrefix reads the value from a file, e.g. "file:/etc/wemix/password"
+	EnvFileRefPrefix = "file:"
+	// EnvVarRefPrefix takes the value from wemixvisor's own environment, e.g. "env:KEYSTORE_PASSWORD"
+	EnvVarRefPrefix = "env:"
+)
+
+// RedactedValue replaces secret values in logs, status and API output
+const RedactedValue = "[REDACTED]"
+
+// secretNameMarkers mark plain environment values as secrets by name
+var secretNameMarkers = []string{"PASSWORD", "PASSWD", "SECRET", "TOKEN", "PRIVATE", "KEY", "CREDENTIAL"}
+
+// EnvVar is a resolved node environment variable
+type EnvVar struct {
+	Name   string
+	Value  string
+	Secret bool
+}
+
+// String returns the variable as NAME=value, with a secret value redacted
+func (v EnvVar) String() string {
+	return v.Name + "=" + v.Display()
+}
+
+// Display returns the value, or RedactedValue for a secret
+func (v EnvVar) Display() string {
+	if v.Secret {
+		return RedactedValue
+	}
+	return v.Value
+}
+
+// ResolveEnvironment returns the extra environment of the node: the
+// variables of the env files in order, then the Environment map, each
+// overriding earlier ones. References are resolved on every call, so
+// changes to the referenced files apply from the next node start. Values
+// from env files and references are secret, as are plain values whose
+// name suggests a secret.
+func (c *Config) ResolveEnvironment() ([]EnvVar, error) {
+	vars := make(map[string]EnvVar)
+
+	for _, path := range c.EnvFiles {
+		entries, err := ParseEnvFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for name, value := range entries {
+			resolved, err := resolveEnvValue(name, value)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			vars[name] = EnvVar{Name: name, Value: resolved, Secret: true}
+		}
+	}
+
+	for name, value := range c.Environment {
+		resolved, err := resolveEnvValue(name, value)
+		if err != nil {
+			return nil, err
+		}
+		vars[name] = EnvVar{
+			Name:   name,
+			Value:  resolved,
+			Secret: isEnvRef(value) || isSecretName(name),
+		}
+	}
+
+	return sortedEnvVars(vars), nil
+}
+
+// RedactedEnvironment returns the Environment map for display, with
+// references and secret-looking values redacted. Env files are not read.
+func (c *Config) RedactedEnvironment() map[string]string {
+	redacted := make(map[string]string, len(c.Environment))
+	for name, value := range c.Environment {
+		v := EnvVar{Name: name, Value: value, Secret: isEnvRef(value) || isSecretName(name)}
+		redacted[name] = v.Display()
+	}
+	return redacted
+}
+
+// RedactEnvVars returns the variables as a name to display value map
+func RedactEnvVars(vars []EnvVar) map[string]string {
+	redacted := make(map[string]string, len(vars))
+	for _, v := range vars {
+		redacted[v.Name] = v.Display()
+	}
+	return redacted
+}
+
+// ParseEnvFile reads a dotenv-style file of NAME=value lines. Blank lines
+// and lines starting with # are skipped and an "export " prefix is allowed.
+// Values may be double-quoted, with Go escapes, or single-quoted, taken
+// literally; unquoted values end at a " #" comment.
+func ParseEnvFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open env file: %w", err)
+	}
+	defer file.Close()
+
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		name, value, ok := strings.Cut(line, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !validEnvName(name) {
+			return nil, fmt.Errorf("%s:%d: expected NAME=value", path, lineNo)
+		}
+
+		value, err := parseEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		vars[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read env file %s: %w", path, err)
+	}
+
+	return vars, nil
+}
+
+// parseEnvValue unquotes a value from an env file
+func parseEnvValue(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, `"`):
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return "", fmt.Errorf("invalid double-quoted value")
+		}
+		return unquoted, nil
+	case strings.HasPrefix(value, "'"):
+		if len(value) < 2 || !strings.HasSuffix(value, "'") {
+			return "", fmt.Errorf("unterminated single-quoted value")
+		}
+		return value[1 : len(value)-1], nil
+	}
+
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value, nil
+}
+
+// resolveEnvValue returns the value of a variable, following a reference
+func resolveEnvValue(name, value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, EnvFileRefPrefix):
+		path := strings.TrimPrefix(value, EnvFileRefPrefix)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("cannot resolve %s: %w", name, err)
+		}
+		// Secret files commonly end with a newline that is not part of the value
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case strings.HasPrefix(value, EnvVarRefPrefix):
+		ref := strings.TrimPrefix(value, EnvVarRefPrefix)
+		resolved, ok := os.LookupEnv(ref)
+		if !ok {
+			return "", fmt.Errorf("cannot resolve %s: environment variable %s is not set", name, ref)
+		}
+		return resolved, nil
+	}
+	return value, nil
+}
+
+// validateEnvironment checks the env file paths and reference syntax
+// without reading secrets
+func validateEnvironment(cfg *Config) error {
+	for _, path := range cfg.EnvFiles {
+		if path == "" {
+			return fmt.Errorf("empty env file path")
+		}
+	}
+	for name, value := range cfg.Environment {
+		if !validEnvName(name) {
+			return fmt.Errorf("invalid environment variable name %q", name)
+		}
+		if value == EnvFileRefPrefix || value == EnvVarRefPrefix {
+			return fmt.Errorf("environment variable %s has an empty reference", name)
+		}
+	}
+	return nil
+}
+
+// isEnvRef reports whether value is a file: or env: reference
+func isEnvRef(value string) bool {
+	return strings.HasPrefix(value, EnvFileRefPrefix) || strings.HasPrefix(value, EnvVarRefPrefix)
+}
+
+// isSecretName reports whether a variable name suggests a secret value
+func isSecretName(name string) bool {
+	upper := strings.ToUpper(name)
+	for _, marker := range secretNameMarkers {
+		if strings.Contains(upper, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// validEnvName reports whether name is usable as an environment variable
+func validEnvName(name string) bool {
+	return name != "" && !strings.ContainsAny(name, "= \t")
+}
+
+// sortedEnvVars returns the variables ordered by name
+func sortedEnvVars(vars map[string]EnvVar) []EnvVar {
+	sorted := make([]EnvVar, 0, len(vars))
+	for _, v := range vars {
+		sorted = append(sorted, v)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
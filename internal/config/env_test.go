@@ -0,0 +1,151 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.env")
+	content := `# node settings
+LOG_LEVEL=info
+export GOMAXPROCS=4
+GREETING="hello\nworld"
+LITERAL='a $b "c"'
+TRAILING=value # comment
+EMPTY=
+
+`
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+
+	vars, err := ParseEnvFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"LOG_LEVEL":  "info",
+		"GOMAXPROCS": "4",
+		"GREETING":   "hello\nworld",
+		"LITERAL":    `a $b "c"`,
+		"TRAILING":   "value",
+		"EMPTY":      "",
+	}, vars)
+}
+
+func TestParseEnvFile_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		errMsg  string
+	}{
+		{name: "missing equals", content: "LOG_LEVEL\n", errMsg: "node.env:1: expected NAME=value"},
+		{name: "empty name", content: "=value\n", errMsg: "expected NAME=value"},
+		{name: "bad double quotes", content: "A=ok\nB=\"open\n", errMsg: "node.env:2: invalid double-quoted value"},
+		{name: "unterminated single quotes", content: "B='open\n", errMsg: "unterminated"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "node.env")
+			require.NoError(t, os.WriteFile(path, []byte(tt.content), 0600))
+
+			_, err := ParseEnvFile(path)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+
+	_, err := ParseEnvFile(filepath.Join(t.TempDir(), "missing.env"))
+	assert.Error(t, err)
+}
+
+func TestConfig_ResolveEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	secretPath := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(secretPath, []byte("s3cret\n"), 0600))
+	envPath := filepath.Join(dir, "node.env")
+	require.NoError(t, os.WriteFile(envPath, []byte("API_TOKEN=abc\nLOG_LEVEL=debug\n"), 0600))
+	t.Setenv("WEMIXVISOR_TEST_SEED", "seed-value")
+
+	cfg := &Config{
+		EnvFiles: []string{envPath},
+		Environment: map[string]string{
+			"KEYSTORE_PASSWORD": "file:" + secretPath,
+			"SEED":              "env:WEMIXVISOR_TEST_SEED",
+			"LOG_LEVEL":         "info",
+		},
+	}
+
+	vars, err := cfg.ResolveEnvironment()
+	require.NoError(t, err)
+	assert.Equal(t, []EnvVar{
+		{Name: "API_TOKEN", Value: "abc", Secret: true},
+		{Name: "KEYSTORE_PASSWORD", Value: "s3cret", Secret: true},
+		{Name: "LOG_LEVEL", Value: "info", Secret: false},
+		{Name: "SEED", Value: "seed-value", Secret: true},
+	}, vars)
+
+	assert.Equal(t, map[string]string{
+		"API_TOKEN":         RedactedValue,
+		"KEYSTORE_PASSWORD": RedactedValue,
+		"LOG_LEVEL":         "info",
+		"SEED":              RedactedValue,
+	}, RedactEnvVars(vars))
+	assert.Equal(t, "KEYSTORE_PASSWORD="+RedactedValue, vars[1].String())
+
+	// Edited secret files are picked up on the next resolution
+	require.NoError(t, os.WriteFile(secretPath, []byte("rotated"), 0600))
+	vars, err = cfg.ResolveEnvironment()
+	require.NoError(t, err)
+	assert.Equal(t, "rotated", vars[1].Value)
+}
+
+func TestConfig_ResolveEnvironment_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		errMsg string
+	}{
+		{
+			name:   "missing secret file",
+			config: &Config{Environment: map[string]string{"PASS": "file:/nonexistent/password"}},
+			errMsg: "cannot resolve PASS",
+		},
+		{
+			name:   "unset variable",
+			config: &Config{Environment: map[string]string{"PASS": "env:WEMIXVISOR_TEST_UNSET"}},
+			errMsg: "WEMIXVISOR_TEST_UNSET is not set",
+		},
+		{
+			name:   "missing env file",
+			config: &Config{EnvFiles: []string{"/nonexistent/node.env"}},
+			errMsg: "failed to open env file",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.config.ResolveEnvironment()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestConfig_RedactedEnvironment(t *testing.T) {
+	cfg := &Config{
+		Environment: map[string]string{
+			"LOG_LEVEL":         "info",
+			"KEYSTORE_PASSWORD": "hunter2",
+			"SEED":              "env:SEED",
+		},
+	}
+
+	assert.Equal(t, map[string]string{
+		"LOG_LEVEL":         "info",
+		"KEYSTORE_PASSWORD": RedactedValue,
+		"SEED":              RedactedValue,
+	}, cfg.RedactedEnvironment())
+}
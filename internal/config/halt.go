@@ -0,0 +1,43 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Capture groups a halt marker uses to report the upgrade it halted for
+const (
+	HaltMarkerName   = "name"
+	HaltMarkerHeight = "height"
+)
+
+// DefaultHaltMarkers match the line Cosmos SDK style nodes print when they
+// stop themselves for an upgrade, e.g.
+//
+//	UPGRADE "v2.0.0" NEEDED at height: 1000000: {"binaries":...}
+var DefaultHaltMarkers = []string{
+	`UPGRADE "(?P<name>[^"]+)" NEEDED at (?:height: (?P<height>\d+))?`,
+}
+
+// HaltMarkerPatterns compiles the configured halt markers, or the default
+// ones when none are configured. Every marker must capture the upgrade name
+// in a "name" group; a "height" group is optional.
+func (c *Config) HaltMarkerPatterns() ([]*regexp.Regexp, error) {
+	markers := c.HaltMarkers
+	if len(markers) == 0 {
+		markers = DefaultHaltMarkers
+	}
+
+	patterns := make([]*regexp.Regexp, 0, len(markers))
+	for _, marker := range markers {
+		re, err := regexp.Compile(marker)
+		if err != nil {
+			return nil, fmt.Errorf("invalid halt marker %q: %w", marker, err)
+		}
+		if re.SubexpIndex(HaltMarkerName) < 0 {
+			return nil, fmt.Errorf("halt marker %q has no (?P<%s>...) group", marker, HaltMarkerName)
+		}
+		patterns = append(patterns, re)
+	}
+	return patterns, nil
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_HaltMarkerPatterns(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		patterns, err := (&Config{}).HaltMarkerPatterns()
+		require.NoError(t, err)
+		require.Len(t, patterns, 1)
+
+		match := patterns[0].FindStringSubmatch(`UPGRADE "v2.0.0" NEEDED at height: 1000: {}`)
+		require.NotNil(t, match)
+		assert.Equal(t, "v2.0.0", match[patterns[0].SubexpIndex(HaltMarkerName)])
+		assert.Equal(t, "1000", match[patterns[0].SubexpIndex(HaltMarkerHeight)])
+	})
+
+	t.Run("configured", func(t *testing.T) {
+		cfg := &Config{HaltMarkers: []string{`halting for (?P<name>\S+)`}}
+		patterns, err := cfg.HaltMarkerPatterns()
+		require.NoError(t, err)
+		require.Len(t, patterns, 1)
+		assert.True(t, patterns[0].MatchString("halting for v3"))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := (&Config{HaltMarkers: []string{`(?P<name>`}}).HaltMarkerPatterns()
+		assert.Error(t, err)
+	})
+
+	t.Run("missing name group", func(t *testing.T) {
+		_, err := (&Config{HaltMarkers: []string{`UPGRADE NEEDED`}}).HaltMarkerPatterns()
+		assert.ErrorContains(t, err, "no (?P<name>...) group")
+	})
+}
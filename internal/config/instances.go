@@ -0,0 +1,161 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// InstanceConfig describes one named node supervised alongside others, such
+// as a validator and its sentry. Each instance has its own home and with it
+// its own binaries, upgrade tracking, logs and control socket. Fields left
+// empty inherit the base configuration.
+type InstanceConfig struct {
+	Name       string   `toml:"name" mapstructure:"name"`
+	Home       string   `toml:"home" mapstructure:"home"`
+	DaemonName string   `toml:"daemon_name" mapstructure:"daemon_name"`
+	Args       []string `toml:"args" mapstructure:"args"`
+	RPCAddress string   `toml:"rpc_address" mapstructure:"rpc_address"`
+	RPCPort    int      `toml:"rpc_port" mapstructure:"rpc_port"`
+}
+
+// instancesFile is the on-disk layout of the instances file
+type instancesFile struct {
+	Instances []InstanceConfig `toml:"instance"`
+}
+
+// LoadInstances reads the instance definitions from the instances file, if
+// one exists. Instances already present in the configuration are kept.
+func (c *Config) LoadInstances() error {
+	if len(c.Instances) > 0 {
+		return nil
+	}
+
+	data, err := os.ReadFile(c.InstancesFilePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read instances file: %w", err)
+	}
+
+	var file instancesFile
+	if err := toml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse instances file: %w", err)
+	}
+	if err := validateInstances(file.Instances); err != nil {
+		return fmt.Errorf("invalid instances file: %w", err)
+	}
+
+	c.Instances = file.Instances
+	return nil
+}
+
+// IsMultiInstance reports whether named node instances are configured
+func (c *Config) IsMultiInstance() bool {
+	return len(c.Instances) > 0
+}
+
+// InstanceNames returns the names of the configured instances in order
+func (c *Config) InstanceNames() []string {
+	names := make([]string, 0, len(c.Instances))
+	for _, inst := range c.Instances {
+		names = append(names, inst.Name)
+	}
+	return names
+}
+
+// ForInstance returns a copy of the configuration for the named instance,
+// with the instance's settings applied over the base configuration
+func (c *Config) ForInstance(name string) (*Config, error) {
+	for _, inst := range c.Instances {
+		if inst.Name == name {
+			return c.applyInstance(inst), nil
+		}
+	}
+	return nil, fmt.Errorf("unknown instance %q (configured: %s)",
+		name, strings.Join(c.InstanceNames(), ", "))
+}
+
+// InstanceConfigs returns the configuration of every instance in order
+func (c *Config) InstanceConfigs() []*Config {
+	configs := make([]*Config, 0, len(c.Instances))
+	for _, inst := range c.Instances {
+		configs = append(configs, c.applyInstance(inst))
+	}
+	return configs
+}
+
+// applyInstance derives an instance configuration from the base one
+func (c *Config) applyInstance(inst InstanceConfig) *Config {
+	cfg := *c
+	cfg.Instance = inst.Name
+	cfg.Instances = nil
+	// Remember where the instance was defined, as its home differs
+	cfg.InstancesFile = c.InstancesFilePath()
+
+	if inst.Home != "" {
+		cfg.Home = inst.Home
+		// Backups kept under the base home move along with the instance
+		if rel, err := filepath.Rel(c.Home, c.DataBackupPath); err == nil && c.DataBackupPath != "" && !strings.HasPrefix(rel, "..") {
+			cfg.DataBackupPath = filepath.Join(inst.Home, rel)
+		}
+	}
+	if inst.DaemonName != "" {
+		cfg.Name = inst.DaemonName
+	}
+	if inst.Args != nil {
+		cfg.Args = inst.Args
+	}
+	if inst.RPCAddress != "" {
+		cfg.RPCAddress = inst.RPCAddress
+		if inst.RPCPort == 0 {
+			if _, port, err := net.SplitHostPort(inst.RPCAddress); err == nil {
+				if p, err := strconv.Atoi(port); err == nil {
+					cfg.RPCPort = p
+				}
+			}
+		}
+	}
+	if inst.RPCPort != 0 {
+		cfg.RPCPort = inst.RPCPort
+	}
+
+	return &cfg
+}
+
+// validateInstances checks that instances are uniquely named and do not
+// share a home
+func validateInstances(instances []InstanceConfig) error {
+	names := make(map[string]bool, len(instances))
+	homes := make(map[string]string, len(instances))
+
+	for i, inst := range instances {
+		if inst.Name == "" {
+			return fmt.Errorf("instance %d has no name", i+1)
+		}
+		if strings.ContainsAny(inst.Name, "/\\:*?\"<>| ") {
+			return fmt.Errorf("instance name contains invalid characters: %s", inst.Name)
+		}
+		if names[inst.Name] {
+			return fmt.Errorf("duplicate instance name: %s", inst.Name)
+		}
+		names[inst.Name] = true
+
+		if inst.Home == "" {
+			return fmt.Errorf("instance %s has no home directory", inst.Name)
+		}
+		home := filepath.Clean(inst.Home)
+		if other, ok := homes[home]; ok {
+			return fmt.Errorf("instances %s and %s share home %s", other, inst.Name, home)
+		}
+		homes[home] = inst.Name
+	}
+
+	return nil
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testInstancesFile = `
+[[instance]]
+name = "validator"
+home = "/data/validator"
+args = ["--syncmode", "full"]
+
+[[instance]]
+name = "sentry"
+home = "/data/sentry"
+daemon_name = "gwemix"
+rpc_address = "localhost:8546"
+`
+
+func writeInstancesFile(t *testing.T, home, content string) {
+	t.Helper()
+	dir := filepath.Join(home, "wemixvisor")
+	require.NoError(t, os.MkdirAll(dir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, InstancesFileName), []byte(content), 0644))
+}
+
+func TestLoadInstances(t *testing.T) {
+	home := t.TempDir()
+	writeInstancesFile(t, home, testInstancesFile)
+
+	cfg := &Config{Home: home}
+	require.NoError(t, cfg.LoadInstances())
+
+	assert.True(t, cfg.IsMultiInstance())
+	assert.Equal(t, []string{"validator", "sentry"}, cfg.InstanceNames())
+	assert.Equal(t, []string{"--syncmode", "full"}, cfg.Instances[0].Args)
+}
+
+func TestLoadInstances_NoFile(t *testing.T) {
+	cfg := &Config{Home: t.TempDir()}
+	require.NoError(t, cfg.LoadInstances())
+	assert.False(t, cfg.IsMultiInstance())
+}
+
+func TestLoadInstances_Invalid(t *testing.T) {
+	home := t.TempDir()
+	writeInstancesFile(t, home, `
+[[instance]]
+name = "a"
+home = "/data/node"
+
+[[instance]]
+name = "b"
+home = "/data/node"
+`)
+
+	cfg := &Config{Home: home}
+	err := cfg.LoadInstances()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "share home")
+}
+
+func TestForInstance(t *testing.T) {
+	home := t.TempDir()
+	writeInstancesFile(t, home, testInstancesFile)
+
+	cfg := &Config{
+		Home:           home,
+		Name:           "wemixd",
+		RPCAddress:     "localhost:8545",
+		RPCPort:        8545,
+		DataBackupPath: filepath.Join(home, "backups"),
+	}
+	require.NoError(t, cfg.LoadInstances())
+
+	sentry, err := cfg.ForInstance("sentry")
+	require.NoError(t, err)
+	assert.Equal(t, "sentry", sentry.Instance)
+	assert.Equal(t, "/data/sentry", sentry.Home)
+	assert.Equal(t, "gwemix", sentry.Name)
+	assert.Equal(t, "localhost:8546", sentry.RPCAddress)
+	assert.Equal(t, 8546, sentry.RPCPort)
+	assert.Equal(t, "/data/sentry/backups", sentry.DataBackupPath)
+	assert.Equal(t, filepath.Join(home, "wemixvisor", InstancesFileName), sentry.InstancesFilePath())
+	assert.False(t, sentry.IsMultiInstance())
+
+	validator, err := cfg.ForInstance("validator")
+	require.NoError(t, err)
+	assert.Equal(t, "wemixd", validator.Name)
+	assert.Equal(t, 8545, validator.RPCPort)
+
+	// The base configuration is left untouched
+	assert.Equal(t, home, cfg.Home)
+	assert.Empty(t, cfg.Instance)
+
+	_, err = cfg.ForInstance("archive")
+	assert.Error(t, err)
+}
@@ -8,13 +8,21 @@ import (
 
 // Directory and file name constants
 const (
-	WemixvisorDirName   = "wemixvisor"
-	CurrentDirName      = "current"
-	GenesisDirName      = "genesis"
-	UpgradesDirName     = "upgrades"
-	BinDirName          = "bin"
-	DataDirName         = "data"
-	UpgradeInfoFileName = "upgrade-info.json"
+	WemixvisorDirName    = "wemixvisor"
+	CurrentDirName       = "current"
+	GenesisDirName       = "genesis"
+	UpgradesDirName      = "upgrades"
+	BinDirName           = "bin"
+	DataDirName          = "data"
+	UpgradeInfoFileName  = "upgrade-info.json"
+	ControlSocketName    = "wemixvisor.sock"
+	NodeStateFileName    = "node.json"
+	NodeOutputPipeName   = "node.out"
+	InstancesFileName    = "instances.toml"
+	RuntimeStateFileName = "state.json"
+	VersionCacheFileName = "versions.json"
+	UpgradeJournalName   = "upgrade-journal.jsonl"
+	AnnouncedFileName    = "announced-upgrades.json"
 )
 
 // PathProvider defines methods for accessing wemixvisor paths
@@ -75,6 +83,63 @@ func (c *Config) UpgradeInfoFilePath() string {
 	return filepath.Join(c.Home, DataDirName, UpgradeInfoFileName)
 }
 
+// InstancesFilePath returns the path of the file defining named node
+// instances, by default under the wemixvisor directory
+func (c *Config) InstancesFilePath() string {
+	if c.InstancesFile != "" {
+		return c.InstancesFile
+	}
+	return filepath.Join(c.WemixvisorDir(), InstancesFileName)
+}
+
+// ControlSocketPath returns the path of the supervisor control socket
+func (c *Config) ControlSocketPath() string {
+	return filepath.Join(c.WemixvisorDir(), ControlSocketName)
+}
+
+// NodeStatePath returns the path of the file recording the running node
+// process, used to re-adopt it after the supervisor restarts
+func (c *Config) NodeStatePath() string {
+	return filepath.Join(c.WemixvisorDir(), NodeStateFileName)
+}
+
+// RuntimeStatePath returns the path of the file persisting supervisor
+// runtime state across restarts
+func (c *Config) RuntimeStatePath() string {
+	return filepath.Join(c.WemixvisorDir(), RuntimeStateFileName)
+}
+
+// VersionCachePath returns the path of the cache of node binary versions
+func (c *Config) VersionCachePath() string {
+	return filepath.Join(c.WemixvisorDir(), VersionCacheFileName)
+}
+
+// UpgradeJournalPath returns the path of the write-ahead journal of the
+// upgrade in progress
+func (c *Config) UpgradeJournalPath() string {
+	return filepath.Join(c.WemixvisorDir(), UpgradeJournalName)
+}
+
+// AnnouncedUpgradesPath returns the path of the list of upgrades scheduled
+// from governance proposals, which the supervisor stages ahead of time
+func (c *Config) AnnouncedUpgradesPath() string {
+	return filepath.Join(c.WemixvisorDir(), AnnouncedFileName)
+}
+
+// NodeOutputPipePath returns the path of the named pipe carrying node output
+func (c *Config) NodeOutputPipePath() string {
+	return filepath.Join(c.WemixvisorDir(), NodeOutputPipeName)
+}
+
+// NodeLogPath returns the resolved path of the node output log, or an empty
+// string if node output is not captured to a file
+func (c *Config) NodeLogPath() string {
+	if c.LogFile == "" || filepath.IsAbs(c.LogFile) {
+		return c.LogFile
+	}
+	return filepath.Join(c.Home, c.LogFile)
+}
+
 // SymlinkManager handles symbolic link operations for binary versions
 type SymlinkManager struct {
 	config *Config
@@ -101,23 +166,86 @@ func (s *SymlinkManager) LinkToUpgrade(name string) error {
 	return s.createSymlink(upgradeDir)
 }
 
-// createSymlink creates a relative symbolic link from current to target
-func (s *SymlinkManager) createSymlink(targetDir string) error {
+// LinkTo points current at targetDir, which is typically a target
+// previously returned by CurrentTarget
+func (s *SymlinkManager) LinkTo(targetDir string) error {
+	if _, err := os.Stat(targetDir); err != nil {
+		return fmt.Errorf("link target does not exist: %w", err)
+	}
+
+	return s.createSymlink(targetDir)
+}
+
+// CurrentTarget returns the absolute path of the directory current points
+// at, or an empty string if current does not exist
+func (s *SymlinkManager) CurrentTarget() (string, error) {
 	currentDir := s.config.CurrentDir()
 
-	if err := os.RemoveAll(currentDir); err != nil {
-		return fmt.Errorf("failed to remove current link: %w", err)
+	target, err := os.Readlink(currentDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to read current link: %w", err)
+	}
+
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(currentDir), target)
 	}
+	return filepath.Clean(target), nil
+}
+
+// createSymlink points current at target through a relative symbolic link.
+// The link is created under a temporary name and renamed over current, so
+// current always refers to either the old or the new target.
+func (s *SymlinkManager) createSymlink(targetDir string) error {
+	currentDir := s.config.CurrentDir()
+	tmpLink := currentDir + ".tmp"
 
 	relPath, err := filepath.Rel(filepath.Dir(currentDir), targetDir)
 	if err != nil {
 		return fmt.Errorf("failed to create relative path: %w", err)
 	}
 
-	if err := os.Symlink(relPath, currentDir); err != nil {
+	// A real directory cannot be replaced by rename
+	if info, err := os.Lstat(currentDir); err == nil && info.IsDir() {
+		if err := os.RemoveAll(currentDir); err != nil {
+			return fmt.Errorf("failed to remove current directory: %w", err)
+		}
+	}
+
+	if err := os.Remove(tmpLink); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove stale temporary link: %w", err)
+	}
+
+	if err := os.Symlink(relPath, tmpLink); err != nil {
 		return fmt.Errorf("failed to create symlink: %w", err)
 	}
 
+	if err := os.Rename(tmpLink, currentDir); err != nil {
+		os.Remove(tmpLink)
+		return fmt.Errorf("failed to replace current link: %w", err)
+	}
+
+	return nil
+}
+
+// CheckExecutable verifies that path is a regular file with an execute
+// permission bit set
+func CheckExecutable(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("binary not found: %w", err)
+	}
+
+	if !info.Mode().IsRegular() {
+		return fmt.Errorf("binary %s is not a regular file", path)
+	}
+
+	if info.Mode().Perm()&0111 == 0 {
+		return fmt.Errorf("binary %s is not executable", path)
+	}
+
 	return nil
 }
 
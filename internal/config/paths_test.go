@@ -0,0 +1,95 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeBinary creates an executable node binary in dir/bin
+func writeBinary(t *testing.T, cfg *Config, dir string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, BinDirName), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, BinDirName, cfg.Name), []byte("#!/bin/sh\n"), 0755))
+}
+
+func TestSymlinkManager_SwitchAndRestore(t *testing.T) {
+	cfg := &Config{Home: t.TempDir(), Name: "wemixd"}
+	writeBinary(t, cfg, cfg.GenesisDir())
+	writeBinary(t, cfg, cfg.UpgradeDir("v1.1.0"))
+	writeBinary(t, cfg, cfg.UpgradeDir("v1.2.0"))
+	symlinks := NewSymlinkManager(cfg)
+
+	target, err := symlinks.CurrentTarget()
+	require.NoError(t, err)
+	assert.Empty(t, target, "no target before current exists")
+
+	require.NoError(t, symlinks.LinkToUpgrade("v1.1.0"))
+	previous, err := symlinks.CurrentTarget()
+	require.NoError(t, err)
+	assert.Equal(t, cfg.UpgradeDir("v1.1.0"), previous)
+
+	require.NoError(t, symlinks.LinkToUpgrade("v1.2.0"))
+	target, err = symlinks.CurrentTarget()
+	require.NoError(t, err)
+	assert.Equal(t, cfg.UpgradeDir("v1.2.0"), target)
+
+	_, err = os.Lstat(cfg.CurrentDir() + ".tmp")
+	assert.True(t, os.IsNotExist(err), "temporary link should be renamed away")
+
+	// Restore the exact prior target
+	require.NoError(t, symlinks.LinkTo(previous))
+	link, err := os.Readlink(cfg.CurrentDir())
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(UpgradesDirName, "v1.1.0"), link)
+	assert.FileExists(t, cfg.CurrentBin())
+
+	assert.Error(t, symlinks.LinkTo(filepath.Join(cfg.Home, "missing")))
+}
+
+func TestSymlinkManager_ReplacesCurrentDirectory(t *testing.T) {
+	cfg := &Config{Home: t.TempDir(), Name: "wemixd"}
+	writeBinary(t, cfg, cfg.GenesisDir())
+	writeBinary(t, cfg, cfg.CurrentDir())
+
+	require.NoError(t, NewSymlinkManager(cfg).LinkToGenesis())
+
+	info, err := os.Lstat(cfg.CurrentDir())
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&os.ModeSymlink, "current should be a symlink")
+}
+
+func TestSymlinkManager_ReplacesStaleTemporaryLink(t *testing.T) {
+	cfg := &Config{Home: t.TempDir(), Name: "wemixd"}
+	writeBinary(t, cfg, cfg.GenesisDir())
+	require.NoError(t, os.Symlink("nowhere", cfg.CurrentDir()+".tmp"))
+
+	require.NoError(t, NewSymlinkManager(cfg).LinkToGenesis())
+
+	assert.FileExists(t, cfg.CurrentBin())
+}
+
+func TestCheckExecutable(t *testing.T) {
+	dir := t.TempDir()
+	executable := filepath.Join(dir, "wemixd")
+	require.NoError(t, os.WriteFile(executable, []byte("#!/bin/sh\n"), 0755))
+	plain := filepath.Join(dir, "plain")
+	require.NoError(t, os.WriteFile(plain, []byte("data"), 0644))
+
+	assert.NoError(t, CheckExecutable(executable))
+
+	err := CheckExecutable(plain)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not executable")
+
+	err = CheckExecutable(dir)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not a regular file")
+
+	err = CheckExecutable(filepath.Join(dir, "missing"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "binary not found")
+}
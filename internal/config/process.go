@@ -0,0 +1,195 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// RlimitUnlimited lifts a resource limit, e.g. daemon_rlimit_core = "unlimited"
+const RlimitUnlimited = "unlimited"
+
+// RlimInfinity is the value of an unlimited resource limit
+const RlimInfinity = ^uint64(0)
+
+// Bounds of the node scheduling and OOM settings
+const (
+	MinNice        = -20
+	MaxNice        = 19
+	MinOOMScoreAdj = -1000
+	MaxOOMScoreAdj = 1000
+)
+
+// nrOpenPath holds the kernel ceiling for RLIMIT_NOFILE, replaced in tests
+var nrOpenPath = "/proc/sys/fs/nr_open"
+
+// HasNodeCredential reports whether the node runs as a different user or
+// group than wemixvisor
+func (c *Config) HasNodeCredential() bool {
+	return c.RunAsUser != "" || c.RunAsGroup != "" || len(c.SupplementaryGroups) > 0
+}
+
+// NodeCredential returns the user, group and supplementary groups the node
+// runs as, or nil if it runs as wemixvisor's own user. Users and groups are
+// given by name or numeric ID. The group defaults to the primary group of
+// the user, and the node gets no supplementary groups unless configured.
+func (c *Config) NodeCredential() (*syscall.Credential, error) {
+	if !c.HasNodeCredential() {
+		return nil, nil
+	}
+
+	cred := &syscall.Credential{
+		Uid:    uint32(os.Getuid()),
+		Gid:    uint32(os.Getgid()),
+		Groups: []uint32{},
+	}
+
+	if c.RunAsUser != "" {
+		uid, gid, err := lookupUser(c.RunAsUser)
+		if err != nil {
+			return nil, err
+		}
+		cred.Uid = uid
+		if gid != nil {
+			cred.Gid = *gid
+		} else if c.RunAsGroup == "" {
+			return nil, fmt.Errorf("user %s has no passwd entry, set the group to run as", c.RunAsUser)
+		}
+	}
+
+	if c.RunAsGroup != "" {
+		gid, err := lookupGroup(c.RunAsGroup)
+		if err != nil {
+			return nil, err
+		}
+		cred.Gid = gid
+	}
+
+	for _, name := range c.SupplementaryGroups {
+		gid, err := lookupGroup(name)
+		if err != nil {
+			return nil, err
+		}
+		cred.Groups = append(cred.Groups, gid)
+	}
+
+	return cred, nil
+}
+
+// RlimitCoreValue returns the core dump size limit of the node and whether
+// one is configured
+func (c *Config) RlimitCoreValue() (uint64, bool, error) {
+	if c.RlimitCore == "" {
+		return 0, false, nil
+	}
+	limit, err := ParseRlimit(c.RlimitCore)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid core limit: %w", err)
+	}
+	return limit, true, nil
+}
+
+// ParseRlimit parses a resource limit given as a number or "unlimited"
+func ParseRlimit(value string) (uint64, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, RlimitUnlimited) || strings.EqualFold(value, "infinity") {
+		return RlimInfinity, nil
+	}
+	limit, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%q is neither a number nor %q", value, RlimitUnlimited)
+	}
+	return limit, nil
+}
+
+// validateProcessLimits checks that the node user and groups exist, that
+// wemixvisor may switch to them, and that the limits are within the range
+// the kernel accepts
+func validateProcessLimits(cfg *Config) error {
+	cred, err := cfg.NodeCredential()
+	if err != nil {
+		return err
+	}
+	if cred != nil && os.Geteuid() != 0 && (cred.Uid != uint32(os.Geteuid()) || cred.Gid != uint32(os.Getegid())) {
+		return fmt.Errorf("running the node as another user or group requires running wemixvisor as root")
+	}
+
+	if cfg.RlimitNoFile > 0 {
+		if ceiling, ok := readNrOpen(); ok && cfg.RlimitNoFile > ceiling {
+			return fmt.Errorf("open file limit %d exceeds the kernel maximum %d (fs.nr_open)", cfg.RlimitNoFile, ceiling)
+		}
+	}
+
+	if _, _, err := cfg.RlimitCoreValue(); err != nil {
+		return err
+	}
+
+	if cfg.Nice < MinNice || cfg.Nice > MaxNice {
+		return fmt.Errorf("nice value %d out of range (%d to %d)", cfg.Nice, MinNice, MaxNice)
+	}
+
+	if cfg.OOMScoreAdj != nil && (*cfg.OOMScoreAdj < MinOOMScoreAdj || *cfg.OOMScoreAdj > MaxOOMScoreAdj) {
+		return fmt.Errorf("OOM score adjustment %d out of range (%d to %d)", *cfg.OOMScoreAdj, MinOOMScoreAdj, MaxOOMScoreAdj)
+	}
+
+	return nil
+}
+
+// lookupUser resolves a user name or numeric ID to its UID and primary GID.
+// A numeric ID without a passwd entry yields no GID.
+func lookupUser(name string) (uint32, *uint32, error) {
+	var u *user.User
+	var err error
+	if id, convErr := strconv.ParseUint(name, 10, 32); convErr == nil {
+		u, err = user.LookupId(name)
+		if err != nil {
+			return uint32(id), nil, nil
+		}
+	} else if u, err = user.Lookup(name); err != nil {
+		return 0, nil, fmt.Errorf("unknown user %s: %w", name, err)
+	}
+
+	uid, err := strconv.ParseUint(u.Uid, 10, 32)
+	if err != nil {
+		return 0, nil, fmt.Errorf("user %s has non-numeric uid %s", name, u.Uid)
+	}
+	gid, err := strconv.ParseUint(u.Gid, 10, 32)
+	if err != nil {
+		return 0, nil, fmt.Errorf("user %s has non-numeric gid %s", name, u.Gid)
+	}
+	primary := uint32(gid)
+	return uint32(uid), &primary, nil
+}
+
+// lookupGroup resolves a group name or numeric ID to its GID
+func lookupGroup(name string) (uint32, error) {
+	if id, err := strconv.ParseUint(name, 10, 32); err == nil {
+		return uint32(id), nil
+	}
+
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		return 0, fmt.Errorf("unknown group %s: %w", name, err)
+	}
+	gid, err := strconv.ParseUint(g.Gid, 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("group %s has non-numeric gid %s", name, g.Gid)
+	}
+	return uint32(gid), nil
+}
+
+// readNrOpen returns the kernel ceiling for the open file limit, if known
+func readNrOpen() (uint64, bool) {
+	data, err := os.ReadFile(nrOpenPath)
+	if err != nil {
+		return 0, false
+	}
+	ceiling, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return ceiling, true
+}
@@ -0,0 +1,143 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_NodeCredential(t *testing.T) {
+	cred, err := (&Config{}).NodeCredential()
+	require.NoError(t, err)
+	assert.Nil(t, cred, "no credential without a user or group")
+
+	cred, err = (&Config{RunAsUser: "root"}).NodeCredential()
+	require.NoError(t, err)
+	assert.Equal(t, &syscall.Credential{Uid: 0, Gid: 0, Groups: []uint32{}}, cred)
+
+	cred, err = (&Config{
+		RunAsUser:           "0",
+		RunAsGroup:          "4242",
+		SupplementaryGroups: []string{"root", "4243"},
+	}).NodeCredential()
+	require.NoError(t, err)
+	assert.Equal(t, &syscall.Credential{Uid: 0, Gid: 4242, Groups: []uint32{0, 4243}}, cred)
+
+	// A numeric user without a passwd entry needs an explicit group
+	cred, err = (&Config{RunAsUser: "4242", RunAsGroup: "4242"}).NodeCredential()
+	require.NoError(t, err)
+	assert.Equal(t, uint32(4242), cred.Uid)
+}
+
+func TestConfig_NodeCredential_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config *Config
+		errMsg string
+	}{
+		{
+			name:   "unknown user",
+			config: &Config{RunAsUser: "wemixvisor-no-such-user"},
+			errMsg: "unknown user wemixvisor-no-such-user",
+		},
+		{
+			name:   "unknown group",
+			config: &Config{RunAsGroup: "wemixvisor-no-such-group"},
+			errMsg: "unknown group wemixvisor-no-such-group",
+		},
+		{
+			name:   "unknown supplementary group",
+			config: &Config{SupplementaryGroups: []string{"wemixvisor-no-such-group"}},
+			errMsg: "unknown group",
+		},
+		{
+			name:   "numeric user without group",
+			config: &Config{RunAsUser: "4242"},
+			errMsg: "has no passwd entry",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.config.NodeCredential()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestParseRlimit(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    uint64
+		wantErr bool
+	}{
+		{value: "0", want: 0},
+		{value: "65536", want: 65536},
+		{value: "unlimited", want: RlimInfinity},
+		{value: "Infinity", want: RlimInfinity},
+		{value: "-1", wantErr: true},
+		{value: "1G", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseRlimit(tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateProcessLimits(t *testing.T) {
+	nrOpen := filepath.Join(t.TempDir(), "nr_open")
+	require.NoError(t, os.WriteFile(nrOpen, []byte("1048576\n"), 0644))
+	original := nrOpenPath
+	nrOpenPath = nrOpen
+	defer func() { nrOpenPath = original }()
+
+	intPtr := func(v int) *int { return &v }
+
+	tests := []struct {
+		name   string
+		config *Config
+		errMsg string
+	}{
+		{name: "defaults", config: &Config{}},
+		{
+			name: "all set",
+			config: &Config{
+				RlimitNoFile: 65536,
+				RlimitCore:   "0",
+				Nice:         5,
+				OOMScoreAdj:  intPtr(-500),
+			},
+		},
+		{name: "open file limit above kernel maximum", config: &Config{RlimitNoFile: 2 << 20}, errMsg: "exceeds the kernel maximum 1048576"},
+		{name: "bad core limit", config: &Config{RlimitCore: "lots"}, errMsg: "invalid core limit"},
+		{name: "nice too low", config: &Config{Nice: -21}, errMsg: "nice value -21 out of range"},
+		{name: "nice too high", config: &Config{Nice: 20}, errMsg: "nice value 20 out of range"},
+		{name: "OOM score too high", config: &Config{OOMScoreAdj: intPtr(1001)}, errMsg: "OOM score adjustment 1001 out of range"},
+		{name: "unknown user", config: &Config{RunAsUser: "wemixvisor-no-such-user"}, errMsg: "unknown user"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateProcessLimits(tt.config)
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Stop step actions
+const (
+	StopActionRPC     = "rpc"
+	StopActionSIGINT  = "SIGINT"
+	StopActionSIGTERM = "SIGTERM"
+	StopActionSIGQUIT = "SIGQUIT"
+	StopActionSIGKILL = "SIGKILL"
+)
+
+// DefaultKillTimeout is how long to wait for the node to exit after SIGKILL
+const DefaultKillTimeout = 5 * time.Second
+
+// StopStep is one step of the node stop sequence: an action followed by
+// a wait of up to Timeout for the node to exit
+type StopStep struct {
+	Action  string
+	Timeout time.Duration
+}
+
+// String returns the step in its configuration form, e.g. "SIGINT:30s"
+func (s StopStep) String() string {
+	return fmt.Sprintf("%s:%s", s.Action, s.Timeout)
+}
+
+// IsSignal reports whether the step sends a signal rather than calling RPC
+func (s StopStep) IsSignal() bool {
+	return s.Action != StopActionRPC
+}
+
+// ParseStopStep parses a step of the form "<action>[:<timeout>]". Signal
+// names are case-insensitive and may omit the SIG prefix. A missing timeout
+// defaults to grace, or DefaultKillTimeout for SIGKILL.
+func ParseStopStep(spec string, grace time.Duration) (StopStep, error) {
+	spec = strings.TrimSpace(spec)
+	name, timeoutSpec, hasTimeout := strings.Cut(spec, ":")
+
+	var step StopStep
+	switch action := strings.ToUpper(strings.TrimSpace(name)); action {
+	case "RPC":
+		step.Action = StopActionRPC
+	case "INT", "SIGINT":
+		step.Action = StopActionSIGINT
+	case "TERM", "SIGTERM":
+		step.Action = StopActionSIGTERM
+	case "QUIT", "SIGQUIT":
+		step.Action = StopActionSIGQUIT
+	case "KILL", "SIGKILL":
+		step.Action = StopActionSIGKILL
+	default:
+		return step, fmt.Errorf("unknown stop step action: %q", name)
+	}
+
+	step.Timeout = grace
+	if step.Action == StopActionSIGKILL {
+		step.Timeout = DefaultKillTimeout
+	}
+
+	if hasTimeout {
+		timeout, err := time.ParseDuration(strings.TrimSpace(timeoutSpec))
+		if err != nil {
+			return step, fmt.Errorf("invalid timeout in stop step %q: %w", spec, err)
+		}
+		if timeout <= 0 {
+			return step, fmt.Errorf("stop step %q timeout must be positive", spec)
+		}
+		step.Timeout = timeout
+	}
+
+	return step, nil
+}
+
+// StopSteps returns the configured stop sequence. Without an explicit
+// sequence the node gets SIGTERM and the shutdown grace period, then SIGKILL.
+func (c *Config) StopSteps() ([]StopStep, error) {
+	grace := c.ShutdownGrace
+	if grace <= 0 {
+		grace = DefaultShutdownGrace
+	}
+
+	if len(c.StopSequence) == 0 {
+		return []StopStep{
+			{Action: StopActionSIGTERM, Timeout: grace},
+			{Action: StopActionSIGKILL, Timeout: DefaultKillTimeout},
+		}, nil
+	}
+
+	steps := make([]StopStep, 0, len(c.StopSequence))
+	for _, spec := range c.StopSequence {
+		// Accept a single comma-separated entry as well, as produced by
+		// flags and environment variables
+		for _, part := range strings.Split(spec, ",") {
+			if strings.TrimSpace(part) == "" {
+				continue
+			}
+			step, err := ParseStopStep(part, grace)
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, step)
+		}
+	}
+
+	if len(steps) == 0 {
+		return nil, fmt.Errorf("stop sequence has no steps")
+	}
+	if steps[len(steps)-1].Action != StopActionSIGKILL {
+		return nil, fmt.Errorf("stop sequence must end with SIGKILL")
+	}
+
+	return steps, nil
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStopStep(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    StopStep
+		wantErr bool
+	}{
+		{"rpc:10s", StopStep{StopActionRPC, 10 * time.Second}, false},
+		{"SIGINT:30s", StopStep{StopActionSIGINT, 30 * time.Second}, false},
+		{"term", StopStep{StopActionSIGTERM, time.Minute}, false},
+		{" sigquit : 2s ", StopStep{StopActionSIGQUIT, 2 * time.Second}, false},
+		{"KILL", StopStep{StopActionSIGKILL, DefaultKillTimeout}, false},
+		{"SIGHUP:1s", StopStep{}, true},
+		{"SIGINT:soon", StopStep{}, true},
+		{"SIGINT:0s", StopStep{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			step, err := ParseStopStep(tt.spec, time.Minute)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, step)
+		})
+	}
+}
+
+func TestConfig_StopSteps(t *testing.T) {
+	cfg := &Config{ShutdownGrace: 10 * time.Second}
+
+	steps, err := cfg.StopSteps()
+	require.NoError(t, err)
+	assert.Equal(t, []StopStep{
+		{StopActionSIGTERM, 10 * time.Second},
+		{StopActionSIGKILL, DefaultKillTimeout},
+	}, steps)
+
+	cfg.StopSequence = []string{"rpc:5s,SIGINT", "SIGKILL:1s"}
+	steps, err = cfg.StopSteps()
+	require.NoError(t, err)
+	assert.Equal(t, []StopStep{
+		{StopActionRPC, 5 * time.Second},
+		{StopActionSIGINT, 10 * time.Second},
+		{StopActionSIGKILL, time.Second},
+	}, steps)
+
+	cfg.StopSequence = []string{"SIGINT:5s"}
+	_, err = cfg.StopSteps()
+	assert.Error(t, err, "sequence must end with SIGKILL")
+}
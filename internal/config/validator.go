@@ -95,7 +95,9 @@ func (v *Validator) registerDefaultRules() {
 		&networkValidationRule{},
 		&resourceValidationRule{},
 		&securityValidationRule{},
+		&processValidationRule{},
 		&compatibilityValidationRule{},
+		&instanceValidationRule{},
 	}
 }
 
@@ -395,6 +397,11 @@ func (r *resourceValidationRule) Validate(cfg *Config) error {
 		return fmt.Errorf("health check interval too short (min 5s)")
 	}
 
+	// Validate node memory limit
+	if cfg.MaxMemoryMB < 0 {
+		return fmt.Errorf("max memory cannot be negative")
+	}
+
 	// Validate metrics interval
 	if cfg.MetricsInterval < 0 {
 		return fmt.Errorf("metrics interval cannot be negative")
@@ -413,6 +420,103 @@ func (r *resourceValidationRule) Validate(cfg *Config) error {
 		return fmt.Errorf("max restarts too high (max 100)")
 	}
 
+	// Validate restart policy
+	if cfg.RestartBackoffInitial < 0 || cfg.RestartBackoffMax < 0 {
+		return fmt.Errorf("restart backoff cannot be negative")
+	}
+
+	if cfg.RestartBackoffMax > 0 && cfg.RestartBackoffInitial > cfg.RestartBackoffMax {
+		return fmt.Errorf("initial restart backoff exceeds maximum backoff")
+	}
+
+	if cfg.RestartBackoffFactor != 0 && cfg.RestartBackoffFactor < 1 {
+		return fmt.Errorf("restart backoff factor must be at least 1")
+	}
+
+	if cfg.RestartBackoffJitter < 0 || cfg.RestartBackoffJitter > 1 {
+		return fmt.Errorf("restart backoff jitter must be between 0 and 1")
+	}
+
+	if cfg.RestartResetAfter < 0 || cfg.CrashLoopWindow < 0 || cfg.CrashLoopThreshold < 0 || cfg.CrashRecordsMax < 0 {
+		return fmt.Errorf("crash loop settings cannot be negative")
+	}
+
+	// Validate node output capture
+	if cfg.LogMaxSizeMB < 0 || cfg.LogMaxBackups < 0 || cfg.LogTailLines < 0 {
+		return fmt.Errorf("log rotation settings cannot be negative")
+	}
+
+	if cfg.LogMaxAge < 0 || cfg.LogRotateInterval < 0 {
+		return fmt.Errorf("log rotation durations cannot be negative")
+	}
+
+	// Validate startup readiness
+	switch cfg.ReadinessCheck {
+	case "", ReadinessNone, ReadinessRPC, ReadinessHeight:
+	case ReadinessCommand:
+		if cfg.ReadinessCommand == "" {
+			return fmt.Errorf("readiness command is required for the command readiness check")
+		}
+	default:
+		return fmt.Errorf("unknown readiness check: %s", cfg.ReadinessCheck)
+	}
+
+	if cfg.ReadinessInterval < 0 || cfg.StartupTimeout < 0 {
+		return fmt.Errorf("readiness durations cannot be negative")
+	}
+
+	// Validate stalled-chain watchdog
+	if cfg.StallTimeout < 0 || cfg.ExpectedBlockInterval < 0 {
+		return fmt.Errorf("stall watchdog durations cannot be negative")
+	}
+
+	if cfg.StallTimeout > 0 {
+		switch cfg.StallAction {
+		case "", StallActionAlert, StallActionRestart:
+		case StallActionRestartWithArgs:
+			if len(cfg.StallRestartArgs) == 0 {
+				return fmt.Errorf("stall restart args are required for the restart_with_args stall action")
+			}
+		default:
+			return fmt.Errorf("unknown stall action: %s", cfg.StallAction)
+		}
+
+		if cfg.ExpectedBlockInterval > 0 && cfg.StallTimeout <= cfg.ExpectedBlockInterval {
+			return fmt.Errorf("stall timeout must be longer than the expected block interval")
+		}
+	}
+
+	// Validate halt markers
+	if _, err := cfg.HaltMarkerPatterns(); err != nil {
+		return err
+	}
+
+	// Validate post-upgrade verification
+	if cfg.UpgradeVerifyBlocks < 0 || cfg.UpgradeVerifyTimeout < 0 || cfg.UpgradeVerifyCommandTimeout < 0 {
+		return fmt.Errorf("upgrade verification settings cannot be negative")
+	}
+
+	// Validate upgrade staging
+	if cfg.StagingAlertBlocks < 0 {
+		return fmt.Errorf("staging alert blocks cannot be negative")
+	}
+
+	// Validate pre-switch smoke command
+	if cfg.SmokeTimeout < 0 {
+		return fmt.Errorf("smoke timeout cannot be negative")
+	}
+
+	// Validate stop sequence
+	steps, err := cfg.StopSteps()
+	if err != nil {
+		return fmt.Errorf("invalid stop sequence: %w", err)
+	}
+	for _, step := range steps {
+		if step.Action == StopActionRPC && cfg.StopRPCMethod == "" {
+			return fmt.Errorf("stop RPC method is required for the rpc stop step")
+		}
+	}
+
 	// Validate pre-upgrade max retries
 	if cfg.PreUpgradeMaxRetries < 0 {
 		return fmt.Errorf("pre-upgrade max retries cannot be negative")
@@ -443,6 +547,10 @@ func (r *securityValidationRule) Validate(cfg *Config) error {
 		return fmt.Errorf("downloading binaries without checksum verification is extremely unsafe")
 	}
 
+	if err := validateEnvironment(cfg); err != nil {
+		return fmt.Errorf("invalid node environment: %w", err)
+	}
+
 	// Validate custom pre-upgrade script if specified
 	if cfg.CustomPreUpgrade != "" {
 		// Check if file exists
@@ -464,6 +572,34 @@ func (r *securityValidationRule) Validate(cfg *Config) error {
 	return nil
 }
 
+// processValidationRule validates the privileges and limits of the node process
+type processValidationRule struct{}
+
+func (r *processValidationRule) Name() string {
+	return "ProcessValidation"
+}
+
+func (r *processValidationRule) Validate(cfg *Config) error {
+	if err := validateProcessLimits(cfg); err != nil {
+		return fmt.Errorf("invalid node process settings: %w", err)
+	}
+	return nil
+}
+
+// instanceValidationRule validates named node instances
+type instanceValidationRule struct{}
+
+func (r *instanceValidationRule) Name() string {
+	return "InstanceValidation"
+}
+
+func (r *instanceValidationRule) Validate(cfg *Config) error {
+	if err := validateInstances(cfg.Instances); err != nil {
+		return fmt.Errorf("invalid instances: %w", err)
+	}
+	return nil
+}
+
 // compatibilityValidationRule validates version compatibility
 type compatibilityValidationRule struct{}
 
@@ -531,6 +531,138 @@ func TestResourceValidationRule(t *testing.T) {
 			wantErr: true,
 			errMsg:  "too high",
 		},
+		{
+			name: "initial backoff exceeds max",
+			config: &Config{
+				RestartBackoffInitial: 10 * time.Minute,
+				RestartBackoffMax:     time.Minute,
+			},
+			wantErr: true,
+			errMsg:  "exceeds maximum",
+		},
+		{
+			name: "backoff factor below one",
+			config: &Config{
+				RestartBackoffFactor: 0.5,
+			},
+			wantErr: true,
+			errMsg:  "at least 1",
+		},
+		{
+			name: "jitter out of range",
+			config: &Config{
+				RestartBackoffJitter: 1.5,
+			},
+			wantErr: true,
+			errMsg:  "between 0 and 1",
+		},
+		{
+			name: "negative log backups",
+			config: &Config{
+				LogMaxBackups: -1,
+			},
+			wantErr: true,
+			errMsg:  "cannot be negative",
+		},
+		{
+			name: "unknown readiness check",
+			config: &Config{
+				ReadinessCheck: "magic",
+			},
+			wantErr: true,
+			errMsg:  "unknown readiness check",
+		},
+		{
+			name: "command readiness without command",
+			config: &Config{
+				ReadinessCheck: ReadinessCommand,
+			},
+			wantErr: true,
+			errMsg:  "readiness command is required",
+		},
+		{
+			name: "negative max memory",
+			config: &Config{
+				MaxMemoryMB: -1,
+			},
+			wantErr: true,
+			errMsg:  "max memory cannot be negative",
+		},
+		{
+			name: "unknown stall action",
+			config: &Config{
+				StallTimeout: time.Minute,
+				StallAction:  "reboot",
+			},
+			wantErr: true,
+			errMsg:  "unknown stall action",
+		},
+		{
+			name: "stall restart with args without args",
+			config: &Config{
+				StallTimeout: time.Minute,
+				StallAction:  StallActionRestartWithArgs,
+			},
+			wantErr: true,
+			errMsg:  "stall restart args are required",
+		},
+		{
+			name: "stall timeout within block interval",
+			config: &Config{
+				StallTimeout:          time.Second,
+				ExpectedBlockInterval: 2 * time.Second,
+			},
+			wantErr: true,
+			errMsg:  "longer than the expected block interval",
+		},
+		{
+			name: "stop sequence without SIGKILL",
+			config: &Config{
+				StopSequence: []string{"SIGINT:30s"},
+			},
+			wantErr: true,
+			errMsg:  "must end with SIGKILL",
+		},
+		{
+			name: "rpc stop step without method",
+			config: &Config{
+				StopSequence: []string{"rpc:10s", "SIGKILL"},
+			},
+			wantErr: true,
+			errMsg:  "stop RPC method is required",
+		},
+		{
+			name: "negative crash loop threshold",
+			config: &Config{
+				CrashLoopThreshold: -1,
+			},
+			wantErr: true,
+			errMsg:  "cannot be negative",
+		},
+		{
+			name: "negative staging alert blocks",
+			config: &Config{
+				StagingAlertBlocks: -1,
+			},
+			wantErr: true,
+			errMsg:  "staging alert blocks cannot be negative",
+		},
+		{
+			name: "negative smoke timeout",
+			config: &Config{
+				SmokeTimeout: -time.Second,
+			},
+			wantErr: true,
+			errMsg:  "smoke timeout cannot be negative",
+		},
+		{
+			name: "negative verification command timeout",
+			config: &Config{
+				UpgradeVerifyCommandTimeout: -time.Second,
+			},
+			wantErr: true,
+			errMsg:  "upgrade verification settings cannot be negative",
+		},
 	}
 
 	for _, tt := range tests {
@@ -595,6 +727,30 @@ func TestSecurityValidationRule(t *testing.T) {
 			wantErr: true,
 			errMsg:  "not found",
 		},
+		{
+			name: "environment references",
+			config: &Config{
+				Environment: map[string]string{"KEYSTORE_PASSWORD": "file:/etc/wemix/password"},
+				EnvFiles:    []string{"/etc/wemix/node.env"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "empty environment reference",
+			config: &Config{
+				Environment: map[string]string{"KEYSTORE_PASSWORD": "env:"},
+			},
+			wantErr: true,
+			errMsg:  "empty reference",
+		},
+		{
+			name: "empty env file path",
+			config: &Config{
+				EnvFiles: []string{""},
+			},
+			wantErr: true,
+			errMsg:  "empty env file path",
+		},
 	}
 
 	for _, tt := range tests {
@@ -671,6 +827,90 @@ func TestCompatibilityValidationRule(t *testing.T) {
 	}
 }
 
+func TestProcessValidationRule(t *testing.T) {
+	rule := &processValidationRule{}
+	assert.Equal(t, "ProcessValidation", rule.Name())
+
+	assert.NoError(t, rule.Validate(&Config{}))
+	assert.NoError(t, rule.Validate(&Config{RunAsUser: "root", Nice: 10}))
+
+	err := rule.Validate(&Config{Nice: 40})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid node process settings: nice value 40 out of range")
+}
+
+func TestInstanceValidationRule(t *testing.T) {
+	rule := &instanceValidationRule{}
+	assert.Equal(t, "InstanceValidation", rule.Name())
+
+	tests := []struct {
+		name    string
+		config  *Config
+		wantErr bool
+	}{
+		{
+			name:    "no instances",
+			config:  &Config{},
+			wantErr: false,
+		},
+		{
+			name: "distinct instances",
+			config: &Config{
+				Instances: []InstanceConfig{
+					{Name: "validator", Home: "/data/validator"},
+					{Name: "sentry", Home: "/data/sentry"},
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "duplicate name",
+			config: &Config{
+				Instances: []InstanceConfig{
+					{Name: "node", Home: "/data/a"},
+					{Name: "node", Home: "/data/b"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "shared home",
+			config: &Config{
+				Instances: []InstanceConfig{
+					{Name: "a", Home: "/data/node"},
+					{Name: "b", Home: "/data/node/"},
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "missing home",
+			config: &Config{
+				Instances: []InstanceConfig{{Name: "a"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "name with slash",
+			config: &Config{
+				Instances: []InstanceConfig{{Name: "a/b", Home: "/data/a"}},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := rule.Validate(tt.config)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
 func TestValidator_AddRule(t *testing.T) {
 	logger := logger.NewTestLogger()
 	validator := NewValidator(logger)
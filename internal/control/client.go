@@ -0,0 +1,145 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/node"
+	"github.com/wemix/wemixvisor/internal/orchestrator"
+	"github.com/wemix/wemixvisor/internal/staging"
+	"github.com/wemix/wemixvisor/pkg/types"
+)
+
+// Default values for the control client
+const (
+	DefaultDialTimeout = 2 * time.Second
+)
+
+// ErrNotRunning is returned when no supervisor is listening on the socket
+var ErrNotRunning = errors.New("wemixvisor is not running")
+
+// Client sends requests to a running supervisor
+type Client struct {
+	socketPath  string
+	dialTimeout time.Duration
+	timeout     time.Duration
+}
+
+// NewClient creates a control client for the configured socket path
+func NewClient(cfg *config.Config) *Client {
+	return &Client{
+		socketPath:  cfg.ControlSocketPath(),
+		dialTimeout: DefaultDialTimeout,
+		timeout:     DefaultRequestTimeout,
+	}
+}
+
+// IsAvailable returns true if a supervisor answers on the control socket
+func (c *Client) IsAvailable() bool {
+	_, err := c.Call(&Request{Command: CommandPing})
+	return err == nil
+}
+
+// Call sends a request and waits for the response.
+// A response with OK=false is returned as an error.
+func (c *Client) Call(req *Request) (*Response, error) {
+	conn, err := net.DialTimeout("unix", c.socketPath, c.dialTimeout)
+	if err != nil {
+		return nil, ErrNotRunning
+	}
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(c.timeout))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, fmt.Errorf("failed to send control request: %w", err)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("failed to read control response: %w", err)
+	}
+
+	if !resp.OK {
+		return &resp, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
+
+// Status returns the status of the supervised node
+func (c *Client) Status() (*node.Status, error) {
+	resp, err := c.Call(&Request{Command: CommandStatus})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Status, nil
+}
+
+// Start starts the supervised node with the given arguments
+func (c *Client) Start(args []string) (*node.Status, error) {
+	resp, err := c.Call(&Request{Command: CommandStart, Args: args})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Status, nil
+}
+
+// Stop stops the supervised node
+func (c *Client) Stop() (*node.Status, error) {
+	resp, err := c.Call(&Request{Command: CommandStop})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Status, nil
+}
+
+// Restart restarts the supervised node, replacing its arguments if any are given
+func (c *Client) Restart(args []string) (*node.Status, error) {
+	resp, err := c.Call(&Request{Command: CommandRestart, Args: args})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Status, nil
+}
+
+// UpgradeStatus returns the live upgrade orchestrator status
+func (c *Client) UpgradeStatus() (*orchestrator.UpgradeStatus, error) {
+	resp, err := c.Call(&Request{Command: CommandUpgradeStatus})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Upgrade, nil
+}
+
+// ScheduleUpgrade hands an upgrade plan to the live orchestrator
+func (c *Client) ScheduleUpgrade(info *types.UpgradeInfo) (*orchestrator.UpgradeStatus, error) {
+	resp, err := c.Call(&Request{Command: CommandUpgradeSchedule, Upgrade: info})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Upgrade, nil
+}
+
+// RollbackUpgrade asks the live orchestrator to roll the node back to
+// target, or to the last known-good upgrade if target is empty
+func (c *Client) RollbackUpgrade(target string) (*orchestrator.RollbackResult, error) {
+	resp, err := c.Call(&Request{Command: CommandUpgradeRollback, Target: target})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Rollback, nil
+}
+
+// UpgradeStaging returns the staging of the upgrade binaries known to the
+// supervisor
+func (c *Client) UpgradeStaging() ([]staging.Status, error) {
+	resp, err := c.Call(&Request{Command: CommandUpgradeStaging})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Staging, nil
+}
@@ -0,0 +1,50 @@
+// Package control provides the local control channel between the CLI and a
+// running wemixvisor supervisor.
+//
+// The supervisor listens on a Unix domain socket under WemixvisorDir(). Each
+// connection carries exactly one JSON-encoded Request followed by one
+// JSON-encoded Response, after which the connection is closed.
+package control
+
+import (
+	"github.com/wemix/wemixvisor/internal/node"
+	"github.com/wemix/wemixvisor/internal/orchestrator"
+	"github.com/wemix/wemixvisor/internal/staging"
+	"github.com/wemix/wemixvisor/pkg/types"
+)
+
+// Command names understood by the control server
+const (
+	CommandPing            = "ping"
+	CommandStart           = "start"
+	CommandStop            = "stop"
+	CommandRestart         = "restart"
+	CommandStatus          = "status"
+	CommandUpgradeStatus   = "upgrade.status"
+	CommandUpgradeSchedule = "upgrade.schedule"
+	CommandUpgradeRollback = "upgrade.rollback"
+	CommandUpgradeStaging  = "upgrade.staging"
+)
+
+// Request is a single command sent to the supervisor
+type Request struct {
+	Command string             `json:"command"`
+	Args    []string           `json:"args,omitempty"`
+	Upgrade *types.UpgradeInfo `json:"upgrade,omitempty"`
+	Target  string             `json:"target,omitempty"`
+}
+
+// Response is the supervisor's reply to a Request
+type Response struct {
+	OK       bool                         `json:"ok"`
+	Error    string                       `json:"error,omitempty"`
+	Status   *node.Status                 `json:"status,omitempty"`
+	Upgrade  *orchestrator.UpgradeStatus  `json:"upgrade,omitempty"`
+	Rollback *orchestrator.RollbackResult `json:"rollback,omitempty"`
+	Staging  []staging.Status             `json:"staging,omitempty"`
+}
+
+// errorResponse builds a failed response from an error
+func errorResponse(err error) *Response {
+	return &Response{OK: false, Error: err.Error()}
+}
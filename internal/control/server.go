@@ -0,0 +1,288 @@
+package control
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/node"
+	"github.com/wemix/wemixvisor/internal/orchestrator"
+	"github.com/wemix/wemixvisor/internal/staging"
+	"github.com/wemix/wemixvisor/pkg/logger"
+	"github.com/wemix/wemixvisor/pkg/types"
+)
+
+// Default values for the control server
+const (
+	DefaultRequestTimeout = 2 * time.Minute
+	SocketPermissions     = 0600
+)
+
+// NodeManager is the subset of node.Manager exposed over the control socket
+type NodeManager interface {
+	Start(args []string) error
+	Stop() error
+	Restart() error
+	GetState() node.NodeState
+	GetStatus() *node.Status
+	SetNodeArgs(args []string)
+}
+
+// UpgradeController is the subset of the upgrade orchestrator exposed over
+// the control socket
+type UpgradeController interface {
+	GetStatus() *orchestrator.UpgradeStatus
+	ScheduleUpgrade(upgrade *types.UpgradeInfo) error
+	Rollback(target string) (*orchestrator.RollbackResult, error)
+}
+
+// StagingReporter reports the staging of upgrade binaries over the control
+// socket
+type StagingReporter interface {
+	Status() []staging.Status
+}
+
+// Server serves control requests for a running supervisor
+type Server struct {
+	socketPath string
+	logger     *logger.Logger
+	manager    NodeManager
+	upgrades   UpgradeController
+	stager     StagingReporter
+
+	listener net.Listener
+	mu       sync.Mutex
+	wg       sync.WaitGroup
+}
+
+// NewServer creates a control server bound to the configured socket path
+func NewServer(cfg *config.Config, manager NodeManager, log *logger.Logger) *Server {
+	return &Server{
+		socketPath: cfg.ControlSocketPath(),
+		logger:     log,
+		manager:    manager,
+	}
+}
+
+// SetUpgradeController attaches the upgrade orchestrator to the server
+func (s *Server) SetUpgradeController(upgrades UpgradeController) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.upgrades = upgrades
+}
+
+// SetStager attaches the upgrade binary stager to the server
+func (s *Server) SetStager(stager StagingReporter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stager = stager
+}
+
+// SocketPath returns the path of the Unix socket the server listens on
+func (s *Server) SocketPath() string {
+	return s.socketPath
+}
+
+// Start begins listening on the control socket
+func (s *Server) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener != nil {
+		return fmt.Errorf("control server already started")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.socketPath), 0755); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+
+	if err := s.removeStaleSocket(); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("unix", s.socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on control socket: %w", err)
+	}
+
+	if err := os.Chmod(s.socketPath, SocketPermissions); err != nil {
+		listener.Close()
+		return fmt.Errorf("failed to set control socket permissions: %w", err)
+	}
+
+	s.listener = listener
+	s.wg.Add(1)
+	go s.acceptLoop(listener)
+
+	s.logger.Info("control server started", zap.String("socket", s.socketPath))
+	return nil
+}
+
+// Stop closes the control socket and waits for in-flight requests
+func (s *Server) Stop() error {
+	s.mu.Lock()
+	listener := s.listener
+	s.listener = nil
+	s.mu.Unlock()
+
+	if listener == nil {
+		return nil
+	}
+
+	err := listener.Close()
+	s.wg.Wait()
+	os.Remove(s.socketPath)
+
+	s.logger.Info("control server stopped")
+	return err
+}
+
+// removeStaleSocket removes a socket file left behind by a dead supervisor.
+// It refuses to touch a socket that another supervisor is still serving.
+func (s *Server) removeStaleSocket() error {
+	if _, err := os.Stat(s.socketPath); os.IsNotExist(err) {
+		return nil
+	}
+
+	if conn, err := net.DialTimeout("unix", s.socketPath, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("another wemixvisor is already serving %s", s.socketPath)
+	}
+
+	if err := os.Remove(s.socketPath); err != nil {
+		return fmt.Errorf("failed to remove stale control socket: %w", err)
+	}
+	return nil
+}
+
+// acceptLoop accepts connections until the listener is closed
+func (s *Server) acceptLoop(listener net.Listener) {
+	defer s.wg.Done()
+
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			s.logger.Warn("control socket accept failed", zap.Error(err))
+			continue
+		}
+
+		s.wg.Add(1)
+		go s.handleConn(conn)
+	}
+}
+
+// handleConn reads a single request and writes its response
+func (s *Server) handleConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer conn.Close()
+
+	conn.SetDeadline(time.Now().Add(DefaultRequestTimeout))
+
+	var req Request
+	if err := json.NewDecoder(conn).Decode(&req); err != nil {
+		s.writeResponse(conn, errorResponse(fmt.Errorf("invalid request: %w", err)))
+		return
+	}
+
+	s.logger.Debug("control request received",
+		zap.String("command", req.Command),
+		zap.Strings("args", req.Args))
+
+	s.writeResponse(conn, s.dispatch(&req))
+}
+
+// writeResponse encodes a response onto the connection
+func (s *Server) writeResponse(conn net.Conn, resp *Response) {
+	if err := json.NewEncoder(conn).Encode(resp); err != nil {
+		s.logger.Warn("failed to write control response", zap.Error(err))
+	}
+}
+
+// dispatch executes a request against the live manager and orchestrator
+func (s *Server) dispatch(req *Request) *Response {
+	switch req.Command {
+	case CommandPing:
+		return &Response{OK: true}
+	case CommandStatus:
+		return &Response{OK: true, Status: s.manager.GetStatus()}
+	case CommandStart:
+		if err := s.manager.Start(req.Args); err != nil {
+			return errorResponse(err)
+		}
+		return &Response{OK: true, Status: s.manager.GetStatus()}
+	case CommandStop:
+		if err := s.manager.Stop(); err != nil {
+			return errorResponse(err)
+		}
+		return &Response{OK: true, Status: s.manager.GetStatus()}
+	case CommandRestart:
+		if len(req.Args) > 0 {
+			s.manager.SetNodeArgs(req.Args)
+		}
+		if err := s.manager.Restart(); err != nil {
+			return errorResponse(err)
+		}
+		return &Response{OK: true, Status: s.manager.GetStatus()}
+	case CommandUpgradeStatus:
+		upgrades := s.getUpgradeController()
+		if upgrades == nil {
+			return errorResponse(fmt.Errorf("upgrade automation is not enabled"))
+		}
+		return &Response{OK: true, Upgrade: upgrades.GetStatus()}
+	case CommandUpgradeSchedule:
+		upgrades := s.getUpgradeController()
+		if upgrades == nil {
+			return errorResponse(fmt.Errorf("upgrade automation is not enabled"))
+		}
+		if req.Upgrade == nil {
+			return errorResponse(fmt.Errorf("upgrade info is required"))
+		}
+		if err := upgrades.ScheduleUpgrade(req.Upgrade); err != nil {
+			return errorResponse(err)
+		}
+		return &Response{OK: true, Upgrade: upgrades.GetStatus()}
+	case CommandUpgradeRollback:
+		upgrades := s.getUpgradeController()
+		if upgrades == nil {
+			return errorResponse(fmt.Errorf("upgrade automation is not enabled"))
+		}
+		result, err := upgrades.Rollback(req.Target)
+		if err != nil {
+			return errorResponse(err)
+		}
+		return &Response{OK: true, Rollback: result, Status: s.manager.GetStatus()}
+	case CommandUpgradeStaging:
+		stager := s.getStager()
+		if stager == nil {
+			return errorResponse(fmt.Errorf("upgrade staging is not enabled"))
+		}
+		return &Response{OK: true, Staging: stager.Status()}
+	default:
+		return errorResponse(fmt.Errorf("unknown command: %s", req.Command))
+	}
+}
+
+// getUpgradeController returns the attached upgrade controller, if any
+func (s *Server) getUpgradeController() UpgradeController {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.upgrades
+}
+
+// getStager returns the attached stager, if any
+func (s *Server) getStager() StagingReporter {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.stager
+}
@@ -0,0 +1,252 @@
+package control
+
+import (
+	"errors"
+	"net"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/node"
+	"github.com/wemix/wemixvisor/internal/orchestrator"
+	"github.com/wemix/wemixvisor/internal/staging"
+	"github.com/wemix/wemixvisor/pkg/logger"
+	"github.com/wemix/wemixvisor/pkg/types"
+)
+
+// mockManager is a mock implementation of NodeManager for testing
+type mockManager struct {
+	mu       sync.Mutex
+	state    node.NodeState
+	args     []string
+	startErr error
+	restarts int
+}
+
+func (m *mockManager) Start(args []string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.startErr != nil {
+		return m.startErr
+	}
+	m.state = node.StateRunning
+	m.args = args
+	return nil
+}
+
+func (m *mockManager) Stop() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.state != node.StateRunning {
+		return errors.New("node is not running")
+	}
+	m.state = node.StateStopped
+	return nil
+}
+
+func (m *mockManager) Restart() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.state = node.StateRunning
+	m.restarts++
+	return nil
+}
+
+func (m *mockManager) GetState() node.NodeState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+func (m *mockManager) GetStatus() *node.Status {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pid := 0
+	if m.state == node.StateRunning {
+		pid = 4242
+	}
+	return &node.Status{State: m.state, StateString: m.state.String(), PID: pid, RestartCount: m.restarts}
+}
+
+func (m *mockManager) SetNodeArgs(args []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.args = args
+}
+
+// mockUpgrades is a mock implementation of UpgradeController for testing
+type mockUpgrades struct {
+	mu         sync.Mutex
+	pending    *types.UpgradeInfo
+	rolledBack string
+}
+
+func (m *mockUpgrades) GetStatus() *orchestrator.UpgradeStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return &orchestrator.UpgradeStatus{PendingUpgrade: m.pending, CurrentHeight: 100}
+}
+
+func (m *mockUpgrades) ScheduleUpgrade(upgrade *types.UpgradeInfo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pending = upgrade
+	return nil
+}
+
+func (m *mockUpgrades) Rollback(target string) (*orchestrator.RollbackResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if target == "" {
+		target = "v1.0.0"
+	}
+	m.rolledBack = target
+	return &orchestrator.RollbackResult{From: "v2.0.0", To: target}, nil
+}
+
+func newTestServer(t *testing.T, manager NodeManager) (*Server, *Client) {
+	t.Helper()
+
+	cfg := &config.Config{Home: t.TempDir(), Name: "wemixd"}
+	server := NewServer(cfg, manager, logger.NewTestLogger())
+	require.NoError(t, server.Start())
+	t.Cleanup(func() { server.Stop() })
+
+	return server, NewClient(cfg)
+}
+
+func TestServer_StartStopStatus(t *testing.T) {
+	manager := &mockManager{state: node.StateStopped}
+	_, client := newTestServer(t, manager)
+
+	assert.True(t, client.IsAvailable())
+
+	status, err := client.Status()
+	require.NoError(t, err)
+	assert.Equal(t, node.StateStopped, status.State)
+
+	status, err = client.Start([]string{"--datadir", "/data"})
+	require.NoError(t, err)
+	assert.Equal(t, node.StateRunning, status.State)
+	assert.Equal(t, 4242, status.PID)
+	assert.Equal(t, []string{"--datadir", "/data"}, manager.args)
+
+	status, err = client.Restart([]string{"--syncmode", "full"})
+	require.NoError(t, err)
+	assert.Equal(t, 1, status.RestartCount)
+	assert.Equal(t, []string{"--syncmode", "full"}, manager.args)
+
+	_, err = client.Stop()
+	require.NoError(t, err)
+	assert.Equal(t, node.StateStopped, manager.GetState())
+
+	_, err = client.Stop()
+	assert.EqualError(t, err, "node is not running")
+}
+
+func TestServer_StartError(t *testing.T) {
+	manager := &mockManager{startErr: errors.New("binary not found")}
+	_, client := newTestServer(t, manager)
+
+	_, err := client.Start(nil)
+	assert.EqualError(t, err, "binary not found")
+}
+
+func TestServer_UpgradeCommands(t *testing.T) {
+	server, client := newTestServer(t, &mockManager{})
+
+	_, err := client.UpgradeStatus()
+	assert.Error(t, err, "upgrade commands require an attached controller")
+
+	upgrades := &mockUpgrades{}
+	server.SetUpgradeController(upgrades)
+
+	status, err := client.ScheduleUpgrade(&types.UpgradeInfo{Name: "v2.0.0", Height: 500})
+	require.NoError(t, err)
+	require.NotNil(t, status.PendingUpgrade)
+	assert.Equal(t, "v2.0.0", status.PendingUpgrade.Name)
+
+	status, err = client.UpgradeStatus()
+	require.NoError(t, err)
+	assert.Equal(t, int64(100), status.CurrentHeight)
+
+	result, err := client.RollbackUpgrade("")
+	require.NoError(t, err)
+	assert.Equal(t, &orchestrator.RollbackResult{From: "v2.0.0", To: "v1.0.0"}, result)
+
+	result, err = client.RollbackUpgrade("genesis")
+	require.NoError(t, err)
+	assert.Equal(t, "genesis", result.To)
+	assert.Equal(t, "genesis", upgrades.rolledBack)
+}
+
+// stagingFunc is a StagingReporter backed by a function
+type stagingFunc func() []staging.Status
+
+func (f stagingFunc) Status() []staging.Status {
+	return f()
+}
+
+func TestServer_UpgradeStaging(t *testing.T) {
+	server, client := newTestServer(t, &mockManager{})
+
+	_, err := client.UpgradeStaging()
+	assert.Error(t, err, "staging requires an attached stager")
+
+	server.SetStager(stagingFunc(func() []staging.Status {
+		return []staging.Status{{Name: "v2.0.0", Height: 500, State: staging.StateStaged}}
+	}))
+
+	statuses, err := client.UpgradeStaging()
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "v2.0.0", statuses[0].Name)
+	assert.Equal(t, staging.StateStaged, statuses[0].State)
+}
+
+func TestServer_UnknownCommand(t *testing.T) {
+	_, client := newTestServer(t, &mockManager{})
+
+	_, err := client.Call(&Request{Command: "explode"})
+	assert.EqualError(t, err, "unknown command: explode")
+}
+
+func TestClient_NotRunning(t *testing.T) {
+	client := NewClient(&config.Config{Home: t.TempDir()})
+
+	assert.False(t, client.IsAvailable())
+	_, err := client.Status()
+	assert.ErrorIs(t, err, ErrNotRunning)
+}
+
+func TestServer_StaleSocket(t *testing.T) {
+	cfg := &config.Config{Home: t.TempDir(), Name: "wemixd"}
+	require.NoError(t, os.MkdirAll(cfg.WemixvisorDir(), 0755))
+
+	// Leave a socket file behind with nobody listening on it
+	listener, err := net.Listen("unix", cfg.ControlSocketPath())
+	require.NoError(t, err)
+	listener.(*net.UnixListener).SetUnlinkOnClose(false)
+	listener.Close()
+
+	server := NewServer(cfg, &mockManager{}, logger.NewTestLogger())
+	require.NoError(t, server.Start())
+	defer server.Stop()
+
+	assert.True(t, NewClient(cfg).IsAvailable())
+}
+
+func TestServer_RefusesLiveSocket(t *testing.T) {
+	cfg := &config.Config{Home: t.TempDir(), Name: "wemixd"}
+
+	first := NewServer(cfg, &mockManager{}, logger.NewTestLogger())
+	require.NoError(t, first.Start())
+	defer first.Stop()
+
+	second := NewServer(cfg, &mockManager{}, logger.NewTestLogger())
+	assert.Error(t, second.Start())
+	assert.True(t, NewClient(cfg).IsAvailable())
+}
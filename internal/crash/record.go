@@ -0,0 +1,87 @@
+// Package crash records forensic details about unexpected node exits so they
+// can be inspected after the fact.
+package crash
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/wemix/wemixvisor/internal/metrics"
+	"github.com/wemix/wemixvisor/internal/monitor"
+)
+
+// DefaultOutputLines is the number of node output lines kept in a record
+const DefaultOutputLines = 300
+
+// Record describes a single unexpected node exit
+type Record struct {
+	ID           string                   `json:"id"`
+	Time         time.Time                `json:"time"`
+	PID          int                      `json:"pid"`
+	ExitCode     int                      `json:"exit_code"`
+	Signal       string                   `json:"signal,omitempty"`
+	Reason       string                   `json:"reason"`
+	Uptime       time.Duration            `json:"uptime"`
+	Binary       string                   `json:"binary"`
+	Upgrade      string                   `json:"upgrade,omitempty"`
+	Version      string                   `json:"version,omitempty"`
+	Args         []string                 `json:"args,omitempty"`
+	RestartCount int                      `json:"restart_count"`
+	Rusage       *Rusage                  `json:"rusage,omitempty"`
+	Output       []string                 `json:"output,omitempty"`
+	Health       *monitor.HealthStatus    `json:"health,omitempty"`
+	Metrics      *metrics.MetricsSnapshot `json:"metrics,omitempty"`
+}
+
+// Rusage holds the resource usage of the exited process
+type Rusage struct {
+	UserTime         time.Duration `json:"user_time"`
+	SystemTime       time.Duration `json:"system_time"`
+	MaxRSSKB         int64         `json:"max_rss_kb"`
+	MinorFaults      int64         `json:"minor_faults"`
+	MajorFaults      int64         `json:"major_faults"`
+	BlockInputs      int64         `json:"block_inputs"`
+	BlockOutputs     int64         `json:"block_outputs"`
+	VoluntaryCtxSw   int64         `json:"voluntary_ctx_switches"`
+	InvoluntaryCtxSw int64         `json:"involuntary_ctx_switches"`
+}
+
+// SetExit fills the exit code, signal and resource usage from the process
+// state, falling back to the wait error when no state is available
+func (r *Record) SetExit(state *os.ProcessState, err error) {
+	r.ExitCode = -1
+
+	if state == nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			state = exitErr.ProcessState
+		}
+	}
+	if state == nil {
+		return
+	}
+
+	r.PID = state.Pid()
+	r.ExitCode = state.ExitCode()
+
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		r.Signal = status.Signal().String()
+	}
+
+	if usage, ok := state.SysUsage().(*syscall.Rusage); ok && usage != nil {
+		r.Rusage = &Rusage{
+			UserTime:         state.UserTime(),
+			SystemTime:       state.SystemTime(),
+			MaxRSSKB:         int64(usage.Maxrss),
+			MinorFaults:      int64(usage.Minflt),
+			MajorFaults:      int64(usage.Majflt),
+			BlockInputs:      int64(usage.Inblock),
+			BlockOutputs:     int64(usage.Oublock),
+			VoluntaryCtxSw:   int64(usage.Nvcsw),
+			InvoluntaryCtxSw: int64(usage.Nivcsw),
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package crash
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/pkg/fsutil"
+)
+
+// Store constants
+const (
+	CrashesDirName = "crashes"
+	RecordIDFormat = "20060102T150405.000Z"
+	recordExt      = ".json"
+)
+
+// Store persists crash records as JSON files under the wemixvisor home
+type Store struct {
+	dir        string
+	maxRecords int
+}
+
+// NewStore creates a crash record store for the given configuration
+func NewStore(cfg *config.Config) *Store {
+	maxRecords := config.DefaultCrashRecordsMax
+	if cfg.CrashRecordsMax > 0 {
+		maxRecords = cfg.CrashRecordsMax
+	}
+
+	return &Store{
+		dir:        filepath.Join(cfg.WemixvisorDir(), CrashesDirName),
+		maxRecords: maxRecords,
+	}
+}
+
+// Dir returns the directory holding crash records
+func (s *Store) Dir() string {
+	return s.dir
+}
+
+// Save writes a record, assigning its ID from its time if unset, and prunes
+// the oldest records beyond the retention limit
+func (s *Store) Save(record *Record) (string, error) {
+	if record.Time.IsZero() {
+		record.Time = time.Now()
+	}
+	if record.ID == "" {
+		record.ID = record.Time.UTC().Format(RecordIDFormat)
+	}
+
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create crash directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal crash record: %w", err)
+	}
+
+	path := filepath.Join(s.dir, record.ID+recordExt)
+	if err := fsutil.WriteFileAtomic(path, data, 0644); err != nil {
+		return "", fmt.Errorf("failed to save crash record: %w", err)
+	}
+
+	s.prune()
+	return path, nil
+}
+
+// List returns all stored records, newest first
+func (s *Store) List() ([]*Record, error) {
+	ids, err := s.ids()
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*Record, 0, len(ids))
+	for i := len(ids) - 1; i >= 0; i-- {
+		record, err := s.Load(ids[i])
+		if err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+// Load reads a record by ID. The special ID "latest" returns the most
+// recent record.
+func (s *Store) Load(id string) (*Record, error) {
+	if id == "latest" {
+		ids, err := s.ids()
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			return nil, fmt.Errorf("no crash records found")
+		}
+		id = ids[len(ids)-1]
+	}
+
+	if strings.ContainsAny(id, `/\`) {
+		return nil, fmt.Errorf("invalid crash record id: %s", id)
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.dir, id+recordExt))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("crash record not found: %s", id)
+		}
+		return nil, fmt.Errorf("failed to read crash record: %w", err)
+	}
+
+	var record Record
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse crash record %s: %w", id, err)
+	}
+	return &record, nil
+}
+
+// ids returns the IDs of stored records, oldest first
+func (s *Store) ids() ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read crash directory: %w", err)
+	}
+
+	var ids []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, recordExt) {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(name, recordExt))
+	}
+
+	// IDs are timestamps, so lexical order is chronological
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// prune removes the oldest records beyond the retention limit
+func (s *Store) prune() {
+	ids, err := s.ids()
+	if err != nil || len(ids) <= s.maxRecords {
+		return
+	}
+
+	for _, id := range ids[:len(ids)-s.maxRecords] {
+		os.Remove(filepath.Join(s.dir, id+recordExt))
+	}
+}
@@ -0,0 +1,105 @@
+package crash
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wemix/wemixvisor/internal/config"
+)
+
+func TestStore_SaveLoadList(t *testing.T) {
+	cfg := &config.Config{Home: t.TempDir()}
+	store := NewStore(cfg)
+	assert.Equal(t, filepath.Join(cfg.WemixvisorDir(), CrashesDirName), store.Dir())
+
+	base := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		_, err := store.Save(&Record{
+			Time:     base.Add(time.Duration(i) * time.Minute),
+			ExitCode: i + 1,
+			Reason:   "exit status",
+			Output:   []string{"line"},
+		})
+		require.NoError(t, err)
+	}
+
+	records, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, records, 3)
+	assert.Equal(t, 3, records[0].ExitCode, "newest record should come first")
+	assert.Equal(t, "20240501T120200.000Z", records[0].ID)
+
+	record, err := store.Load("latest")
+	require.NoError(t, err)
+	assert.Equal(t, records[0].ID, record.ID)
+
+	record, err = store.Load("20240501T120000.000Z")
+	require.NoError(t, err)
+	assert.Equal(t, 1, record.ExitCode)
+	assert.Equal(t, []string{"line"}, record.Output)
+}
+
+func TestStore_LoadErrors(t *testing.T) {
+	store := NewStore(&config.Config{Home: t.TempDir()})
+
+	_, err := store.Load("latest")
+	assert.EqualError(t, err, "no crash records found")
+
+	_, err = store.Load("missing")
+	assert.EqualError(t, err, "crash record not found: missing")
+
+	_, err = store.Load("../../etc/passwd")
+	assert.Error(t, err)
+
+	records, err := store.List()
+	require.NoError(t, err)
+	assert.Empty(t, records)
+}
+
+func TestStore_Prune(t *testing.T) {
+	store := NewStore(&config.Config{Home: t.TempDir(), CrashRecordsMax: 2})
+
+	base := time.Now()
+	for i := 0; i < 5; i++ {
+		_, err := store.Save(&Record{Time: base.Add(time.Duration(i) * time.Second), ExitCode: i})
+		require.NoError(t, err)
+	}
+
+	records, err := store.List()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	assert.Equal(t, 4, records[0].ExitCode)
+	assert.Equal(t, 3, records[1].ExitCode)
+
+	entries, err := os.ReadDir(store.Dir())
+	require.NoError(t, err)
+	assert.Len(t, entries, 2)
+}
+
+func TestRecord_SetExit(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "exit 7")
+	err := cmd.Run()
+	require.Error(t, err)
+
+	record := &Record{}
+	record.SetExit(cmd.ProcessState, err)
+	assert.Equal(t, 7, record.ExitCode)
+	assert.Empty(t, record.Signal)
+	assert.Equal(t, cmd.ProcessState.Pid(), record.PID)
+	require.NotNil(t, record.Rusage)
+	assert.Greater(t, record.Rusage.MaxRSSKB, int64(0))
+
+	cmd = exec.Command("sh", "-c", "kill -KILL $$")
+	err = cmd.Run()
+	require.Error(t, err)
+
+	record = &Record{}
+	record.SetExit(nil, err)
+	assert.Equal(t, -1, record.ExitCode)
+	assert.Equal(t, "killed", record.Signal)
+}
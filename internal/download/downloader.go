@@ -72,7 +72,7 @@ func (d *Downloader) DownloadBinary(url, destPath string) error {
 // DownloadAndVerify downloads a binary and verifies its checksum
 func (d *Downloader) DownloadAndVerify(url, destPath, checksumURL string) error {
 	// Download checksum file
-	checksumData, err := d.fetchChecksum(checksumURL)
+	checksumData, err := d.FetchChecksum(checksumURL)
 	if err != nil {
 		return fmt.Errorf("failed to fetch checksum: %w", err)
 	}
@@ -83,7 +83,7 @@ func (d *Downloader) DownloadAndVerify(url, destPath, checksumURL string) error
 	}
 
 	// Verify checksum
-	if err := d.verifyChecksum(destPath, checksumData); err != nil {
+	if err := d.VerifyChecksum(destPath, checksumData); err != nil {
 		// Remove downloaded file if verification fails
 		os.Remove(destPath)
 		return fmt.Errorf("checksum verification failed: %w", err)
@@ -161,8 +161,8 @@ func (d *Downloader) attemptDownload(url, destPath string) error {
 	return nil
 }
 
-// fetchChecksum downloads and parses checksum file
-func (d *Downloader) fetchChecksum(url string) (string, error) {
+// FetchChecksum downloads and parses checksum file
+func (d *Downloader) FetchChecksum(url string) (string, error) {
 	resp, err := d.client.Get(url)
 	if err != nil {
 		return "", fmt.Errorf("failed to fetch checksum: %w", err)
@@ -189,8 +189,9 @@ func (d *Downloader) fetchChecksum(url string) (string, error) {
 	return checksumStr, nil
 }
 
-// verifyChecksum verifies the downloaded file's checksum
-func (d *Downloader) verifyChecksum(filePath, expectedChecksum string) error {
+// VerifyChecksum verifies that the file has the expected SHA256 or SHA512
+// checksum
+func (d *Downloader) VerifyChecksum(filePath, expectedChecksum string) error {
 	// Determine hash algorithm based on checksum length
 	var h hash.Hash
 	switch len(expectedChecksum) {
@@ -352,21 +352,21 @@ func TestVerifyChecksum(t *testing.T) {
 	// Test SHA256
 	sha256Hash := sha256.Sum256(content)
 	sha256Checksum := hex.EncodeToString(sha256Hash[:])
-	err := downloader.verifyChecksum(filePath, sha256Checksum)
+	err := downloader.VerifyChecksum(filePath, sha256Checksum)
 	if err != nil {
 		t.Errorf("SHA256 verification failed: %v", err)
 	}
 
 	// Test wrong checksum
 	wrongChecksum := "0000000000000000000000000000000000000000000000000000000000000000"
-	err = downloader.verifyChecksum(filePath, wrongChecksum)
+	err = downloader.VerifyChecksum(filePath, wrongChecksum)
 	if err == nil {
 		t.Error("expected checksum mismatch error")
 	}
 
 	// Test unsupported checksum length
 	shortChecksum := "00000000"
-	err = downloader.verifyChecksum(filePath, shortChecksum)
+	err = downloader.VerifyChecksum(filePath, shortChecksum)
 	if err == nil {
 		t.Error("expected unsupported checksum length error")
 	}
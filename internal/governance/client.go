@@ -150,6 +150,15 @@ func (c *WBFTClient) GetBlock(height int64) (*BlockInfo, error) {
 	}, nil
 }
 
+// GetBlockTime returns the timestamp of the block at the given height
+func (c *WBFTClient) GetBlockTime(height int64) (time.Time, error) {
+	block, err := c.GetBlock(height)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return block.Time, nil
+}
+
 // GetGovernanceProposals returns a list of governance proposals
 func (c *WBFTClient) GetGovernanceProposals(status ProposalStatus) ([]*Proposal, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
@@ -174,6 +183,7 @@ func (c *WBFTClient) GetGovernanceProposals(status ProposalStatus) ([]*Proposal,
 				Plan        struct {
 					Name   string `json:"name"`
 					Height string `json:"height"`
+					Time   string `json:"time"`
 					Info   string `json:"info"`
 				} `json:"plan,omitempty"`
 			} `json:"content"`
@@ -221,6 +231,12 @@ func (c *WBFTClient) GetGovernanceProposals(status ProposalStatus) ([]*Proposal,
 				Info:   p.Content.Plan.Info,
 				Status: UpgradeStatusScheduled,
 			}
+			// A plan time is chain time, compared with block timestamps
+			if p.Content.Plan.Time != "" {
+				if t, err := time.Parse(time.RFC3339Nano, p.Content.Plan.Time); err == nil && !t.IsZero() {
+					proposal.UpgradeInfo.BlockTime = &t
+				}
+			}
 		case "/cosmos.params.v1beta1.ParameterChangeProposal":
 			proposal.Type = ProposalTypeParameter
 		case "/cosmos.gov.v1beta1.TextProposal":
@@ -586,6 +586,7 @@ func TestWBFTClient_ParseProposalContent(t *testing.T) {
 							"plan": {
 								"name": "v2.0.0",
 								"height": "10000",
+								"time": "2026-11-01T12:00:00Z",
 								"info": "{\"binaries\":{\"linux\":{\"url\":\"https://example.com/binary\",\"checksum\":\"sha256:abc123\"}}}"
 							}
 						}
@@ -631,6 +632,11 @@ func TestWBFTClient_ParseProposalContent(t *testing.T) {
 	assert.Equal(t, ProposalTypeUpgrade, proposals[0].Type)
 	assert.NotNil(t, proposals[0].UpgradeInfo)
 	assert.Equal(t, "v2.0.0", proposals[0].UpgradeInfo.Name)
+	assert.Equal(t, int64(10000), proposals[0].UpgradeInfo.Height)
+	if assert.NotNil(t, proposals[0].UpgradeInfo.BlockTime, "plan time should be a block time condition") {
+		assert.Equal(t, time.Date(2026, 11, 1, 12, 0, 0, 0, time.UTC), proposals[0].UpgradeInfo.BlockTime.UTC())
+	}
+	assert.Nil(t, proposals[0].UpgradeInfo.Time)
 
 	// Check parameter change proposal
 	assert.Equal(t, ProposalTypeParameter, proposals[1].Type)
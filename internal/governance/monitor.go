@@ -301,12 +301,16 @@ func (m *Monitor) processUpgradeQueue() error {
 		return fmt.Errorf("failed to get upgrade queue: %w", err)
 	}
 
+	blockTime := m.latestBlockTime(upgrades, currentHeight)
+	now := time.Now()
+
 	for _, upgrade := range upgrades {
 		// Check if upgrade should be triggered
-		if upgrade.Height <= currentHeight && upgrade.Status == UpgradeStatusScheduled {
+		if upgrade.Status == UpgradeStatusScheduled && upgrade.Reached(currentHeight, blockTime, now) {
 			m.logger.Info("triggering upgrade",
 				zap.String("name", upgrade.Name),
-				zap.Int64("height", upgrade.Height))
+				zap.String("condition", upgrade.activation().Condition()),
+				zap.Int64("current_height", currentHeight))
 
 			if err := m.triggerUpgrade(upgrade); err != nil {
 				m.logger.Error("failed to trigger upgrade",
@@ -330,6 +334,26 @@ func (m *Monitor) processUpgradeQueue() error {
 	return nil
 }
 
+// latestBlockTime returns the timestamp of the block at currentHeight when
+// an upgrade waits for one, or the zero time otherwise
+func (m *Monitor) latestBlockTime(upgrades []*UpgradeInfo, currentHeight int64) time.Time {
+	for _, upgrade := range upgrades {
+		if upgrade.BlockTime == nil {
+			continue
+		}
+
+		block, err := m.rpcClient.GetBlock(currentHeight)
+		if err != nil {
+			m.logger.Warn("failed to get block time",
+				zap.Int64("height", currentHeight),
+				zap.Error(err))
+			return time.Time{}
+		}
+		return block.Time
+	}
+	return time.Time{}
+}
+
 // validateUpgradeProposal validates an upgrade proposal
 func (m *Monitor) validateUpgradeProposal(proposal *Proposal) error {
 	// Check if upgrade info is valid
@@ -344,7 +368,9 @@ func (m *Monitor) validateUpgradeProposal(proposal *Proposal) error {
 			return fmt.Errorf("failed to get current height: %w", err)
 		}
 
-		if proposal.UpgradeHeight <= currentHeight {
+		// An upgrade activated by time alone has no height to check
+		timeOnly := proposal.UpgradeHeight == 0 && proposal.UpgradeInfo.activation().HasTime()
+		if !timeOnly && proposal.UpgradeHeight <= currentHeight {
 			return fmt.Errorf("upgrade height %d is not in the future (current: %d)",
 				proposal.UpgradeHeight, currentHeight)
 		}
@@ -378,6 +404,12 @@ func (m *Monitor) triggerUpgrade(upgrade *UpgradeInfo) error {
 		"height": upgrade.Height,
 		"info":   upgrade.Info,
 	}
+	if upgrade.Time != nil {
+		upgradeData["time"] = upgrade.Time
+	}
+	if upgrade.BlockTime != nil {
+		upgradeData["block_time"] = upgrade.BlockTime
+	}
 
 	if err := writeUpgradeInfo(upgradeInfoPath, upgradeData); err != nil {
 		return fmt.Errorf("failed to write upgrade info: %w", err)
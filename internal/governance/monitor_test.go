@@ -286,6 +286,32 @@ func TestMonitor_ValidateUpgradeProposal_WithMockClient(t *testing.T) {
 	mockClient.AssertExpectations(t)
 }
 
+func TestMonitor_ValidateUpgradeProposal_TimeOnly(t *testing.T) {
+	cfg := &config.Config{Home: "/tmp/test"}
+	testLogger := logger.NewTestLogger()
+	monitor := NewMonitor(cfg, testLogger)
+
+	mockClient := &MockWBFTClient{}
+	monitor.rpcClient = mockClient
+	mockClient.On("GetCurrentHeight").Return(int64(1000), nil)
+
+	// Test case: an upgrade activated by block time has no height to check
+	activation := time.Now().Add(time.Hour)
+	proposal := &Proposal{
+		ID:   "1",
+		Type: ProposalTypeUpgrade,
+		UpgradeInfo: &UpgradeInfo{
+			Name:      "test-upgrade",
+			BlockTime: &activation,
+		},
+	}
+
+	err := monitor.validateUpgradeProposal(proposal)
+	assert.NoError(t, err)
+
+	mockClient.AssertExpectations(t)
+}
+
 func TestMonitor_ValidateUpgradeProposal_RpcError(t *testing.T) {
 	cfg := &config.Config{Home: "/tmp/test"}
 	testLogger := logger.NewTestLogger()
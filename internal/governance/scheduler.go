@@ -2,12 +2,15 @@ package governance
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"sort"
 	"sync"
 	"time"
 
 	"github.com/wemix/wemixvisor/internal/config"
 	"github.com/wemix/wemixvisor/pkg/logger"
+	"github.com/wemix/wemixvisor/pkg/types"
 	"go.uber.org/zap"
 )
 
@@ -106,6 +109,8 @@ func (us *UpgradeScheduler) ScheduleUpgrade(proposal *Proposal) error {
 	upgrade := &UpgradeInfo{
 		Name:          proposal.UpgradeInfo.Name,
 		Height:        proposal.UpgradeHeight,
+		Time:          proposal.UpgradeInfo.Time,
+		BlockTime:     proposal.UpgradeInfo.BlockTime,
 		Info:          proposal.UpgradeInfo.Info,
 		Binaries:      proposal.UpgradeInfo.Binaries,
 		UpgradeURL:    proposal.UpgradeInfo.UpgradeURL,
@@ -121,10 +126,11 @@ func (us *UpgradeScheduler) ScheduleUpgrade(proposal *Proposal) error {
 
 	// Sort queue by height
 	us.sortScheduledQueue()
+	us.announce()
 
 	us.logger.Info("upgrade scheduled",
 		zap.String("name", upgrade.Name),
-		zap.Int64("height", upgrade.Height),
+		zap.String("condition", upgrade.activation().Condition()),
 		zap.String("proposal_id", proposal.ID))
 
 	return nil
@@ -242,16 +248,24 @@ func (us *UpgradeScheduler) CancelUpgrade(name string) error {
 
 // IsUpgradeReady checks if an upgrade is ready to be executed at the given height
 func (us *UpgradeScheduler) IsUpgradeReady(currentHeight int64) (*UpgradeInfo, bool) {
+	return us.IsUpgradeReadyAt(currentHeight, time.Time{})
+}
+
+// IsUpgradeReadyAt checks if an upgrade is ready to be executed at the given
+// height and latest block timestamp. Wall-clock activation times are checked
+// against the host clock; a zero blockTime never satisfies a block
+// timestamp condition.
+func (us *UpgradeScheduler) IsUpgradeReadyAt(currentHeight int64, blockTime time.Time) (*UpgradeInfo, bool) {
 	us.mu.RLock()
 	defer us.mu.RUnlock()
 
-	if len(us.scheduledQueue) == 0 {
-		return nil, false
-	}
-
-	nextUpgrade := us.scheduledQueue[0]
-	if nextUpgrade.Height <= currentHeight && nextUpgrade.Status == UpgradeStatusScheduled {
-		return nextUpgrade, true
+	// The queue is sorted by height, but a time condition can make a later
+	// upgrade ready first
+	now := time.Now()
+	for _, upgrade := range us.scheduledQueue {
+		if upgrade.Status == UpgradeStatusScheduled && upgrade.Reached(currentHeight, blockTime, now) {
+			return upgrade, true
+		}
 	}
 
 	return nil, false
@@ -317,8 +331,8 @@ func (us *UpgradeScheduler) validateUpgrade(upgrade *UpgradeInfo) error {
 		return fmt.Errorf("upgrade %s already scheduled", upgrade.Name)
 	}
 
-	// Check upgrade height
-	if upgrade.Height <= 0 {
+	// Check upgrade height, which a time-based upgrade may leave unset
+	if upgrade.Height < 0 || (upgrade.Height == 0 && !upgrade.activation().HasTime()) {
 		return fmt.Errorf("invalid upgrade height: %d", upgrade.Height)
 	}
 
@@ -371,6 +385,27 @@ func (us *UpgradeScheduler) moveToCompleted(upgrade *UpgradeInfo) {
 		}
 		return us.completedQueue[i].CompletedTime.After(*us.completedQueue[j].CompletedTime)
 	})
+
+	us.announce()
+}
+
+// announce writes the names and activation conditions of the scheduled
+// upgrades to the announced upgrades file, from which the supervisor stages
+// their binaries ahead of time. Caller must hold mu.
+func (us *UpgradeScheduler) announce() {
+	announced := make([]*types.UpgradeInfo, len(us.scheduledQueue))
+	for i, upgrade := range us.scheduledQueue {
+		announced[i] = upgrade.activation()
+	}
+
+	path := us.cfg.AnnouncedUpgradesPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		us.logger.Warn("failed to create announced upgrades directory", zap.Error(err))
+		return
+	}
+	if err := types.WriteUpgradeInfoList(path, announced); err != nil {
+		us.logger.Warn("failed to announce scheduled upgrades", zap.Error(err))
+	}
 }
 
 // loadPersistedState loads upgrade state from persistent storage
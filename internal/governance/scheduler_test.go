@@ -5,12 +5,14 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/wemix/wemixvisor/internal/config"
 	"github.com/wemix/wemixvisor/pkg/logger"
+	"github.com/wemix/wemixvisor/pkg/types"
 )
 
 func TestNewUpgradeScheduler(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
@@ -28,7 +30,7 @@ func TestNewUpgradeScheduler(t *testing.T) {
 }
 
 func TestUpgradeScheduler_ScheduleUpgrade(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -60,7 +62,7 @@ func TestUpgradeScheduler_ScheduleUpgrade(t *testing.T) {
 }
 
 func TestUpgradeScheduler_ScheduleUpgrade_NonUpgradeProposal(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -76,7 +78,7 @@ func TestUpgradeScheduler_ScheduleUpgrade_NonUpgradeProposal(t *testing.T) {
 }
 
 func TestUpgradeScheduler_ScheduleUpgrade_MissingUpgradeInfo(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -93,7 +95,7 @@ func TestUpgradeScheduler_ScheduleUpgrade_MissingUpgradeInfo(t *testing.T) {
 }
 
 func TestUpgradeScheduler_GetQueue(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -114,7 +116,7 @@ func TestUpgradeScheduler_GetQueue(t *testing.T) {
 }
 
 func TestUpgradeScheduler_GetUpgrade(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -134,7 +136,7 @@ func TestUpgradeScheduler_GetUpgrade(t *testing.T) {
 }
 
 func TestUpgradeScheduler_GetCurrentUpgrade(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -154,7 +156,7 @@ func TestUpgradeScheduler_GetCurrentUpgrade(t *testing.T) {
 }
 
 func TestUpgradeScheduler_GetNextUpgrade(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -174,7 +176,7 @@ func TestUpgradeScheduler_GetNextUpgrade(t *testing.T) {
 }
 
 func TestUpgradeScheduler_UpdateStatus(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -213,7 +215,7 @@ func TestUpgradeScheduler_UpdateStatus(t *testing.T) {
 }
 
 func TestUpgradeScheduler_CancelUpgrade(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -234,8 +236,46 @@ func TestUpgradeScheduler_CancelUpgrade(t *testing.T) {
 	assert.Len(t, scheduler.completedQueue, 1)
 }
 
+func TestUpgradeScheduler_AnnouncesScheduledUpgrades(t *testing.T) {
+	cfg := &config.Config{Home: t.TempDir()}
+	scheduler := NewUpgradeScheduler(cfg, logger.NewTestLogger())
+	scheduler.SetValidationEnabled(false)
+
+	activation := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+	for _, upgrade := range []*UpgradeInfo{
+		{Name: "v3", Height: 2000},
+		{Name: "v2", Height: 1000},
+		{Name: "v4", Time: &activation},
+	} {
+		require.NoError(t, scheduler.ScheduleUpgrade(&Proposal{
+			ID:            upgrade.Name,
+			Type:          ProposalTypeUpgrade,
+			UpgradeHeight: upgrade.Height,
+			UpgradeInfo:   upgrade,
+		}))
+	}
+
+	announced, err := types.ParseUpgradeInfoList(cfg.AnnouncedUpgradesPath())
+	require.NoError(t, err)
+	require.Len(t, announced, 3)
+	assert.Equal(t, "v4", announced[0].Name)
+	require.NotNil(t, announced[0].Time)
+	assert.True(t, activation.Equal(*announced[0].Time))
+	assert.Equal(t, "v2", announced[1].Name)
+	assert.Equal(t, int64(1000), announced[1].Height)
+	assert.Equal(t, "v3", announced[2].Name)
+
+	// Cancelled upgrades are no longer announced
+	require.NoError(t, scheduler.CancelUpgrade("v2"))
+	announced, err = types.ParseUpgradeInfoList(cfg.AnnouncedUpgradesPath())
+	require.NoError(t, err)
+	require.Len(t, announced, 2)
+	assert.Equal(t, "v4", announced[0].Name)
+	assert.Equal(t, "v3", announced[1].Name)
+}
+
 func TestUpgradeScheduler_CancelUpgrade_InProgress(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -252,7 +292,7 @@ func TestUpgradeScheduler_CancelUpgrade_InProgress(t *testing.T) {
 }
 
 func TestUpgradeScheduler_IsUpgradeReady(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -285,8 +325,38 @@ func TestUpgradeScheduler_IsUpgradeReady(t *testing.T) {
 	assert.Equal(t, testUpgrade, upgrade)
 }
 
+func TestUpgradeScheduler_IsUpgradeReadyAt(t *testing.T) {
+	cfg := &config.Config{Home: t.TempDir()}
+	testLogger := logger.NewTestLogger()
+	scheduler := NewUpgradeScheduler(cfg, testLogger)
+
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Hour)
+	heightUpgrade := &UpgradeInfo{Name: "height-upgrade", Height: 1000, Status: UpgradeStatusScheduled}
+	chainUpgrade := &UpgradeInfo{Name: "chain-upgrade", Height: 5000, BlockTime: &future, Status: UpgradeStatusScheduled}
+	scheduler.scheduledQueue = []*UpgradeInfo{heightUpgrade, chainUpgrade}
+
+	// Test when no condition is reached
+	upgrade, ready := scheduler.IsUpgradeReadyAt(999, past)
+	assert.False(t, ready)
+	assert.Nil(t, upgrade)
+
+	// Test block time reached before either height, judged by the block's
+	// own timestamp rather than the host clock
+	upgrade, ready = scheduler.IsUpgradeReadyAt(999, future)
+	assert.True(t, ready)
+	assert.Equal(t, chainUpgrade, upgrade)
+
+	// Test wall-clock time on a later upgrade in the queue
+	clockUpgrade := &UpgradeInfo{Name: "clock-upgrade", Time: &past, Status: UpgradeStatusScheduled}
+	scheduler.scheduledQueue = []*UpgradeInfo{heightUpgrade, clockUpgrade}
+	upgrade, ready = scheduler.IsUpgradeReady(999)
+	assert.True(t, ready)
+	assert.Equal(t, clockUpgrade, upgrade)
+}
+
 func TestUpgradeScheduler_GetUpgradeStats(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -309,7 +379,7 @@ func TestUpgradeScheduler_GetUpgradeStats(t *testing.T) {
 }
 
 func TestUpgradeScheduler_SetEnabled(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -321,7 +391,7 @@ func TestUpgradeScheduler_SetEnabled(t *testing.T) {
 }
 
 func TestUpgradeScheduler_SetMinUpgradeDelay(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -331,7 +401,7 @@ func TestUpgradeScheduler_SetMinUpgradeDelay(t *testing.T) {
 }
 
 func TestUpgradeScheduler_SetValidationEnabled(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -343,9 +413,10 @@ func TestUpgradeScheduler_SetValidationEnabled(t *testing.T) {
 }
 
 func TestUpgradeScheduler_ValidateUpgrade(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
+	activation := time.Now().Add(time.Hour)
 
 	tests := []struct {
 		name        string
@@ -365,6 +436,11 @@ func TestUpgradeScheduler_ValidateUpgrade(t *testing.T) {
 			expectError: true,
 			errorMsg:    "invalid upgrade height",
 		},
+		{
+			name:        "time without height",
+			upgrade:     &UpgradeInfo{Name: "timed", Time: &activation},
+			expectError: false,
+		},
 		{
 			name:        "duplicate upgrade",
 			upgrade:     &UpgradeInfo{Name: "existing", Height: 1000},
@@ -430,7 +506,7 @@ func TestUpgradeScheduler_ValidateUpgrade(t *testing.T) {
 }
 
 func TestUpgradeScheduler_CleanupOld(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -464,7 +540,7 @@ func TestUpgradeScheduler_CleanupOld(t *testing.T) {
 }
 
 func TestUpgradeScheduler_Start(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -473,7 +549,7 @@ func TestUpgradeScheduler_Start(t *testing.T) {
 }
 
 func TestUpgradeScheduler_Stop(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -482,7 +558,7 @@ func TestUpgradeScheduler_Stop(t *testing.T) {
 }
 
 func TestUpgradeScheduler_LoadPersistedState(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -492,7 +568,7 @@ func TestUpgradeScheduler_LoadPersistedState(t *testing.T) {
 }
 
 func TestUpgradeScheduler_SaveCurrentState(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -510,7 +586,7 @@ func TestUpgradeScheduler_SaveCurrentState(t *testing.T) {
 }
 
 func TestUpgradeScheduler_SortScheduledQueue(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -531,7 +607,7 @@ func TestUpgradeScheduler_SortScheduledQueue(t *testing.T) {
 }
 
 func TestUpgradeScheduler_MoveToCompleted(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -554,7 +630,7 @@ func TestUpgradeScheduler_MoveToCompleted(t *testing.T) {
 }
 
 func TestUpgradeScheduler_UpdateStatus_Error(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -570,7 +646,7 @@ func TestUpgradeScheduler_UpdateStatus_Error(t *testing.T) {
 }
 
 func TestUpgradeScheduler_CancelUpgrade_NotFound(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -580,7 +656,7 @@ func TestUpgradeScheduler_CancelUpgrade_NotFound(t *testing.T) {
 }
 
 func TestUpgradeScheduler_ScheduleUpgrade_ValidationDisabled(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -603,7 +679,7 @@ func TestUpgradeScheduler_ScheduleUpgrade_ValidationDisabled(t *testing.T) {
 }
 
 func TestUpgradeScheduler_ScheduleUpgrade_ValidationEnabled(t *testing.T) {
-	cfg := &config.Config{Home: "/tmp/test"}
+	cfg := &config.Config{Home: t.TempDir()}
 	testLogger := logger.NewTestLogger()
 	scheduler := NewUpgradeScheduler(cfg, testLogger)
 
@@ -6,6 +6,8 @@ import (
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/wemix/wemixvisor/pkg/types"
 )
 
 // ProposalType represents the type of governance proposal
@@ -76,6 +78,8 @@ type VotingStats struct {
 type UpgradeInfo struct {
 	Name        string                 `json:"name"`
 	Height      int64                  `json:"height"`
+	Time        *time.Time             `json:"time,omitempty"`
+	BlockTime   *time.Time             `json:"block_time,omitempty"`
 	Info        string                 `json:"info"`
 	Binaries    map[string]*BinaryInfo `json:"binaries,omitempty"`
 	UpgradeURL  string                 `json:"upgrade_url,omitempty"`
@@ -202,6 +206,22 @@ type SyncStatus struct {
 	LastErrorTime     *time.Time `json:"last_error_time,omitempty"`
 }
 
+// activation returns the activation conditions of the upgrade
+func (u *UpgradeInfo) activation() *types.UpgradeInfo {
+	return &types.UpgradeInfo{
+		Name:      u.Name,
+		Height:    u.Height,
+		Time:      u.Time,
+		BlockTime: u.BlockTime,
+	}
+}
+
+// Reached reports whether any activation condition of the upgrade holds at
+// the given height, latest block timestamp and host time
+func (u *UpgradeInfo) Reached(height int64, blockTime, now time.Time) bool {
+	return u.activation().Reached(height, blockTime, now)
+}
+
 // writeUpgradeInfo writes upgrade information to a JSON file
 func writeUpgradeInfo(path string, data map[string]interface{}) error {
 	jsonData, err := json.MarshalIndent(data, "", "  ")
@@ -1,5 +1,7 @@
 package height
 
+import "time"
+
 // HeightProvider defines the interface for querying blockchain height.
 // This abstraction allows for different implementations (RPC client, mock, etc.)
 // and follows the Dependency Inversion Principle (DIP).
@@ -13,3 +15,14 @@ type HeightProvider interface {
 	// Thread-safe: This method may be called concurrently.
 	GetCurrentHeight() (int64, error)
 }
+
+// BlockTimeProvider is optionally implemented by a HeightProvider that can
+// report block timestamps. The HeightMonitor then also tracks the timestamp
+// of the latest block, so that time-based upgrades can be evaluated against
+// chain time rather than the host clock.
+type BlockTimeProvider interface {
+	// GetBlockTime returns the timestamp of the block at the given height.
+	//
+	// Thread-safe: This method may be called concurrently.
+	GetBlockTime(height int64) (time.Time, error)
+}
@@ -32,9 +32,10 @@ type HeightMonitor struct {
 	logger   *logger.Logger
 
 	// State (protected by mu)
-	currentHeight int64
-	started       bool
-	mu            sync.RWMutex
+	currentHeight    int64
+	currentBlockTime time.Time
+	started          bool
+	mu               sync.RWMutex
 
 	// Configuration
 	pollInterval time.Duration
@@ -155,6 +156,18 @@ func (hm *HeightMonitor) GetCurrentHeight() int64 {
 	return hm.currentHeight
 }
 
+// GetCurrentBlockTime returns the timestamp of the last observed block.
+//
+// Returns the zero time if the provider does not implement
+// BlockTimeProvider or the timestamp has not yet been retrieved.
+//
+// Thread-safe: Can be called concurrently.
+func (hm *HeightMonitor) GetCurrentBlockTime() time.Time {
+	hm.mu.RLock()
+	defer hm.mu.RUnlock()
+	return hm.currentBlockTime
+}
+
 // monitorLoop is the main monitoring goroutine.
 //
 // It continuously polls the HeightProvider and notifies subscribers
@@ -188,6 +201,9 @@ func (hm *HeightMonitor) monitorLoop() {
 				hm.currentHeight = height
 				hm.mu.Unlock()
 
+				// Record the block timestamp before subscribers look at it
+				hm.updateBlockTime(height)
+
 				// Log height change
 				hm.logger.Info("blockchain height updated",
 					"old_height", oldHeight,
@@ -202,6 +218,25 @@ func (hm *HeightMonitor) monitorLoop() {
 	}
 }
 
+// updateBlockTime records the timestamp of the block at height when the
+// provider can report it. On error the previous timestamp is kept.
+func (hm *HeightMonitor) updateBlockTime(height int64) {
+	provider, ok := hm.provider.(BlockTimeProvider)
+	if !ok {
+		return
+	}
+
+	blockTime, err := provider.GetBlockTime(height)
+	if err != nil {
+		hm.logger.Warn("failed to get block time", "height", height, "error", err)
+		return
+	}
+
+	hm.mu.Lock()
+	hm.currentBlockTime = blockTime
+	hm.mu.Unlock()
+}
+
 // notifySubscribers sends a height update to all subscribers.
 //
 // Uses a non-blocking send to prevent slow subscribers from blocking
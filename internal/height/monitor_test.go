@@ -299,6 +299,56 @@ func TestHeightMonitor_MonitorsHeight_Success(t *testing.T) {
 	assert.GreaterOrEqual(t, provider.GetCalls(), 1, "provider should be called at least once")
 }
 
+// mockBlockTimeProvider also reports block timestamps, one second apart
+type mockBlockTimeProvider struct {
+	*MockHeightProvider
+	genesis time.Time
+}
+
+// GetBlockTime implements BlockTimeProvider interface.
+func (m *mockBlockTimeProvider) GetBlockTime(height int64) (time.Time, error) {
+	return m.genesis.Add(time.Duration(height) * time.Second), nil
+}
+
+func TestHeightMonitor_TracksBlockTime(t *testing.T) {
+	// Arrange
+	genesis := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	provider := &mockBlockTimeProvider{MockHeightProvider: NewMockHeightProvider(1001), genesis: genesis}
+	monitor := NewHeightMonitor(provider, 50*time.Millisecond, newTestLogger())
+	assert.True(t, monitor.GetCurrentBlockTime().IsZero(), "block time should be zero before monitoring starts")
+
+	heightCh := monitor.Subscribe()
+
+	// Act
+	require.NoError(t, monitor.Start())
+	defer monitor.Stop()
+
+	select {
+	case <-heightCh:
+	case <-time.After(time.Second):
+		t.Fatal("no height update")
+	}
+
+	// Assert
+	assert.Equal(t, genesis.Add(1001*time.Second), monitor.GetCurrentBlockTime(),
+		"block time should be recorded before subscribers are notified")
+}
+
+func TestHeightMonitor_BlockTimeWithoutProvider(t *testing.T) {
+	// Arrange
+	provider := NewMockHeightProvider(1000)
+	monitor := NewHeightMonitor(provider, 50*time.Millisecond, newTestLogger())
+
+	// Act
+	require.NoError(t, monitor.Start())
+	defer monitor.Stop()
+	time.Sleep(150 * time.Millisecond)
+
+	// Assert
+	assert.Equal(t, int64(1000), monitor.GetCurrentHeight())
+	assert.True(t, monitor.GetCurrentBlockTime().IsZero(), "block time should stay unknown")
+}
+
 func TestHeightMonitor_MonitorsHeight_ProviderError(t *testing.T) {
 	// Arrange
 	provider := NewMockHeightProvider(1000)
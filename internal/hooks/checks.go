@@ -0,0 +1,413 @@
+package hooks
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"debug/elf"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/version"
+	"github.com/wemix/wemixvisor/pkg/types"
+)
+
+// DependencyCheckTimeout bounds the run of ldd on an upgrade binary
+const DependencyCheckTimeout = 30 * time.Second
+
+// BinaryCheck is one step of the validation an upgrade binary must pass
+// before the node is switched to it
+type BinaryCheck interface {
+	Name() string
+	Check(ctx context.Context, binary string, info *types.UpgradeInfo) error
+}
+
+// BinaryCheckError reports the check an upgrade binary failed
+type BinaryCheckError struct {
+	Check  string
+	Binary string
+	Err    error
+}
+
+func (e *BinaryCheckError) Error() string {
+	return fmt.Sprintf("%s check failed for %s: %v", e.Check, e.Binary, e.Err)
+}
+
+func (e *BinaryCheckError) Unwrap() error {
+	return e.Err
+}
+
+// ExpectedVersion returns the version the upgrade declares for its binary
+// in the "version" field of its info, if any
+func ExpectedVersion(info *types.UpgradeInfo) string {
+	if info == nil {
+		return ""
+	}
+	expected, _ := info.Info["version"].(string)
+	return strings.TrimSpace(expected)
+}
+
+// DefaultBinaryChecks returns the checks run on every upgrade binary: its
+// ELF header must match the host, its dynamic dependencies must resolve,
+// it must report the version the upgrade declares, if any, and the
+// configured smoke command, if any, must succeed
+func DefaultBinaryChecks(cfg *config.Config, versions *version.Cache) []BinaryCheck {
+	checks := []BinaryCheck{
+		&ELFCheck{GOOS: runtime.GOOS, GOARCH: runtime.GOARCH},
+		&DependencyCheck{},
+		NewVersionCheck(versions),
+	}
+	if cfg.SmokeCommand != "" {
+		checks = append(checks, NewSmokeCheck(cfg))
+	}
+	return checks
+}
+
+// isScript reports whether the file at path starts with a #! line. Scripts
+// are run through their interpreter, so they have no ELF header to check.
+func isScript(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	magic := make([]byte, 2)
+	if _, err := io.ReadFull(file, magic); err != nil {
+		return false, nil
+	}
+	return string(magic) == "#!", nil
+}
+
+// elfTarget is the ELF machine, class and byte order of the binaries of
+// an architecture
+type elfTarget struct {
+	machine elf.Machine
+	class   elf.Class
+	order   binary.ByteOrder
+}
+
+var elfTargets = map[string]elfTarget{
+	"386":      {elf.EM_386, elf.ELFCLASS32, binary.LittleEndian},
+	"amd64":    {elf.EM_X86_64, elf.ELFCLASS64, binary.LittleEndian},
+	"arm":      {elf.EM_ARM, elf.ELFCLASS32, binary.LittleEndian},
+	"arm64":    {elf.EM_AARCH64, elf.ELFCLASS64, binary.LittleEndian},
+	"loong64":  {elf.EM_LOONGARCH, elf.ELFCLASS64, binary.LittleEndian},
+	"mips":     {elf.EM_MIPS, elf.ELFCLASS32, binary.BigEndian},
+	"mipsle":   {elf.EM_MIPS, elf.ELFCLASS32, binary.LittleEndian},
+	"mips64":   {elf.EM_MIPS, elf.ELFCLASS64, binary.BigEndian},
+	"mips64le": {elf.EM_MIPS, elf.ELFCLASS64, binary.LittleEndian},
+	"ppc64":    {elf.EM_PPC64, elf.ELFCLASS64, binary.BigEndian},
+	"ppc64le":  {elf.EM_PPC64, elf.ELFCLASS64, binary.LittleEndian},
+	"riscv64":  {elf.EM_RISCV, elf.ELFCLASS64, binary.LittleEndian},
+	"s390x":    {elf.EM_S390, elf.ELFCLASS64, binary.BigEndian},
+}
+
+// elfOSABIs lists the OS ABIs a host accepts in the ELF header
+var elfOSABIs = map[string][]elf.OSABI{
+	"linux":   {elf.ELFOSABI_NONE, elf.ELFOSABI_LINUX},
+	"freebsd": {elf.ELFOSABI_NONE, elf.ELFOSABI_FREEBSD},
+	"netbsd":  {elf.ELFOSABI_NONE, elf.ELFOSABI_NETBSD},
+	"openbsd": {elf.ELFOSABI_NONE, elf.ELFOSABI_OPENBSD},
+}
+
+// ELFCheck checks that the ELF header of a binary matches the operating
+// system and architecture of the host. Scripts, and hosts whose
+// executables are not ELF, are not checked.
+type ELFCheck struct {
+	GOOS   string
+	GOARCH string
+}
+
+func (c *ELFCheck) Name() string {
+	return "elf"
+}
+
+func (c *ELFCheck) Check(ctx context.Context, binary string, info *types.UpgradeInfo) error {
+	switch c.GOOS {
+	case "darwin", "ios", "windows", "plan9":
+		return nil
+	}
+	if script, err := isScript(binary); err != nil || script {
+		return err
+	}
+
+	f, err := elf.Open(binary)
+	if err != nil {
+		return fmt.Errorf("not an ELF executable: %w", err)
+	}
+	defer f.Close()
+
+	if f.Type != elf.ET_EXEC && f.Type != elf.ET_DYN {
+		return fmt.Errorf("ELF type is %s, not an executable", f.Type)
+	}
+
+	target, ok := elfTargets[c.GOARCH]
+	if !ok {
+		return nil
+	}
+	if f.Machine != target.machine || f.Class != target.class || f.ByteOrder != target.order {
+		return fmt.Errorf("binary is built for %s %s %s, host is %s/%s",
+			f.Machine, f.Class, byteOrderName(f.ByteOrder), c.GOOS, c.GOARCH)
+	}
+
+	if abis, ok := elfOSABIs[c.GOOS]; ok {
+		for _, abi := range abis {
+			if f.OSABI == abi {
+				return nil
+			}
+		}
+		return fmt.Errorf("binary is built for OS ABI %s, host is %s", f.OSABI, c.GOOS)
+	}
+	return nil
+}
+
+// byteOrderName names a byte order the way ELF does
+func byteOrderName(order binary.ByteOrder) string {
+	if order == binary.BigEndian {
+		return "big-endian"
+	}
+	return "little-endian"
+}
+
+// DependencyCheck checks that the dynamic loader and shared libraries a
+// binary needs resolve on the host. The libraries are resolved by ldd
+// when it is available, otherwise they are looked up in the binary's run
+// path, LD_LIBRARY_PATH and the usual library directories. Statically
+// linked binaries and scripts pass.
+type DependencyCheck struct{}
+
+func (c *DependencyCheck) Name() string {
+	return "dependencies"
+}
+
+func (c *DependencyCheck) Check(ctx context.Context, binary string, info *types.UpgradeInfo) error {
+	f, err := elf.Open(binary)
+	if err != nil {
+		// Not ELF: the ELF check judges whether that is acceptable
+		return nil
+	}
+	defer f.Close()
+
+	interpreter := elfInterpreter(f)
+	libs, err := f.ImportedLibraries()
+	if err != nil {
+		return fmt.Errorf("failed to read needed libraries: %w", err)
+	}
+	if interpreter == "" && len(libs) == 0 {
+		return nil
+	}
+
+	if interpreter != "" {
+		if _, err := os.Stat(interpreter); err != nil {
+			return fmt.Errorf("dynamic loader %s not found", interpreter)
+		}
+	}
+
+	if ldd, err := exec.LookPath("ldd"); err == nil {
+		missing, err := lddMissing(ctx, ldd, binary)
+		if err == nil {
+			return missingLibrariesError(missing)
+		}
+	}
+
+	return missingLibrariesError(searchLibraries(f, binary, libs))
+}
+
+// elfInterpreter returns the dynamic loader requested by f, if any
+func elfInterpreter(f *elf.File) string {
+	for _, prog := range f.Progs {
+		if prog.Type != elf.PT_INTERP {
+			continue
+		}
+		data, err := io.ReadAll(prog.Open())
+		if err != nil {
+			return ""
+		}
+		return strings.TrimRight(string(data), "\x00")
+	}
+	return ""
+}
+
+// lddMissing runs ldd on binary and returns the libraries it could not
+// resolve
+func lddMissing(ctx context.Context, ldd, binary string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, DependencyCheckTimeout)
+	defer cancel()
+
+	output, err := exec.CommandContext(ctx, ldd, binary).CombinedOutput()
+
+	var missing []string
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasSuffix(line, "not found") {
+			missing = append(missing, strings.Fields(line)[0])
+		}
+	}
+
+	if len(missing) == 0 && err != nil {
+		return nil, fmt.Errorf("ldd failed: %w", err)
+	}
+	return missing, nil
+}
+
+// defaultLibraryDirs are searched for libraries when ldd is not available
+var defaultLibraryDirs = []string{"/lib", "/usr/lib", "/lib64", "/usr/lib64", "/usr/local/lib"}
+
+// searchLibraries looks up libs in the run path of f, LD_LIBRARY_PATH and
+// the usual library directories, and returns those not found
+func searchLibraries(f *elf.File, binary string, libs []string) []string {
+	var dirs []string
+	origin := filepath.Dir(binary)
+	for _, tag := range []elf.DynTag{elf.DT_RUNPATH, elf.DT_RPATH} {
+		paths, _ := f.DynString(tag)
+		for _, path := range paths {
+			for _, dir := range filepath.SplitList(path) {
+				dir = strings.ReplaceAll(dir, "${ORIGIN}", origin)
+				dirs = append(dirs, strings.ReplaceAll(dir, "$ORIGIN", origin))
+			}
+		}
+	}
+	dirs = append(dirs, filepath.SplitList(os.Getenv("LD_LIBRARY_PATH"))...)
+	dirs = append(dirs, defaultLibraryDirs...)
+	for _, pattern := range []string{"/lib/*-linux-*", "/usr/lib/*-linux-*"} {
+		matches, _ := filepath.Glob(pattern)
+		dirs = append(dirs, matches...)
+	}
+
+	var missing []string
+	for _, lib := range libs {
+		if !libraryExists(lib, dirs) {
+			missing = append(missing, lib)
+		}
+	}
+	return missing
+}
+
+// libraryExists reports whether lib is a path that exists or is found in
+// one of dirs
+func libraryExists(lib string, dirs []string) bool {
+	if strings.Contains(lib, "/") {
+		_, err := os.Stat(lib)
+		return err == nil
+	}
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(dir, lib)); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// missingLibrariesError reports unresolved libraries, if any
+func missingLibrariesError(missing []string) error {
+	if len(missing) == 0 {
+		return nil
+	}
+	return fmt.Errorf("unresolved shared libraries: %s", strings.Join(missing, ", "))
+}
+
+// VersionCheck runs a binary for its version and compares it with the
+// version the upgrade declares. Upgrades that declare no version pass.
+type VersionCheck struct {
+	versions *version.Cache
+}
+
+// NewVersionCheck creates a version check resolving versions through
+// versions
+func NewVersionCheck(versions *version.Cache) *VersionCheck {
+	return &VersionCheck{versions: versions}
+}
+
+func (c *VersionCheck) Name() string {
+	return "version"
+}
+
+func (c *VersionCheck) Check(ctx context.Context, binary string, info *types.UpgradeInfo) error {
+	expected := ExpectedVersion(info)
+	if expected == "" {
+		return nil
+	}
+
+	reported, err := c.versions.Lookup(binary)
+	if err != nil {
+		return fmt.Errorf("cannot run %s version: %w", filepath.Base(binary), err)
+	}
+
+	if !versionMatches(reported, expected) {
+		return fmt.Errorf("binary reports version %q, upgrade expects %s", reported.Raw, expected)
+	}
+	return nil
+}
+
+// versionMatches reports whether the reported version is the expected
+// one, ignoring a leading "v"
+func versionMatches(reported *version.Info, expected string) bool {
+	want := strings.TrimPrefix(expected, "v")
+	if reported.Semver != "" {
+		return strings.TrimPrefix(reported.Semver, "v") == want
+	}
+	for _, field := range strings.Fields(reported.Raw) {
+		if strings.TrimPrefix(field, "v") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// SmokeCheck runs the configured smoke command with /bin/sh -c in the node
+// home, with UPGRADE_BINARY set to the binary and the environment of a
+// pre-upgrade hook, and passes if it succeeds within the smoke timeout
+type SmokeCheck struct {
+	cfg *config.Config
+}
+
+// NewSmokeCheck creates a check running the smoke command of cfg
+func NewSmokeCheck(cfg *config.Config) *SmokeCheck {
+	return &SmokeCheck{cfg: cfg}
+}
+
+func (c *SmokeCheck) Name() string {
+	return "smoke"
+}
+
+func (c *SmokeCheck) Check(ctx context.Context, binary string, info *types.UpgradeInfo) error {
+	timeout := c.cfg.SmokeTimeout
+	if timeout <= 0 {
+		timeout = config.DefaultSmokeTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", c.cfg.SmokeCommand)
+	cmd.Dir = c.cfg.Home
+	cmd.Env = append(hookEnv(c.cfg, info, c.cfg.Home), "UPGRADE_BINARY="+binary)
+	// Do not wait on children of the shell still holding its output
+	cmd.WaitDelay = time.Second
+
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("smoke command timed out after %v", timeout)
+	}
+
+	msg := strings.TrimSpace(string(output))
+	if msg == "" {
+		return fmt.Errorf("smoke command failed: %w", err)
+	}
+	return fmt.Errorf("smoke command failed: %w: %s", err, msg)
+}
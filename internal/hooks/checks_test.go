@@ -0,0 +1,238 @@
+package hooks
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/version"
+	"github.com/wemix/wemixvisor/pkg/logger"
+	"github.com/wemix/wemixvisor/pkg/types"
+)
+
+// failingCheck is a check every binary fails
+type failingCheck struct{}
+
+func (c *failingCheck) Name() string {
+	return "failing"
+}
+
+func (c *failingCheck) Check(ctx context.Context, binary string, info *types.UpgradeInfo) error {
+	return errors.New("rejected")
+}
+
+func writeBinary(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "wemixd")
+	if err := os.WriteFile(path, []byte(content), 0755); err != nil {
+		t.Fatalf("failed to write binary: %v", err)
+	}
+	return path
+}
+
+func testExecutable(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS != "linux" {
+		t.Skip("skipping ELF test on non-Linux host")
+	}
+	path, err := os.Executable()
+	if err != nil {
+		t.Fatalf("failed to locate test binary: %v", err)
+	}
+	return path
+}
+
+func TestELFCheck(t *testing.T) {
+	binary := testExecutable(t)
+
+	check := &ELFCheck{GOOS: runtime.GOOS, GOARCH: runtime.GOARCH}
+	if err := check.Check(context.Background(), binary, nil); err != nil {
+		t.Errorf("host binary failed ELF check: %v", err)
+	}
+
+	otherArch := "arm64"
+	if runtime.GOARCH == "arm64" {
+		otherArch = "amd64"
+	}
+	check = &ELFCheck{GOOS: runtime.GOOS, GOARCH: otherArch}
+	err := check.Check(context.Background(), binary, nil)
+	if err == nil || !strings.Contains(err.Error(), "host is linux/"+otherArch) {
+		t.Errorf("expected architecture mismatch, got %v", err)
+	}
+}
+
+func TestELFCheckNotELF(t *testing.T) {
+	check := &ELFCheck{GOOS: "linux", GOARCH: "amd64"}
+
+	err := check.Check(context.Background(), writeBinary(t, "garbage that is not a binary"), nil)
+	if err == nil || !strings.Contains(err.Error(), "not an ELF executable") {
+		t.Errorf("expected not an ELF executable, got %v", err)
+	}
+
+	if err := check.Check(context.Background(), writeBinary(t, "#!/bin/sh\necho test\n"), nil); err != nil {
+		t.Errorf("scripts should not be checked: %v", err)
+	}
+}
+
+func TestDependencyCheck(t *testing.T) {
+	binary := testExecutable(t)
+
+	check := &DependencyCheck{}
+	if err := check.Check(context.Background(), binary, nil); err != nil {
+		t.Errorf("host binary failed dependency check: %v", err)
+	}
+	if err := check.Check(context.Background(), writeBinary(t, "#!/bin/sh\n"), nil); err != nil {
+		t.Errorf("scripts should pass the dependency check: %v", err)
+	}
+}
+
+func TestSearchLibraries(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "libfound.so.1"), nil, 0644); err != nil {
+		t.Fatalf("failed to write library: %v", err)
+	}
+
+	if !libraryExists("libfound.so.1", []string{"", dir}) {
+		t.Error("expected library to be found")
+	}
+	if libraryExists("libmissing.so.1", []string{dir}) {
+		t.Error("expected library to be missing")
+	}
+
+	err := missingLibrariesError([]string{"libmissing.so.1"})
+	if err == nil || !strings.Contains(err.Error(), "libmissing.so.1") {
+		t.Errorf("expected unresolved library error, got %v", err)
+	}
+}
+
+func TestVersionCheck(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping script test on Windows")
+	}
+
+	cfg := &config.Config{Home: t.TempDir(), Name: "wemixd"}
+	logger, _ := logger.New(false, true, "")
+	check := NewVersionCheck(version.NewCache(cfg, logger))
+	binary := writeBinary(t, "#!/bin/sh\necho \"wemixd version v2.0.0\"\n")
+
+	tests := []struct {
+		name     string
+		expected string
+		wantErr  bool
+	}{
+		{"no expected version", "", false},
+		{"same version", "v2.0.0", false},
+		{"without v prefix", "2.0.0", false},
+		{"other version", "v2.1.0", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := &types.UpgradeInfo{Name: "v2.0.0", Info: map[string]interface{}{}}
+			if tt.expected != "" {
+				info.Info["version"] = tt.expected
+			}
+
+			err := check.Check(context.Background(), binary, info)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "upgrade expects "+tt.expected) {
+					t.Errorf("expected version mismatch, got %v", err)
+				}
+			} else if err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSmokeCheck(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping script test on Windows")
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		wantErr string
+	}{
+		{"success", `test "$UPGRADE_BINARY" = /opt/wemixd && test "$UPGRADE_NAME" = v2.0.0`, ""},
+		{"failure", "echo database locked; exit 3", "smoke command failed: exit status 3: database locked"},
+		{"timeout", "sleep 5", "smoke command timed out after 200ms"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Home:         t.TempDir(),
+				Name:         "wemixd",
+				SmokeCommand: tt.command,
+				SmokeTimeout: 200 * time.Millisecond,
+			}
+
+			start := time.Now()
+			err := NewSmokeCheck(cfg).Check(context.Background(), "/opt/wemixd", &types.UpgradeInfo{Name: "v2.0.0"})
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("expected %q, got %v", tt.wantErr, err)
+			}
+			if time.Since(start) > 3*time.Second {
+				t.Errorf("smoke check took %v", time.Since(start))
+			}
+		})
+	}
+}
+
+func TestDefaultBinaryChecks(t *testing.T) {
+	cfg := &config.Config{Home: t.TempDir(), Name: "wemixd"}
+	logger, _ := logger.New(false, true, "")
+	versions := version.NewCache(cfg, logger)
+
+	if got := len(DefaultBinaryChecks(cfg, versions)); got != 3 {
+		t.Errorf("expected 3 checks without a smoke command, got %d", got)
+	}
+
+	cfg.SmokeCommand = "true"
+	checks := DefaultBinaryChecks(cfg, versions)
+	if got := checks[len(checks)-1].Name(); got != "smoke" {
+		t.Errorf("expected the smoke check last, got %s", got)
+	}
+}
+
+func TestValidateUpgradeFailedCheck(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &config.Config{
+		Home: tmpDir,
+		Name: "wemixd",
+	}
+	logger, _ := logger.New(false, true, "")
+	hook := NewPreUpgradeHook(cfg, logger)
+	hook.AddCheck(&failingCheck{})
+
+	upgradeBin := cfg.UpgradeBin("v2.0.0")
+	os.MkdirAll(filepath.Dir(upgradeBin), 0755)
+	os.WriteFile(upgradeBin, []byte("#!/bin/sh\necho test\n"), 0755)
+
+	// Should fail validation, naming the failed check
+	err := hook.ValidateUpgrade(&types.UpgradeInfo{Name: "v2.0.0", Height: 1000000})
+	var checkErr *BinaryCheckError
+	if !errors.As(err, &checkErr) {
+		t.Fatalf("expected a check error, got %v", err)
+	}
+	if checkErr.Check != "failing" || checkErr.Binary != upgradeBin {
+		t.Errorf("unexpected check error: %v", checkErr)
+	}
+	if err.Error() != "failing check failed for "+upgradeBin+": rejected" {
+		t.Errorf("unexpected error message: %v", err)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/version"
 	"github.com/wemix/wemixvisor/pkg/logger"
 	"github.com/wemix/wemixvisor/pkg/types"
 	"go.uber.org/zap"
@@ -19,18 +20,30 @@ import (
 
 // PreUpgradeHook manages pre-upgrade hook execution
 type PreUpgradeHook struct {
-	cfg    *config.Config
-	logger *logger.Logger
+	cfg      *config.Config
+	logger   *logger.Logger
+	versions *version.Cache
+	checks   []BinaryCheck
 }
 
-// NewPreUpgradeHook creates a new pre-upgrade hook manager
+// NewPreUpgradeHook creates a new pre-upgrade hook manager validating
+// upgrade binaries with the default checks
 func NewPreUpgradeHook(cfg *config.Config, logger *logger.Logger) *PreUpgradeHook {
+	versions := version.NewCache(cfg, logger)
 	return &PreUpgradeHook{
-		cfg:    cfg,
-		logger: logger,
+		cfg:      cfg,
+		logger:   logger,
+		versions: versions,
+		checks:   DefaultBinaryChecks(cfg, versions),
 	}
 }
 
+// AddCheck adds a check upgrade binaries must pass before the node is
+// switched to them
+func (h *PreUpgradeHook) AddCheck(check BinaryCheck) {
+	h.checks = append(h.checks, check)
+}
+
 // Execute runs the pre-upgrade hook for the given upgrade
 func (h *PreUpgradeHook) Execute(info *types.UpgradeInfo) error {
 	if info == nil {
@@ -83,18 +96,7 @@ func (h *PreUpgradeHook) runScript(scriptPath string, info *types.UpgradeInfo, m
 		return fmt.Errorf("failed to make script executable: %w", err)
 	}
 
-	// Set environment variables
-	env := os.Environ()
-	env = append(env, fmt.Sprintf("DAEMON_HOME=%s", h.cfg.Home))
-	env = append(env, fmt.Sprintf("DAEMON_NAME=%s", h.cfg.Name))
-	env = append(env, fmt.Sprintf("UPGRADE_NAME=%s", info.Name))
-	env = append(env, fmt.Sprintf("UPGRADE_HEIGHT=%d", info.Height))
-	if info.Info != nil && len(info.Info) > 0 {
-		// Convert map to JSON string for environment variable
-		if infoBytes, err := json.Marshal(info.Info); err == nil {
-			env = append(env, fmt.Sprintf("UPGRADE_INFO=%s", string(infoBytes)))
-		}
-	}
+	env := hookEnv(h.cfg, info, h.cfg.Home)
 
 	retries := 0
 	for {
@@ -114,6 +116,44 @@ func (h *PreUpgradeHook) runScript(scriptPath string, info *types.UpgradeInfo, m
 	}
 }
 
+// Rehearse runs the pre-upgrade hook for the given upgrade once, without
+// retries, in a no-op environment: DAEMON_HOME points at scratchHome and
+// UPGRADE_DRY_RUN=true is set, so a hook leaves the node home alone. It
+// returns the path of the script run, or "" if the upgrade has no hook.
+func (h *PreUpgradeHook) Rehearse(info *types.UpgradeInfo, scratchHome string) (string, error) {
+	scriptPath := h.cfg.CustomPreUpgrade
+	if scriptPath == "" {
+		scriptPath = filepath.Join(h.cfg.UpgradeDir(info.Name), "pre-upgrade")
+	}
+	if _, err := os.Stat(scriptPath); os.IsNotExist(err) {
+		return "", nil
+	}
+
+	h.logger.Info("rehearsing pre-upgrade script",
+		zap.String("path", scriptPath),
+		zap.String("scratch_home", scratchHome))
+
+	env := append(hookEnv(h.cfg, info, scratchHome), "UPGRADE_DRY_RUN=true")
+	return scriptPath, h.executeScriptOnce(scriptPath, env)
+}
+
+// hookEnv returns the environment of a pre-upgrade script run for the
+// given upgrade with DAEMON_HOME set to home
+func hookEnv(cfg *config.Config, info *types.UpgradeInfo, home string) []string {
+	env := os.Environ()
+	env = append(env, fmt.Sprintf("DAEMON_HOME=%s", home))
+	env = append(env, fmt.Sprintf("DAEMON_NAME=%s", cfg.Name))
+	env = append(env, fmt.Sprintf("UPGRADE_NAME=%s", info.Name))
+	env = append(env, fmt.Sprintf("UPGRADE_HEIGHT=%d", info.Height))
+	if info.Info != nil && len(info.Info) > 0 {
+		// Convert map to JSON string for environment variable
+		if infoBytes, err := json.Marshal(info.Info); err == nil {
+			env = append(env, fmt.Sprintf("UPGRADE_INFO=%s", string(infoBytes)))
+		}
+	}
+	return env
+}
+
 // executeScriptOnce runs the script once with a timeout
 func (h *PreUpgradeHook) executeScriptOnce(scriptPath string, env []string) error {
 	// Create context with timeout (5 minutes default)
@@ -168,11 +208,66 @@ func (h *PreUpgradeHook) ValidateUpgrade(info *types.UpgradeInfo) error {
 		return fmt.Errorf("upgrade binary is not executable: %s", upgradeBin)
 	}
 
-	// Additional validation can be added here
-	// - Check binary version
-	// - Verify checksum
-	// - Check dependencies
+	h.checkUpgradeVersion(upgradeBin)
+
+	if err := h.ValidateBinary(upgradeBin, info); err != nil {
+		return err
+	}
 
 	h.logger.Info("upgrade validation passed", zap.String("name", info.Name))
 	return nil
+}
+
+// ValidateBinary runs the checks of the hook on binary, the binary of the
+// given upgrade, in order, and returns a *BinaryCheckError for the first
+// check it fails
+func (h *PreUpgradeHook) ValidateBinary(binary string, info *types.UpgradeInfo) error {
+	ctx := context.Background()
+	for _, check := range h.checks {
+		if err := check.Check(ctx, binary, info); err != nil {
+			h.logger.Error("upgrade binary failed validation",
+				zap.String("binary", binary),
+				zap.String("check", check.Name()),
+				zap.Error(err))
+			return &BinaryCheckError{Check: check.Name(), Binary: binary, Err: err}
+		}
+		h.logger.Debug("upgrade binary check passed",
+			zap.String("binary", binary),
+			zap.String("check", check.Name()))
+	}
+	return nil
+}
+
+// checkUpgradeVersion reports the version of the upgrade binary and warns
+// if it is a copy of the current binary, which would halt at the upgrade
+// height again. Binaries that cannot report a version are not rejected.
+func (h *PreUpgradeHook) checkUpgradeVersion(upgradeBin string) {
+	upgrade, err := h.versions.Lookup(upgradeBin)
+	if err != nil {
+		h.logger.Warn("cannot determine upgrade binary version",
+			zap.String("binary", upgradeBin),
+			zap.Error(err))
+		return
+	}
+
+	h.logger.Info("upgrade binary version",
+		zap.String("version", upgrade.Raw),
+		zap.String("semver", upgrade.Semver),
+		zap.String("commit", upgrade.Commit),
+		zap.String("sha256", upgrade.SHA256))
+
+	currentPath, err := filepath.EvalSymlinks(h.cfg.CurrentBin())
+	if err != nil {
+		return
+	}
+	upgradePath, err := filepath.EvalSymlinks(upgradeBin)
+	if err != nil || currentPath == upgradePath {
+		// Already switched to the upgrade
+		return
+	}
+	if current, err := h.versions.Hash(currentPath); err == nil && current == upgrade.SHA256 {
+		h.logger.Warn("upgrade binary is identical to the current binary",
+			zap.String("current", currentPath),
+			zap.String("upgrade", upgradePath))
+	}
 }
\ No newline at end of file
@@ -242,6 +242,58 @@ func TestExecuteNoScript(t *testing.T) {
 	}
 }
 
+func TestRehearseScript(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("skipping script test on Windows")
+	}
+
+	tmpDir := t.TempDir()
+	scratchHome := t.TempDir()
+
+	// The script fails unless it runs as a dry run in the scratch home
+	scriptPath := filepath.Join(tmpDir, "custom-pre-upgrade.sh")
+	scriptContent := `#!/bin/sh
+[ "$UPGRADE_DRY_RUN" = "true" ] || exit 1
+touch "$DAEMON_HOME/rehearsed"
+`
+	os.WriteFile(scriptPath, []byte(scriptContent), 0755)
+
+	cfg := &config.Config{
+		Home:                 tmpDir,
+		Name:                 "wemixd",
+		CustomPreUpgrade:     scriptPath,
+		PreUpgradeMaxRetries: 2,
+	}
+	logger, _ := logger.New(false, true, "")
+	hook := NewPreUpgradeHook(cfg, logger)
+
+	info := &types.UpgradeInfo{
+		Name:   "v2.0.0",
+		Height: 1000000,
+	}
+
+	script, err := hook.Rehearse(info, scratchHome)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if script != scriptPath {
+		t.Errorf("expected %s to run, got %q", scriptPath, script)
+	}
+	if _, err := os.Stat(filepath.Join(scratchHome, "rehearsed")); err != nil {
+		t.Error("expected the script to run against the scratch home")
+	}
+	if _, err := os.Stat(filepath.Join(tmpDir, "rehearsed")); !os.IsNotExist(err) {
+		t.Error("expected the node home to be left alone")
+	}
+
+	// Without a script there is nothing to rehearse
+	cfg.CustomPreUpgrade = ""
+	script, err = hook.Rehearse(info, scratchHome)
+	if err != nil || script != "" {
+		t.Errorf("expected no script, got %q, %v", script, err)
+	}
+}
+
 func TestExecuteNilInfo(t *testing.T) {
 	cfg := &config.Config{
 		Home: t.TempDir(),
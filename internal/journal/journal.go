@@ -0,0 +1,267 @@
+// Package journal keeps a write-ahead journal of the upgrade in progress.
+// Each completed upgrade step is appended as one durable record, so that
+// after a crash the supervisor can tell how far the upgrade got and either
+// finish it or roll it back before starting the node.
+package journal
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/pkg/fsutil"
+	"github.com/wemix/wemixvisor/pkg/types"
+)
+
+// Upgrade steps, in the order they are recorded
+const (
+	StepBegin          = "begin"
+	StepNodeStopped    = "node_stopped"
+	StepBinaryReady    = "binary_ready"
+	StepBackedUp       = "backed_up"
+	StepHooksRun       = "hooks_run"
+	StepBinarySwitched = "binary_switched"
+	StepNodeStarted    = "node_started"
+	StepVerified       = "verified"
+	StepFailed         = "failed"
+	StepCommitted      = "committed"
+	StepAborted        = "aborted"
+)
+
+// fileLocks holds one lock per journal file, shared by all Journals for it
+var fileLocks sync.Map
+
+// Record is one line of the journal
+type Record struct {
+	Step           string             `json:"step"`
+	Time           time.Time          `json:"time"`
+	Upgrade        *types.UpgradeInfo `json:"upgrade,omitempty"`
+	PreviousTarget string             `json:"previous_target,omitempty"`
+	BackupPath     string             `json:"backup_path,omitempty"`
+	Error          string             `json:"error,omitempty"`
+}
+
+// Upgrade is the progress of a journaled upgrade that was neither committed
+// nor aborted
+type Upgrade struct {
+	Info *types.UpgradeInfo
+	// PreviousTarget is the directory current pointed at before the
+	// upgrade, empty if there was none
+	PreviousTarget string
+	// BackupPath is the pre-upgrade backup, empty if none was taken
+	BackupPath string
+	// Steps lists the recorded steps in order, starting with StepBegin
+	Steps []string
+	// Error is the error recorded with StepFailed, if any
+	Error string
+}
+
+// Done reports whether step was recorded
+func (u *Upgrade) Done(step string) bool {
+	for _, s := range u.Steps {
+		if s == step {
+			return true
+		}
+	}
+	return false
+}
+
+// Journal appends upgrade steps to the journal file. Journals for the same
+// file share a lock, so the orchestrator and the upgrader can record the
+// steps they carry out through their own Journal.
+type Journal struct {
+	path string
+	mu   *sync.Mutex
+}
+
+// New creates an upgrade journal for the given configuration
+func New(cfg *config.Config) *Journal {
+	path := cfg.UpgradeJournalPath()
+	mu, _ := fileLocks.LoadOrStore(path, &sync.Mutex{})
+
+	return &Journal{
+		path: path,
+		mu:   mu.(*sync.Mutex),
+	}
+}
+
+// Path returns the path of the journal file
+func (j *Journal) Path() string {
+	return j.path
+}
+
+// Begin starts the journal of a new upgrade, replacing the journal of the
+// previous one
+func (j *Journal) Begin(info *types.UpgradeInfo, previousTarget string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	line, err := encode(&Record{
+		Step:           StepBegin,
+		Upgrade:        info,
+		PreviousTarget: previousTarget,
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(j.path), 0755); err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+
+	if err := fsutil.WriteFileAtomic(j.path, line, 0644); err != nil {
+		return fmt.Errorf("failed to replace upgrade journal: %w", err)
+	}
+	return nil
+}
+
+// Step records that step completed
+func (j *Journal) Step(step string) error {
+	return j.append(&Record{Step: step})
+}
+
+// BackedUp records the pre-upgrade backup at path
+func (j *Journal) BackedUp(path string) error {
+	return j.append(&Record{Step: StepBackedUp, BackupPath: path})
+}
+
+// Fail records that the upgrade failed with err
+func (j *Journal) Fail(err error) error {
+	return j.append(&Record{Step: StepFailed, Error: err.Error()})
+}
+
+// Commit records that the upgrade completed
+func (j *Journal) Commit() error {
+	return j.append(&Record{Step: StepCommitted})
+}
+
+// Abort records that the upgrade was rolled back
+func (j *Journal) Abort() error {
+	return j.append(&Record{Step: StepAborted})
+}
+
+// Incomplete returns the upgrade recorded in the journal if it was neither
+// committed nor aborted, or nil otherwise. Records torn by a crash while
+// they were written are skipped.
+func (j *Journal) Incomplete() (*Upgrade, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	data, err := os.ReadFile(j.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read upgrade journal: %w", err)
+	}
+
+	var upgrade *Upgrade
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+
+		if rec.Step == StepBegin {
+			if rec.Upgrade == nil {
+				return nil, fmt.Errorf("upgrade journal begins without an upgrade")
+			}
+			upgrade = &Upgrade{Info: rec.Upgrade, PreviousTarget: rec.PreviousTarget}
+		}
+		if upgrade == nil {
+			return nil, fmt.Errorf("upgrade journal does not start with %s", StepBegin)
+		}
+
+		upgrade.Steps = append(upgrade.Steps, rec.Step)
+		switch rec.Step {
+		case StepBackedUp:
+			upgrade.BackupPath = rec.BackupPath
+		case StepFailed:
+			upgrade.Error = rec.Error
+		case StepCommitted, StepAborted:
+			return nil, nil
+		}
+	}
+
+	return upgrade, nil
+}
+
+// append writes rec to the end of the journal and flushes it to disk.
+// Without a journal begun by Begin there is nothing to record to.
+func (j *Journal) append(rec *Record) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	line, err := encode(rec)
+	if err != nil {
+		return err
+	}
+
+	// Start on a new line after a record torn by a crash
+	if torn, err := endsTorn(j.path); err == nil && torn {
+		line = append([]byte{'\n'}, line...)
+	}
+
+	if err := appendSynced(j.path, line, os.O_APPEND); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to record upgrade step %s: %w", rec.Step, err)
+	}
+	return nil
+}
+
+// endsTorn reports whether the file at path does not end with a newline
+func endsTorn(path string) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil || info.Size() == 0 {
+		return false, err
+	}
+
+	last := make([]byte, 1)
+	if _, err := f.ReadAt(last, info.Size()-1); err != nil {
+		return false, err
+	}
+	return last[0] != '\n', nil
+}
+
+// encode timestamps rec and returns it as one journal line
+func encode(rec *Record) ([]byte, error) {
+	rec.Time = time.Now()
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal journal record: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// appendSynced writes data to path, opened with the extra flags, and
+// flushes it to disk
+func appendSynced(path string, data []byte, flag int) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|flag, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
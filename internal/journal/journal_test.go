@@ -0,0 +1,92 @@
+package journal
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/pkg/types"
+)
+
+func TestJournal_IncompleteUpgrade(t *testing.T) {
+	cfg := &config.Config{Home: t.TempDir()}
+	j := New(cfg)
+	assert.Equal(t, cfg.UpgradeJournalPath(), j.Path())
+
+	pending, err := j.Incomplete()
+	require.NoError(t, err)
+	assert.Nil(t, pending, "no journal means no interrupted upgrade")
+
+	info := &types.UpgradeInfo{Name: "v2", Height: 100}
+	require.NoError(t, j.Begin(info, "/home/wemixvisor/genesis"))
+	require.NoError(t, j.Step(StepNodeStopped))
+	require.NoError(t, New(cfg).Step(StepBinaryReady), "journals for the same file share it")
+	require.NoError(t, j.BackedUp("/backups/pre-upgrade-v2.tar.gz"))
+
+	pending, err = j.Incomplete()
+	require.NoError(t, err)
+	require.NotNil(t, pending)
+	assert.Equal(t, info, pending.Info)
+	assert.Equal(t, "/home/wemixvisor/genesis", pending.PreviousTarget)
+	assert.Equal(t, "/backups/pre-upgrade-v2.tar.gz", pending.BackupPath)
+	assert.Equal(t, []string{StepBegin, StepNodeStopped, StepBinaryReady, StepBackedUp}, pending.Steps)
+	assert.True(t, pending.Done(StepBackedUp))
+	assert.False(t, pending.Done(StepBinarySwitched))
+
+	require.NoError(t, j.Fail(errors.New("node did not become ready")))
+	pending, err = j.Incomplete()
+	require.NoError(t, err)
+	assert.Equal(t, "node did not become ready", pending.Error)
+
+	require.NoError(t, j.Abort())
+	pending, err = j.Incomplete()
+	require.NoError(t, err)
+	assert.Nil(t, pending, "an aborted upgrade is complete")
+
+	// The next upgrade replaces the journal
+	require.NoError(t, j.Begin(&types.UpgradeInfo{Name: "v3", Height: 200}, ""))
+	pending, err = j.Incomplete()
+	require.NoError(t, err)
+	assert.Equal(t, "v3", pending.Info.Name)
+	assert.Equal(t, []string{StepBegin}, pending.Steps)
+
+	require.NoError(t, j.Commit())
+	pending, err = j.Incomplete()
+	require.NoError(t, err)
+	assert.Nil(t, pending, "a committed upgrade is complete")
+}
+
+func TestJournal_TornRecord(t *testing.T) {
+	cfg := &config.Config{Home: t.TempDir()}
+	j := New(cfg)
+	require.NoError(t, j.Begin(&types.UpgradeInfo{Name: "v2", Height: 100}, ""))
+	require.NoError(t, j.Step(StepNodeStopped))
+
+	// A crash while a record was being written
+	f, err := os.OpenFile(j.Path(), os.O_WRONLY|os.O_APPEND, 0644)
+	require.NoError(t, err)
+	_, err = f.WriteString(`{"step":"binary_re`)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	pending, err := j.Incomplete()
+	require.NoError(t, err)
+	assert.Equal(t, []string{StepBegin, StepNodeStopped}, pending.Steps)
+
+	require.NoError(t, j.Abort())
+	pending, err = j.Incomplete()
+	require.NoError(t, err)
+	assert.Nil(t, pending, "records after a torn one are read")
+}
+
+func TestJournal_StepsWithoutUpgradeAreDropped(t *testing.T) {
+	j := New(&config.Config{Home: t.TempDir()})
+
+	require.NoError(t, j.Step(StepBinaryReady))
+
+	_, err := os.Stat(j.Path())
+	assert.True(t, os.IsNotExist(err))
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/shirou/gopsutil/v3/disk"
 	"github.com/shirou/gopsutil/v3/mem"
 	"github.com/shirou/gopsutil/v3/net"
+	"github.com/wemix/wemixvisor/internal/procstat"
 	"github.com/wemix/wemixvisor/pkg/logger"
 )
 
@@ -41,11 +42,25 @@ type Collector struct {
 	// Process metrics
 	processRestarts prometheus.Counter
 	processUptime   prometheus.Gauge
+	stopSteps       *prometheus.CounterVec
 
 	// Node metrics
 	nodeHeight  prometheus.Gauge
 	nodePeers   prometheus.Gauge
 	nodeSyncing prometheus.Gauge
+	nodeStall   prometheus.Gauge
+	stallAction *prometheus.CounterVec
+	nodeVersion *prometheus.GaugeVec
+
+	// Node process group metrics
+	nodeProcesses prometheus.Gauge
+	nodeRSS       prometheus.Gauge
+	nodeCPU       prometheus.Gauge
+	nodeOpenFDs   prometheus.Gauge
+	nodeMaxFDs    prometheus.Gauge
+	nodeThreads   prometheus.Gauge
+	nodeIO        *prometheus.GaugeVec
+	nodeCtxSwitch *prometheus.GaugeVec
 
 	// Governance metrics
 	proposalTotal    prometheus.Gauge
@@ -77,6 +92,7 @@ type Collector struct {
 	nodeHeightFunc    func() (int64, error)
 	nodePeersFunc     func() (int, error)
 	nodeSyncingFunc   func() (bool, error)
+	nodeProcessFunc   func() (int, error)
 	proposalStatsFunc func() (*GovernanceMetrics, error)
 }
 
@@ -166,6 +182,11 @@ func (c *Collector) initPrometheusMetrics() {
 		Help: "Process uptime in seconds",
 	})
 
+	c.stopSteps = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "wemixvisor_process_stop_steps_total",
+		Help: "Total number of stop sequence steps taken, by step and outcome",
+	}, []string{"step", "outcome"})
+
 	// Node metrics
 	c.nodeHeight = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "wemixvisor_node_height",
@@ -182,6 +203,62 @@ func (c *Collector) initPrometheusMetrics() {
 		Help: "Whether node is syncing (1) or not (0)",
 	})
 
+	c.nodeStall = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "wemixvisor_node_stall_seconds",
+		Help: "Seconds since the blockchain height last advanced",
+	})
+
+	c.stallAction = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "wemixvisor_node_stall_actions_total",
+		Help: "Total number of actions taken on a stalled chain, by action and outcome",
+	}, []string{"action", "outcome"})
+
+	c.nodeVersion = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "wemixvisor_node_version_info",
+		Help: "Version of the running node binary, always 1",
+	}, []string{"version", "commit", "go_version"})
+
+	// Node process group metrics
+	c.nodeProcesses = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "wemixvisor_node_processes",
+		Help: "Number of processes in the node's process group",
+	})
+
+	c.nodeRSS = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "wemixvisor_node_resident_memory_bytes",
+		Help: "Resident memory of the node's process group in bytes",
+	})
+
+	c.nodeCPU = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "wemixvisor_node_cpu_seconds",
+		Help: "User and system CPU time of the node's process group in seconds",
+	})
+
+	c.nodeOpenFDs = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "wemixvisor_node_open_fds",
+		Help: "Open file descriptors of the node's process group",
+	})
+
+	c.nodeMaxFDs = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "wemixvisor_node_max_fds",
+		Help: "Open file descriptor limit of the node process, 0 if unlimited",
+	})
+
+	c.nodeThreads = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "wemixvisor_node_threads",
+		Help: "Threads of the node's process group",
+	})
+
+	c.nodeIO = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "wemixvisor_node_io_bytes",
+		Help: "Storage bytes read and written by the node's process group, by direction",
+	}, []string{"direction"})
+
+	c.nodeCtxSwitch = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "wemixvisor_node_context_switches",
+		Help: "Context switches of the node's process group, by type",
+	}, []string{"type"})
+
 	// Governance metrics
 	c.proposalTotal = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "wemixvisor_proposals_total",
@@ -244,48 +321,63 @@ func (c *Collector) initPrometheusMetrics() {
 	})
 }
 
-// registerMetrics registers all metrics with the Prometheus registry
+// registerMetrics registers all metrics with the Prometheus registry,
+// adding the configured constant labels to each
 func (c *Collector) registerMetrics() {
+	registerer := prometheus.WrapRegistererWith(c.config.Labels, c.registry)
+
 	// System metrics
 	if c.config.EnableSystemMetrics {
-		c.registry.MustRegister(c.cpuUsage)
-		c.registry.MustRegister(c.memoryUsage)
-		c.registry.MustRegister(c.diskUsage)
-		c.registry.MustRegister(c.goroutines)
-		c.registry.MustRegister(c.networkRxBytes)
-		c.registry.MustRegister(c.networkTxBytes)
+		registerer.MustRegister(c.cpuUsage)
+		registerer.MustRegister(c.memoryUsage)
+		registerer.MustRegister(c.diskUsage)
+		registerer.MustRegister(c.goroutines)
+		registerer.MustRegister(c.networkRxBytes)
+		registerer.MustRegister(c.networkTxBytes)
 	}
 
 	// Application metrics
 	if c.config.EnableAppMetrics {
-		c.registry.MustRegister(c.upgradeTotal)
-		c.registry.MustRegister(c.upgradeSuccess)
-		c.registry.MustRegister(c.upgradeFailed)
-		c.registry.MustRegister(c.upgradePending)
-		c.registry.MustRegister(c.processRestarts)
-		c.registry.MustRegister(c.processUptime)
-		c.registry.MustRegister(c.nodeHeight)
-		c.registry.MustRegister(c.nodePeers)
-		c.registry.MustRegister(c.nodeSyncing)
+		registerer.MustRegister(c.upgradeTotal)
+		registerer.MustRegister(c.upgradeSuccess)
+		registerer.MustRegister(c.upgradeFailed)
+		registerer.MustRegister(c.upgradePending)
+		registerer.MustRegister(c.processRestarts)
+		registerer.MustRegister(c.processUptime)
+		registerer.MustRegister(c.stopSteps)
+		registerer.MustRegister(c.nodeHeight)
+		registerer.MustRegister(c.nodePeers)
+		registerer.MustRegister(c.nodeSyncing)
+		registerer.MustRegister(c.nodeStall)
+		registerer.MustRegister(c.stallAction)
+		registerer.MustRegister(c.nodeVersion)
+		registerer.MustRegister(c.nodeProcesses)
+		registerer.MustRegister(c.nodeRSS)
+		registerer.MustRegister(c.nodeCPU)
+		registerer.MustRegister(c.nodeOpenFDs)
+		registerer.MustRegister(c.nodeMaxFDs)
+		registerer.MustRegister(c.nodeThreads)
+		registerer.MustRegister(c.nodeIO)
+		registerer.MustRegister(c.nodeCtxSwitch)
 	}
 
 	// Governance metrics
 	if c.config.EnableGovMetrics {
-		c.registry.MustRegister(c.proposalTotal)
-		c.registry.MustRegister(c.proposalVoting)
-		c.registry.MustRegister(c.proposalPassed)
-		c.registry.MustRegister(c.proposalRejected)
-		c.registry.MustRegister(c.votingPower)
-		c.registry.MustRegister(c.votingTurnout)
-		c.registry.MustRegister(c.validatorActive)
-		c.registry.MustRegister(c.validatorJailed)
+		registerer.MustRegister(c.proposalTotal)
+		registerer.MustRegister(c.proposalVoting)
+		registerer.MustRegister(c.proposalPassed)
+		registerer.MustRegister(c.proposalRejected)
+		registerer.MustRegister(c.votingPower)
+		registerer.MustRegister(c.votingTurnout)
+		registerer.MustRegister(c.validatorActive)
+		registerer.MustRegister(c.validatorJailed)
 	}
 
 	// Performance metrics
 	if c.config.EnablePerfMetrics {
-		c.registry.MustRegister(c.rpcLatency)
-		c.registry.MustRegister(c.apiLatency)
-		c.registry.MustRegister(c.tps)
+		registerer.MustRegister(c.rpcLatency)
+		registerer.MustRegister(c.apiLatency)
+		registerer.MustRegister(c.tps)
 	}
 }
 
@@ -352,6 +444,9 @@ func (c *Collector) collect() {
 	if c.config.EnableAppMetrics {
 		snapshot.Application = c.collectApplicationMetrics()
 		c.updateApplicationPrometheus(snapshot.Application)
+
+		snapshot.Process = c.collectProcessMetrics()
+		c.updateProcessPrometheus(snapshot.Process)
 	}
 
 	// Collect governance metrics
@@ -445,6 +540,27 @@ func (c *Collector) collectApplicationMetrics() *ApplicationMetrics {
 	return metrics
 }
 
+// collectProcessMetrics reads the resource usage of the node's process
+// group, returning nil while no node process is running
+func (c *Collector) collectProcessMetrics() *ProcessMetrics {
+	if c.nodeProcessFunc == nil {
+		return nil
+	}
+
+	pgid, err := c.nodeProcessFunc()
+	if err != nil || pgid <= 0 {
+		return nil
+	}
+
+	stats, err := procstat.ReadGroup(pgid)
+	if err != nil {
+		c.logger.Debug("Failed to read node process metrics", "pgid", pgid, "error", err)
+		return nil
+	}
+
+	return &ProcessMetrics{Stats: *stats, Timestamp: time.Now()}
+}
+
 // collectGovernanceMetrics collects governance-related metrics
 func (c *Collector) collectGovernanceMetrics() *GovernanceMetrics {
 	metrics := &GovernanceMetrics{
@@ -499,6 +615,25 @@ func (c *Collector) updateApplicationPrometheus(metrics *ApplicationMetrics) {
 	}
 }
 
+// updateProcessPrometheus updates Prometheus metrics for the node's process
+// group, resetting them while no node process is running
+func (c *Collector) updateProcessPrometheus(metrics *ProcessMetrics) {
+	if metrics == nil {
+		metrics = &ProcessMetrics{}
+	}
+
+	c.nodeProcesses.Set(float64(metrics.Processes))
+	c.nodeRSS.Set(float64(metrics.RSSBytes))
+	c.nodeCPU.Set(metrics.CPUSeconds)
+	c.nodeOpenFDs.Set(float64(metrics.OpenFDs))
+	c.nodeMaxFDs.Set(float64(metrics.MaxFDs))
+	c.nodeThreads.Set(float64(metrics.Threads))
+	c.nodeIO.WithLabelValues("read").Set(float64(metrics.ReadBytes))
+	c.nodeIO.WithLabelValues("write").Set(float64(metrics.WriteBytes))
+	c.nodeCtxSwitch.WithLabelValues("voluntary").Set(float64(metrics.VoluntarySwitches))
+	c.nodeCtxSwitch.WithLabelValues("involuntary").Set(float64(metrics.InvoluntarySwitches))
+}
+
 // updateGovernancePrometheus updates Prometheus metrics for governance
 func (c *Collector) updateGovernancePrometheus(metrics *GovernanceMetrics) {
 	if metrics == nil {
@@ -549,6 +684,12 @@ func (c *Collector) SetNodeSyncingCallback(fn func() (bool, error)) {
 	c.nodeSyncingFunc = fn
 }
 
+// SetNodeProcessCallback sets the callback for getting the process group
+// of the running node, whose resource usage is collected
+func (c *Collector) SetNodeProcessCallback(fn func() (int, error)) {
+	c.nodeProcessFunc = fn
+}
+
 // SetProposalStatsCallback sets the callback for getting proposal statistics
 func (c *Collector) SetProposalStatsCallback(fn func() (*GovernanceMetrics, error)) {
 	c.proposalStatsFunc = fn
@@ -579,6 +720,27 @@ func (c *Collector) IncrementProcessRestarts() {
 	c.processRestarts.Inc()
 }
 
+// RecordStopStep counts a step of the node stop sequence and its outcome
+func (c *Collector) RecordStopStep(step, outcome string) {
+	c.stopSteps.WithLabelValues(step, outcome).Inc()
+}
+
+// SetNodeStall sets the time since the blockchain height last advanced
+func (c *Collector) SetNodeStall(d time.Duration) {
+	c.nodeStall.Set(d.Seconds())
+}
+
+// RecordStallAction counts an action taken on a stalled chain and its outcome
+func (c *Collector) RecordStallAction(action, outcome string) {
+	c.stallAction.WithLabelValues(action, outcome).Inc()
+}
+
+// SetNodeVersion reports the version of the running node binary
+func (c *Collector) SetNodeVersion(version, commit, goVersion string) {
+	c.nodeVersion.Reset()
+	c.nodeVersion.WithLabelValues(version, commit, goVersion).Set(1)
+}
+
 // ObserveRPCLatency records an RPC latency observation
 func (c *Collector) ObserveRPCLatency(latencyMS float64) {
 	c.rpcLatency.Observe(latencyMS)
@@ -2,6 +2,9 @@ package metrics
 
 import (
 	"context"
+	"errors"
+	"os"
+	"syscall"
 	"testing"
 	"time"
 
@@ -332,6 +335,94 @@ func TestCollectorSetUpgradePending(t *testing.T) {
 	}
 }
 
+// TestCollectorSetNodeVersion tests that only the latest node version is reported
+func TestCollectorSetNodeVersion(t *testing.T) {
+	// Arrange
+	config := &CollectorConfig{
+		Enabled:          true,
+		EnableAppMetrics: true,
+	}
+	collector := NewCollector(config, logger.NewTestLogger())
+
+	// Act
+	collector.SetNodeVersion("1.0.0", "abc1234", "go1.21.5")
+	collector.SetNodeVersion("1.1.0", "def5678", "go1.22.1")
+
+	// Assert
+	families, err := collector.registry.Gather()
+	require.NoError(t, err)
+
+	for _, mf := range families {
+		if mf.GetName() != "wemixvisor_node_version_info" {
+			continue
+		}
+		require.Len(t, mf.GetMetric(), 1)
+		labels := make(map[string]string)
+		for _, label := range mf.GetMetric()[0].GetLabel() {
+			labels[label.GetName()] = label.GetValue()
+		}
+		assert.Equal(t, map[string]string{
+			"version":    "1.1.0",
+			"commit":     "def5678",
+			"go_version": "go1.22.1",
+		}, labels)
+		assert.Equal(t, float64(1), mf.GetMetric()[0].GetGauge().GetValue())
+		return
+	}
+	t.Error("node_version_info metric not found")
+}
+
+// gaugeValue returns the value of the unlabeled gauge name
+func gaugeValue(t *testing.T, collector *Collector, name string) float64 {
+	t.Helper()
+	families, err := collector.registry.Gather()
+	require.NoError(t, err)
+	for _, mf := range families {
+		if mf.GetName() == name {
+			require.Len(t, mf.GetMetric(), 1)
+			return mf.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	t.Fatalf("metric %s not found", name)
+	return 0
+}
+
+func TestCollectorProcessMetrics(t *testing.T) {
+	// Arrange
+	config := &CollectorConfig{
+		Enabled:          true,
+		EnableAppMetrics: true,
+	}
+	collector := NewCollector(config, logger.NewTestLogger())
+
+	// The process group of the test stands in for the node
+	pgid, err := syscall.Getpgid(os.Getpid())
+	require.NoError(t, err)
+	collector.SetNodeProcessCallback(func() (int, error) {
+		return pgid, nil
+	})
+
+	// Act
+	collector.collect()
+
+	// Assert
+	snapshot := collector.GetSnapshot()
+	require.NotNil(t, snapshot)
+	require.NotNil(t, snapshot.Process)
+	assert.Equal(t, pgid, snapshot.Process.PGID)
+	assert.Greater(t, snapshot.Process.RSSBytes, uint64(0))
+	assert.Greater(t, snapshot.Process.Threads, 0)
+	assert.Greater(t, gaugeValue(t, collector, "wemixvisor_node_resident_memory_bytes"), float64(0))
+
+	// Metrics are reset once the node is gone
+	collector.SetNodeProcessCallback(func() (int, error) {
+		return 0, errors.New("node is not running")
+	})
+	collector.collect()
+	assert.Nil(t, collector.GetSnapshot().Process)
+	assert.Equal(t, float64(0), gaugeValue(t, collector, "wemixvisor_node_resident_memory_bytes"))
+}
+
 // TestCollectorObserveLatency tests latency observation methods
 func TestCollectorObserveLatency(t *testing.T) {
 	tests := []struct {
@@ -411,6 +502,37 @@ func TestCollectorGetRegistry(t *testing.T) {
 	assert.Equal(t, collector.registry, registry)
 }
 
+// TestCollectorInstanceLabel tests that configured labels are attached to
+// every metric
+func TestCollectorInstanceLabel(t *testing.T) {
+	// Arrange
+	config := &CollectorConfig{
+		Enabled:             true,
+		EnableSystemMetrics: true,
+		Labels:              map[string]string{InstanceLabel: "sentry"},
+	}
+	collector := NewCollector(config, logger.NewTestLogger())
+
+	// Act
+	families, err := collector.GetRegistry().Gather()
+	require.NoError(t, err)
+
+	// Assert
+	require.NotEmpty(t, families)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			found := false
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == InstanceLabel {
+					assert.Equal(t, "sentry", label.GetValue())
+					found = true
+				}
+			}
+			assert.True(t, found, "metric %s has no instance label", family.GetName())
+		}
+	}
+}
+
 // TestCollectorRecordError tests error recording
 func TestCollectorRecordError(t *testing.T) {
 	// Arrange
@@ -507,7 +629,7 @@ func TestCollectorMetricsRegistration(t *testing.T) {
 			enableApp:           true,
 			enableGov:           true,
 			enablePerf:          true,
-			expectedMetricCount: 26, // System(6) + App(9) + Gov(8) + Perf(3)
+			expectedMetricCount: 33, // System(6) + App(16) + Gov(8) + Perf(3)
 		},
 		{
 			name:                "only system metrics",
@@ -3,6 +3,8 @@ package metrics
 
 import (
 	"time"
+
+	"github.com/wemix/wemixvisor/internal/procstat"
 )
 
 // MetricType represents the type of metric
@@ -90,6 +92,13 @@ type ApplicationMetrics struct {
 	Timestamp time.Time `json:"timestamp"`
 }
 
+// ProcessMetrics holds the resource usage of the node's process group,
+// read from /proc
+type ProcessMetrics struct {
+	procstat.Stats
+	Timestamp time.Time `json:"timestamp"`
+}
+
 // GovernanceMetrics holds governance-related metrics
 type GovernanceMetrics struct {
 	// Proposal metrics
@@ -170,11 +179,16 @@ type Alert struct {
 type MetricsSnapshot struct {
 	System      *SystemMetrics      `json:"system"`
 	Application *ApplicationMetrics `json:"application"`
+	Process     *ProcessMetrics     `json:"process,omitempty"`
 	Governance  *GovernanceMetrics  `json:"governance"`
 	Performance *PerformanceMetrics `json:"performance"`
 	Timestamp   time.Time           `json:"timestamp"`
 }
 
+// InstanceLabel is the metric label naming the node instance in
+// multi-instance mode
+const InstanceLabel = "node_instance"
+
 // CollectorConfig represents configuration for metrics collector
 type CollectorConfig struct {
 	Enabled             bool          `json:"enabled"`
@@ -186,4 +200,7 @@ type CollectorConfig struct {
 	EnablePerfMetrics   bool          `json:"enable_perf_metrics"`
 	PrometheusPort      int           `json:"prometheus_port"`
 	PrometheusPath      string        `json:"prometheus_path"`
+
+	// Labels are added to every exported metric, e.g. the node instance
+	Labels map[string]string `json:"labels,omitempty"`
 }
\ No newline at end of file
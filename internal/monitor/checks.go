@@ -8,8 +8,13 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"os/exec"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
+
+	"github.com/wemix/wemixvisor/internal/procstat"
 )
 
 // ProcessCheck checks if the node process is running
@@ -57,6 +62,11 @@ type RPCHealthCheck struct {
 	url string
 }
 
+// NewRPCHealthCheck creates a check for the RPC endpoint at url
+func NewRPCHealthCheck(url string) *RPCHealthCheck {
+	return &RPCHealthCheck{url: url}
+}
+
 func (c *RPCHealthCheck) Name() string {
 	return "rpc_endpoint"
 }
@@ -264,9 +274,118 @@ func (c *SyncingCheck) Check(ctx context.Context) error {
 	return fmt.Errorf("unable to determine sync status")
 }
 
-// MemoryCheck checks memory usage
+// BlockHeightCheck checks that the node reports a block height of at least
+// minHeight
+type BlockHeightCheck struct {
+	rpcURL    string
+	minHeight uint64
+}
+
+// NewBlockHeightCheck creates a check for the block height reported at rpcURL
+func NewBlockHeightCheck(rpcURL string, minHeight uint64) *BlockHeightCheck {
+	return &BlockHeightCheck{rpcURL: rpcURL, minHeight: minHeight}
+}
+
+func (c *BlockHeightCheck) Name() string {
+	return "block_height"
+}
+
+func (c *BlockHeightCheck) Check(ctx context.Context) error {
+	// Prepare JSON-RPC request for eth_blockNumber
+	reqBody := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  "eth_blockNumber",
+		"params":  []interface{}{},
+		"id":      1,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	// Create request with context
+	req, err := http.NewRequestWithContext(ctx, "POST", c.rpcURL, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	// Send request
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to get block height: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Parse response
+	var result struct {
+		Result string `json:"result"`
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+
+	if err := json.Unmarshal(body, &result); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+
+	height, err := strconv.ParseUint(strings.TrimPrefix(result.Result, "0x"), 16, 64)
+	if err != nil {
+		return fmt.Errorf("invalid block height %q: %w", result.Result, err)
+	}
+
+	if height < c.minHeight {
+		return fmt.Errorf("block height %d below %d", height, c.minHeight)
+	}
+
+	return nil
+}
+
+// CommandCheck runs a shell command and passes if it exits with status 0
+type CommandCheck struct {
+	command string
+	dir     string
+}
+
+// NewCommandCheck creates a check that runs command with /bin/sh -c in dir
+func NewCommandCheck(command, dir string) *CommandCheck {
+	return &CommandCheck{command: command, dir: dir}
+}
+
+func (c *CommandCheck) Name() string {
+	return "command"
+}
+
+func (c *CommandCheck) Check(ctx context.Context) error {
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", c.command)
+	cmd.Dir = c.dir
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		msg := strings.TrimSpace(string(output))
+		if msg == "" {
+			return fmt.Errorf("command failed: %w", err)
+		}
+		return fmt.Errorf("command failed: %w: %s", err, msg)
+	}
+	return nil
+}
+
+// MemoryCheck checks the resident memory of the node's process group
+// against a limit. It passes while no limit is set or no node is running.
 type MemoryCheck struct {
 	maxMemoryMB int64
+	pid         func() int
+}
+
+// NewMemoryCheck creates a check that fails once the process group led by
+// the process pid returns uses more than maxMemoryMB
+func NewMemoryCheck(maxMemoryMB int64, pid func() int) *MemoryCheck {
+	return &MemoryCheck{maxMemoryMB: maxMemoryMB, pid: pid}
 }
 
 func (c *MemoryCheck) Name() string {
@@ -274,8 +393,25 @@ func (c *MemoryCheck) Name() string {
 }
 
 func (c *MemoryCheck) Check(ctx context.Context) error {
-	// This is a placeholder - in production, you would check actual memory usage
-	// For now, we'll just pass the check
+	if c.maxMemoryMB <= 0 || c.pid == nil {
+		return nil
+	}
+
+	pgid := c.pid()
+	if pgid <= 0 {
+		return nil
+	}
+
+	stats, err := procstat.ReadGroup(pgid)
+	if err != nil {
+		return fmt.Errorf("cannot read node memory usage: %w", err)
+	}
+
+	usedMB := int64(stats.RSSBytes / (1024 * 1024))
+	if usedMB > c.maxMemoryMB {
+		return fmt.Errorf("node memory usage too high: %d MB > %d MB", usedMB, c.maxMemoryMB)
+	}
+
 	return nil
 }
 
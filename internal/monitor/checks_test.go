@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"syscall"
 	"testing"
 	"time"
 
@@ -209,9 +210,79 @@ func TestDiskSpaceCheck(t *testing.T) {
 	}
 }
 
+func TestBlockHeightCheck(t *testing.T) {
+	height := "0x0"
+
+	// Create mock RPC server
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&req)
+
+		if req["method"] == "eth_blockNumber" {
+			resp := map[string]interface{}{
+				"jsonrpc": "2.0",
+				"id":      req["id"],
+				"result":  height,
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(resp)
+		}
+	}))
+	defer server.Close()
+
+	check := NewBlockHeightCheck(server.URL, 1)
+	assert.Equal(t, "block_height", check.Name())
+
+	// Genesis only, no block observed yet
+	err := check.Check(context.Background())
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "below 1")
+
+	height = "0x1a"
+	assert.NoError(t, check.Check(context.Background()))
+
+	// Unreachable endpoint
+	check = NewBlockHeightCheck("http://127.0.0.1:1", 1)
+	assert.Error(t, check.Check(context.Background()))
+}
+
+func TestCommandCheck(t *testing.T) {
+	dir := t.TempDir()
+
+	check := NewCommandCheck("test -f marker", dir)
+	assert.Equal(t, "command", check.Name())
+
+	err := check.Check(context.Background())
+	assert.Error(t, err)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "marker"), nil, 0644))
+	assert.NoError(t, check.Check(context.Background()))
+
+	check = NewCommandCheck("echo not ready; exit 2", dir)
+	err = check.Check(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "not ready")
+}
+
 func TestMemoryCheck(t *testing.T) {
-	// Memory check is a placeholder in the current implementation
+	// Without a node process the check passes
 	check := &MemoryCheck{maxMemoryMB: 1000}
-	err := check.Check(context.Background())
-	assert.NoError(t, err) // Should always pass for now
+	assert.NoError(t, check.Check(context.Background()))
+
+	// The process group of the test stands in for the node
+	pgid, err := syscall.Getpgid(os.Getpid())
+	require.NoError(t, err)
+	nodePID := func() int { return pgid }
+
+	check = NewMemoryCheck(1<<20, nodePID)
+	assert.NoError(t, check.Check(context.Background()))
+
+	check = NewMemoryCheck(1, nodePID)
+	err = check.Check(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "node memory usage too high")
+
+	// A limit of zero disables the check
+	check = NewMemoryCheck(0, nodePID)
+	assert.NoError(t, check.Check(context.Background()))
 }
\ No newline at end of file
@@ -50,6 +50,7 @@ type HealthChecker struct {
 	rpcURL        string
 	checkInterval time.Duration
 	checks        []HealthCheck
+	nodePIDFunc   func() int
 
 	lastStatus  HealthStatus
 	statusMutex sync.RWMutex
@@ -78,7 +79,7 @@ func NewHealthChecker(cfg *config.Config, log *logger.Logger) *HealthChecker {
 
 	rpcURL := fmt.Sprintf("http://localhost:%d", rpcPort)
 
-	return &HealthChecker{
+	h := &HealthChecker{
 		config:        cfg,
 		logger:        log,
 		httpClient:    &http.Client{Timeout: DefaultCheckTimeout},
@@ -89,6 +90,26 @@ func NewHealthChecker(cfg *config.Config, log *logger.Logger) *HealthChecker {
 		statusCh:      make(chan HealthStatus, 1),
 		checks:        createDefaultChecks(rpcURL),
 	}
+
+	if cfg.MaxMemoryMB > 0 {
+		h.checks = append(h.checks, NewMemoryCheck(cfg.MaxMemoryMB, h.nodePID))
+	}
+
+	return h
+}
+
+// SetNodePIDFunc sets the function returning the PID of the running node,
+// or 0 if there is none. It must be called before Start.
+func (h *HealthChecker) SetNodePIDFunc(fn func() int) {
+	h.nodePIDFunc = fn
+}
+
+// nodePID returns the PID of the running node, or 0 if it is unknown
+func (h *HealthChecker) nodePID() int {
+	if h.nodePIDFunc == nil {
+		return 0
+	}
+	return h.nodePIDFunc()
 }
 
 // createDefaultChecks creates the default health checks
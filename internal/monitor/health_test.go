@@ -2,10 +2,13 @@ package monitor
 
 import (
 	"context"
+	"os"
+	"syscall"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/wemix/wemixvisor/internal/config"
 	"github.com/wemix/wemixvisor/pkg/logger"
 )
@@ -24,6 +27,26 @@ func TestNewHealthChecker(t *testing.T) {
 	assert.Len(t, checker.checks, 4) // Should have 4 default checks
 }
 
+func TestNewHealthChecker_MemoryLimit(t *testing.T) {
+	cfg := &config.Config{MaxMemoryMB: 1}
+	checker := NewHealthChecker(cfg, logger.NewTestLogger())
+	require.Len(t, checker.checks, 5)
+
+	memory := checker.checks[4]
+	assert.Equal(t, "memory", memory.Name())
+
+	// Passes until the node PID is known
+	assert.NoError(t, memory.Check(context.Background()))
+
+	// The process group of the test stands in for the node
+	pgid, err := syscall.Getpgid(os.Getpid())
+	require.NoError(t, err)
+	checker.SetNodePIDFunc(func() int { return pgid })
+	err = memory.Check(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "node memory usage too high")
+}
+
 func TestHealthChecker_Start_Stop(t *testing.T) {
 	cfg := &config.Config{
 		HealthCheckInterval: 100 * time.Millisecond, // Short interval for testing
@@ -0,0 +1,299 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wemix/wemixvisor/pkg/fsutil"
+)
+
+// DefaultAdoptPollInterval is how often an adopted node is checked for exit
+// when it cannot be watched through a pidfd
+const DefaultAdoptPollInterval = time.Second
+
+// processRecord identifies the running node process across supervisor
+// restarts. StartTicks, the process start time in clock ticks since boot,
+// guards against adopting an unrelated process that reused the PID.
+type processRecord struct {
+	PID        int       `json:"pid"`
+	StartTicks uint64    `json:"start_ticks"`
+	StartedAt  time.Time `json:"started_at"`
+	Binary     string    `json:"binary"`
+	Args       []string  `json:"args"`
+}
+
+// saveProcessRecord persists the record of the current node process.
+// Caller must hold stateMutex.
+func (m *Manager) saveProcessRecord() {
+	pid := m.process.Pid
+	ticks, err := processStartTicks(pid)
+	if err != nil {
+		m.logger.Warn("cannot identify node process, it will not be re-adopted",
+			zap.Int("pid", pid),
+			zap.Error(err))
+		return
+	}
+
+	record := &processRecord{
+		PID:        pid,
+		StartTicks: ticks,
+		StartedAt:  m.startTime,
+		Binary:     m.config.CurrentBin(),
+		Args:       m.nodeArgs,
+	}
+	if binary, err := filepath.EvalSymlinks(record.Binary); err == nil {
+		record.Binary = binary
+	}
+
+	if err := writeProcessRecord(m.config.NodeStatePath(), record); err != nil {
+		m.logger.Warn("failed to write node state file", zap.Error(err))
+	}
+}
+
+// removeProcessRecord deletes the node state file once the process is gone
+func (m *Manager) removeProcessRecord() {
+	if err := os.Remove(m.config.NodeStatePath()); err != nil && !os.IsNotExist(err) {
+		m.logger.Warn("failed to remove node state file", zap.Error(err))
+	}
+}
+
+// releaseProcessRecord removes the state file after the node exited, unless
+// the process was detached and left to another supervisor
+func (m *Manager) releaseProcessRecord(owned func() bool) {
+	m.stateMutex.RLock()
+	defer m.stateMutex.RUnlock()
+
+	if owned() {
+		m.removeProcessRecord()
+	}
+}
+
+// writeProcessRecord atomically writes record to path
+func writeProcessRecord(path string, record *processRecord) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal node state: %w", err)
+	}
+
+	if err := fsutil.WriteFileAtomic(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save node state: %w", err)
+	}
+	return nil
+}
+
+// readProcessRecord reads the record at path, returning nil if none exists
+func readProcessRecord(path string) (*processRecord, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read node state: %w", err)
+	}
+
+	var record processRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to parse node state: %w", err)
+	}
+	return &record, nil
+}
+
+// adoptRunningNode takes over a node process left running by a previous
+// supervisor, if the state file names one that is still alive. It reports
+// whether a process was adopted. Caller must hold stateMutex.
+func (m *Manager) adoptRunningNode() bool {
+	record, err := readProcessRecord(m.config.NodeStatePath())
+	if err != nil {
+		m.logger.Warn("ignoring unreadable node state file", zap.Error(err))
+		m.removeProcessRecord()
+		return false
+	}
+	if record == nil {
+		return false
+	}
+
+	ticks, err := processStartTicks(record.PID)
+	if err != nil || ticks != record.StartTicks {
+		m.logger.Info("previous node process is gone, starting a new one",
+			zap.Int("pid", record.PID))
+		m.removeProcessRecord()
+		return false
+	}
+
+	process, err := os.FindProcess(record.PID)
+	if err != nil {
+		m.removeProcessRecord()
+		return false
+	}
+
+	if current, err := filepath.EvalSymlinks(m.config.CurrentBin()); err == nil && current != record.Binary {
+		m.logger.Warn("adopted node runs a different binary than the current one",
+			zap.String("running", record.Binary),
+			zap.String("current", current))
+	}
+
+	m.cmd = nil
+	m.process = process
+	m.exitCh = make(chan struct{})
+	m.startTime = record.StartedAt
+	m.nodeArgs = record.Args
+	m.adopted = true
+	m.state = StateRunning
+	m.readyCh = make(chan struct{})
+	m.readyErr = nil
+	close(m.readyCh)
+	m.persistRuntimeState()
+
+	// Resume capturing the output the node writes into the pipe
+	m.outputDone = nil
+	m.outputMark = m.output.Written()
+	if done, err := m.output.Follow(m.config.NodeOutputPipePath()); err != nil {
+		m.logger.Warn("cannot resume node output capture", zap.Error(err))
+	} else {
+		m.outputDone = done
+	}
+
+	go m.monitorAdopted(process, m.exitCh, m.outputDone)
+
+	m.logger.Info("adopted running node",
+		zap.Int("pid", record.PID),
+		zap.String("binary", record.Binary),
+		zap.Strings("args", record.Args),
+		zap.Time("started_at", record.StartedAt))
+
+	return true
+}
+
+// StopRecordedNode stops a node process left running by a previous
+// supervisor, if the state file names one that is still alive, instead of
+// adopting it. It reports whether a process was stopped.
+func (m *Manager) StopRecordedNode() (bool, error) {
+	m.stateMutex.Lock()
+	if m.state != StateStopped {
+		m.stateMutex.Unlock()
+		return false, fmt.Errorf("node is not in stopped state: %v", m.state)
+	}
+	adopted := m.adoptRunningNode()
+	m.stateMutex.Unlock()
+
+	if !adopted {
+		return false, nil
+	}
+	if err := m.Stop(); err != nil {
+		return true, fmt.Errorf("failed to stop node left running: %w", err)
+	}
+	return true, nil
+}
+
+// monitorAdopted waits for an adopted node process to exit and handles the
+// exit like that of a child process
+func (m *Manager) monitorAdopted(process *os.Process, exited chan struct{}, outputDone <-chan struct{}) {
+	ticks, err := processStartTicks(process.Pid)
+	if err == nil && !waitForExit(m.ctx, process.Pid, ticks) {
+		// The manager was closed or detached while the node kept running
+		return
+	}
+	m.releaseProcessRecord(func() bool { return m.process == process })
+	close(exited)
+
+	awaitOutput(outputDone)
+
+	m.stateMutex.Lock()
+	defer m.stateMutex.Unlock()
+
+	if m.process != process || m.state == StateStopping {
+		return
+	}
+
+	// The exit status of a process that is not our child is unknown
+	exitErr := fmt.Errorf("adopted node process %d exited", process.Pid)
+	m.handleExit(nil, exitErr)
+}
+
+// waitForExit blocks until the process identified by pid and its start time
+// exits, returning false if ctx is done first
+func waitForExit(ctx context.Context, pid int, ticks uint64) bool {
+	if exited, err := waitPidfd(ctx, pid, DefaultAdoptPollInterval); err == nil {
+		return exited
+	}
+
+	ticker := time.NewTicker(DefaultAdoptPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if current, err := processStartTicks(pid); err != nil || current != ticks {
+			return true
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+}
+
+// processStartTicks returns the start time of a live process in clock ticks
+// since boot, read from /proc/<pid>/stat. Zombies count as exited.
+func processStartTicks(pid int) (uint64, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, err
+	}
+
+	// The command name may contain spaces, so fields are counted from the
+	// closing parenthesis: state is field 3 and starttime field 22
+	stat := string(data)
+	end := strings.LastIndexByte(stat, ')')
+	if end < 0 {
+		return 0, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 20 {
+		return 0, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	if fields[0] == "Z" || fields[0] == "X" {
+		return 0, fmt.Errorf("process %d has exited", pid)
+	}
+
+	return strconv.ParseUint(fields[19], 10, 64)
+}
+
+// Detach releases the node process without stopping it, leaving the state
+// file in place so the next supervisor can adopt the node. The manager must
+// not be used afterwards.
+func (m *Manager) Detach() error {
+	m.stateMutex.Lock()
+	if !m.state.IsActive() {
+		m.stateMutex.Unlock()
+		return fmt.Errorf("node is not running")
+	}
+
+	pid := m.process.Pid
+	m.state = StateStopped
+	m.cmd = nil
+	m.process = nil
+	m.stateMutex.Unlock()
+
+	m.stopMonitoring()
+	m.cancel()
+
+	m.logger.Info("detached from node, leaving it running", zap.Int("pid", pid))
+
+	if err := m.output.Close(); err != nil {
+		return fmt.Errorf("failed to close node output: %w", err)
+	}
+	return nil
+}
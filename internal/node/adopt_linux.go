@@ -0,0 +1,36 @@
+package node
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// waitPidfd waits for pid to exit using a pidfd, which becomes readable when
+// the process terminates. It returns false if ctx is done first, and an
+// error if the kernel does not support pidfds.
+func waitPidfd(ctx context.Context, pid int, interval time.Duration) (bool, error) {
+	fd, err := unix.PidfdOpen(pid, 0)
+	if err != nil {
+		return false, err
+	}
+	defer unix.Close(fd)
+
+	fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+	for {
+		n, err := unix.Poll(fds, int(interval.Milliseconds()))
+		if err != nil && err != unix.EINTR {
+			return false, err
+		}
+		if n > 0 {
+			return true, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return false, nil
+		default:
+		}
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package node
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// waitPidfd is only available on Linux; callers fall back to polling
+func waitPidfd(ctx context.Context, pid int, interval time.Duration) (bool, error) {
+	return false, errors.ErrUnsupported
+}
@@ -0,0 +1,145 @@
+package node
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/crash"
+	"github.com/wemix/wemixvisor/pkg/logger"
+)
+
+// mockChattyScript writes a line every tick until signalled
+const mockChattyScript = `#!/bin/sh
+if [ "$1" = "version" ]; then
+  echo "v1.0.0"
+  exit 0
+fi
+trap 'exit 0' TERM INT
+while true; do
+  echo tick
+  sleep 0.1
+done
+`
+
+// setupAdoptTest installs script as the current binary
+func setupAdoptTest(t *testing.T, script string) *config.Config {
+	t.Helper()
+
+	homeDir := t.TempDir()
+	binDir := filepath.Join(homeDir, "wemixvisor", "current", "bin")
+	require.NoError(t, os.MkdirAll(binDir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(binDir, "wemixd"), []byte(script), 0755))
+
+	return &config.Config{
+		Home:          homeDir,
+		Name:          "wemixd",
+		ShutdownGrace: time.Second,
+	}
+}
+
+func TestManager_AdoptsDetachedNode(t *testing.T) {
+	cfg := setupAdoptTest(t, mockChattyScript)
+
+	first := NewManager(cfg, logger.NewTestLogger())
+	require.NoError(t, first.Start([]string{"--flag"}))
+	pid := first.GetPID()
+
+	record, err := readProcessRecord(cfg.NodeStatePath())
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, pid, record.PID)
+	assert.Equal(t, []string{"--flag"}, record.Args)
+
+	require.NoError(t, first.Detach())
+
+	// The node keeps writing output while no supervisor is reading it
+	time.Sleep(500 * time.Millisecond)
+	require.NoError(t, syscall.Kill(pid, 0), "node should outlive its supervisor")
+
+	second := NewManager(cfg, logger.NewTestLogger())
+	defer second.Close()
+
+	require.NoError(t, second.Start(nil))
+	assert.Equal(t, pid, second.GetPID(), "running node should be adopted, not started again")
+	assert.Equal(t, StateRunning, second.GetState())
+	assert.True(t, second.GetStatus().Adopted)
+
+	require.Eventually(t, func() bool {
+		return strings.Contains(strings.Join(second.OutputTail(0), "\n"), "tick")
+	}, 2*time.Second, 50*time.Millisecond, "output capture should resume")
+
+	require.NoError(t, second.Stop())
+	assert.Equal(t, StateStopped, second.GetState())
+	assert.Eventually(t, func() bool {
+		return syscall.Kill(pid, 0) != nil
+	}, 2*time.Second, 50*time.Millisecond, "node should be gone after stop")
+
+	_, err = os.Stat(cfg.NodeStatePath())
+	assert.True(t, os.IsNotExist(err), "state file should be removed once the node exits")
+}
+
+func TestManager_AdoptedNodeCrashIsDetected(t *testing.T) {
+	cfg := setupAdoptTest(t, mockChattyScript)
+
+	first := NewManager(cfg, logger.NewTestLogger())
+	require.NoError(t, first.Start(nil))
+	pid := first.GetPID()
+	require.NoError(t, first.Detach())
+
+	second := NewManager(cfg, logger.NewTestLogger())
+	defer second.Close()
+	require.NoError(t, second.Start(nil))
+	require.Equal(t, pid, second.GetPID())
+
+	require.NoError(t, syscall.Kill(-pid, syscall.SIGKILL))
+
+	// Restarts are disabled, so the crash leaves the node in StateError
+	require.Eventually(t, func() bool {
+		return second.GetState() == StateError
+	}, 5*time.Second, 50*time.Millisecond)
+
+	record, err := crash.NewStore(cfg).Load("latest")
+	require.NoError(t, err)
+	assert.Equal(t, pid, record.PID)
+}
+
+func TestManager_StaleStateFileStartsNewNode(t *testing.T) {
+	cfg := setupAdoptTest(t, mockChattyScript)
+
+	stale := &processRecord{PID: os.Getpid(), StartTicks: 1, Args: []string{"--old"}}
+	require.NoError(t, writeProcessRecord(cfg.NodeStatePath(), stale))
+
+	manager := NewManager(cfg, logger.NewTestLogger())
+	defer manager.Close()
+
+	require.NoError(t, manager.Start([]string{"--new"}))
+	assert.NotEqual(t, os.Getpid(), manager.GetPID())
+	assert.False(t, manager.GetStatus().Adopted)
+
+	record, err := readProcessRecord(cfg.NodeStatePath())
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.Equal(t, manager.GetPID(), record.PID)
+	assert.Equal(t, []string{"--new"}, record.Args)
+}
+
+func TestProcessStartTicks(t *testing.T) {
+	ticks, err := processStartTicks(os.Getpid())
+	require.NoError(t, err)
+	assert.NotZero(t, ticks)
+
+	again, err := processStartTicks(os.Getpid())
+	require.NoError(t, err)
+	assert.Equal(t, ticks, again)
+
+	_, err = processStartTicks(-1)
+	assert.Error(t, err)
+}
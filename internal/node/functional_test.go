@@ -385,7 +385,9 @@ func TestEnvironmentVariables(t *testing.T) {
 	manager := NewManager(cfg, logger)
 
 	// Test environment building
-	env := manager.buildEnvironment()
+	vars, err := cfg.ResolveEnvironment()
+	require.NoError(t, err)
+	env := manager.buildEnvironment(vars)
 
 	foundWemixHome := false
 	foundNetwork := false
@@ -408,7 +410,7 @@ func TestEnvironmentVariables(t *testing.T) {
 	assert.True(t, foundCustom)
 
 	// Test actual execution with environment
-	err := manager.Start([]string{"--test"})
+	err = manager.Start([]string{"--test"})
 	require.NoError(t, err)
 	assert.True(t, manager.IsHealthy())
 
@@ -0,0 +1,97 @@
+package node
+
+import (
+	"os/exec"
+	"regexp"
+	"strconv"
+
+	"go.uber.org/zap"
+
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/pkg/types"
+)
+
+// UpgradeHalts returns the channel on which upgrades the node halted for are
+// delivered. A node that exits after printing a halt marker is not treated
+// as crashed: it is left in StateUpgrading, without being restarted, until
+// the upgrade is applied and the node started again.
+func (m *Manager) UpgradeHalts() <-chan *types.UpgradeInfo {
+	return m.halts
+}
+
+// findUpgradeHalt returns the upgrade named by the most recent line in lines
+// matching a halt marker, or nil if none matches
+func findUpgradeHalt(lines []string, markers []*regexp.Regexp) *types.UpgradeInfo {
+	for i := len(lines) - 1; i >= 0; i-- {
+		for _, marker := range markers {
+			match := marker.FindStringSubmatch(lines[i])
+			if match == nil {
+				continue
+			}
+
+			name := match[marker.SubexpIndex(config.HaltMarkerName)]
+			if name == "" {
+				continue
+			}
+
+			info := &types.UpgradeInfo{Name: name}
+			if idx := marker.SubexpIndex(config.HaltMarkerHeight); idx >= 0 && match[idx] != "" {
+				if height, err := strconv.ParseInt(match[idx], 10, 64); err == nil {
+					info.Height = height
+				}
+			}
+			return info
+		}
+	}
+	return nil
+}
+
+// detectUpgradeHalt looks for a halt marker in the output written since the
+// node was started or adopted.
+// Caller must hold stateMutex.
+func (m *Manager) detectUpgradeHalt() *types.UpgradeInfo {
+	if len(m.haltMarkers) == 0 {
+		return nil
+	}
+
+	written := m.output.Written() - m.outputMark
+	if written == 0 {
+		return nil
+	}
+	return findUpgradeHalt(m.output.Tail(int(written)), m.haltMarkers)
+}
+
+// handleUpgradeHalt handles a node that stopped itself for an upgrade.
+// Caller must hold stateMutex.
+func (m *Manager) handleUpgradeHalt(info *types.UpgradeInfo) {
+	m.state = StateUpgrading
+	m.logger.Info("node halted for upgrade",
+		zap.String("name", info.Name),
+		zap.Int64("height", info.Height))
+
+	if m.doneCh != nil {
+		close(m.doneCh)
+		m.doneCh = nil
+	}
+
+	select {
+	case m.halts <- info:
+	default:
+		m.logger.Warn("previous upgrade halt not handled yet, dropping this one",
+			zap.String("name", info.Name))
+	}
+}
+
+// handleExit handles an exit the manager did not ask for: an upgrade halt
+// announced in the node output or, failing that, a crash. cmd is nil for an
+// adopted node.
+// Caller must hold stateMutex.
+func (m *Manager) handleExit(cmd *exec.Cmd, err error) {
+	if info := m.detectUpgradeHalt(); info != nil {
+		m.handleUpgradeHalt(info)
+		return
+	}
+
+	m.recordCrash(cmd, err)
+	m.handleProcessCrash(err)
+}
@@ -0,0 +1,75 @@
+package node
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wemix/wemixvisor/pkg/logger"
+)
+
+// mockHaltScript prints an upgrade halt marker and exits with an error, as
+// nodes stopping themselves at an upgrade height do
+const mockHaltScript = `#!/bin/sh
+echo "executing block"
+echo 'UPGRADE "v2.0.0" NEEDED at height: 1000: {}'
+exit 1
+`
+
+func TestFindUpgradeHalt(t *testing.T) {
+	markers := []*regexp.Regexp{
+		regexp.MustCompile(`UPGRADE "(?P<name>[^"]+)" NEEDED at (?:height: (?P<height>\d+))?`),
+		regexp.MustCompile(`halting for (?P<name>\S+)`),
+	}
+
+	tests := []struct {
+		name       string
+		lines      []string
+		wantName   string
+		wantHeight int64
+	}{
+		{"no marker", []string{"executing block", "panic: boom"}, "", 0},
+		{"with height", []string{`UPGRADE "v2" NEEDED at height: 42: {}`}, "v2", 42},
+		{"without height", []string{"halting for v3"}, "v3", 0},
+		{"latest wins", []string{"halting for v3", `UPGRADE "v4" NEEDED at height: 7`}, "v4", 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := findUpgradeHalt(tt.lines, markers)
+			if tt.wantName == "" {
+				assert.Nil(t, info)
+				return
+			}
+			require.NotNil(t, info)
+			assert.Equal(t, tt.wantName, info.Name)
+			assert.Equal(t, tt.wantHeight, info.Height)
+		})
+	}
+}
+
+func TestManager_UpgradeHaltIsNotACrash(t *testing.T) {
+	cfg := setupStopTest(t, mockHaltScript)
+	cfg.RestartOnFailure = true
+	cfg.MaxRestarts = 3
+	cfg.RestartDelay = 10 * time.Millisecond
+
+	manager := NewManager(cfg, logger.NewTestLogger())
+	defer manager.Close()
+
+	require.NoError(t, manager.Start(nil))
+
+	select {
+	case info := <-manager.UpgradeHalts():
+		assert.Equal(t, "v2.0.0", info.Name)
+		assert.Equal(t, int64(1000), info.Height)
+	case <-time.After(5 * time.Second):
+		t.Fatal("upgrade halt was not reported")
+	}
+
+	time.Sleep(200 * time.Millisecond)
+	assert.Equal(t, StateUpgrading, manager.GetState())
+	assert.Equal(t, 0, manager.GetRestartCount(), "a halted node must not be restarted")
+}
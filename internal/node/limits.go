@@ -0,0 +1,74 @@
+package node
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/wemix/wemixvisor/internal/config"
+)
+
+// ExecNodeCommand is the hidden wemixvisor command the node is started
+// through when process limits are configured. The command applies the
+// limits, niceness and OOM score adjustment to its own process, switches to
+// the node user and then executes the node binary in its place, so the node
+// never runs without them.
+const ExecNodeCommand = "exec-node"
+
+// execStatusFd is the descriptor on which ExecNodeCommand reports a failure
+// to the supervisor. It is closed on exec, so end of file means the node
+// binary was executed.
+const execStatusFd = 3
+
+// hasProcessLimits reports whether resource limits, niceness or an OOM
+// score adjustment are configured for the node
+func hasProcessLimits(cfg *config.Config) bool {
+	return cfg.RlimitNoFile > 0 || cfg.RlimitCore != "" || cfg.Nice != 0 || cfg.OOMScoreAdj != nil
+}
+
+// execStatus is the supervisor's side of the status pipe of a node started
+// through ExecNodeCommand. A nil execStatus reports success.
+type execStatus struct {
+	r *os.File
+	w *os.File
+}
+
+// newExecStatus creates the status pipe
+func newExecStatus() (*execStatus, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil, err
+	}
+	return &execStatus{r: r, w: w}, nil
+}
+
+// close releases both ends of the pipe
+func (s *execStatus) close() {
+	if s == nil {
+		return
+	}
+	s.r.Close()
+	s.w.Close()
+}
+
+// wait blocks until the started helper has executed the node binary or
+// failed, returning the failure it reported
+func (s *execStatus) wait() error {
+	if s == nil {
+		return nil
+	}
+	defer s.r.Close()
+
+	// Only the helper holds the write end from here on
+	s.w.Close()
+
+	msg, err := io.ReadAll(s.r)
+	if err != nil {
+		return err
+	}
+	if len(msg) > 0 {
+		return errors.New(strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package node
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+
+	"github.com/wemix/wemixvisor/internal/config"
+)
+
+// startThroughHelper rewrites cmd to start the node binary through
+// ExecNodeCommand when process limits are configured, moving the node
+// credential into the helper, which switches user only after applying the
+// limits that need privileges. It returns the status pipe to wait on once
+// cmd is started, or nil if no limits are configured.
+func startThroughHelper(cfg *config.Config, cmd *exec.Cmd) (*execStatus, error) {
+	if !hasProcessLimits(cfg) {
+		return nil, nil
+	}
+
+	helper, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate wemixvisor executable: %w", err)
+	}
+
+	args := []string{helper, ExecNodeCommand}
+	if cfg.RlimitNoFile > 0 {
+		args = append(args, "--nofile", strconv.FormatUint(cfg.RlimitNoFile, 10))
+	}
+	if core, ok, err := cfg.RlimitCoreValue(); err != nil {
+		return nil, err
+	} else if ok {
+		args = append(args, "--core", strconv.FormatUint(core, 10))
+	}
+	if cfg.Nice != 0 {
+		args = append(args, "--nice", strconv.Itoa(cfg.Nice))
+	}
+	if cfg.OOMScoreAdj != nil {
+		args = append(args, "--oom-score-adj", strconv.Itoa(*cfg.OOMScoreAdj))
+	}
+	if cred := cmd.SysProcAttr.Credential; cred != nil {
+		groups := make([]string, len(cred.Groups))
+		for i, gid := range cred.Groups {
+			groups[i] = strconv.FormatUint(uint64(gid), 10)
+		}
+		args = append(args,
+			"--uid", strconv.FormatUint(uint64(cred.Uid), 10),
+			"--gid", strconv.FormatUint(uint64(cred.Gid), 10),
+			"--groups", strings.Join(groups, ","))
+		cmd.SysProcAttr.Credential = nil
+	}
+	args = append(args, "--")
+	args = append(args, cmd.Args...)
+
+	status, err := newExecStatus()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create status pipe: %w", err)
+	}
+
+	cmd.Path = helper
+	cmd.Args = args
+	cmd.ExtraFiles = []*os.File{status.w}
+	return status, nil
+}
+
+// ExecNode implements ExecNodeCommand: it applies the limits given in args
+// to the current process, switches user and executes the node binary. It
+// returns only on failure, after reporting the error to the supervisor.
+func ExecNode(args []string) error {
+	unix.CloseOnExec(execStatusFd)
+
+	err := execNode(args)
+	status := os.NewFile(execStatusFd, "exec-status")
+	fmt.Fprint(status, err)
+	status.Close()
+	return err
+}
+
+// execNode parses args, applies them and executes the node binary
+func execNode(args []string) error {
+	fs := flag.NewFlagSet(ExecNodeCommand, flag.ContinueOnError)
+	nofile := fs.Uint64("nofile", 0, "open file limit")
+	core := fs.String("core", "", "core dump size limit")
+	nice := fs.Int("nice", 0, "niceness")
+	oomScoreAdj := fs.String("oom-score-adj", "", "OOM score adjustment")
+	uid := fs.Int("uid", -1, "user ID to run as")
+	gid := fs.Int("gid", -1, "group ID to run as")
+	groups := fs.String("groups", "", "comma-separated supplementary group IDs")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("no node binary given")
+	}
+
+	// Niceness is per thread on Linux: set it on the thread that executes
+	// the node, which becomes the node's only thread
+	runtime.LockOSThread()
+
+	if *nofile > 0 {
+		limit := &unix.Rlimit{Cur: *nofile, Max: *nofile}
+		if err := unix.Setrlimit(unix.RLIMIT_NOFILE, limit); err != nil {
+			return fmt.Errorf("failed to set open file limit: %w", err)
+		}
+	}
+
+	if *core != "" {
+		value, err := strconv.ParseUint(*core, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid core limit: %w", err)
+		}
+		limit := &unix.Rlimit{Cur: value, Max: value}
+		if err := unix.Setrlimit(unix.RLIMIT_CORE, limit); err != nil {
+			return fmt.Errorf("failed to set core limit: %w", err)
+		}
+	}
+
+	if *nice != 0 {
+		if err := unix.Setpriority(unix.PRIO_PROCESS, 0, *nice); err != nil {
+			return fmt.Errorf("failed to set nice value: %w", err)
+		}
+	}
+
+	if *oomScoreAdj != "" {
+		if err := os.WriteFile("/proc/self/oom_score_adj", []byte(*oomScoreAdj), 0644); err != nil {
+			return fmt.Errorf("failed to set OOM score adjustment: %w", err)
+		}
+	}
+
+	if *uid >= 0 {
+		if err := switchUser(*uid, *gid, *groups); err != nil {
+			return err
+		}
+	}
+
+	binary := fs.Arg(0)
+	if err := unix.Exec(binary, fs.Args(), os.Environ()); err != nil {
+		return fmt.Errorf("failed to execute %s: %w", binary, err)
+	}
+	return nil
+}
+
+// switchUser sets the supplementary groups, group and user of the process
+func switchUser(uid, gid int, groups string) error {
+	var gids []int
+	if groups != "" {
+		for _, g := range strings.Split(groups, ",") {
+			id, err := strconv.Atoi(g)
+			if err != nil {
+				return fmt.Errorf("invalid group ID %q: %w", g, err)
+			}
+			gids = append(gids, id)
+		}
+	}
+
+	if err := syscall.Setgroups(gids); err != nil {
+		return fmt.Errorf("failed to set supplementary groups: %w", err)
+	}
+	if gid >= 0 {
+		if err := syscall.Setgid(gid); err != nil {
+			return fmt.Errorf("failed to set group: %w", err)
+		}
+	}
+	if err := syscall.Setuid(uid); err != nil {
+		return fmt.Errorf("failed to set user: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+//go:build !linux
+
+package node
+
+import (
+	"errors"
+	"os/exec"
+
+	"github.com/wemix/wemixvisor/internal/config"
+)
+
+// startThroughHelper is only available on Linux; starting a node that
+// should be bounded fails elsewhere
+func startThroughHelper(cfg *config.Config, cmd *exec.Cmd) (*execStatus, error) {
+	if hasProcessLimits(cfg) {
+		return nil, errors.ErrUnsupported
+	}
+	return nil, nil
+}
+
+// ExecNode implements ExecNodeCommand, which is only available on Linux
+func ExecNode(args []string) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,150 @@
+package node
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wemix/wemixvisor/pkg/logger"
+)
+
+// TestMain lets the test binary stand in for wemixvisor as the helper the
+// node is started through when limits are configured
+func TestMain(m *testing.M) {
+	if len(os.Args) > 1 && os.Args[1] == ExecNodeCommand {
+		ExecNode(os.Args[2:])
+		os.Exit(1)
+	}
+	os.Exit(m.Run())
+}
+
+// procField returns the value of the first line of /proc/<pid>/<name>
+// starting with prefix, or the whole file if prefix is empty
+func procField(t *testing.T, pid int, name, prefix string) string {
+	t.Helper()
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/%s", pid, name))
+	require.NoError(t, err)
+	if prefix == "" {
+		return strings.TrimSpace(string(data))
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.HasPrefix(line, prefix) {
+			return strings.Join(strings.Fields(strings.TrimPrefix(line, prefix)), " ")
+		}
+	}
+	t.Fatalf("%s not found in /proc/%d/%s", prefix, pid, name)
+	return ""
+}
+
+func TestManager_AppliesProcessLimits(t *testing.T) {
+	cfg := setupStopTest(t, mockLoopScript)
+	cfg.RlimitNoFile = 512
+	cfg.RlimitCore = "0"
+	cfg.Nice = 5
+	oomScoreAdj := 300
+	cfg.OOMScoreAdj = &oomScoreAdj
+
+	manager := NewManager(cfg, logger.NewTestLogger())
+	defer manager.Close()
+	require.NoError(t, manager.Start(nil))
+	pid := manager.GetPID()
+
+	assert.True(t, strings.HasPrefix(procField(t, pid, "limits", "Max open files"), "512 512 "))
+	assert.True(t, strings.HasPrefix(procField(t, pid, "limits", "Max core file size"), "0 0 "))
+	assert.Equal(t, "300", procField(t, pid, "oom_score_adj", ""))
+
+	// Field 19 of stat is the nice value
+	stat := procField(t, pid, "stat", "")
+	fields := strings.Fields(stat[strings.LastIndexByte(stat, ')')+1:])
+	assert.Equal(t, "5", fields[16])
+}
+
+func TestManager_AppliesProcessLimitsBeforeExec(t *testing.T) {
+	// The node records the limits it sees as soon as it starts
+	script := `#!/bin/sh
+echo "$(ulimit -n) $(cat /proc/self/oom_score_adj)" > "$0.limits"
+trap 'exit 0' TERM INT
+while true; do
+  sleep 1
+done
+`
+	cfg := setupStopTest(t, script)
+	cfg.RlimitNoFile = 512
+	oomScoreAdj := 300
+	cfg.OOMScoreAdj = &oomScoreAdj
+
+	manager := NewManager(cfg, logger.NewTestLogger())
+	defer manager.Close()
+	require.NoError(t, manager.Start([]string{"--flag"}))
+
+	limits := cfg.CurrentBin() + ".limits"
+	require.Eventually(t, func() bool {
+		data, err := os.ReadFile(limits)
+		return err == nil && len(data) > 0
+	}, 5*time.Second, 20*time.Millisecond)
+	data, err := os.ReadFile(limits)
+	require.NoError(t, err)
+	assert.Equal(t, "512 300", strings.TrimSpace(string(data)))
+
+	// The helper executed the node in its own place
+	cmdline := procField(t, manager.GetPID(), "cmdline", "")
+	assert.Contains(t, cmdline, "wemixd")
+	assert.Contains(t, cmdline, "--flag")
+	assert.NotContains(t, cmdline, ExecNodeCommand)
+}
+
+func TestManager_LimitFailureStopsNode(t *testing.T) {
+	// Not even root may raise the open file limit above fs.nr_open
+	data, err := os.ReadFile("/proc/sys/fs/nr_open")
+	require.NoError(t, err)
+	nrOpen, err := strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+	require.NoError(t, err)
+
+	cfg := setupStopTest(t, mockLoopScript)
+	cfg.RlimitNoFile = nrOpen + 1
+
+	manager := NewManager(cfg, logger.NewTestLogger())
+	defer manager.Close()
+
+	err = manager.Start(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to apply node process limits: failed to set open file limit")
+	assert.Equal(t, StateError, manager.GetState())
+}
+
+func TestManager_RunsNodeAsConfiguredUser(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("switching users requires root")
+	}
+
+	cfg := setupStopTest(t, mockLoopScript)
+	cfg.RunAsUser = "65534"
+	cfg.RunAsGroup = "65534"
+	cfg.SupplementaryGroups = []string{"4242"}
+	// Only a privileged process may lower the niceness, so it must be
+	// applied before switching user
+	cfg.Nice = -5
+
+	// The unprivileged node must be able to reach its home and binary
+	require.NoError(t, os.Chmod(filepath.Dir(cfg.Home), 0755))
+	require.NoError(t, os.Chmod(cfg.Home, 0755))
+
+	manager := NewManager(cfg, logger.NewTestLogger())
+	defer manager.Close()
+	require.NoError(t, manager.Start(nil))
+	pid := manager.GetPID()
+
+	assert.Equal(t, "65534 65534 65534 65534", procField(t, pid, "status", "Uid:"))
+	assert.Equal(t, "65534 65534 65534 65534", procField(t, pid, "status", "Gid:"))
+	assert.Equal(t, "4242", procField(t, pid, "status", "Groups:"))
+
+	stat := procField(t, pid, "stat", "")
+	fields := strings.Fields(stat[strings.LastIndexByte(stat, ')')+1:])
+	assert.Equal(t, "-5", fields[16])
+}
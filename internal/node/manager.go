@@ -6,17 +6,23 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
-	"strings"
+	"regexp"
 	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
 	"go.uber.org/zap"
 
 	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/crash"
 	"github.com/wemix/wemixvisor/internal/metrics"
 	"github.com/wemix/wemixvisor/internal/monitor"
+	"github.com/wemix/wemixvisor/internal/nodelog"
+	"github.com/wemix/wemixvisor/internal/state"
+	"github.com/wemix/wemixvisor/internal/version"
 	"github.com/wemix/wemixvisor/pkg/logger"
+	"github.com/wemix/wemixvisor/pkg/types"
 )
 
 // Default values for manager configuration
@@ -27,6 +33,7 @@ const (
 	DefaultAutoRestartDelay   = 5 * time.Second
 	DefaultVersionTimeout     = 5 * time.Second
 	DefaultProcessExitWait    = 100 * time.Millisecond
+	DefaultOutputWaitDelay    = 5 * time.Second
 	ErrorChannelBufferSize    = 10
 )
 
@@ -38,19 +45,47 @@ type Manager struct {
 	// Process management
 	cmd        *exec.Cmd
 	process    *os.Process
+	exitCh     chan struct{}
+	adopted    bool
 	state      NodeState
 	stateMutex sync.RWMutex
 
 	// CLI pass-through
 	nodeArgs    []string
 	nodeOptions map[string]string
+	environment []config.EnvVar // resolved at the last start
 
 	// Monitoring
 	startTime        time.Time
 	restartCount     int
 	maxRestarts      int
+	restartPolicy    *RestartPolicy
+	crashTimes       []time.Time
+	restartHistory   []RestartDecision
 	healthChecker    *monitor.HealthChecker
 	metricsCollector *metrics.Collector
+	stall            *StallWatchdog
+
+	// Startup readiness
+	readiness monitor.HealthCheck
+	readyCh   chan struct{}
+	readyErr  error
+
+	// Node output capture and crash forensics
+	output      *nodelog.Sink
+	outputDone  <-chan struct{}
+	outputMark  uint64 // lines of output written before the last start
+	crashStore  *crash.Store
+	lastCrash   *state.Crash
+	lastVersion atomic.Value
+	versions    *version.Cache
+
+	// Upgrade halts announced in the node output
+	haltMarkers []*regexp.Regexp
+	halts       chan *types.UpgradeInfo
+
+	// Runtime state persisted across supervisor restarts
+	stateStore *state.Store
 
 	// Channels for lifecycle management
 	stopCh    chan struct{}
@@ -80,7 +115,14 @@ func NewManager(cfg *config.Config, log *logger.Logger) *Manager {
 		state:         StateStopped,
 		nodeOptions:   make(map[string]string),
 		maxRestarts:   maxRestarts,
+		restartPolicy: NewRestartPolicy(cfg),
+		readiness:     newReadinessCheck(cfg),
 		healthChecker: healthChecker,
+		output:        nodelog.NewSink(cfg, log),
+		crashStore:    crash.NewStore(cfg),
+		stateStore:    state.NewStore(cfg),
+		versions:      version.NewCache(cfg, log),
+		halts:         make(chan *types.UpgradeInfo, 1),
 		stopCh:        make(chan struct{}),
 		restartCh:     make(chan struct{}),
 		errorCh:       make(chan error, ErrorChannelBufferSize),
@@ -89,7 +131,15 @@ func NewManager(cfg *config.Config, log *logger.Logger) *Manager {
 		cancel:        cancel,
 	}
 
+	markers, err := cfg.HaltMarkerPatterns()
+	if err != nil {
+		log.Warn("upgrade halt detection disabled", zap.Error(err))
+	}
+	manager.haltMarkers = markers
+
+	healthChecker.SetNodePIDFunc(manager.GetPID)
 	manager.initMetricsCollector(cfg, log)
+	manager.restoreRuntimeState()
 
 	return manager
 }
@@ -110,23 +160,48 @@ func (m *Manager) initMetricsCollector(cfg *config.Config, log *logger.Logger) {
 		PrometheusPort:      cfg.MetricsPort,
 		PrometheusPath:      cfg.MetricsPath,
 	}
+	if cfg.Instance != "" {
+		collectorConfig.Labels = map[string]string{metrics.InstanceLabel: cfg.Instance}
+	}
 	m.metricsCollector = metrics.NewCollector(collectorConfig, log)
 
 	m.metricsCollector.SetNodeHeightCallback(func() (int64, error) {
 		return 0, nil
 	})
+
+	// The node leads its own process group, so its PID is the group ID
+	m.metricsCollector.SetNodeProcessCallback(func() (int, error) {
+		pid := m.GetPID()
+		if pid == 0 {
+			return 0, fmt.Errorf("node is not running")
+		}
+		return pid, nil
+	})
 }
 
-// Start starts the node with the given arguments
+// Start starts the node with the given arguments.
+// A nil args slice reuses the arguments of the previous start.
 func (m *Manager) Start(args []string) error {
 	m.stateMutex.Lock()
 
-	if m.state != StateStopped {
+	// A node halted for an upgrade is stopped as well
+	if m.state != StateStopped && m.state != StateUpgrading {
 		m.stateMutex.Unlock()
 		return fmt.Errorf("node is not in stopped state: %v", m.state)
 	}
 
+	// A node left running by a previous supervisor is adopted rather than
+	// started twice
+	if m.adoptRunningNode() {
+		m.stateMutex.Unlock()
+		m.startMonitoring()
+		return nil
+	}
+
 	m.state = StateStarting
+	if args == nil {
+		args = m.nodeArgs
+	}
 	m.nodeArgs = args
 
 	cmdPath := m.config.CurrentBin()
@@ -155,6 +230,9 @@ func (m *Manager) Start(args []string) error {
 	m.stateMutex.Unlock()
 
 	m.startMonitoring()
+	if m.metricsCollector != nil {
+		go m.reportVersion()
+	}
 
 	m.logger.Info("node started successfully",
 		zap.Int("pid", m.process.Pid),
@@ -165,47 +243,144 @@ func (m *Manager) Start(args []string) error {
 
 // startProcess creates and starts the node process
 func (m *Manager) startProcess(cmdPath string, args []string) error {
-	cmd := exec.CommandContext(m.ctx, cmdPath, args...)
-	cmd.Env = m.buildEnvironment()
+	// References are resolved on every start, so edited secret files apply
+	vars, err := m.config.ResolveEnvironment()
+	if err != nil {
+		return fmt.Errorf("failed to resolve node environment: %w", err)
+	}
+	if len(vars) > 0 {
+		shown := make([]string, len(vars))
+		for i, v := range vars {
+			shown[i] = v.String()
+		}
+		m.logger.Info("node environment", zap.Strings("env", shown))
+	}
+
+	// The process is not tied to m.ctx: it is stopped through the stop
+	// sequence, or deliberately left running by Detach
+	cmd := exec.Command(cmdPath, args...)
+	cmd.Env = m.buildEnvironment(vars)
 
 	if m.config.Home != "" {
 		cmd.Dir = m.config.Home
 	}
 
-	m.setupProcessOutput(cmd)
+	pipe := m.setupProcessOutput(cmd)
+	if pipe != nil {
+		// The node holds its own handle once started
+		defer pipe.Close()
+	}
+
+	cred, err := m.config.NodeCredential()
+	if err != nil {
+		return fmt.Errorf("failed to resolve node user: %w", err)
+	}
+	if cred != nil {
+		m.logger.Info("node runs as",
+			zap.Uint32("uid", cred.Uid),
+			zap.Uint32("gid", cred.Gid),
+			zap.Uint32s("groups", cred.Groups))
+	}
 
 	cmd.SysProcAttr = &syscall.SysProcAttr{
-		Setpgid: true,
-		Pgid:    0,
+		Setpgid:    true,
+		Pgid:       0,
+		Credential: cred,
+	}
+
+	// A node that should be bounded is started through a helper that
+	// applies the limits before executing it
+	status, err := startThroughHelper(m.config, cmd)
+	if err != nil {
+		return fmt.Errorf("failed to apply node process limits: %w", err)
 	}
 
 	if err := cmd.Start(); err != nil {
+		status.close()
 		return fmt.Errorf("failed to start process: %w", err)
 	}
 
+	if err := status.wait(); err != nil {
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		cmd.Wait()
+		return fmt.Errorf("failed to apply node process limits: %w", err)
+	}
+
 	m.cmd = cmd
 	m.process = cmd.Process
+	m.environment = vars
+	m.exitCh = make(chan struct{})
+	m.adopted = false
 	m.startTime = time.Now()
-	m.state = StateRunning
+	m.saveProcessRecord()
+	m.persistRuntimeState()
+	m.beginReadiness(cmd)
+
+	go m.monitor(cmd, m.exitCh, m.outputDone)
 
 	return nil
 }
 
-// setupProcessOutput configures stdout/stderr for the process
-func (m *Manager) setupProcessOutput(cmd *exec.Cmd) {
-	logFile := m.getLogFile()
-	if logFile != nil {
-		cmd.Stdout = logFile
-		cmd.Stderr = logFile
-	} else {
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
+// setupProcessOutput routes stdout/stderr of the process into the output
+// sink through a named pipe, so the node keeps a valid stdout if the
+// supervisor restarts. It returns the node's end of the pipe, which the
+// caller closes once the process has started.
+func (m *Manager) setupProcessOutput(cmd *exec.Cmd) *os.File {
+	// Reopening per start picks up a log file moved by external tooling;
+	// on failure the sink falls back to stdout
+	m.output.Open()
+	m.outputDone = nil
+	m.outputMark = m.output.Written()
+
+	pipe, size, err := nodelog.OpenPipe(m.config.NodeOutputPipePath())
+	if err == nil {
+		var done <-chan struct{}
+		if done, err = m.output.Follow(m.config.NodeOutputPipePath()); err == nil {
+			m.logger.Debug("routing node output through a pipe",
+				zap.String("path", m.config.NodeOutputPipePath()),
+				zap.Int("buffer_bytes", size))
+			cmd.Stdout = pipe
+			cmd.Stderr = pipe
+			m.outputDone = done
+			return pipe
+		}
+		pipe.Close()
+	}
+
+	m.logger.Warn("cannot route node output through a pipe, the node will not outlive the supervisor",
+		zap.Error(err))
+
+	cmd.Stdout = m.output
+	cmd.Stderr = m.output
+
+	// Don't let a leftover grandchild holding the pipe block Wait forever
+	cmd.WaitDelay = DefaultOutputWaitDelay
+	return nil
+}
+
+// awaitOutput gives the output relay a moment to drain the last lines the
+// node wrote before it exited
+func awaitOutput(done <-chan struct{}) {
+	if done == nil {
+		return
+	}
+
+	timer := time.NewTimer(DefaultOutputWaitDelay)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+	case <-timer.C:
 	}
 }
 
-// startMonitoring starts all monitoring goroutines
+// OutputTail returns up to n of the most recent lines of node output
+func (m *Manager) OutputTail(n int) []string {
+	return m.output.Tail(n)
+}
+
+// startMonitoring starts health and metrics monitoring
 func (m *Manager) startMonitoring() {
-	go m.monitor()
 	go m.monitorHealth()
 
 	if m.metricsCollector != nil {
@@ -223,7 +398,9 @@ func (m *Manager) monitorHealth() {
 				m.logger.Warn("health check failed",
 					zap.Bool("healthy", status.Healthy),
 					zap.Any("checks", status.Checks))
+				continue
 			}
+			m.resetRestartCountIfStable()
 		case <-m.ctx.Done():
 			return
 		}
@@ -234,7 +411,7 @@ func (m *Manager) monitorHealth() {
 func (m *Manager) Stop() error {
 	m.stateMutex.Lock()
 
-	if m.state != StateRunning {
+	if !m.state.IsActive() {
 		m.stateMutex.Unlock()
 		return fmt.Errorf("node is not running")
 	}
@@ -242,65 +419,21 @@ func (m *Manager) Stop() error {
 	m.state = StateStopping
 	pid := m.process.Pid
 	process := m.process
+	exited := m.exitCh
 	m.stateMutex.Unlock()
 
 	m.logger.Info("stopping node", zap.Int("pid", pid))
 
-	if err := m.sendStopSignal(pid, process); err != nil {
-		return err
+	if err := m.runStopSequence(pid, process, exited); err != nil {
+		m.logger.Error("failed to stop node", zap.Error(err))
 	}
 
-	m.waitForShutdown(pid, process)
 	m.stopMonitoring()
 	m.cleanupState()
 
 	return nil
 }
 
-// sendStopSignal sends SIGTERM to the process group
-func (m *Manager) sendStopSignal(pid int, process *os.Process) error {
-	if err := syscall.Kill(-pid, syscall.SIGTERM); err != nil {
-		m.logger.Error("failed to send SIGTERM to process group", zap.Error(err))
-		if err := process.Signal(syscall.SIGTERM); err != nil {
-			m.logger.Error("failed to send SIGTERM to process", zap.Error(err))
-			if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil {
-				return fmt.Errorf("failed to kill process: %w", err)
-			}
-		}
-	}
-	return nil
-}
-
-// waitForShutdown waits for the process to exit gracefully or forces termination
-func (m *Manager) waitForShutdown(pid int, process *os.Process) {
-	shutdownGrace := DefaultShutdownGrace
-	if m.config.ShutdownGrace > 0 {
-		shutdownGrace = m.config.ShutdownGrace
-	}
-
-	timer := time.NewTimer(shutdownGrace)
-	defer timer.Stop()
-
-	select {
-	case <-m.doneCh:
-		m.logger.Info("node stopped gracefully")
-	case <-timer.C:
-		m.logger.Warn("grace period exceeded, forcing shutdown")
-		m.forceKill(pid, process)
-	}
-}
-
-// forceKill forcefully terminates the process
-func (m *Manager) forceKill(pid int, process *os.Process) {
-	if err := syscall.Kill(-pid, syscall.SIGKILL); err != nil {
-		m.logger.Error("failed to kill process group", zap.Error(err))
-		if err := process.Kill(); err != nil {
-			m.logger.Error("failed to kill process", zap.Error(err))
-		}
-	}
-	time.Sleep(DefaultProcessExitWait)
-}
-
 // stopMonitoring stops health and metrics monitoring
 func (m *Manager) stopMonitoring() {
 	m.healthChecker.Stop()
@@ -324,12 +457,16 @@ func (m *Manager) cleanupState() {
 
 // Restart restarts the node with the same arguments
 func (m *Manager) Restart() error {
-	m.logger.Info("restarting node")
+	return m.restart(m.nodeArgs, DefaultRestartDelay)
+}
 
-	args := m.nodeArgs
+// restart stops the node if it is active and starts it with args after
+// waiting delay
+func (m *Manager) restart(args []string, delay time.Duration) error {
+	m.logger.Info("restarting node")
 
 	currentState := m.GetState()
-	if currentState == StateRunning {
+	if currentState.IsActive() {
 		if err := m.Stop(); err != nil {
 			return fmt.Errorf("failed to stop node for restart: %w", err)
 		}
@@ -337,7 +474,7 @@ func (m *Manager) Restart() error {
 		m.resetState()
 	}
 
-	time.Sleep(DefaultRestartDelay)
+	time.Sleep(delay)
 
 	m.stateMutex.Lock()
 	if m.doneCh == nil {
@@ -349,8 +486,13 @@ func (m *Manager) Restart() error {
 		return fmt.Errorf("failed to start node after restart: %w", err)
 	}
 
+	m.stateMutex.Lock()
 	m.restartCount++
-	m.logger.Info("node restarted successfully", zap.Int("restart_count", m.restartCount))
+	count := m.restartCount
+	m.persistRuntimeState()
+	m.stateMutex.Unlock()
+
+	m.logger.Info("node restarted successfully", zap.Int("restart_count", count))
 
 	return nil
 }
@@ -358,6 +500,10 @@ func (m *Manager) Restart() error {
 // resetState resets manager state to stopped
 func (m *Manager) resetState() {
 	m.stateMutex.Lock()
+	if m.state == StateCrashLoop {
+		// A manual restart out of a crash loop starts a fresh crash window
+		m.crashTimes = nil
+	}
 	m.state = StateStopped
 	m.cmd = nil
 	m.process = nil
@@ -374,13 +520,14 @@ func (m *Manager) GetState() NodeState {
 // GetStatus returns detailed status information
 func (m *Manager) GetStatus() *Status {
 	m.stateMutex.RLock()
-	defer m.stateMutex.RUnlock()
 
 	status := &Status{
+		Instance:     m.config.Instance,
 		State:        m.state,
 		StateString:  m.state.String(),
 		StartTime:    m.startTime,
 		RestartCount: m.restartCount,
+		LastCrash:    m.lastCrash,
 		Network:      m.config.Network,
 		Binary:       m.config.CurrentBin(),
 	}
@@ -388,16 +535,37 @@ func (m *Manager) GetStatus() *Status {
 	if m.process != nil {
 		status.PID = m.process.Pid
 		status.Uptime = time.Since(m.startTime)
-	}
-
-	if status.Binary != "" && m.state == StateRunning {
-		status.Version = m.GetVersion()
+		status.Adopted = m.adopted
 	}
 
 	if m.state == StateRunning && m.healthChecker != nil {
 		status.Health = m.buildHealthStatus()
 	}
 
+	if n := len(m.restartHistory); n > 0 {
+		last := m.restartHistory[n-1]
+		status.LastRestart = &last
+		status.RestartHistory = append([]RestartDecision(nil), m.restartHistory...)
+	}
+
+	if len(m.environment) > 0 {
+		status.Environment = config.RedactEnvVars(m.environment)
+	}
+
+	if m.stall != nil {
+		status.Stall = m.stall.status(m.state)
+	}
+	m.stateMutex.RUnlock()
+
+	// Looked up without the lock, as a binary not seen before is run
+	if status.Binary != "" && status.State == StateRunning {
+		status.Version = "unknown"
+		if info := m.VersionInfo(); info != nil {
+			status.Version = info.Raw
+			status.VersionInfo = info
+		}
+	}
+
 	return status
 }
 
@@ -426,27 +594,75 @@ func (m *Manager) Wait() <-chan struct{} {
 	return m.doneCh
 }
 
-// monitor monitors the node process and handles crashes
-func (m *Manager) monitor() {
-	m.stateMutex.RLock()
-	cmd := m.cmd
-	m.stateMutex.RUnlock()
-
-	if cmd == nil {
-		return
-	}
-
+// monitor waits for the node process to exit, closes exited and handles
+// crashes
+func (m *Manager) monitor(cmd *exec.Cmd, exited chan struct{}, outputDone <-chan struct{}) {
 	err := cmd.Wait()
+	m.releaseProcessRecord(func() bool { return m.cmd == cmd })
+	close(exited)
 	go m.reapZombies()
 
+	awaitOutput(outputDone)
+
 	m.stateMutex.Lock()
 	defer m.stateMutex.Unlock()
 
-	if m.state == StateStopping {
+	if m.cmd != cmd || m.state == StateStopping {
 		return
 	}
 
-	m.handleProcessCrash(err)
+	m.handleExit(cmd, err)
+}
+
+// recordCrash writes a forensics record for an unexpected exit.
+// Caller must hold stateMutex.
+func (m *Manager) recordCrash(cmd *exec.Cmd, err error) {
+	record := &crash.Record{
+		Time:         time.Now(),
+		Reason:       exitReason(err),
+		Uptime:       time.Since(m.startTime),
+		Binary:       m.config.CurrentBin(),
+		Args:         append([]string(nil), m.nodeArgs...),
+		RestartCount: m.restartCount,
+		Output:       m.output.Tail(crash.DefaultOutputLines),
+	}
+	var state *os.ProcessState
+	if cmd != nil {
+		state = cmd.ProcessState
+	}
+	record.SetExit(state, err)
+
+	if m.process != nil && record.PID == 0 {
+		record.PID = m.process.Pid
+	}
+	if binary, err := filepath.EvalSymlinks(record.Binary); err == nil {
+		record.Binary = binary
+	}
+	if target, err := filepath.EvalSymlinks(m.config.CurrentDir()); err == nil {
+		record.Upgrade = filepath.Base(target)
+	}
+	if version, ok := m.lastVersion.Load().(string); ok {
+		record.Version = version
+	}
+	if m.healthChecker != nil {
+		health := m.healthChecker.GetStatus()
+		record.Health = &health
+	}
+	if m.metricsCollector != nil {
+		record.Metrics = m.metricsCollector.GetSnapshot()
+	}
+
+	path, saveErr := m.crashStore.Save(record)
+	if saveErr != nil {
+		m.logger.Warn("failed to write crash record", zap.Error(saveErr))
+		return
+	}
+
+	m.logger.Info("crash record written",
+		zap.String("id", record.ID),
+		zap.String("path", path),
+		zap.Int("exit_code", record.ExitCode),
+		zap.String("signal", record.Signal))
 }
 
 // handleProcessCrash handles unexpected process termination
@@ -459,15 +675,36 @@ func (m *Manager) handleProcessCrash(err error) {
 		m.doneCh = nil
 	}
 
-	if m.shouldAutoRestart() {
-		m.scheduleAutoRestart()
-	} else {
+	now := time.Now()
+	reason := exitReason(err)
+	m.lastCrash = &state.Crash{Time: now, Reason: reason}
+
+	if m.restartCount > 0 && m.restartPolicy.ShouldReset(now.Sub(m.startTime)) {
+		m.logger.Info("node ran stable before crash, resetting restart counter",
+			zap.Int("previous_count", m.restartCount))
+		m.restartCount = 0
+	}
+	m.crashTimes = m.restartPolicy.RecordCrash(m.crashTimes, now)
+	m.persistRuntimeState()
+
+	switch {
+	case !m.config.RestartOnFailure:
 		m.state = StateError
-		if m.restartCount >= m.maxRestarts {
-			m.logger.Error("max restart attempts reached",
-				zap.Int("restart_count", m.restartCount),
-				zap.Int("max", m.maxRestarts))
-		}
+		m.recordRestartDecision(RestartActionDisabled, reason, 0)
+	case m.restartPolicy.IsCrashLoop(m.crashTimes):
+		m.state = StateCrashLoop
+		m.recordRestartDecision(RestartActionCrashLoop, reason, 0)
+		m.raiseCrashLoopAlert(reason)
+	case !m.shouldAutoRestart():
+		m.state = StateError
+		m.recordRestartDecision(RestartActionGiveUp, reason, 0)
+		m.logger.Error("max restart attempts reached",
+			zap.Int("restart_count", m.restartCount),
+			zap.Int("max", m.maxRestarts))
+	default:
+		delay := m.restartPolicy.Backoff(m.restartCount)
+		m.recordRestartDecision(RestartActionRestart, reason, delay)
+		m.scheduleAutoRestart(delay)
 	}
 }
 
@@ -476,23 +713,94 @@ func (m *Manager) shouldAutoRestart() bool {
 	return m.config.RestartOnFailure && m.restartCount < m.maxRestarts
 }
 
-// scheduleAutoRestart schedules an automatic restart
-func (m *Manager) scheduleAutoRestart() {
+// scheduleAutoRestart schedules an automatic restart after the given delay
+func (m *Manager) scheduleAutoRestart(delay time.Duration) {
 	m.logger.Info("attempting auto-restart",
 		zap.Int("attempt", m.restartCount+1),
-		zap.Int("max", m.maxRestarts))
+		zap.Int("max", m.maxRestarts),
+		zap.Duration("delay", delay))
 
+	// The policy's delay replaces the fixed delay of manual restarts
 	go func() {
-		time.Sleep(DefaultAutoRestartDelay)
-		if err := m.Restart(); err != nil {
+		time.Sleep(delay)
+		if err := m.restart(m.nodeArgs, 0); err != nil {
 			m.logger.Error("auto-restart failed", zap.Error(err))
 			m.errorCh <- err
+			return
+		}
+		if m.metricsCollector != nil {
+			m.metricsCollector.IncrementProcessRestarts()
 		}
 	}()
 }
 
-// buildEnvironment builds the environment variables for the node
-func (m *Manager) buildEnvironment() []string {
+// recordRestartDecision appends a decision to the bounded restart history.
+// Caller must hold stateMutex.
+func (m *Manager) recordRestartDecision(action RestartAction, reason string, delay time.Duration) {
+	decision := RestartDecision{
+		Time:    time.Now(),
+		Action:  action,
+		Reason:  reason,
+		Attempt: m.restartCount + 1,
+		Delay:   delay,
+	}
+
+	m.restartHistory = append(m.restartHistory, decision)
+	if len(m.restartHistory) > MaxRestartHistory {
+		m.restartHistory = m.restartHistory[len(m.restartHistory)-MaxRestartHistory:]
+	}
+
+	m.logger.Info("restart decision recorded",
+		zap.String("action", string(action)),
+		zap.String("reason", reason),
+		zap.Int("attempt", decision.Attempt),
+		zap.Duration("delay", delay))
+}
+
+// raiseCrashLoopAlert reports a crash loop through logs and an alert
+func (m *Manager) raiseCrashLoopAlert(reason string) {
+	m.logger.Error("node is crash-looping, automatic restarts suspended",
+		zap.Int("crashes", len(m.crashTimes)),
+		zap.Duration("window", m.restartPolicy.CrashLoopWindow),
+		zap.String("last_reason", reason))
+
+	m.GenerateAlert(&metrics.Alert{
+		ID:        fmt.Sprintf("node-crash-loop-%d", time.Now().Unix()),
+		Name:      "NodeCrashLoop",
+		Level:     metrics.AlertLevelCritical,
+		Message:   fmt.Sprintf("node crashed %d times within %v", len(m.crashTimes), m.restartPolicy.CrashLoopWindow),
+		Source:    "node",
+		Metric:    "process_restarts",
+		Value:     float64(len(m.crashTimes)),
+		Threshold: float64(m.restartPolicy.CrashLoopThreshold),
+		Labels:    map[string]string{"reason": reason},
+		Timestamp: time.Now(),
+	})
+}
+
+// resetRestartCountIfStable forgets earlier restarts once the node has been
+// running healthily for the policy's reset window
+func (m *Manager) resetRestartCountIfStable() {
+	m.stateMutex.Lock()
+	defer m.stateMutex.Unlock()
+
+	if m.state != StateRunning || m.restartCount == 0 {
+		return
+	}
+	if !m.restartPolicy.ShouldReset(time.Since(m.startTime)) {
+		return
+	}
+
+	m.logger.Info("node healthy for reset window, resetting restart counter",
+		zap.Int("previous_count", m.restartCount))
+	m.restartCount = 0
+	m.crashTimes = nil
+	m.persistRuntimeState()
+}
+
+// buildEnvironment builds the environment variables for the node from the
+// supervisor's environment and the resolved configured variables
+func (m *Manager) buildEnvironment(vars []config.EnvVar) []string {
 	env := os.Environ()
 
 	if m.config.Home != "" {
@@ -502,91 +810,46 @@ func (m *Manager) buildEnvironment() []string {
 		env = append(env, fmt.Sprintf("WEMIX_NETWORK=%s", m.config.Network))
 	}
 
-	for k, v := range m.config.Environment {
-		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	for _, v := range vars {
+		env = append(env, v.Name+"="+v.Value)
 	}
 
 	return env
 }
 
-// getLogFile returns the log file for node output
-func (m *Manager) getLogFile() *os.File {
-	if m.config.LogFile == "" {
-		return nil
-	}
-
-	logPath := m.config.LogFile
-	if !filepath.IsAbs(logPath) {
-		logPath = filepath.Join(m.config.Home, logPath)
+// GetVersion returns the version reported by the current binary
+func (m *Manager) GetVersion() string {
+	if m.GetState() != StateRunning {
+		return "unknown"
 	}
 
-	logDir := filepath.Dir(logPath)
-	if err := os.MkdirAll(logDir, 0755); err != nil {
-		m.logger.Error("failed to create log directory", zap.Error(err))
-		return nil
+	if info := m.VersionInfo(); info != nil {
+		return info.Raw
 	}
+	return "unknown"
+}
 
-	file, err := os.OpenFile(logPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+// VersionInfo returns the parsed version of the current binary, or nil if
+// it cannot be determined. The binary is only run the first time a binary
+// with its content is seen.
+func (m *Manager) VersionInfo() *version.Info {
+	info, err := m.versions.Lookup(m.config.CurrentBin())
 	if err != nil {
-		m.logger.Error("failed to open log file", zap.Error(err))
+		m.logger.Debug("failed to get binary version", zap.Error(err))
 		return nil
 	}
 
-	return file
+	// Remembered for crash records, which must not run the binary
+	m.lastVersion.Store(info.Raw)
+	return info
 }
 
-// getBinaryVersion tries to get the version of the binary
-func (m *Manager) getBinaryVersion() (string, error) {
-	cmdPath := m.config.CurrentBin()
-	if cmdPath == "" {
-		return "", fmt.Errorf("no binary path configured")
+// reportVersion resolves the version of a newly started binary for the
+// version metric
+func (m *Manager) reportVersion() {
+	if info := m.VersionInfo(); info != nil {
+		m.metricsCollector.SetNodeVersion(info.Semver, info.Commit, info.GoVersion)
 	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), DefaultVersionTimeout)
-	defer cancel()
-
-	versionCmds := [][]string{
-		{"version"},
-		{"--version"},
-		{"-version"},
-		{"-v"},
-	}
-
-	for _, args := range versionCmds {
-		cmd := exec.CommandContext(ctx, cmdPath, args...)
-		output, err := cmd.Output()
-
-		if err == nil && len(output) > 0 {
-			lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-			if len(lines) > 0 && lines[0] != "" {
-				return lines[0], nil
-			}
-		}
-
-		if ctx.Err() != nil {
-			return "", fmt.Errorf("timeout getting version: %w", ctx.Err())
-		}
-	}
-
-	return "", fmt.Errorf("unable to determine binary version")
-}
-
-// GetVersion returns the cached version or fetches it if not cached
-func (m *Manager) GetVersion() string {
-	m.stateMutex.RLock()
-	defer m.stateMutex.RUnlock()
-
-	if m.state != StateRunning {
-		return "unknown"
-	}
-
-	version, err := m.getBinaryVersion()
-	if err != nil {
-		m.logger.Debug("failed to get binary version", zap.Error(err))
-		return "unknown"
-	}
-
-	return version
 }
 
 // reapZombies reaps any zombie child processes
@@ -658,11 +921,30 @@ func (m *Manager) GetMetrics() *metrics.MetricsSnapshot {
 	return nil
 }
 
+// GenerateAlert reports an alert through the metrics collector, or logs it
+// if metrics collection is disabled
+func (m *Manager) GenerateAlert(alert *metrics.Alert) {
+	if m.metricsCollector != nil {
+		m.metricsCollector.GenerateAlert(alert)
+		return
+	}
+
+	m.logger.Warn("alert generated",
+		zap.String("name", alert.Name),
+		zap.String("level", string(alert.Level)),
+		zap.String("message", alert.Message))
+}
+
 // Close gracefully shuts down the manager
 func (m *Manager) Close() error {
+	var err error
+	if m.GetState().IsActive() {
+		err = m.Stop()
+	}
 	m.cancel()
-	if m.GetState() == StateRunning {
-		return m.Stop()
+
+	if closeErr := m.output.Close(); closeErr != nil && err == nil {
+		err = closeErr
 	}
-	return nil
+	return err
 }
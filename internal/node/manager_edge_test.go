@@ -365,6 +365,10 @@ sleep 10
 	err = manager.Start([]string{"--test"})
 	require.NoError(t, err)
 
+	// Give the script time to install its trap; Stop returns as soon as
+	// the process exits
+	time.Sleep(200 * time.Millisecond)
+
 	// Time the stop operation
 	start := time.Now()
 	err = manager.Stop()
@@ -247,7 +247,9 @@ func TestManager_BuildEnvironment_WithCustomVars(t *testing.T) {
 	logger := logger.NewTestLogger()
 	manager := NewManager(cfg, logger)
 
-	env := manager.buildEnvironment()
+	vars, err := cfg.ResolveEnvironment()
+	require.NoError(t, err)
+	env := manager.buildEnvironment(vars)
 
 	// Check all custom vars are present
 	envMap := make(map[string]string)
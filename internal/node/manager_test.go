@@ -11,6 +11,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/crash"
 	"github.com/wemix/wemixvisor/pkg/logger"
 )
 
@@ -26,6 +27,7 @@ func TestNodeState_String(t *testing.T) {
 		{StateUpgrading, "upgrading"},
 		{StateError, "error"},
 		{StateCrashed, "crashed"},
+		{StateCrashLoop, "crash_loop"},
 		{NodeState(99), "unknown"},
 	}
 
@@ -258,7 +260,9 @@ func TestManager_BuildEnvironment(t *testing.T) {
 	logger := logger.NewTestLogger()
 	manager := NewManager(cfg, logger)
 
-	env := manager.buildEnvironment()
+	vars, err := cfg.ResolveEnvironment()
+	require.NoError(t, err)
+	env := manager.buildEnvironment(vars)
 
 	// Check that our custom environment variables are present
 	hasHome := false
@@ -282,6 +286,51 @@ func TestManager_BuildEnvironment(t *testing.T) {
 	assert.True(t, hasCustom)
 }
 
+func TestManager_EnvironmentSecretsResolvedOnStart(t *testing.T) {
+	script := `#!/bin/sh
+if [ "$1" = "version" ]; then
+  echo "v1.0.0"
+  exit 0
+fi
+echo "$KEYSTORE_PASSWORD" >> env.log
+trap 'exit 0' TERM INT
+while true; do
+  sleep 0.1
+done
+`
+	cfg := setupStopTest(t, script)
+	secretPath := filepath.Join(t.TempDir(), "password")
+	require.NoError(t, ioutil.WriteFile(secretPath, []byte("first\n"), 0600))
+	cfg.Environment = map[string]string{
+		"KEYSTORE_PASSWORD": "file:" + secretPath,
+		"LOG_LEVEL":         "info",
+	}
+
+	manager := NewManager(cfg, logger.NewTestLogger())
+	defer manager.Close()
+
+	require.NoError(t, manager.Start(nil))
+	status := manager.GetStatus()
+	assert.Equal(t, config.RedactedValue, status.Environment["KEYSTORE_PASSWORD"])
+	assert.Equal(t, "info", status.Environment["LOG_LEVEL"])
+
+	// A rotated secret applies from the next start
+	require.NoError(t, ioutil.WriteFile(secretPath, []byte("second\n"), 0600))
+	require.NoError(t, manager.Stop())
+	require.NoError(t, manager.Start(nil))
+	require.NoError(t, manager.Stop())
+
+	data, err := ioutil.ReadFile(filepath.Join(cfg.Home, "env.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "first\nsecond\n", string(data))
+
+	// An unresolvable reference keeps the node from starting
+	require.NoError(t, os.Remove(secretPath))
+	err = manager.Start(nil)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cannot resolve KEYSTORE_PASSWORD")
+}
+
 func TestManager_IsHealthy(t *testing.T) {
 	if testing.Short() {
 		t.Skip("Skipping functional test in short mode")
@@ -393,8 +442,111 @@ func TestManager_GetVersion(t *testing.T) {
 	assert.Contains(t, version, "v1.2.3")
 }
 
+func TestManager_VersionResolvedOncePerBinary(t *testing.T) {
+	script := `#!/bin/sh
+if [ "$1" = "version" ]; then
+  echo probe >> "$(dirname "$0")/probes.log"
+  echo "Wemix"
+  echo "Version: 1.2.3-stable"
+  echo "Git Commit: abcdef1234567"
+  echo "Go Version: go1.21.5"
+  exit 0
+fi
+trap 'exit 0' TERM INT
+while true; do
+  sleep 0.1
+done
+`
+	cfg := setupStopTest(t, script)
+	manager := NewManager(cfg, logger.NewTestLogger())
+	defer manager.Close()
+	require.NoError(t, manager.Start(nil))
+
+	for i := 0; i < 3; i++ {
+		status := manager.GetStatus()
+		assert.Equal(t, "Wemix", status.Version)
+		require.NotNil(t, status.VersionInfo)
+		assert.Equal(t, "1.2.3-stable", status.VersionInfo.Semver)
+		assert.Equal(t, "abcdef1234567", status.VersionInfo.Commit)
+		assert.Equal(t, "go1.21.5", status.VersionInfo.GoVersion)
+	}
+	assert.Equal(t, "Wemix", manager.GetVersion())
+
+	data, err := ioutil.ReadFile(filepath.Join(filepath.Dir(cfg.CurrentBin()), "probes.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "probe\n", string(data), "binary should be run for its version once")
+}
+
+func TestManager_CollectsProcessGroupMetrics(t *testing.T) {
+	script := `#!/bin/sh
+if [ "$1" = "version" ]; then
+  echo "v1.0.0"
+  exit 0
+fi
+sleep 30 &
+trap 'kill $!; exit 0' TERM INT
+while true; do
+  sleep 0.1
+done
+`
+	cfg := setupStopTest(t, script)
+	cfg.MetricsEnabled = true
+	cfg.EnableAppMetrics = true
+	cfg.MetricsCollectionInterval = 50 * time.Millisecond
+	manager := NewManager(cfg, logger.NewTestLogger())
+	defer manager.Close()
+	require.NoError(t, manager.Start(nil))
+
+	// The shell, its background sleep and the loop's sleep share the group
+	require.Eventually(t, func() bool {
+		snapshot := manager.GetMetrics()
+		return snapshot != nil && snapshot.Process != nil && snapshot.Process.Processes >= 2
+	}, 3*time.Second, 20*time.Millisecond)
+
+	process := manager.GetMetrics().Process
+	assert.Equal(t, manager.GetPID(), process.PGID)
+	assert.Greater(t, process.RSSBytes, uint64(0))
+	assert.Greater(t, process.OpenFDs, 0)
+}
+
 // Helper functions
 
+func TestManager_CrashWritesRecord(t *testing.T) {
+	homeDir := t.TempDir()
+	binDir := filepath.Join(homeDir, "wemixvisor", "current", "bin")
+	require.NoError(t, os.MkdirAll(binDir, 0755))
+
+	script := `#!/bin/sh
+echo "starting node"
+echo "fatal: database corrupted" >&2
+exit 3
+`
+	require.NoError(t, ioutil.WriteFile(filepath.Join(binDir, "wemixd"), []byte(script), 0755))
+
+	cfg := &config.Config{
+		Home:             homeDir,
+		Name:             "wemixd",
+		RestartOnFailure: false,
+	}
+	manager := NewManager(cfg, logger.NewTestLogger())
+	defer manager.Close()
+
+	require.NoError(t, manager.Start([]string{"--datadir", "/data"}))
+
+	assert.Eventually(t, func() bool {
+		return manager.GetState() == StateError
+	}, 5*time.Second, 50*time.Millisecond)
+
+	record, err := crash.NewStore(cfg).Load("latest")
+	require.NoError(t, err)
+	assert.Equal(t, 3, record.ExitCode)
+	assert.Equal(t, "exit status 3", record.Reason)
+	assert.Equal(t, []string{"--datadir", "/data"}, record.Args)
+	assert.Equal(t, filepath.Join(binDir, "wemixd"), record.Binary)
+	assert.Contains(t, record.Output, "fatal: database corrupted")
+	assert.NotNil(t, record.Rusage)
+}
+
 func createMockBinary(t *testing.T, path string) {
 	t.Helper()
 
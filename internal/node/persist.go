@@ -0,0 +1,46 @@
+package node
+
+import (
+	"go.uber.org/zap"
+
+	"github.com/wemix/wemixvisor/internal/state"
+)
+
+// restoreRuntimeState loads the restart count, start time and last crash
+// saved by a previous supervisor. A missing or unreadable state file leaves
+// the manager with fresh state.
+func (m *Manager) restoreRuntimeState() {
+	st, err := m.stateStore.Load()
+	if err != nil {
+		m.logger.Warn("failed to load runtime state, starting fresh",
+			zap.String("path", m.stateStore.Path()),
+			zap.Error(err))
+		return
+	}
+
+	m.restartCount = st.Node.RestartCount
+	m.startTime = st.Node.StartTime
+	m.lastCrash = st.Node.LastCrash
+
+	if m.restartCount > 0 || m.lastCrash != nil {
+		m.logger.Info("restored runtime state",
+			zap.Int("restart_count", m.restartCount),
+			zap.Time("start_time", m.startTime))
+	}
+}
+
+// persistRuntimeState saves the manager's part of the runtime state.
+// Caller must hold stateMutex.
+func (m *Manager) persistRuntimeState() {
+	node := state.NodeState{
+		RestartCount: m.restartCount,
+		StartTime:    m.startTime,
+		LastCrash:    m.lastCrash,
+	}
+
+	if err := m.stateStore.Update(func(st *state.State) {
+		st.Node = node
+	}); err != nil {
+		m.logger.Warn("failed to save runtime state", zap.Error(err))
+	}
+}
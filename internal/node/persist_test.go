@@ -0,0 +1,65 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wemix/wemixvisor/internal/state"
+	"github.com/wemix/wemixvisor/pkg/logger"
+)
+
+// mockExitScript answers version queries, otherwise exits with status 3
+// shortly after starting
+const mockExitScript = `#!/bin/sh
+if [ "$1" = "version" ]; then
+  echo "v1.0.0"
+  exit 0
+fi
+sleep 0.2
+exit 3
+`
+
+func TestManager_RestoresRuntimeState(t *testing.T) {
+	cfg := setupStopTest(t, mockArgsScript)
+	started := time.Now().Add(-time.Hour).Round(time.Second)
+	require.NoError(t, state.NewStore(cfg).Update(func(st *state.State) {
+		st.Node = state.NodeState{
+			RestartCount: 2,
+			StartTime:    started,
+			LastCrash:    &state.Crash{Time: started, Reason: "exit status 1"},
+		}
+	}))
+
+	manager := NewManager(cfg, logger.NewTestLogger())
+	defer manager.Close()
+
+	status := manager.GetStatus()
+	assert.Equal(t, 2, status.RestartCount)
+	assert.True(t, started.Equal(status.StartTime))
+	require.NotNil(t, status.LastCrash)
+	assert.Equal(t, "exit status 1", status.LastCrash.Reason)
+}
+
+func TestManager_PersistsCrash(t *testing.T) {
+	cfg := setupStopTest(t, mockExitScript)
+	manager := NewManager(cfg, logger.NewTestLogger())
+	defer manager.Close()
+
+	require.NoError(t, manager.Start(nil))
+	require.Eventually(t, func() bool { return manager.GetState() == StateError },
+		3*time.Second, 20*time.Millisecond)
+
+	st, err := state.NewStore(cfg).Load()
+	require.NoError(t, err)
+	assert.False(t, st.Node.StartTime.IsZero())
+	require.NotNil(t, st.Node.LastCrash)
+	assert.Equal(t, "exit status 3", st.Node.LastCrash.Reason)
+
+	// A manager created after a supervisor restart reports the crash
+	restored := NewManager(cfg, logger.NewTestLogger())
+	defer restored.Close()
+	require.NotNil(t, restored.GetStatus().LastCrash)
+	assert.Equal(t, "exit status 3", restored.GetStatus().LastCrash.Reason)
+}
@@ -0,0 +1,211 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/monitor"
+)
+
+// Readiness errors
+var (
+	ErrStartupTimeout    = errors.New("node did not become ready before the startup timeout")
+	ErrExitedBeforeReady = errors.New("node exited before becoming ready")
+	ErrStartAborted      = errors.New("node start was aborted")
+)
+
+// newReadinessCheck builds the readiness condition selected in configuration.
+// It returns nil when starts are not gated on readiness.
+func newReadinessCheck(cfg *config.Config) monitor.HealthCheck {
+	rpcPort := monitor.DefaultRPCPort
+	if cfg.RPCPort > 0 {
+		rpcPort = cfg.RPCPort
+	}
+	rpcURL := fmt.Sprintf("http://localhost:%d", rpcPort)
+
+	switch cfg.ReadinessCheck {
+	case config.ReadinessRPC:
+		return monitor.NewRPCHealthCheck(rpcURL)
+	case config.ReadinessHeight:
+		return monitor.NewBlockHeightCheck(rpcURL, 1)
+	case config.ReadinessCommand:
+		return monitor.NewCommandCheck(cfg.ReadinessCommand, cfg.Home)
+	default:
+		return nil
+	}
+}
+
+// beginReadiness prepares readiness tracking for a freshly started process.
+// Without a readiness check the node is running immediately.
+// Caller must hold stateMutex.
+func (m *Manager) beginReadiness(cmd *exec.Cmd) {
+	m.readyCh = make(chan struct{})
+	m.readyErr = nil
+
+	if m.readiness == nil {
+		m.state = StateRunning
+		close(m.readyCh)
+		return
+	}
+
+	go m.awaitReadiness(cmd, m.readyCh)
+}
+
+// awaitReadiness polls the readiness check until it passes, the startup
+// timeout expires or the process goes away
+func (m *Manager) awaitReadiness(cmd *exec.Cmd, readyCh chan struct{}) {
+	timeout := config.DefaultStartupTimeout
+	if m.config.StartupTimeout > 0 {
+		timeout = m.config.StartupTimeout
+	}
+	interval := config.DefaultReadinessInterval
+	if m.config.ReadinessInterval > 0 {
+		interval = m.config.ReadinessInterval
+	}
+
+	ctx, cancel := context.WithTimeout(m.ctx, timeout)
+	defer cancel()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	m.logger.Info("waiting for node readiness",
+		zap.String("check", m.readiness.Name()),
+		zap.Duration("timeout", timeout))
+
+	var lastErr error
+	for {
+		if done, err := m.startOutcome(cmd); done {
+			m.finishReadiness(readyCh, err)
+			return
+		}
+
+		checkCtx, checkCancel := context.WithTimeout(ctx, interval)
+		lastErr = m.readiness.Check(checkCtx)
+		checkCancel()
+
+		if lastErr == nil {
+			m.stateMutex.Lock()
+			if m.cmd == cmd && m.state == StateStarting {
+				m.state = StateRunning
+				m.logger.Info("node is ready",
+					zap.Duration("startup", time.Since(m.startTime)))
+			}
+			m.stateMutex.Unlock()
+			m.finishReadiness(readyCh, nil)
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			if done, err := m.startOutcome(cmd); done {
+				m.finishReadiness(readyCh, err)
+				return
+			}
+			m.logger.Error("node did not become ready, aborting start",
+				zap.Duration("timeout", timeout),
+				zap.Error(lastErr))
+			m.abortStart(cmd)
+			m.finishReadiness(readyCh, fmt.Errorf("%w: %v", ErrStartupTimeout, lastErr))
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// startOutcome reports whether the start attempt for cmd was already decided
+// by something other than the readiness check, such as a crash or a stop
+func (m *Manager) startOutcome(cmd *exec.Cmd) (bool, error) {
+	m.stateMutex.RLock()
+	defer m.stateMutex.RUnlock()
+
+	if m.cmd != cmd {
+		if m.state == StateStopped || m.state == StateStopping {
+			return true, ErrStartAborted
+		}
+		return true, ErrExitedBeforeReady
+	}
+
+	switch m.state {
+	case StateStarting:
+		return false, nil
+	case StateRunning:
+		return true, nil
+	case StateStopping, StateStopped:
+		return true, ErrStartAborted
+	default:
+		return true, ErrExitedBeforeReady
+	}
+}
+
+// abortStart stops a process that failed its readiness check and leaves the
+// manager in StateError
+func (m *Manager) abortStart(cmd *exec.Cmd) {
+	m.stateMutex.Lock()
+	if m.cmd != cmd || m.state != StateStarting {
+		m.stateMutex.Unlock()
+		return
+	}
+	m.state = StateStopping
+	pid := m.process.Pid
+	process := m.process
+	exited := m.exitCh
+	m.stateMutex.Unlock()
+
+	if err := m.runStopSequence(pid, process, exited); err != nil {
+		m.logger.Warn("failed to stop node after readiness timeout", zap.Error(err))
+	}
+	m.stopMonitoring()
+
+	m.stateMutex.Lock()
+	m.state = StateError
+	m.cmd = nil
+	m.process = nil
+	if m.doneCh != nil {
+		close(m.doneCh)
+		m.doneCh = nil
+	}
+	m.stateMutex.Unlock()
+}
+
+// finishReadiness publishes the readiness outcome to waiters
+func (m *Manager) finishReadiness(readyCh chan struct{}, err error) {
+	m.stateMutex.Lock()
+	if m.readyCh == readyCh {
+		m.readyErr = err
+	}
+	m.stateMutex.Unlock()
+
+	close(readyCh)
+}
+
+// WaitReady blocks until the most recently started node is ready, its start
+// fails, or ctx is done
+func (m *Manager) WaitReady(ctx context.Context) error {
+	m.stateMutex.RLock()
+	readyCh := m.readyCh
+	m.stateMutex.RUnlock()
+
+	if readyCh == nil {
+		return fmt.Errorf("node has not been started")
+	}
+
+	select {
+	case <-readyCh:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	m.stateMutex.RLock()
+	defer m.stateMutex.RUnlock()
+	if m.readyCh != readyCh {
+		return fmt.Errorf("node was restarted while waiting for readiness")
+	}
+	return m.readyErr
+}
@@ -0,0 +1,127 @@
+package node
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/pkg/logger"
+)
+
+// setupReadinessTest installs script as the current binary and returns a
+// config gated on the given readiness command
+func setupReadinessTest(t *testing.T, script, readinessCommand string) *config.Config {
+	t.Helper()
+
+	homeDir := t.TempDir()
+	binDir := filepath.Join(homeDir, "wemixvisor", "current", "bin")
+	require.NoError(t, os.MkdirAll(binDir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(binDir, "wemixd"), []byte(script), 0755))
+
+	return &config.Config{
+		Home:              homeDir,
+		Name:              "wemixd",
+		ShutdownGrace:     time.Second,
+		ReadinessCheck:    config.ReadinessCommand,
+		ReadinessCommand:  readinessCommand,
+		ReadinessInterval: 50 * time.Millisecond,
+		StartupTimeout:    5 * time.Second,
+	}
+}
+
+func TestManager_ReadinessGatesRunning(t *testing.T) {
+	script := `#!/bin/sh
+trap 'exit 0' TERM INT
+sleep 1
+touch ready
+while true; do
+  sleep 1
+done
+`
+	cfg := setupReadinessTest(t, script, "test -f ready")
+	manager := NewManager(cfg, logger.NewTestLogger())
+	defer manager.Close()
+
+	require.NoError(t, manager.Start(nil))
+	assert.Equal(t, StateStarting, manager.GetState())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, manager.WaitReady(ctx))
+	assert.Equal(t, StateRunning, manager.GetState())
+}
+
+func TestManager_ReadinessTimeoutFailsStart(t *testing.T) {
+	cfg := setupReadinessTest(t, mockLoopScript, "false")
+	cfg.StartupTimeout = 500 * time.Millisecond
+
+	manager := NewManager(cfg, logger.NewTestLogger())
+	defer manager.Close()
+
+	require.NoError(t, manager.Start(nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := manager.WaitReady(ctx)
+	assert.ErrorIs(t, err, ErrStartupTimeout)
+	assert.Equal(t, StateError, manager.GetState())
+	assert.Equal(t, 0, manager.GetPID())
+}
+
+func TestManager_ReadinessExitBeforeReady(t *testing.T) {
+	cfg := setupReadinessTest(t, "#!/bin/sh\nexit 1\n", "false")
+
+	manager := NewManager(cfg, logger.NewTestLogger())
+	defer manager.Close()
+
+	require.NoError(t, manager.Start(nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.ErrorIs(t, manager.WaitReady(ctx), ErrExitedBeforeReady)
+}
+
+func TestManager_StopWhileStarting(t *testing.T) {
+	cfg := setupReadinessTest(t, mockLoopScript, "false")
+
+	manager := NewManager(cfg, logger.NewTestLogger())
+	defer manager.Close()
+
+	require.NoError(t, manager.Start(nil))
+	assert.Equal(t, StateStarting, manager.GetState())
+
+	require.NoError(t, manager.Stop())
+	assert.Equal(t, StateStopped, manager.GetState())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.ErrorIs(t, manager.WaitReady(ctx), ErrStartAborted)
+}
+
+func TestManager_WaitReadyWithoutCheck(t *testing.T) {
+	cfg := setupReadinessTest(t, mockLoopScript, "")
+	cfg.ReadinessCheck = config.ReadinessNone
+
+	manager := NewManager(cfg, logger.NewTestLogger())
+	defer manager.Close()
+
+	assert.Error(t, manager.WaitReady(context.Background()), "not started yet")
+
+	require.NoError(t, manager.Start(nil))
+	assert.Equal(t, StateRunning, manager.GetState())
+	assert.NoError(t, manager.WaitReady(context.Background()))
+}
+
+// mockLoopScript runs until signalled
+const mockLoopScript = `#!/bin/sh
+trap 'exit 0' TERM INT
+while true; do
+  sleep 1
+done
+`
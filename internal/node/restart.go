@@ -0,0 +1,141 @@
+package node
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"os/exec"
+	"syscall"
+	"time"
+
+	"github.com/wemix/wemixvisor/internal/config"
+)
+
+// MaxRestartHistory is the number of restart decisions kept
+const MaxRestartHistory = 20
+
+// RestartAction describes what the manager decided to do after an exit
+type RestartAction string
+
+const (
+	// RestartActionRestart schedules an automatic restart
+	RestartActionRestart RestartAction = "restart"
+	// RestartActionGiveUp stops restarting because the restart budget is spent
+	RestartActionGiveUp RestartAction = "give_up"
+	// RestartActionCrashLoop stops restarting because the node is crash-looping
+	RestartActionCrashLoop RestartAction = "crash_loop"
+	// RestartActionDisabled records an exit while auto-restart is disabled
+	RestartActionDisabled RestartAction = "disabled"
+)
+
+// RestartDecision records a single restart decision and why it was made
+type RestartDecision struct {
+	Time    time.Time     `json:"time"`
+	Action  RestartAction `json:"action"`
+	Reason  string        `json:"reason"`
+	Attempt int           `json:"attempt"`
+	Delay   time.Duration `json:"delay"`
+}
+
+// RestartPolicy decides how long to wait between restarts and when a node
+// is crash-looping
+type RestartPolicy struct {
+	InitialDelay       time.Duration
+	MaxDelay           time.Duration
+	Factor             float64
+	Jitter             float64
+	ResetAfter         time.Duration
+	CrashLoopWindow    time.Duration
+	CrashLoopThreshold int
+}
+
+// NewRestartPolicy creates a restart policy from configuration, falling back
+// to defaults for unset values
+func NewRestartPolicy(cfg *config.Config) *RestartPolicy {
+	policy := &RestartPolicy{
+		InitialDelay:       config.DefaultRestartBackoffInitial,
+		MaxDelay:           config.DefaultRestartBackoffMax,
+		Factor:             config.DefaultRestartBackoffFactor,
+		Jitter:             cfg.RestartBackoffJitter,
+		ResetAfter:         config.DefaultRestartResetAfter,
+		CrashLoopWindow:    config.DefaultCrashLoopWindow,
+		CrashLoopThreshold: config.DefaultCrashLoopThreshold,
+	}
+
+	if cfg.RestartBackoffInitial > 0 {
+		policy.InitialDelay = cfg.RestartBackoffInitial
+	}
+	if cfg.RestartBackoffMax > 0 {
+		policy.MaxDelay = cfg.RestartBackoffMax
+	}
+	if cfg.RestartBackoffFactor >= 1 {
+		policy.Factor = cfg.RestartBackoffFactor
+	}
+	if cfg.RestartResetAfter > 0 {
+		policy.ResetAfter = cfg.RestartResetAfter
+	}
+	if cfg.CrashLoopWindow > 0 {
+		policy.CrashLoopWindow = cfg.CrashLoopWindow
+	}
+	if cfg.CrashLoopThreshold > 0 {
+		policy.CrashLoopThreshold = cfg.CrashLoopThreshold
+	}
+
+	return policy
+}
+
+// Backoff returns the delay before the given restart attempt (0-based),
+// growing exponentially up to MaxDelay with random jitter applied
+func (p *RestartPolicy) Backoff(attempt int) time.Duration {
+	delay := float64(p.InitialDelay) * math.Pow(p.Factor, float64(attempt))
+	if delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(delay)
+}
+
+// ShouldReset returns true if a run of the given length counts as healthy
+// enough to forget earlier restarts
+func (p *RestartPolicy) ShouldReset(uptime time.Duration) bool {
+	return uptime >= p.ResetAfter
+}
+
+// RecordCrash appends a crash time and drops crashes outside the window
+func (p *RestartPolicy) RecordCrash(crashes []time.Time, now time.Time) []time.Time {
+	crashes = append(crashes, now)
+
+	cutoff := now.Add(-p.CrashLoopWindow)
+	kept := crashes[:0]
+	for _, t := range crashes {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
+
+// IsCrashLoop returns true if the recent crashes exceed the threshold
+func (p *RestartPolicy) IsCrashLoop(crashes []time.Time) bool {
+	return len(crashes) >= p.CrashLoopThreshold
+}
+
+// exitReason describes why a process exited based on its wait error
+func exitReason(err error) string {
+	if err == nil {
+		return "exited with status 0"
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return "killed by signal " + status.Signal().String()
+		}
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,159 @@
+package node
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/pkg/logger"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNewRestartPolicy_Defaults(t *testing.T) {
+	policy := NewRestartPolicy(&config.Config{})
+
+	assert.Equal(t, config.DefaultRestartBackoffInitial, policy.InitialDelay)
+	assert.Equal(t, config.DefaultRestartBackoffMax, policy.MaxDelay)
+	assert.Equal(t, config.DefaultRestartBackoffFactor, policy.Factor)
+	assert.Equal(t, 0.0, policy.Jitter)
+	assert.Equal(t, config.DefaultRestartResetAfter, policy.ResetAfter)
+	assert.Equal(t, config.DefaultCrashLoopWindow, policy.CrashLoopWindow)
+	assert.Equal(t, config.DefaultCrashLoopThreshold, policy.CrashLoopThreshold)
+}
+
+func TestNewRestartPolicy_FromConfig(t *testing.T) {
+	policy := NewRestartPolicy(&config.Config{
+		RestartBackoffInitial: time.Second,
+		RestartBackoffMax:     time.Minute,
+		RestartBackoffFactor:  3,
+		RestartBackoffJitter:  0.1,
+		RestartResetAfter:     time.Hour,
+		CrashLoopWindow:       30 * time.Minute,
+		CrashLoopThreshold:    3,
+	})
+
+	assert.Equal(t, time.Second, policy.InitialDelay)
+	assert.Equal(t, time.Minute, policy.MaxDelay)
+	assert.Equal(t, 3.0, policy.Factor)
+	assert.Equal(t, 0.1, policy.Jitter)
+	assert.Equal(t, time.Hour, policy.ResetAfter)
+	assert.Equal(t, 30*time.Minute, policy.CrashLoopWindow)
+	assert.Equal(t, 3, policy.CrashLoopThreshold)
+}
+
+func TestRestartPolicy_Backoff(t *testing.T) {
+	policy := &RestartPolicy{
+		InitialDelay: time.Second,
+		MaxDelay:     10 * time.Second,
+		Factor:       2,
+	}
+
+	assert.Equal(t, 1*time.Second, policy.Backoff(0))
+	assert.Equal(t, 2*time.Second, policy.Backoff(1))
+	assert.Equal(t, 4*time.Second, policy.Backoff(2))
+	assert.Equal(t, 8*time.Second, policy.Backoff(3))
+	assert.Equal(t, 10*time.Second, policy.Backoff(4))
+	assert.Equal(t, 10*time.Second, policy.Backoff(50))
+}
+
+func TestRestartPolicy_BackoffJitter(t *testing.T) {
+	policy := &RestartPolicy{
+		InitialDelay: 10 * time.Second,
+		MaxDelay:     time.Minute,
+		Factor:       2,
+		Jitter:       0.2,
+	}
+
+	for i := 0; i < 100; i++ {
+		delay := policy.Backoff(0)
+		assert.GreaterOrEqual(t, delay, 8*time.Second)
+		assert.LessOrEqual(t, delay, 12*time.Second)
+	}
+}
+
+func TestRestartPolicy_CrashLoop(t *testing.T) {
+	policy := &RestartPolicy{
+		CrashLoopWindow:    time.Minute,
+		CrashLoopThreshold: 3,
+	}
+
+	now := time.Now()
+	var crashes []time.Time
+
+	// Old crashes fall out of the window
+	crashes = policy.RecordCrash(crashes, now.Add(-5*time.Minute))
+	crashes = policy.RecordCrash(crashes, now.Add(-2*time.Minute))
+	crashes = policy.RecordCrash(crashes, now.Add(-30*time.Second))
+	assert.Len(t, crashes, 1)
+	assert.False(t, policy.IsCrashLoop(crashes))
+
+	crashes = policy.RecordCrash(crashes, now.Add(-10*time.Second))
+	assert.False(t, policy.IsCrashLoop(crashes))
+
+	crashes = policy.RecordCrash(crashes, now)
+	assert.Len(t, crashes, 3)
+	assert.True(t, policy.IsCrashLoop(crashes))
+}
+
+func TestRestartPolicy_ShouldReset(t *testing.T) {
+	policy := &RestartPolicy{ResetAfter: 10 * time.Minute}
+
+	assert.False(t, policy.ShouldReset(time.Minute))
+	assert.True(t, policy.ShouldReset(10*time.Minute))
+	assert.True(t, policy.ShouldReset(time.Hour))
+}
+
+func TestExitReason(t *testing.T) {
+	assert.Equal(t, "exited with status 0", exitReason(nil))
+	assert.Equal(t, "boom", exitReason(errors.New("boom")))
+
+	err := exec.Command("sh", "-c", "exit 3").Run()
+	require.Error(t, err)
+	assert.Equal(t, "exit status 3", exitReason(err))
+
+	err = exec.Command("sh", "-c", "kill -KILL $$").Run()
+	require.Error(t, err)
+	assert.Equal(t, "killed by signal killed", exitReason(err))
+}
+
+func TestManager_CrashLoopAlertWithoutMetrics(t *testing.T) {
+	homeDir := t.TempDir()
+	binDir := filepath.Join(homeDir, "wemixvisor", "current", "bin")
+	require.NoError(t, os.MkdirAll(binDir, 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(binDir, "wemixd"), []byte("#!/bin/sh\nexit 1\n"), 0755))
+
+	cfg := &config.Config{
+		Home:                  homeDir,
+		Name:                  "wemixd",
+		RestartOnFailure:      true,
+		MaxRestarts:           10,
+		RestartBackoffInitial: 100 * time.Millisecond,
+		RestartBackoffMax:     100 * time.Millisecond,
+		CrashLoopWindow:       time.Minute,
+		CrashLoopThreshold:    3,
+	}
+
+	core, logs := observer.New(zap.WarnLevel)
+	manager := NewManager(cfg, &logger.Logger{Logger: zap.New(core)})
+	defer manager.Close()
+
+	start := time.Now()
+	require.NoError(t, manager.Start(nil))
+
+	// Two restarts with the policy's delay alone stay well below the fixed
+	// manual restart delay
+	require.Eventually(t, func() bool {
+		return manager.GetState() == StateCrashLoop
+	}, 5*time.Second, 20*time.Millisecond)
+	assert.Less(t, time.Since(start), 2*DefaultRestartDelay)
+
+	alerts := logs.FilterMessage("alert generated").FilterField(zap.String("name", "NodeCrashLoop"))
+	assert.Equal(t, 1, alerts.Len(), "crash-loop alert should be raised without metrics")
+}
@@ -0,0 +1,315 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/height"
+	"github.com/wemix/wemixvisor/internal/metrics"
+	"github.com/wemix/wemixvisor/pkg/logger"
+)
+
+// MaxStallHistory bounds the stall actions kept for status reporting
+const MaxStallHistory = 20
+
+// StallEvent records an action the watchdog took on a stalled chain
+type StallEvent struct {
+	Time          time.Time     `json:"time"`
+	Height        int64         `json:"height"`
+	StallDuration time.Duration `json:"stall_duration"`
+	Action        string        `json:"action"`
+	Error         string        `json:"error,omitempty"`
+}
+
+// StallStatus reports chain progress as seen by the stall watchdog
+type StallStatus struct {
+	Height        int64         `json:"height"`
+	LastAdvance   time.Time     `json:"last_advance"`
+	StallDuration time.Duration `json:"stall_duration"`
+	MissedBlocks  int64         `json:"missed_blocks"`
+	Stalled       bool          `json:"stalled"`
+	Timeout       time.Duration `json:"timeout"`
+	Action        string        `json:"action"`
+	History       []StallEvent  `json:"history,omitempty"`
+}
+
+// StallWatchdog acts on a node whose process is alive but whose chain
+// height has stopped advancing. It follows the height reported by a
+// height.HeightMonitor and, once the height has not moved for the stall
+// timeout while the node is running, raises an alert and optionally
+// restarts the node. Time the node spends stopped, starting or upgrading
+// does not count towards the timeout.
+type StallWatchdog struct {
+	manager *Manager
+	monitor *height.HeightMonitor
+	logger  *logger.Logger
+
+	timeout       time.Duration
+	blockInterval time.Duration
+	action        string
+	extraArgs     []string
+
+	mu          sync.Mutex
+	height      int64
+	lastAdvance time.Time
+	windowStart time.Time // start of the period judged against the timeout
+	stalled     bool
+	history     []StallEvent
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewStallWatchdog creates a stall watchdog for the manager's node using the
+// manager's configuration. Its state is included in the manager's Status.
+func NewStallWatchdog(m *Manager, monitor *height.HeightMonitor, log *logger.Logger) *StallWatchdog {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	blockInterval := config.DefaultExpectedBlockInterval
+	if m.config.ExpectedBlockInterval > 0 {
+		blockInterval = m.config.ExpectedBlockInterval
+	}
+	action := config.StallActionAlert
+	if m.config.StallAction != "" {
+		action = m.config.StallAction
+	}
+
+	w := &StallWatchdog{
+		manager:       m,
+		monitor:       monitor,
+		logger:        log,
+		timeout:       m.config.StallTimeout,
+		blockInterval: blockInterval,
+		action:        action,
+		extraArgs:     m.config.StallRestartArgs,
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+
+	m.stateMutex.Lock()
+	m.stall = w
+	m.stateMutex.Unlock()
+
+	return w
+}
+
+// Start begins watching the chain height
+func (w *StallWatchdog) Start() error {
+	if w.timeout <= 0 {
+		return fmt.Errorf("stall timeout must be positive")
+	}
+
+	w.mu.Lock()
+	w.height = w.monitor.GetCurrentHeight()
+	w.lastAdvance = time.Now()
+	w.windowStart = w.lastAdvance
+	w.mu.Unlock()
+
+	updates := w.monitor.Subscribe()
+
+	w.wg.Add(1)
+	go w.run(updates)
+
+	w.logger.Info("stall watchdog started",
+		zap.Duration("timeout", w.timeout),
+		zap.Duration("block_interval", w.blockInterval),
+		zap.String("action", w.action))
+	return nil
+}
+
+// Stop stops the watchdog and waits for it to finish
+func (w *StallWatchdog) Stop() {
+	w.cancel()
+	w.wg.Wait()
+}
+
+// run follows height updates and checks for a stall at least once per
+// expected block interval
+func (w *StallWatchdog) run(updates <-chan int64) {
+	defer w.wg.Done()
+
+	interval := w.blockInterval
+	if quarter := w.timeout / 4; quarter < interval {
+		interval = quarter
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case h := <-updates:
+			w.observeHeight(h)
+		case <-ticker.C:
+			w.check()
+		}
+	}
+}
+
+// observeHeight records a height reported by the monitor
+func (w *StallWatchdog) observeHeight(h int64) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if h <= w.height {
+		return
+	}
+
+	if w.stalled {
+		w.logger.Info("chain height advancing again",
+			zap.Int64("height", h),
+			zap.Duration("stalled_for", time.Since(w.lastAdvance)))
+	}
+
+	w.height = h
+	w.lastAdvance = time.Now()
+	w.windowStart = w.lastAdvance
+	w.stalled = false
+}
+
+// check acts on the node once it has been running for the stall timeout
+// without its height advancing. While stalled, the action is repeated once
+// per timeout.
+func (w *StallWatchdog) check() {
+	now := time.Now()
+	collector := w.manager.metricsCollector
+
+	if w.manager.GetState() != StateRunning {
+		// A node that is not running gets a full timeout once it is back
+		w.mu.Lock()
+		w.windowStart = now
+		w.mu.Unlock()
+
+		if collector != nil {
+			collector.SetNodeStall(0)
+		}
+		return
+	}
+
+	w.mu.Lock()
+	stall := now.Sub(w.lastAdvance)
+	h := w.height
+	// Without a height reading there is no chain progress to judge
+	due := h > 0 && now.Sub(w.windowStart) >= w.timeout
+	if due {
+		w.stalled = true
+	}
+	w.mu.Unlock()
+
+	if collector != nil {
+		collector.SetNodeStall(stall)
+	}
+
+	if due {
+		w.act(h, stall)
+	}
+}
+
+// act reports the stall and carries out the configured action
+func (w *StallWatchdog) act(h int64, stall time.Duration) {
+	event := StallEvent{
+		Time:          time.Now(),
+		Height:        h,
+		StallDuration: stall,
+		Action:        w.action,
+	}
+
+	w.logger.Warn("chain height stalled",
+		zap.Int64("height", h),
+		zap.Duration("stalled_for", stall),
+		zap.Int64("missed_blocks", int64(stall/w.blockInterval)),
+		zap.String("action", w.action))
+	w.raiseStallAlert(event)
+
+	var err error
+	switch w.action {
+	case config.StallActionRestart:
+		err = w.manager.Restart()
+	case config.StallActionRestartWithArgs:
+		err = w.manager.restartWithExtraArgs(w.extraArgs)
+	}
+
+	outcome := "ok"
+	if err != nil {
+		outcome = "failed"
+		event.Error = err.Error()
+		w.logger.Error("stall action failed",
+			zap.String("action", w.action),
+			zap.Error(err))
+	}
+	if collector := w.manager.metricsCollector; collector != nil {
+		collector.RecordStallAction(w.action, outcome)
+	}
+
+	w.mu.Lock()
+	w.windowStart = time.Now()
+	w.history = append(w.history, event)
+	if len(w.history) > MaxStallHistory {
+		w.history = w.history[len(w.history)-MaxStallHistory:]
+	}
+	w.mu.Unlock()
+}
+
+// raiseStallAlert reports a stall through the metrics collector
+func (w *StallWatchdog) raiseStallAlert(event StallEvent) {
+	collector := w.manager.metricsCollector
+	if collector == nil {
+		return
+	}
+
+	collector.GenerateAlert(&metrics.Alert{
+		ID:        fmt.Sprintf("node-stalled-%d", event.Time.Unix()),
+		Name:      "NodeStalled",
+		Level:     metrics.AlertLevelWarning,
+		Message:   fmt.Sprintf("chain height %d has not advanced for %v", event.Height, event.StallDuration.Round(time.Second)),
+		Source:    "node",
+		Metric:    "node_stall_seconds",
+		Value:     event.StallDuration.Seconds(),
+		Threshold: w.timeout.Seconds(),
+		Labels:    map[string]string{"action": event.Action},
+		Timestamp: event.Time,
+	})
+}
+
+// status returns the watchdog's view of chain progress for a node in the
+// given state
+func (w *StallWatchdog) status(state NodeState) *StallStatus {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	status := &StallStatus{
+		Height:      w.height,
+		LastAdvance: w.lastAdvance,
+		Stalled:     w.stalled,
+		Timeout:     w.timeout,
+		Action:      w.action,
+	}
+	if state == StateRunning && !w.lastAdvance.IsZero() {
+		status.StallDuration = time.Since(w.lastAdvance)
+		status.MissedBlocks = int64(status.StallDuration / w.blockInterval)
+	}
+	if len(w.history) > 0 {
+		status.History = append([]StallEvent(nil), w.history...)
+	}
+	return status
+}
+
+// restartWithExtraArgs restarts the node once with extra arguments appended,
+// keeping its regular arguments for later starts
+func (m *Manager) restartWithExtraArgs(extra []string) error {
+	m.stateMutex.RLock()
+	base := m.nodeArgs
+	m.stateMutex.RUnlock()
+
+	args := append(append([]string(nil), base...), extra...)
+	err := m.restart(args, DefaultRestartDelay)
+	m.SetNodeArgs(base)
+	return err
+}
@@ -0,0 +1,130 @@
+package node
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/height"
+	"github.com/wemix/wemixvisor/pkg/logger"
+)
+
+// mockArgsScript answers version queries, otherwise records its arguments
+// and runs until signalled
+const mockArgsScript = `#!/bin/sh
+if [ "$1" = "version" ]; then
+  echo "v1.0.0"
+  exit 0
+fi
+echo "$@" >> args.log
+trap 'exit 0' TERM INT
+while true; do
+  sleep 0.1
+done
+`
+
+// stubHeight is a HeightProvider whose height optionally advances on
+// every query
+type stubHeight struct {
+	height  int64
+	advance bool
+}
+
+func (s *stubHeight) GetCurrentHeight() (int64, error) {
+	if s.advance {
+		return atomic.AddInt64(&s.height, 1), nil
+	}
+	return atomic.LoadInt64(&s.height), nil
+}
+
+// startStallTest starts a node and a stall watchdog fed by provider
+func startStallTest(t *testing.T, provider height.HeightProvider, action string, extraArgs ...string) (*Manager, *config.Config) {
+	t.Helper()
+
+	cfg := setupStopTest(t, mockArgsScript)
+	cfg.StallTimeout = 300 * time.Millisecond
+	cfg.ExpectedBlockInterval = 50 * time.Millisecond
+	cfg.StallAction = action
+	cfg.StallRestartArgs = extraArgs
+
+	log := logger.NewTestLogger()
+	manager := NewManager(cfg, log)
+	t.Cleanup(func() { manager.Close() })
+	require.NoError(t, manager.Start([]string{"--base"}))
+
+	monitor := height.NewHeightMonitor(provider, 20*time.Millisecond, log)
+	require.NoError(t, monitor.Start())
+	t.Cleanup(monitor.Stop)
+
+	watchdog := NewStallWatchdog(manager, monitor, log)
+	require.NoError(t, watchdog.Start())
+	t.Cleanup(watchdog.Stop)
+
+	return manager, cfg
+}
+
+// stallHistory returns the stall actions reported in the manager's status
+func stallHistory(manager *Manager) []StallEvent {
+	if stall := manager.GetStatus().Stall; stall != nil {
+		return stall.History
+	}
+	return nil
+}
+
+func TestStallWatchdog_AlertOnly(t *testing.T) {
+	manager, _ := startStallTest(t, &stubHeight{height: 100}, config.StallActionAlert)
+	pid := manager.GetPID()
+
+	require.Eventually(t, func() bool { return len(stallHistory(manager)) > 0 },
+		3*time.Second, 20*time.Millisecond)
+
+	stall := manager.GetStatus().Stall
+	assert.True(t, stall.Stalled)
+	assert.Equal(t, int64(100), stall.Height)
+	assert.Positive(t, stall.MissedBlocks)
+	assert.Equal(t, config.StallActionAlert, stall.History[0].Action)
+	assert.GreaterOrEqual(t, stall.History[0].StallDuration, 300*time.Millisecond)
+	assert.Empty(t, stall.History[0].Error)
+	assert.Equal(t, pid, manager.GetPID(), "alert only should leave the node running")
+}
+
+func TestStallWatchdog_RestartWithArgs(t *testing.T) {
+	manager, cfg := startStallTest(t, &stubHeight{height: 100},
+		config.StallActionRestartWithArgs, "--resync")
+	pid := manager.GetPID()
+
+	require.Eventually(t, func() bool { return len(stallHistory(manager)) > 0 },
+		5*time.Second, 20*time.Millisecond)
+
+	assert.NotEqual(t, pid, manager.GetPID())
+	assert.Equal(t, StateRunning, manager.GetState())
+	assert.Equal(t, 1, manager.GetRestartCount())
+
+	data, err := ioutil.ReadFile(filepath.Join(cfg.Home, "args.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "--base\n--base --resync\n", string(data))
+
+	// The extra arguments apply to the stall restart only
+	manager.stateMutex.RLock()
+	assert.Equal(t, []string{"--base"}, manager.nodeArgs)
+	manager.stateMutex.RUnlock()
+}
+
+func TestStallWatchdog_AdvancingChainIsNotStalled(t *testing.T) {
+	manager, _ := startStallTest(t, &stubHeight{height: 100, advance: true}, config.StallActionRestart)
+	pid := manager.GetPID()
+
+	time.Sleep(700 * time.Millisecond)
+
+	stall := manager.GetStatus().Stall
+	require.NotNil(t, stall)
+	assert.False(t, stall.Stalled)
+	assert.Greater(t, stall.Height, int64(100))
+	assert.Empty(t, stall.History)
+	assert.Equal(t, pid, manager.GetPID())
+}
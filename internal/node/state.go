@@ -3,6 +3,9 @@ package node
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/wemix/wemixvisor/internal/state"
+	"github.com/wemix/wemixvisor/internal/version"
 )
 
 // NodeState represents the current state of the node
@@ -23,6 +26,8 @@ const (
 	StateError
 	// StateCrashed indicates the node crashed unexpectedly
 	StateCrashed
+	// StateCrashLoop indicates the node crashed repeatedly and restarts were suspended
+	StateCrashLoop
 )
 
 // String returns the string representation of NodeState
@@ -42,13 +47,22 @@ func (s NodeState) String() string {
 		return "error"
 	case StateCrashed:
 		return "crashed"
+	case StateCrashLoop:
+		return "crash_loop"
 	default:
 		return "unknown"
 	}
 }
 
+// IsActive reports whether the node process is alive, either still starting
+// or running
+func (s NodeState) IsActive() bool {
+	return s == StateStarting || s == StateRunning
+}
+
 // Status represents the current status of the node
 type Status struct {
+	Instance     string        `json:"instance,omitempty"`
 	State        NodeState     `json:"state"`
 	StateString  string        `json:"state_string"`
 	PID          int           `json:"pid"`
@@ -59,6 +73,18 @@ type Status struct {
 	Network      string        `json:"network"`
 	Binary       string        `json:"binary"`
 	Health       *HealthStatus `json:"health,omitempty"`
+	Adopted      bool          `json:"adopted,omitempty"`
+
+	// Environment holds the configured node variables, secrets redacted
+	Environment map[string]string `json:"environment,omitempty"`
+
+	LastRestart    *RestartDecision  `json:"last_restart,omitempty"`
+	RestartHistory []RestartDecision `json:"restart_history,omitempty"`
+	LastCrash      *state.Crash      `json:"last_crash,omitempty"`
+
+	Stall *StallStatus `json:"stall,omitempty"`
+
+	VersionInfo *version.Info `json:"version_info,omitempty"`
 }
 
 // MarshalJSON implements json.Marshaler
@@ -0,0 +1,239 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/monitor"
+)
+
+// Stop step outcomes recorded in metrics
+const (
+	StopOutcomeExited  = "exited"
+	StopOutcomeTimeout = "timeout"
+	StopOutcomeFailed  = "failed"
+)
+
+// stopSignals maps signal stop actions to the signal sent to the process group
+var stopSignals = map[string]syscall.Signal{
+	config.StopActionSIGINT:  syscall.SIGINT,
+	config.StopActionSIGTERM: syscall.SIGTERM,
+	config.StopActionSIGQUIT: syscall.SIGQUIT,
+	config.StopActionSIGKILL: syscall.SIGKILL,
+}
+
+// stopSteps returns the configured stop sequence, falling back to the
+// default sequence if the configuration cannot be parsed
+func (m *Manager) stopSteps() []config.StopStep {
+	steps, err := m.config.StopSteps()
+	if err == nil {
+		return steps
+	}
+
+	m.logger.Warn("invalid stop sequence, using default", zap.Error(err))
+	fallback := &config.Config{ShutdownGrace: m.config.ShutdownGrace}
+	steps, _ = fallback.StopSteps()
+	return steps
+}
+
+// runStopSequence takes the configured stop steps in order until the process
+// exits, waiting up to each step's timeout before moving on to the next
+func (m *Manager) runStopSequence(pid int, process *os.Process, exited <-chan struct{}) error {
+	started := time.Now()
+
+	for i, step := range m.stopSteps() {
+		select {
+		case <-exited:
+			return nil
+		default:
+		}
+
+		m.logger.Info("stop step",
+			zap.Int("step", i+1),
+			zap.String("action", step.Action),
+			zap.Duration("timeout", step.Timeout),
+			zap.Int("pid", pid))
+
+		if err := m.executeStopStep(step, pid, process); err != nil {
+			m.logger.Warn("stop step failed",
+				zap.String("action", step.Action),
+				zap.Error(err))
+			m.recordStopStep(step, StopOutcomeFailed)
+			continue
+		}
+
+		timer := time.NewTimer(step.Timeout)
+		select {
+		case <-exited:
+			timer.Stop()
+			m.logger.Info("node stopped",
+				zap.String("action", step.Action),
+				zap.Duration("elapsed", time.Since(started)))
+			m.recordStopStep(step, StopOutcomeExited)
+			return nil
+		case <-timer.C:
+			m.logger.Warn("node still running after stop step",
+				zap.String("action", step.Action),
+				zap.Duration("timeout", step.Timeout))
+			m.recordStopStep(step, StopOutcomeTimeout)
+		}
+	}
+
+	return fmt.Errorf("node did not exit after the stop sequence")
+}
+
+// executeStopStep performs the action of a single stop step
+func (m *Manager) executeStopStep(step config.StopStep, pid int, process *os.Process) error {
+	if !step.IsSignal() {
+		ctx, cancel := context.WithTimeout(m.ctx, step.Timeout)
+		defer cancel()
+		return m.callStopRPC(ctx)
+	}
+
+	sig, ok := stopSignals[step.Action]
+	if !ok {
+		return fmt.Errorf("unsupported stop signal: %s", step.Action)
+	}
+
+	if err := syscall.Kill(-pid, sig); err != nil {
+		m.logger.Warn("failed to signal process group, signalling process",
+			zap.String("signal", step.Action),
+			zap.Error(err))
+		if err := process.Signal(sig); err != nil {
+			return fmt.Errorf("failed to send %s: %w", step.Action, err)
+		}
+	}
+	return nil
+}
+
+// callStopRPC asks the node to shut itself down over JSON-RPC, either via
+// HTTP or an IPC socket
+func (m *Manager) callStopRPC(ctx context.Context) error {
+	if m.config.StopRPCMethod == "" {
+		return fmt.Errorf("no stop RPC method configured")
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"method":  m.config.StopRPCMethod,
+		"params":  []interface{}{},
+		"id":      1,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal stop request: %w", err)
+	}
+
+	endpoint := m.stopRPCEndpoint()
+	var data []byte
+	if strings.HasPrefix(endpoint, "http://") || strings.HasPrefix(endpoint, "https://") {
+		data, err = postJSONRPC(ctx, endpoint, body)
+	} else {
+		data, err = callIPC(ctx, endpoint, body)
+	}
+	if err != nil {
+		return err
+	}
+
+	var resp struct {
+		Error *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return fmt.Errorf("invalid stop RPC response: %w", err)
+	}
+	if resp.Error != nil {
+		return fmt.Errorf("stop RPC error %d: %s", resp.Error.Code, resp.Error.Message)
+	}
+	return nil
+}
+
+// stopRPCEndpoint returns the endpoint for the stop RPC. Without explicit
+// configuration this is the node's local HTTP RPC port; relative IPC socket
+// paths are resolved against the node home.
+func (m *Manager) stopRPCEndpoint() string {
+	endpoint := m.config.StopRPCEndpoint
+	if endpoint == "" {
+		rpcPort := monitor.DefaultRPCPort
+		if m.config.RPCPort > 0 {
+			rpcPort = m.config.RPCPort
+		}
+		return fmt.Sprintf("http://localhost:%d", rpcPort)
+	}
+
+	if !strings.Contains(endpoint, "://") && !filepath.IsAbs(endpoint) {
+		return filepath.Join(m.config.Home, endpoint)
+	}
+	return endpoint
+}
+
+// postJSONRPC sends a JSON-RPC request over HTTP and returns the response body
+func postJSONRPC(ctx context.Context, url string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stop request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("stop RPC request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("stop RPC returned status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read stop RPC response: %w", err)
+	}
+	return data, nil
+}
+
+// callIPC sends a JSON-RPC request over a unix socket and returns the first
+// response message
+func callIPC(ctx context.Context, path string, body []byte) ([]byte, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to IPC endpoint: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	if _, err := conn.Write(body); err != nil {
+		return nil, fmt.Errorf("failed to send stop RPC: %w", err)
+	}
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(conn).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("failed to read stop RPC response: %w", err)
+	}
+	return raw, nil
+}
+
+// recordStopStep records the outcome of a stop step in metrics
+func (m *Manager) recordStopStep(step config.StopStep, outcome string) {
+	if m.metricsCollector != nil {
+		m.metricsCollector.RecordStopStep(step.Action, outcome)
+	}
+}
@@ -0,0 +1,135 @@
+package node
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/pkg/logger"
+)
+
+// mockInterruptScript ignores SIGTERM and exits on SIGINT, noting which
+// signal stopped it
+const mockInterruptScript = `#!/bin/sh
+trap '' TERM
+trap 'echo INT > stopped-by; exit 0' INT
+while true; do
+  sleep 0.1
+done
+`
+
+// setupStopTest installs script as the current binary with the given stop
+// sequence
+func setupStopTest(t *testing.T, script string, sequence ...string) *config.Config {
+	t.Helper()
+
+	homeDir := t.TempDir()
+	binDir := filepath.Join(homeDir, "wemixvisor", "current", "bin")
+	require.NoError(t, os.MkdirAll(binDir, 0755))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(binDir, "wemixd"), []byte(script), 0755))
+
+	return &config.Config{
+		Home:          homeDir,
+		Name:          "wemixd",
+		ShutdownGrace: time.Second,
+		StopSequence:  sequence,
+	}
+}
+
+func TestManager_StopSequenceAdvancesUntilExit(t *testing.T) {
+	cfg := setupStopTest(t, mockInterruptScript, "SIGTERM:300ms", "SIGINT:5s", "SIGKILL:1s")
+	manager := NewManager(cfg, logger.NewTestLogger())
+	defer manager.Close()
+
+	require.NoError(t, manager.Start(nil))
+	time.Sleep(200 * time.Millisecond)
+
+	start := time.Now()
+	require.NoError(t, manager.Stop())
+	elapsed := time.Since(start)
+
+	assert.Equal(t, StateStopped, manager.GetState())
+	assert.GreaterOrEqual(t, elapsed, 300*time.Millisecond, "SIGTERM step should wait its timeout")
+	assert.Less(t, elapsed, 3*time.Second, "SIGINT step should end as soon as the node exits")
+
+	data, err := ioutil.ReadFile(filepath.Join(cfg.Home, "stopped-by"))
+	require.NoError(t, err)
+	assert.Equal(t, "INT\n", string(data))
+}
+
+func TestManager_StopSequenceKillsStubbornNode(t *testing.T) {
+	script := `#!/bin/sh
+trap '' TERM INT
+while true; do
+  sleep 0.1
+done
+`
+	cfg := setupStopTest(t, script, "SIGINT:200ms", "SIGTERM:200ms", "SIGKILL:2s")
+	manager := NewManager(cfg, logger.NewTestLogger())
+	defer manager.Close()
+
+	require.NoError(t, manager.Start(nil))
+	time.Sleep(200 * time.Millisecond)
+
+	start := time.Now()
+	require.NoError(t, manager.Stop())
+
+	assert.Equal(t, StateStopped, manager.GetState())
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestManager_StopSequenceCallsRPC(t *testing.T) {
+	var calls int32
+	var method string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		method = req.Method
+		atomic.AddInt32(&calls, 1)
+		// The node refuses, so the sequence moves on to SIGINT
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"method not found"}}`))
+	}))
+	defer server.Close()
+
+	cfg := setupStopTest(t, mockInterruptScript, "rpc:1s", "SIGINT:5s", "SIGKILL:1s")
+	cfg.StopRPCMethod = "admin_shutdown"
+	cfg.StopRPCEndpoint = server.URL
+
+	manager := NewManager(cfg, logger.NewTestLogger())
+	defer manager.Close()
+
+	require.NoError(t, manager.Start(nil))
+	time.Sleep(200 * time.Millisecond)
+
+	start := time.Now()
+	require.NoError(t, manager.Stop())
+
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+	assert.Equal(t, "admin_shutdown", method)
+	assert.Less(t, time.Since(start), time.Second, "a failed RPC step should not wait its timeout")
+
+	_, err := os.Stat(filepath.Join(cfg.Home, "stopped-by"))
+	assert.NoError(t, err)
+}
+
+func TestManager_StopRPCEndpoint(t *testing.T) {
+	manager := NewManager(&config.Config{Home: "/node", RPCPort: 8588}, logger.NewTestLogger())
+	assert.Equal(t, "http://localhost:8588", manager.stopRPCEndpoint())
+
+	manager.config.StopRPCEndpoint = "geth.ipc"
+	assert.Equal(t, "/node/geth.ipc", manager.stopRPCEndpoint())
+
+	manager.config.StopRPCEndpoint = "/run/geth.ipc"
+	assert.Equal(t, "/run/geth.ipc", manager.stopRPCEndpoint())
+}
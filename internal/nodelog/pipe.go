@@ -0,0 +1,79 @@
+package nodelog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// PipePermissions is the mode of the named pipe carrying node output
+const PipePermissions = 0600
+
+// PipeBufferSize is the buffer the named pipe carrying node output is given
+// where the host allows it, instead of the default 64 KiB
+const PipeBufferSize = 1 << 20
+
+// OpenPipe creates the named pipe at path if needed and returns a handle to
+// pass to the node as stdout and stderr, along with the size of the pipe
+// buffer, or 0 if it is not known.
+//
+// The handle is opened for reading and writing, so the node itself keeps a
+// reader on the pipe. Its writes therefore never fail with a broken pipe
+// while the supervisor is restarting; output written in the meantime is
+// held in the pipe buffer until the next supervisor follows the pipe again.
+// The buffer is bounded: once the node has written a full buffer with no
+// supervisor following the pipe, its writes to stdout and stderr block, and
+// so does the node, until a supervisor follows the pipe again. On Linux the
+// buffer is raised to PipeBufferSize, or to the host's pipe-max-size if
+// that is smaller.
+func OpenPipe(path string) (*os.File, int, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, 0, fmt.Errorf("failed to create pipe directory: %w", err)
+	}
+
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeNamedPipe == 0 {
+		if err := os.Remove(path); err != nil {
+			return nil, 0, fmt.Errorf("failed to remove non-pipe file at %s: %w", path, err)
+		}
+	}
+
+	if err := syscall.Mkfifo(path, PipePermissions); err != nil && !os.IsExist(err) {
+		return nil, 0, fmt.Errorf("failed to create output pipe: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to open output pipe: %w", err)
+	}
+	return file, growPipe(file), nil
+}
+
+// Follow relays output from the named pipe at path into the sink until every
+// writer has closed it or the sink is closed. The returned channel is closed
+// when the relay ends.
+func (s *Sink) Follow(path string) (<-chan struct{}, error) {
+	// Non-blocking mode lets Close interrupt a pending read
+	reader, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output pipe: %w", err)
+	}
+
+	s.mu.Lock()
+	s.relays[reader] = struct{}{}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		io.Copy(s, reader)
+
+		s.mu.Lock()
+		delete(s.relays, reader)
+		s.mu.Unlock()
+		reader.Close()
+	}()
+
+	return done, nil
+}
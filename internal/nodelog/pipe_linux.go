@@ -0,0 +1,35 @@
+//go:build linux
+
+package nodelog
+
+import (
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// pipeMaxSizePath holds the largest buffer an unprivileged process may give
+// a pipe
+const pipeMaxSizePath = "/proc/sys/fs/pipe-max-size"
+
+// growPipe raises the buffer of the pipe to PipeBufferSize, or to the
+// largest size the host allows if that is smaller, and returns the size the
+// pipe ends up with
+func growPipe(file *os.File) int {
+	fd := file.Fd()
+	if _, err := unix.FcntlInt(fd, unix.F_SETPIPE_SZ, PipeBufferSize); err != nil {
+		if data, err := os.ReadFile(pipeMaxSizePath); err == nil {
+			if max, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && max < PipeBufferSize {
+				unix.FcntlInt(fd, unix.F_SETPIPE_SZ, max)
+			}
+		}
+	}
+
+	size, err := unix.FcntlInt(fd, unix.F_GETPIPE_SZ, 0)
+	if err != nil {
+		return 0
+	}
+	return size
+}
@@ -0,0 +1,109 @@
+package nodelog
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/pkg/logger"
+)
+
+func TestSink_FollowRelaysPipeUntilWritersClose(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "node.out")
+	sink := NewSink(&config.Config{Home: dir, LogFile: "node.log"}, logger.NewTestLogger())
+	defer sink.Close()
+
+	writer, _, err := OpenPipe(path)
+	require.NoError(t, err)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&os.ModeNamedPipe)
+
+	done, err := sink.Follow(path)
+	require.NoError(t, err)
+
+	_, err = writer.Write([]byte("first\nsecond\n"))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("relay did not end after the last writer closed")
+	}
+	assert.Equal(t, []string{"first", "second"}, sink.Tail(0))
+
+	data, err := os.ReadFile(filepath.Join(dir, "node.log"))
+	require.NoError(t, err)
+	assert.Equal(t, "first\nsecond\n", string(data))
+}
+
+func TestSink_CloseStopsFollowing(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "node.out")
+	sink := NewSink(&config.Config{Home: dir, LogFile: "node.log"}, logger.NewTestLogger())
+
+	writer, _, err := OpenPipe(path)
+	require.NoError(t, err)
+	defer writer.Close()
+
+	done, err := sink.Follow(path)
+	require.NoError(t, err)
+	require.NoError(t, sink.Close())
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("relay did not end when the sink was closed")
+	}
+
+	// The writer holds its own reader, so writes still succeed
+	_, err = writer.Write([]byte("still alive\n"))
+	assert.NoError(t, err)
+}
+
+func TestOpenPipe_ReplacesRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.out")
+	require.NoError(t, os.WriteFile(path, []byte("stale"), 0644))
+
+	pipe, _, err := OpenPipe(path)
+	require.NoError(t, err)
+	defer pipe.Close()
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.NotZero(t, info.Mode()&os.ModeNamedPipe)
+}
+
+func TestOpenPipe_BuffersOutputWhileNotFollowed(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the pipe buffer is only raised on Linux")
+	}
+
+	writer, size, err := OpenPipe(filepath.Join(t.TempDir(), "node.out"))
+	require.NoError(t, err)
+	defer writer.Close()
+	require.GreaterOrEqual(t, size, 4*64*1024, "the pipe buffer should be raised above the 64 KiB default")
+
+	// Write four default buffers' worth of output with nothing following
+	// the pipe
+	written := make(chan error, 1)
+	go func() {
+		_, err := writer.Write(make([]byte, 4*64*1024))
+		written <- err
+	}()
+
+	select {
+	case err := <-written:
+		assert.NoError(t, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("writes blocked although the pipe buffer has room")
+	}
+}
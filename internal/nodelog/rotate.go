@@ -0,0 +1,301 @@
+// Package nodelog captures the output of the managed node process into
+// rotating, compressed log files and keeps a tail of recent lines in memory.
+package nodelog
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Rotation constants
+const (
+	BackupTimeFormat = "20060102T150405.000"
+	CompressSuffix   = ".gz"
+	LogPermissions   = 0644
+)
+
+// RotatingFile is an io.WriteCloser that rotates its file by size and age,
+// optionally gzips rotated segments and prunes old ones
+type RotatingFile struct {
+	path           string
+	maxSize        int64
+	rotateInterval time.Duration
+	maxAge         time.Duration
+	maxBackups     int
+	compress       bool
+
+	mu       sync.Mutex
+	file     *os.File
+	size     int64
+	openedAt time.Time
+
+	// compressions tracks background compression of rotated segments
+	compressions sync.WaitGroup
+
+	// now is replaceable for tests
+	now func() time.Time
+}
+
+// RotateOptions configures a RotatingFile. Zero values disable the
+// corresponding limit.
+type RotateOptions struct {
+	MaxSize        int64
+	RotateInterval time.Duration
+	MaxAge         time.Duration
+	MaxBackups     int
+	Compress       bool
+}
+
+// NewRotatingFile creates a rotating file at path. The file is opened lazily
+// on the first write or explicitly with Open.
+func NewRotatingFile(path string, opts RotateOptions) *RotatingFile {
+	return &RotatingFile{
+		path:           path,
+		maxSize:        opts.MaxSize,
+		rotateInterval: opts.RotateInterval,
+		maxAge:         opts.MaxAge,
+		maxBackups:     opts.MaxBackups,
+		compress:       opts.Compress,
+		now:            time.Now,
+	}
+}
+
+// Path returns the path of the active log file
+func (r *RotatingFile) Path() string {
+	return r.path
+}
+
+// Open (re)opens the active log file, closing any previously open handle.
+// It is safe to call repeatedly, e.g. once per node start.
+func (r *RotatingFile) Open() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.closeFile()
+	return r.openFile()
+}
+
+// Write writes p to the active file, rotating first if the write would
+// exceed the size limit or the segment is older than the rotate interval
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		if err := r.openFile(); err != nil {
+			return 0, err
+		}
+	}
+
+	if r.shouldRotate(int64(len(p))) {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Rotate forces a rotation of the active file
+func (r *RotatingFile) Rotate() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		if err := r.openFile(); err != nil {
+			return err
+		}
+	}
+	return r.rotate()
+}
+
+// Close closes the active file and waits for pending compressions
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	err := r.closeFile()
+	r.mu.Unlock()
+
+	r.compressions.Wait()
+	return err
+}
+
+// Backups returns the rotated segments of the log, oldest first
+func (r *RotatingFile) Backups() ([]string, error) {
+	dir := filepath.Dir(r.path)
+	prefix, ext := r.backupPrefix()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read log directory: %w", err)
+	}
+
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimSuffix(strings.TrimPrefix(name, prefix), CompressSuffix), ext)
+		if _, err := time.Parse(BackupTimeFormat, stamp); err != nil {
+			continue
+		}
+		backups = append(backups, filepath.Join(dir, name))
+	}
+
+	// The timestamp format sorts lexically in chronological order
+	sort.Strings(backups)
+	return backups, nil
+}
+
+// shouldRotate reports whether the active segment must be rotated before
+// writing n more bytes. Caller must hold mu.
+func (r *RotatingFile) shouldRotate(n int64) bool {
+	if r.size == 0 {
+		return false
+	}
+	if r.maxSize > 0 && r.size+n > r.maxSize {
+		return true
+	}
+	return r.rotateInterval > 0 && r.now().Sub(r.openedAt) >= r.rotateInterval
+}
+
+// openFile opens the active file in append mode. Caller must hold mu.
+func (r *RotatingFile) openFile() error {
+	if err := os.MkdirAll(filepath.Dir(r.path), 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, LogPermissions)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat log file: %w", err)
+	}
+
+	r.file = file
+	r.size = info.Size()
+	r.openedAt = r.now()
+	return nil
+}
+
+// closeFile closes the active file if open. Caller must hold mu.
+func (r *RotatingFile) closeFile() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	r.size = 0
+	return err
+}
+
+// rotate moves the active file aside, opens a fresh one and hands the old
+// segment to compression and pruning. Caller must hold mu.
+func (r *RotatingFile) rotate() error {
+	if err := r.closeFile(); err != nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+
+	prefix, ext := r.backupPrefix()
+	backup := filepath.Join(filepath.Dir(r.path), prefix+r.now().Format(BackupTimeFormat)+ext)
+	if err := os.Rename(r.path, backup); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to rotate log file: %w", err)
+	}
+
+	if err := r.openFile(); err != nil {
+		return err
+	}
+
+	cutoff := r.now().Add(-r.maxAge)
+
+	r.compressions.Add(1)
+	go func() {
+		defer r.compressions.Done()
+		if r.compress {
+			// A failed compression leaves the plain segment in place
+			compressFile(backup)
+		}
+		r.prune(cutoff)
+	}()
+
+	return nil
+}
+
+// prune removes rotated segments beyond the backup count or last modified
+// before cutoff when a maximum age is set
+func (r *RotatingFile) prune(cutoff time.Time) {
+	backups, err := r.Backups()
+	if err != nil {
+		return
+	}
+
+	for i, backup := range backups {
+		expired := r.maxBackups > 0 && i < len(backups)-r.maxBackups
+		if !expired && r.maxAge > 0 {
+			if info, err := os.Stat(backup); err == nil && info.ModTime().Before(cutoff) {
+				expired = true
+			}
+		}
+		if expired {
+			os.Remove(backup)
+		}
+	}
+}
+
+// backupPrefix splits the log file name into the prefix and extension used
+// for rotated segments, e.g. "node.log" becomes "node-" and ".log"
+func (r *RotatingFile) backupPrefix() (string, string) {
+	name := filepath.Base(r.path)
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + "-", ext
+}
+
+// compressFile gzips src into src.gz and removes src on success
+func compressFile(src string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	dst := src + CompressSuffix
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, LogPermissions)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(out)
+	if _, err := io.Copy(gz, in); err != nil {
+		gz.Close()
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dst)
+		return err
+	}
+
+	return os.Remove(src)
+}
@@ -0,0 +1,134 @@
+package nodelog
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock returns a controllable time source that advances one millisecond
+// per call so rotated segments get distinct names
+func fakeClock(start time.Time) (func() time.Time, func(time.Duration)) {
+	now := start
+	return func() time.Time {
+			now = now.Add(time.Millisecond)
+			return now
+		}, func(d time.Duration) {
+			now = now.Add(d)
+		}
+}
+
+func TestRotatingFile_RotatesBySize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "node.log")
+	rf := NewRotatingFile(path, RotateOptions{MaxSize: 10})
+	rf.now, _ = fakeClock(time.Now())
+
+	for i := 0; i < 3; i++ {
+		_, err := rf.Write([]byte("12345678\n"))
+		require.NoError(t, err)
+	}
+	require.NoError(t, rf.Close())
+
+	backups, err := rf.Backups()
+	require.NoError(t, err)
+	assert.Len(t, backups, 2)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "12345678\n", string(data))
+}
+
+func TestRotatingFile_RotatesByAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.log")
+	rf := NewRotatingFile(path, RotateOptions{RotateInterval: time.Hour})
+	now, advance := fakeClock(time.Now())
+	rf.now = now
+
+	_, err := rf.Write([]byte("first\n"))
+	require.NoError(t, err)
+	_, err = rf.Write([]byte("second\n"))
+	require.NoError(t, err)
+
+	advance(2 * time.Hour)
+	_, err = rf.Write([]byte("third\n"))
+	require.NoError(t, err)
+	require.NoError(t, rf.Close())
+
+	backups, err := rf.Backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 1)
+
+	data, err := os.ReadFile(backups[0])
+	require.NoError(t, err)
+	assert.Equal(t, "first\nsecond\n", string(data))
+}
+
+func TestRotatingFile_CompressesAndPrunes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.log")
+	rf := NewRotatingFile(path, RotateOptions{MaxBackups: 2, Compress: true})
+	rf.now, _ = fakeClock(time.Now())
+
+	for i := 0; i < 5; i++ {
+		_, err := rf.Write([]byte("segment\n"))
+		require.NoError(t, err)
+		require.NoError(t, rf.Rotate())
+		// Let each compression finish so pruning sees a stable directory
+		rf.compressions.Wait()
+	}
+	require.NoError(t, rf.Close())
+
+	backups, err := rf.Backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+
+	for _, backup := range backups {
+		assert.True(t, strings.HasSuffix(backup, ".log.gz"), backup)
+
+		file, err := os.Open(backup)
+		require.NoError(t, err)
+		gz, err := gzip.NewReader(file)
+		require.NoError(t, err)
+		data, err := io.ReadAll(gz)
+		require.NoError(t, err)
+		file.Close()
+		assert.Equal(t, "segment\n", string(data))
+	}
+}
+
+func TestRotatingFile_ReopenAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "node.log")
+	rf := NewRotatingFile(path, RotateOptions{})
+
+	require.NoError(t, rf.Open())
+	_, err := rf.Write([]byte("one\n"))
+	require.NoError(t, err)
+
+	// Reopening closes the previous handle and keeps appending
+	require.NoError(t, rf.Open())
+	_, err = rf.Write([]byte("two\n"))
+	require.NoError(t, err)
+	require.NoError(t, rf.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "one\ntwo\n", string(data))
+}
+
+func TestRotatingFile_BackupsIgnoresUnrelatedFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "node.log")
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "node-notes.log"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other-20240101T000000.000.log"), nil, 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "node-20240101T000000.000.log.gz"), nil, 0644))
+
+	backups, err := NewRotatingFile(path, RotateOptions{}).Backups()
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "node-20240101T000000.000.log.gz")}, backups)
+}
@@ -0,0 +1,124 @@
+package nodelog
+
+import (
+	"io"
+	"os"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/pkg/logger"
+)
+
+// bytesPerMB converts the configured size limit to bytes
+const bytesPerMB = 1024 * 1024
+
+// Sink receives the stdout and stderr of the node process. Output goes to a
+// rotating log file when one is configured, otherwise to the supervisor's
+// stdout, and is always mirrored into an in-memory tail buffer.
+type Sink struct {
+	logger *logger.Logger
+
+	mu      sync.Mutex
+	file    *RotatingFile
+	console io.Writer
+	tail    *TailBuffer
+
+	// relays are the named pipes currently followed into the sink
+	relays map[*os.File]struct{}
+}
+
+// NewSink creates a sink for node output from configuration
+func NewSink(cfg *config.Config, log *logger.Logger) *Sink {
+	tailLines := config.DefaultLogTailLines
+	if cfg.LogTailLines > 0 {
+		tailLines = cfg.LogTailLines
+	}
+
+	sink := &Sink{
+		logger:  log,
+		console: os.Stdout,
+		tail:    NewTailBuffer(tailLines),
+		relays:  make(map[*os.File]struct{}),
+	}
+
+	if path := cfg.NodeLogPath(); path != "" {
+		sink.file = NewRotatingFile(path, RotateOptions{
+			MaxSize:        int64(cfg.LogMaxSizeMB) * bytesPerMB,
+			RotateInterval: cfg.LogRotateInterval,
+			MaxAge:         cfg.LogMaxAge,
+			MaxBackups:     cfg.LogMaxBackups,
+			Compress:       cfg.LogCompress,
+		})
+	}
+
+	return sink
+}
+
+// Open (re)opens the log file so a new node process starts writing to a
+// fresh handle. If the file cannot be opened, output falls back to stdout.
+func (s *Sink) Open() error {
+	if s.file == nil {
+		return nil
+	}
+
+	if err := s.file.Open(); err != nil {
+		s.logger.Error("failed to open node log, writing node output to stdout",
+			zap.String("path", s.file.Path()),
+			zap.Error(err))
+		return err
+	}
+	return nil
+}
+
+// Write writes node output to the log destination and the tail buffer
+func (s *Sink) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tail.Write(p)
+
+	if s.file != nil {
+		if _, err := s.file.Write(p); err == nil {
+			return len(p), nil
+		}
+	}
+	return s.console.Write(p)
+}
+
+// Tail returns up to n of the most recent output lines, oldest first.
+// A non-positive n returns all buffered lines.
+func (s *Sink) Tail(n int) []string {
+	return s.tail.Lines(n)
+}
+
+// Written returns the number of output lines received so far. Together
+// with Tail it picks out the lines written since a given point.
+func (s *Sink) Written() uint64 {
+	return s.tail.Written()
+}
+
+// Path returns the active log file path, or an empty string when output is
+// not captured to a file
+func (s *Sink) Path() string {
+	if s.file == nil {
+		return ""
+	}
+	return s.file.Path()
+}
+
+// Close stops following output pipes, closes the log file and waits for
+// pending compressions
+func (s *Sink) Close() error {
+	s.mu.Lock()
+	for reader := range s.relays {
+		reader.Close()
+	}
+	s.mu.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+	return s.file.Close()
+}
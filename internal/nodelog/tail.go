@@ -0,0 +1,101 @@
+package nodelog
+
+import (
+	"bytes"
+	"sync"
+)
+
+// MaxPartialLine bounds how much of an unterminated line is buffered before
+// it is recorded as a line of its own
+const MaxPartialLine = 64 * 1024
+
+// TailBuffer is an io.Writer that keeps the most recent lines written to it
+type TailBuffer struct {
+	mu      sync.RWMutex
+	lines   []string
+	next    int
+	full    bool
+	partial []byte
+	written uint64
+}
+
+// NewTailBuffer creates a tail buffer holding up to capacity lines
+func NewTailBuffer(capacity int) *TailBuffer {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &TailBuffer{lines: make([]string, capacity)}
+}
+
+// Write splits p into lines and records the complete ones. A trailing
+// partial line is held until its newline arrives.
+func (t *TailBuffer) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	data := p
+	for len(data) > 0 {
+		idx := bytes.IndexByte(data, '\n')
+		if idx < 0 {
+			t.partial = append(t.partial, data...)
+			if len(t.partial) >= MaxPartialLine {
+				t.push(string(t.partial))
+				t.partial = t.partial[:0]
+			}
+			break
+		}
+
+		line := data[:idx]
+		if len(t.partial) > 0 {
+			line = append(t.partial, line...)
+			t.partial = t.partial[:0]
+		}
+		t.push(string(bytes.TrimSuffix(line, []byte("\r"))))
+		data = data[idx+1:]
+	}
+
+	return len(p), nil
+}
+
+// Lines returns up to n of the most recent lines, oldest first.
+// A non-positive n returns all buffered lines.
+func (t *TailBuffer) Lines(n int) []string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	count := t.next
+	if t.full {
+		count = len(t.lines)
+	}
+	if n <= 0 || n > count {
+		n = count
+	}
+
+	result := make([]string, 0, n)
+	start := t.next - n
+	for i := 0; i < n; i++ {
+		idx := (start + i + len(t.lines)) % len(t.lines)
+		result = append(result, t.lines[idx])
+	}
+	return result
+}
+
+// Written returns the number of lines recorded since the buffer was
+// created, including those since overwritten
+func (t *TailBuffer) Written() uint64 {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.written
+}
+
+// push records a complete line, overwriting the oldest when full.
+// Caller must hold mu.
+func (t *TailBuffer) push(line string) {
+	t.lines[t.next] = line
+	t.written++
+	t.next++
+	if t.next == len(t.lines) {
+		t.next = 0
+		t.full = true
+	}
+}
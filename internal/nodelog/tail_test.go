@@ -0,0 +1,60 @@
+package nodelog
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/pkg/logger"
+)
+
+func TestTailBuffer_KeepsRecentLines(t *testing.T) {
+	tail := NewTailBuffer(3)
+
+	tail.Write([]byte("a\nb\n"))
+	assert.Equal(t, []string{"a", "b"}, tail.Lines(0))
+
+	tail.Write([]byte("c\nd\ne\n"))
+	assert.Equal(t, []string{"c", "d", "e"}, tail.Lines(0))
+	assert.Equal(t, []string{"d", "e"}, tail.Lines(2))
+	assert.Equal(t, []string{"c", "d", "e"}, tail.Lines(10))
+	assert.Equal(t, uint64(5), tail.Written(), "overwritten lines should still be counted")
+}
+
+func TestTailBuffer_PartialLines(t *testing.T) {
+	tail := NewTailBuffer(10)
+
+	tail.Write([]byte("hel"))
+	assert.Empty(t, tail.Lines(0))
+
+	tail.Write([]byte("lo\r\nwor"))
+	tail.Write([]byte("ld\n"))
+	assert.Equal(t, []string{"hello", "world"}, tail.Lines(0))
+}
+
+func TestTailBuffer_LongPartialLine(t *testing.T) {
+	tail := NewTailBuffer(10)
+
+	tail.Write([]byte(strings.Repeat("x", MaxPartialLine)))
+	lines := tail.Lines(0)
+	require.Len(t, lines, 1)
+	assert.Len(t, lines[0], MaxPartialLine)
+}
+
+func TestSink_WritesFileAndTail(t *testing.T) {
+	home := t.TempDir()
+	cfg := &config.Config{Home: home, LogFile: "logs/node.log", LogTailLines: 2}
+
+	sink := NewSink(cfg, logger.NewTestLogger())
+	assert.Equal(t, filepath.Join(home, "logs", "node.log"), sink.Path())
+	require.NoError(t, sink.Open())
+
+	sink.Write([]byte("one\ntwo\nthree\n"))
+	require.NoError(t, sink.Close())
+
+	assert.Equal(t, []string{"two", "three"}, sink.Tail(0))
+	assert.FileExists(t, sink.Path())
+}
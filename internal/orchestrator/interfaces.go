@@ -1,6 +1,8 @@
 package orchestrator
 
 import (
+	"context"
+
 	"github.com/wemix/wemixvisor/internal/config"
 	"github.com/wemix/wemixvisor/internal/node"
 	"github.com/wemix/wemixvisor/pkg/types"
@@ -36,6 +38,60 @@ type NodeManager interface {
 	GetStatus() *node.Status
 }
 
+// ReadinessWaiter is optionally implemented by a NodeManager whose Start
+// returns before the node is actually serving. When available, the
+// orchestrator waits for readiness before treating a start as successful.
+type ReadinessWaiter interface {
+	// WaitReady blocks until the most recently started node is ready,
+	// its start fails, or ctx is done.
+	WaitReady(ctx context.Context) error
+}
+
+// UpgradePreparer is optionally set on the orchestrator to prepare the node
+// home for an upgrade once the node has stopped and before the binary is
+// switched, e.g. by taking a backup and running pre-upgrade hooks.
+// A preparation error aborts the upgrade.
+type UpgradePreparer interface {
+	// PrepareUpgrade prepares the node home for the given upgrade.
+	PrepareUpgrade(upgrade *types.UpgradeInfo) error
+}
+
+// UpgradeStager is optionally set on the orchestrator to make the binary of
+// an upgrade ready as soon as the upgrade is scheduled, rather than once
+// the node has stopped for it.
+type UpgradeStager interface {
+	// Stage starts staging the upgrade binary in the background.
+	Stage(upgrade *types.UpgradeInfo)
+}
+
+// BackupRestorer is optionally implemented by an UpgradePreparer that takes
+// a pre-upgrade backup. When the configuration asks for it, a rolled back
+// upgrade also restores the backup recorded in the upgrade journal.
+type BackupRestorer interface {
+	// RestoreBackup restores the pre-upgrade backup at backupPath.
+	RestoreBackup(backupPath string) error
+}
+
+// UpgradeCheck is a custom check the upgraded node must pass before the
+// upgrade is declared successful. Checks run after the node is ready and
+// has advanced the configured number of blocks.
+type UpgradeCheck interface {
+	// Name identifies the check in logs and errors.
+	Name() string
+
+	// Check verifies the node running the given upgrade. It must return
+	// once ctx is done.
+	Check(ctx context.Context, upgrade *types.UpgradeInfo) error
+}
+
+// UpgradeFailureHandler is optionally set on the orchestrator to be told
+// about failed upgrades, e.g. to raise an alert.
+type UpgradeFailureHandler interface {
+	// UpgradeFailed is called once a failed upgrade has been rolled back,
+	// or the rollback has failed too.
+	UpgradeFailed(failure *UpgradeFailure)
+}
+
 // ConfigManager defines the interface for accessing configuration.
 // This abstraction allows for different configuration sources and
 // follows the Dependency Inversion Principle (DIP).
@@ -74,3 +130,12 @@ type UpgradeWatcher interface {
 	// Thread-safe: This method may be called concurrently.
 	ClearUpdateFlag()
 }
+
+// UpgradeNotifier is optionally implemented by an UpgradeWatcher that can
+// signal detected upgrades. When available, the orchestrator waits on it
+// instead of polling NeedsUpdate.
+type UpgradeNotifier interface {
+	// Updated returns a channel that is closed once NeedsUpdate would
+	// return true. A fresh channel must be obtained after ClearUpdateFlag.
+	Updated() <-chan struct{}
+}
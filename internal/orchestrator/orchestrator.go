@@ -2,12 +2,18 @@ package orchestrator
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"path/filepath"
 	"sync"
 	"time"
 
+	"github.com/wemix/wemixvisor/internal/config"
 	"github.com/wemix/wemixvisor/internal/height"
+	"github.com/wemix/wemixvisor/internal/journal"
 	"github.com/wemix/wemixvisor/internal/node"
+	"github.com/wemix/wemixvisor/internal/state"
+	"github.com/wemix/wemixvisor/internal/version"
 	"github.com/wemix/wemixvisor/pkg/logger"
 	"github.com/wemix/wemixvisor/pkg/types"
 )
@@ -27,7 +33,8 @@ import (
 // 3. When target height reached, stop node
 // 4. Switch to new binary (symlink management)
 // 5. Restart node with new binary
-// 6. Rollback on failure
+// 6. Verify the node advances past the upgrade height
+// 7. Rollback on failure
 //
 // Thread-safety: All public methods are thread-safe and can be called concurrently.
 type UpgradeOrchestrator struct {
@@ -38,12 +45,28 @@ type UpgradeOrchestrator struct {
 	upgradeWatcher UpgradeWatcher
 	logger         *logger.Logger
 
+	// Optional dependencies (set before Start)
+	preparer       UpgradePreparer
+	stateStore     *state.Store
+	journal        *journal.Journal
+	checks         []UpgradeCheck
+	failureHandler UpgradeFailureHandler
+	stager         UpgradeStager
+
 	// State (protected by mu)
 	pendingUpgrade *types.UpgradeInfo
+	lastUpgrade    *state.CompletedUpgrade
 	upgrading      bool
 	started        bool
 	mu             sync.RWMutex
 
+	// Progress of the current upgrade (protected by mu). stopped is set once
+	// the upgrade takes the node down. previousTarget is the directory
+	// current pointed at before the switch, empty if there was none.
+	stopped        bool
+	switched       bool
+	previousTarget string
+
 	// Lifecycle management
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -51,14 +74,16 @@ type UpgradeOrchestrator struct {
 
 	// Channels for coordination
 	heightCh <-chan int64 // Subscription to height updates
+	haltCh   chan int64   // Heights at which the node halted for an upgrade
 }
 
 // UpgradeStatus represents the current upgrade state.
 type UpgradeStatus struct {
-	PendingUpgrade *types.UpgradeInfo
-	Upgrading      bool
-	CurrentHeight  int64
-	NodeState      node.NodeState
+	PendingUpgrade *types.UpgradeInfo      `json:"pending_upgrade,omitempty"`
+	LastUpgrade    *state.CompletedUpgrade `json:"last_upgrade,omitempty"`
+	Upgrading      bool                    `json:"upgrading"`
+	CurrentHeight  int64                   `json:"current_height"`
+	NodeState      node.NodeState          `json:"node_state"`
 }
 
 // NewUpgradeOrchestrator creates a new UpgradeOrchestrator instance.
@@ -88,11 +113,46 @@ func NewUpgradeOrchestrator(
 		heightMonitor:  heightMonitor,
 		upgradeWatcher: upgradeWatcher,
 		logger:         logger,
+		haltCh:         make(chan int64, 1),
 		ctx:            ctx,
 		cancel:         cancel,
 	}
 }
 
+// SetUpgradePreparer sets the preparer run between stopping the node and
+// switching the binary. It must be called before Start.
+func (uo *UpgradeOrchestrator) SetUpgradePreparer(preparer UpgradePreparer) {
+	uo.mu.Lock()
+	defer uo.mu.Unlock()
+	uo.preparer = preparer
+}
+
+// SetStager sets the stager told about every upgrade scheduled, including
+// a pending upgrade restored on Start. It must be called before Start.
+func (uo *UpgradeOrchestrator) SetStager(stager UpgradeStager) {
+	uo.mu.Lock()
+	defer uo.mu.Unlock()
+	uo.stager = stager
+}
+
+// SetStateStore sets the store through which the pending and last completed
+// upgrade survive a supervisor restart. It must be called before Start,
+// which restores the pending upgrade from it.
+func (uo *UpgradeOrchestrator) SetStateStore(store *state.Store) {
+	uo.mu.Lock()
+	defer uo.mu.Unlock()
+	uo.stateStore = store
+}
+
+// SetJournal sets the write-ahead journal in which each upgrade step is
+// recorded, so that an upgrade interrupted by a crash can be finished or
+// rolled back on the next start. It must be called before Start.
+func (uo *UpgradeOrchestrator) SetJournal(j *journal.Journal) {
+	uo.mu.Lock()
+	defer uo.mu.Unlock()
+	uo.journal = j
+}
+
 // Start begins monitoring for upgrades.
 //
 // Starts two goroutines:
@@ -111,6 +171,8 @@ func (uo *UpgradeOrchestrator) Start() error {
 		return fmt.Errorf("orchestrator already started")
 	}
 
+	uo.restoreState()
+
 	// Subscribe to height updates
 	uo.heightCh = uo.heightMonitor.Subscribe()
 
@@ -150,6 +212,7 @@ func (uo *UpgradeOrchestrator) GetStatus() *UpgradeStatus {
 
 	return &UpgradeStatus{
 		PendingUpgrade: uo.pendingUpgrade,
+		LastUpgrade:    uo.lastUpgrade,
 		Upgrading:      uo.upgrading,
 		CurrentHeight:  uo.heightMonitor.GetCurrentHeight(),
 		NodeState:      uo.nodeManager.GetState(),
@@ -165,41 +228,198 @@ func (uo *UpgradeOrchestrator) ScheduleUpgrade(upgrade *types.UpgradeInfo) error
 	uo.mu.Lock()
 	defer uo.mu.Unlock()
 
+	// A node that halted for an upgrade may still announce it afterwards
+	if uo.alreadyApplied(upgrade) {
+		uo.logger.Info("upgrade already applied, not scheduling it again",
+			"name", upgrade.Name)
+		return nil
+	}
+
 	uo.pendingUpgrade = upgrade
+	uo.persistState()
+	uo.stage(upgrade)
 
 	uo.logger.Info("scheduled upgrade",
+		"name", upgrade.Name,
+		"condition", upgrade.Condition())
+
+	return nil
+}
+
+// NodeHalted executes, without waiting for its activation condition, an
+// upgrade the node stopped itself for. A pending upgrade of the same name
+// keeps its plan; otherwise the announced upgrade replaces it.
+func (uo *UpgradeOrchestrator) NodeHalted(upgrade *types.UpgradeInfo) {
+	uo.mu.Lock()
+	if uo.alreadyApplied(upgrade) {
+		uo.mu.Unlock()
+		uo.logger.Warn("node halted for an upgrade that is already applied",
+			"name", upgrade.Name)
+		return
+	}
+	if uo.pendingUpgrade == nil || uo.pendingUpgrade.Name != upgrade.Name {
+		uo.pendingUpgrade = upgrade
+		uo.persistState()
+	}
+	uo.mu.Unlock()
+
+	uo.logger.Info("node halted for upgrade",
 		"name", upgrade.Name,
 		"height", upgrade.Height)
 
+	select {
+	case uo.haltCh <- upgrade.Height:
+	default:
+	}
+}
+
+// alreadyApplied reports whether upgrade is the last completed upgrade and
+// current still points at its binary.
+// Caller must hold mu.
+func (uo *UpgradeOrchestrator) alreadyApplied(upgrade *types.UpgradeInfo) bool {
+	if uo.lastUpgrade == nil || uo.lastUpgrade.Name != upgrade.Name {
+		return false
+	}
+
+	cfg := uo.configManager.GetConfig()
+	current, err := config.NewSymlinkManager(cfg).CurrentTarget()
+	return err == nil && current == filepath.Clean(cfg.UpgradeDir(upgrade.Name))
+}
+
+// restoreState loads the pending and last completed upgrade saved before a
+// restart. An upgrade scheduled since takes precedence over the saved one.
+// Caller must hold mu.
+func (uo *UpgradeOrchestrator) restoreState() {
+	if uo.stateStore == nil {
+		return
+	}
+
+	st, err := uo.stateStore.Load()
+	if err != nil {
+		uo.logger.Warn("failed to load upgrade state", "error", err)
+		return
+	}
+
+	uo.lastUpgrade = st.Upgrade.Last
+	if uo.pendingUpgrade == nil && st.Upgrade.Pending != nil {
+		uo.pendingUpgrade = st.Upgrade.Pending
+		uo.logger.Info("restored pending upgrade",
+			"name", uo.pendingUpgrade.Name,
+			"height", uo.pendingUpgrade.Height)
+	}
+	uo.stage(uo.pendingUpgrade)
+}
+
+// stage hands upgrade to the stager, if one is set.
+// Caller must hold mu.
+func (uo *UpgradeOrchestrator) stage(upgrade *types.UpgradeInfo) {
+	if uo.stager != nil && upgrade != nil {
+		uo.stager.Stage(upgrade)
+	}
+}
+
+// persistState saves the pending and last completed upgrade.
+// Caller must hold mu.
+func (uo *UpgradeOrchestrator) persistState() {
+	if uo.stateStore == nil {
+		return
+	}
+
+	pending, last := uo.pendingUpgrade, uo.lastUpgrade
+	if err := uo.stateStore.Update(func(st *state.State) {
+		st.Upgrade.Pending = pending
+		st.Upgrade.Last = last
+	}); err != nil {
+		uo.logger.Warn("failed to save upgrade state", "error", err)
+	}
+}
+
+// recordHistory appends a completed upgrade, with the hash of its binary,
+// to the upgrade history that rollback targets are chosen from.
+func (uo *UpgradeOrchestrator) recordHistory(upgrade *types.UpgradeInfo) {
+	uo.mu.RLock()
+	store := uo.stateStore
+	uo.mu.RUnlock()
+	if store == nil {
+		return
+	}
+
+	cfg := uo.configManager.GetConfig()
+	hash, err := version.NewCache(cfg, uo.logger).Hash(cfg.UpgradeBin(upgrade.Name))
+	if err != nil {
+		uo.logger.Warn("failed to hash upgrade binary", "error", err, "upgrade_name", upgrade.Name)
+	}
+
+	if err := store.Update(func(st *state.State) {
+		st.Upgrade.RecordApplied(upgrade.Name, upgrade.Height, hash, time.Now())
+	}); err != nil {
+		uo.logger.Warn("failed to save upgrade history", "error", err)
+	}
+}
+
+// journalRecord applies fn to the upgrade journal, if one is set. Errors
+// are logged and returned.
+func (uo *UpgradeOrchestrator) journalRecord(fn func(j *journal.Journal) error) error {
+	uo.mu.RLock()
+	j := uo.journal
+	uo.mu.RUnlock()
+	if j == nil {
+		return nil
+	}
+
+	if err := fn(j); err != nil {
+		uo.logger.Warn("failed to write upgrade journal", "error", err)
+		return err
+	}
 	return nil
 }
 
+// journalStep records a completed upgrade step in the journal, if one is
+// set.
+func (uo *UpgradeOrchestrator) journalStep(step string) error {
+	return uo.journalRecord(func(j *journal.Journal) error { return j.Step(step) })
+}
+
 // watchUpgradeConfigs monitors upgrade watcher for new upgrade plans.
 //
-// This goroutine continuously checks the UpgradeWatcher for configuration
-// changes and schedules upgrades when detected.
+// This goroutine waits on the UpgradeWatcher for configuration changes,
+// polling it every poll interval unless it implements UpgradeNotifier, and
+// schedules upgrades when detected.
 //
 // The goroutine exits when the context is cancelled (via Stop).
 func (uo *UpgradeOrchestrator) watchUpgradeConfigs() {
 	defer uo.wg.Done()
 
-	cfg := uo.configManager.GetConfig()
-	ticker := time.NewTicker(cfg.PollInterval)
-	defer ticker.Stop()
+	notifier, notifies := uo.upgradeWatcher.(UpgradeNotifier)
+
+	var tick <-chan time.Time
+	if !notifies {
+		cfg := uo.configManager.GetConfig()
+		ticker := time.NewTicker(cfg.PollInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
 
 	for {
+		var updated <-chan struct{}
+		if notifies {
+			updated = notifier.Updated()
+		}
+
 		select {
 		case <-uo.ctx.Done():
 			return
+		case <-updated:
+		case <-tick:
+		}
 
-		case <-ticker.C:
-			if uo.upgradeWatcher.NeedsUpdate() {
-				upgrade := uo.upgradeWatcher.GetCurrentUpgrade()
-				if upgrade != nil {
-					_ = uo.ScheduleUpgrade(upgrade)
-					uo.upgradeWatcher.ClearUpdateFlag()
-				}
+		if uo.upgradeWatcher.NeedsUpdate() {
+			upgrade := uo.upgradeWatcher.GetCurrentUpgrade()
+			if upgrade != nil {
+				_ = uo.ScheduleUpgrade(upgrade)
 			}
+			// A notifier keeps signalling until the flag is cleared
+			uo.upgradeWatcher.ClearUpdateFlag()
 		}
 	}
 }
@@ -207,63 +427,121 @@ func (uo *UpgradeOrchestrator) watchUpgradeConfigs() {
 // monitorHeights monitors blockchain height updates and triggers upgrades.
 //
 // This goroutine listens to height updates from HeightMonitor and
-// triggers the upgrade when the target height is reached.
+// triggers the upgrade once its activation condition is reached. The
+// condition is also checked every poll interval, since a wall-clock
+// activation time can pass without a new block.
 //
 // The goroutine exits when the context is cancelled (via Stop).
 func (uo *UpgradeOrchestrator) monitorHeights() {
 	defer uo.wg.Done()
 
+	cfg := uo.configManager.GetConfig()
+	ticker := time.NewTicker(cfg.PollInterval)
+	defer ticker.Stop()
+
 	for {
+		var currentHeight int64
+		halted := false
 		select {
 		case <-uo.ctx.Done():
 			return
 
-		case currentHeight := <-uo.heightCh:
-			// Check if we have a pending upgrade
-			uo.mu.RLock()
-			pending := uo.pendingUpgrade
-			upgrading := uo.upgrading
-			uo.mu.RUnlock()
+		case currentHeight = <-uo.heightCh:
 
-			if pending == nil || upgrading {
-				continue
-			}
+		case <-ticker.C:
+			currentHeight = uo.heightMonitor.GetCurrentHeight()
 
-			// Check if we've reached the upgrade height
-			if currentHeight >= pending.Height {
-				uo.logger.Info("upgrade height reached, executing upgrade",
-					"current_height", currentHeight,
-					"upgrade_height", pending.Height,
-					"upgrade_name", pending.Name)
-
-				if err := uo.executeUpgrade(pending, currentHeight); err != nil {
-					uo.logger.Error("upgrade failed, attempting rollback",
-						"error", err,
-						"upgrade_name", pending.Name)
-
-					if rollbackErr := uo.rollback(); rollbackErr != nil {
-						uo.logger.Error("rollback failed",
-							"error", rollbackErr)
-					}
-				}
-
-				// Clear the pending upgrade after execution (success or failure)
-				uo.mu.Lock()
-				uo.pendingUpgrade = nil
-				uo.mu.Unlock()
+		case haltHeight := <-uo.haltCh:
+			currentHeight = uo.heightMonitor.GetCurrentHeight()
+			if haltHeight > 0 {
+				currentHeight = haltHeight
 			}
+			halted = true
+		}
+
+		if !uo.checkPending(currentHeight, halted) {
+			return
 		}
 	}
 }
 
+// checkPending executes the pending upgrade if its activation condition is
+// reached at currentHeight, or at once if the node halted for it. Block
+// timestamp conditions are checked against the latest block seen by the
+// height monitor, wall-clock times against the host clock.
+//
+// It returns false if the upgrade was interrupted by shutdown.
+func (uo *UpgradeOrchestrator) checkPending(currentHeight int64, halted bool) bool {
+	// Check if we have a pending upgrade
+	uo.mu.RLock()
+	pending := uo.pendingUpgrade
+	upgrading := uo.upgrading
+	uo.mu.RUnlock()
+
+	if pending == nil || upgrading {
+		return true
+	}
+
+	if !halted && !pending.Reached(currentHeight, uo.heightMonitor.GetCurrentBlockTime(), time.Now()) {
+		return true
+	}
+
+	uo.logger.Info("upgrade condition reached, executing upgrade",
+		"current_height", currentHeight,
+		"condition", pending.Condition(),
+		"upgrade_name", pending.Name)
+
+	// An upgrade activated by time, or by a halt before its height, takes
+	// effect at the current height
+	upgrade := pending
+	if pending.Height == 0 || currentHeight < pending.Height {
+		activated := *pending
+		activated.Height = currentHeight
+		upgrade = &activated
+	}
+
+	err := uo.executeUpgrade(upgrade, currentHeight)
+	if err != nil && uo.ctx.Err() != nil {
+		// Stopping is not a failed upgrade: the journal and the
+		// pending upgrade are left for the next start
+		uo.logger.Warn("upgrade interrupted by shutdown",
+			"error", err,
+			"upgrade_name", upgrade.Name)
+		return false
+	}
+	if err != nil {
+		uo.failUpgrade(upgrade, err)
+	}
+
+	// Clear the pending upgrade after execution (success or failure)
+	uo.mu.Lock()
+	uo.pendingUpgrade = nil
+	if err == nil {
+		uo.lastUpgrade = &state.CompletedUpgrade{
+			Name:        upgrade.Name,
+			Height:      upgrade.Height,
+			CompletedAt: time.Now(),
+		}
+	}
+	uo.persistState()
+	uo.mu.Unlock()
+
+	if err == nil {
+		uo.recordHistory(upgrade)
+		uo.journalRecord((*journal.Journal).Commit)
+	}
+	return true
+}
+
 // executeUpgrade performs the actual upgrade process.
 //
 // Upgrade steps:
 // 1. Validate upgrade plan
 // 2. Stop the current node
-// 3. Switch binary (symlink management)
-// 4. Start node with new binary
-// 5. Rollback on failure
+// 3. Prepare the upgrade (backup, hooks), if a preparer is set
+// 4. Switch binary (symlink management)
+// 5. Start node with new binary
+// 6. Verify the upgraded node
 //
 // Thread-safe: Uses upgrading flag to prevent concurrent upgrades.
 func (uo *UpgradeOrchestrator) executeUpgrade(upgrade *types.UpgradeInfo, currentHeight int64) error {
@@ -274,6 +552,9 @@ func (uo *UpgradeOrchestrator) executeUpgrade(upgrade *types.UpgradeInfo, curren
 		return fmt.Errorf("upgrade already in progress")
 	}
 	uo.upgrading = true
+	uo.stopped = false
+	uo.switched = false
+	uo.previousTarget = ""
 	uo.mu.Unlock()
 
 	// Clear upgrading flag when done
@@ -288,74 +569,302 @@ func (uo *UpgradeOrchestrator) executeUpgrade(upgrade *types.UpgradeInfo, curren
 		return fmt.Errorf("upgrade validation failed: %w", err)
 	}
 
-	// Step 2: Stop the node
-	uo.logger.Info("stopping node for upgrade", "upgrade_name", upgrade.Name)
-	if err := uo.nodeManager.Stop(); err != nil {
-		return fmt.Errorf("failed to stop node: %w", err)
+	if err := uo.journalRecord(func(j *journal.Journal) error {
+		previous, err := config.NewSymlinkManager(uo.configManager.GetConfig()).CurrentTarget()
+		if err != nil {
+			return err
+		}
+		return j.Begin(upgrade, previous)
+	}); err != nil {
+		return fmt.Errorf("failed to journal upgrade: %w", err)
+	}
+
+	// Step 2: Stop the node, unless it halted itself for the upgrade
+	uo.mu.Lock()
+	uo.stopped = true
+	uo.mu.Unlock()
+	if uo.nodeManager.GetState() != node.StateUpgrading {
+		uo.logger.Info("stopping node for upgrade", "upgrade_name", upgrade.Name)
+		if err := uo.nodeManager.Stop(); err != nil {
+			return fmt.Errorf("failed to stop node: %w", err)
+		}
+	}
+	if err := uo.journalStep(journal.StepNodeStopped); err != nil {
+		return err
 	}
 
-	// Step 3: Switch binary
+	// Step 3: Prepare the upgrade
+	uo.mu.RLock()
+	preparer := uo.preparer
+	uo.mu.RUnlock()
+	if preparer != nil {
+		uo.logger.Info("preparing upgrade", "upgrade_name", upgrade.Name)
+		if err := preparer.PrepareUpgrade(upgrade); err != nil {
+			return fmt.Errorf("failed to prepare upgrade: %w", err)
+		}
+	}
+
+	// Step 4: Switch binary
 	uo.logger.Info("switching binary", "upgrade_name", upgrade.Name)
 	if err := uo.switchBinary(upgrade.Name); err != nil {
 		return fmt.Errorf("failed to switch binary: %w", err)
 	}
+	if err := uo.journalStep(journal.StepBinarySwitched); err != nil {
+		return err
+	}
 
-	// Step 4: Start node with new binary
+	// Step 5: Start node with new binary
 	uo.logger.Info("starting node with new binary", "upgrade_name", upgrade.Name)
 	if err := uo.nodeManager.Start(nil); err != nil {
 		return fmt.Errorf("failed to start node: %w", err)
 	}
+	if err := uo.waitForNodeReady(); err != nil {
+		return fmt.Errorf("node did not become ready: %w", err)
+	}
+	if err := uo.journalStep(journal.StepNodeStarted); err != nil {
+		return err
+	}
+
+	// Step 6: Verify the upgraded node
+	if err := uo.verifyUpgrade(upgrade); err != nil {
+		return fmt.Errorf("upgrade verification failed: %w", err)
+	}
+	if err := uo.journalStep(journal.StepVerified); err != nil {
+		return err
+	}
 
 	uo.logger.Info("upgrade completed successfully", "upgrade_name", upgrade.Name)
 	return nil
 }
 
-// rollback reverts to the genesis binary and restarts the node.
+// rollback stops the node if it is still running, restores the binary it
+// ran before the upgrade, and the pre-upgrade backup if configured, and
+// restarts the node.
 //
 // This is called when an upgrade fails to ensure the node can continue
-// operating with the previous binary.
+// operating with the previous binary. If the upgrade failed before the
+// binary was switched, the current link and node data are left as they are.
+// If it failed before the node was stopped, the node is left running.
 //
 // Thread-safe: Protected by internal locks.
 func (uo *UpgradeOrchestrator) rollback() error {
-	uo.logger.Info("rolling back to genesis binary")
+	uo.mu.RLock()
+	stopped := uo.stopped
+	switched := uo.switched
+	uo.mu.RUnlock()
+
+	// A node that halted itself for the upgrade is started again even if
+	// the upgrade failed before taking it down
+	nodeState := uo.nodeManager.GetState()
+	if !stopped && nodeState != node.StateUpgrading {
+		uo.logger.Info("upgrade failed before the node was stopped, leaving it running")
+		return nil
+	}
+
+	uo.logger.Info("rolling back to previous binary")
 
-	// Switch back to genesis binary
-	if err := uo.switchBinary("genesis"); err != nil {
-		return fmt.Errorf("failed to switch to genesis binary: %w", err)
+	if nodeState.IsActive() {
+		if err := uo.nodeManager.Stop(); err != nil {
+			return fmt.Errorf("failed to stop upgraded node: %w", err)
+		}
 	}
 
-	// Restart node with genesis binary
+	if err := uo.restoreBinary(); err != nil {
+		return fmt.Errorf("failed to restore previous binary: %w", err)
+	}
+	if switched {
+		if err := uo.restoreBackup(); err != nil {
+			return fmt.Errorf("failed to restore pre-upgrade backup: %w", err)
+		}
+	}
+
+	// Restart node with the previous binary
 	if err := uo.nodeManager.Start(nil); err != nil {
 		return fmt.Errorf("failed to restart node after rollback: %w", err)
 	}
+	if err := uo.waitForNodeReady(); err != nil {
+		return fmt.Errorf("node did not become ready after rollback: %w", err)
+	}
 
 	uo.logger.Info("rollback completed successfully")
 	return nil
 }
 
-// switchBinary updates the symlink to point to the new binary.
+// Rollback stops the node, points the current symlink at an earlier binary
+// and starts the node again. An empty target rolls back to the last
+// known-good upgrade in the upgrade history; see RollbackBinary. If the
+// rollback fails, the node is restarted on the binary it was running.
+//
+// Requires a state store, which holds the upgrade history.
+func (uo *UpgradeOrchestrator) Rollback(target string) (*RollbackResult, error) {
+	uo.mu.Lock()
+	if uo.stateStore == nil {
+		uo.mu.Unlock()
+		return nil, fmt.Errorf("upgrade history is not available")
+	}
+	if uo.upgrading {
+		uo.mu.Unlock()
+		return nil, fmt.Errorf("upgrade already in progress")
+	}
+	uo.upgrading = true
+	store := uo.stateStore
+	uo.mu.Unlock()
+
+	defer func() {
+		uo.mu.Lock()
+		uo.upgrading = false
+		uo.mu.Unlock()
+	}()
+
+	uo.logger.Info("stopping node for rollback", "target", target)
+	if err := uo.nodeManager.Stop(); err != nil {
+		return nil, fmt.Errorf("failed to stop node: %w", err)
+	}
+
+	result, err := RollbackBinary(uo.configManager.GetConfig(), store, uo.logger, target)
+	if err != nil {
+		if startErr := uo.nodeManager.Start(nil); startErr != nil {
+			uo.logger.Error("failed to restart node after failed rollback", "error", startErr)
+		}
+		return nil, err
+	}
+
+	if err := uo.nodeManager.Start(nil); err != nil {
+		return nil, fmt.Errorf("failed to start node after rollback: %w", err)
+	}
+	if err := uo.waitForNodeReady(); err != nil {
+		return nil, fmt.Errorf("node did not become ready after rollback: %w", err)
+	}
+
+	uo.logger.Info("rollback completed successfully", "from", result.From, "to", result.To)
+	return result, nil
+}
+
+// waitForNodeReady waits until a freshly started node is serving.
+//
+// Node managers that do not implement ReadinessWaiter are considered ready
+// as soon as Start returns. The wait is bounded by the node manager's own
+// startup timeout and by the orchestrator's lifetime.
+func (uo *UpgradeOrchestrator) waitForNodeReady() error {
+	waiter, ok := uo.nodeManager.(ReadinessWaiter)
+	if !ok {
+		return nil
+	}
+
+	uo.logger.Info("waiting for node readiness")
+	if err := waiter.WaitReady(uo.ctx); err != nil {
+		return err
+	}
+
+	uo.logger.Info("node is ready")
+	return nil
+}
+
+// switchBinary atomically points the current symlink at an upgrade.
+//
+// The upgrade binary must exist and be executable. The directory current
+// pointed at before is recorded so that rollback can restore it exactly.
 //
 // Parameters:
 //   - upgradeName: Name of the upgrade (e.g., "v1.2.0")
 //
 // Returns an error if the binary switch fails.
 func (uo *UpgradeOrchestrator) switchBinary(upgradeName string) error {
-	// TODO: Implement actual symlink switching in Phase 8.5
-	// For now, this is a placeholder that passes tests
-	uo.logger.Info("binary switched", "upgrade_name", upgradeName)
+	cfg := uo.configManager.GetConfig()
+
+	if err := config.CheckExecutable(cfg.UpgradeBin(upgradeName)); err != nil {
+		return fmt.Errorf("upgrade binary is not usable: %w", err)
+	}
+
+	symlinks := config.NewSymlinkManager(cfg)
+	previous, err := symlinks.CurrentTarget()
+	if err != nil {
+		return err
+	}
+
+	if err := symlinks.LinkToUpgrade(upgradeName); err != nil {
+		return err
+	}
+
+	uo.mu.Lock()
+	uo.switched = true
+	uo.previousTarget = previous
+	uo.mu.Unlock()
+
+	uo.logger.Info("binary switched",
+		"upgrade_name", upgradeName,
+		"previous_target", previous)
 	return nil
 }
 
+// restoreBinary points the current symlink back at the directory recorded
+// by switchBinary. If current did not exist before the switch, it returns
+// to the last known-good upgrade in the upgrade history, or to genesis
+// without one.
+func (uo *UpgradeOrchestrator) restoreBinary() error {
+	uo.mu.RLock()
+	switched := uo.switched
+	previous := uo.previousTarget
+	store := uo.stateStore
+	uo.mu.RUnlock()
+
+	if !switched {
+		return nil
+	}
+
+	cfg := uo.configManager.GetConfig()
+	symlinks := config.NewSymlinkManager(cfg)
+	switch {
+	case previous != "":
+		if err := symlinks.LinkTo(previous); err != nil {
+			return err
+		}
+		uo.logger.Info("binary restored", "target", previous)
+
+	case store != nil && uo.restoreKnownGood(cfg, store):
+
+	default:
+		uo.logger.Info("no previous binary recorded, restoring genesis")
+		if err := symlinks.LinkToGenesis(); err != nil {
+			return err
+		}
+	}
+
+	uo.mu.Lock()
+	uo.switched = false
+	uo.mu.Unlock()
+	return nil
+}
+
+// restoreKnownGood points the current symlink at the last known-good
+// upgrade in the upgrade history, reporting whether it did
+func (uo *UpgradeOrchestrator) restoreKnownGood(cfg *config.Config, store *state.Store) bool {
+	if _, err := RollbackBinary(cfg, store, uo.logger, ""); err != nil {
+		if !errors.Is(err, ErrNoKnownGood) {
+			uo.logger.Warn("failed to restore last known-good binary", "error", err)
+		}
+		return false
+	}
+	return true
+}
+
 // validateUpgrade validates an upgrade plan before execution.
 //
 // Checks:
 // - Upgrade name is not empty
-// - Target height is valid
+// - Target height is valid, unless an activation time is set
 // - Binary exists for the upgrade
 // - Current height hasn't exceeded target height
 //
 // Returns an error if validation fails.
 func (uo *UpgradeOrchestrator) validateUpgrade(upgrade *types.UpgradeInfo, currentHeight int64) error {
+	return ValidateUpgrade(upgrade, currentHeight)
+}
+
+// ValidateUpgrade runs the checks the orchestrator applies to an upgrade
+// plan before executing it at currentHeight. Upgrade rehearsals use it to
+// check a plan without executing it.
+func ValidateUpgrade(upgrade *types.UpgradeInfo, currentHeight int64) error {
 	if upgrade == nil {
 		return fmt.Errorf("upgrade info is nil")
 	}
@@ -364,11 +873,11 @@ func (uo *UpgradeOrchestrator) validateUpgrade(upgrade *types.UpgradeInfo, curre
 		return fmt.Errorf("upgrade name is empty")
 	}
 
-	if upgrade.Height <= 0 {
+	if upgrade.Height < 0 || (upgrade.Height == 0 && !upgrade.HasTime()) {
 		return fmt.Errorf("upgrade height must be positive, got %d", upgrade.Height)
 	}
 
-	if currentHeight > upgrade.Height {
+	if upgrade.Height > 0 && currentHeight > upgrade.Height {
 		return fmt.Errorf("current height %d has already exceeded upgrade height %d",
 			currentHeight, upgrade.Height)
 	}
@@ -1,6 +1,10 @@
 package orchestrator
 
 import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -9,7 +13,10 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/wemix/wemixvisor/internal/config"
 	"github.com/wemix/wemixvisor/internal/height"
+	"github.com/wemix/wemixvisor/internal/journal"
 	"github.com/wemix/wemixvisor/internal/node"
+	"github.com/wemix/wemixvisor/internal/state"
+	"github.com/wemix/wemixvisor/internal/version"
 	"github.com/wemix/wemixvisor/pkg/logger"
 	"github.com/wemix/wemixvisor/pkg/types"
 )
@@ -107,6 +114,14 @@ func (m *MockNodeManager) SetStopError(err error) {
 	m.stopErr = err
 }
 
+// SetState sets the state reported by GetState.
+func (m *MockNodeManager) SetState(state node.NodeState) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.state = state
+	m.status.State = state
+}
+
 // GetStartCalls returns the number of times Start was called.
 func (m *MockNodeManager) GetStartCalls() int {
 	m.mu.Lock()
@@ -131,6 +146,29 @@ func (m *MockNodeManager) GetStartArgs() [][]string {
 	return result
 }
 
+// MockReadyNodeManager is a MockNodeManager that also implements
+// ReadinessWaiter.
+type MockReadyNodeManager struct {
+	*MockNodeManager
+	readyErr   error
+	readyCalls int
+}
+
+// WaitReady implements ReadinessWaiter interface.
+func (m *MockReadyNodeManager) WaitReady(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.readyCalls++
+	return m.readyErr
+}
+
+// GetReadyCalls returns the number of times WaitReady was called.
+func (m *MockReadyNodeManager) GetReadyCalls() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.readyCalls
+}
+
 // MockConfigManager is a mock implementation of ConfigManager for testing.
 type MockConfigManager struct {
 	mu     sync.Mutex
@@ -148,6 +186,30 @@ func NewMockConfigManager() *MockConfigManager {
 	}
 }
 
+// newHomeConfigManager creates a MockConfigManager whose home is a temp dir
+// holding executable genesis and upgrade binaries, with current linked to
+// genesis.
+func newHomeConfigManager(t *testing.T, upgrades ...string) *MockConfigManager {
+	t.Helper()
+	cfg := &config.Config{
+		Home:         t.TempDir(),
+		Name:         "wemix",
+		PollInterval: 1 * time.Second,
+	}
+
+	writeBin := func(path string) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"), 0755))
+	}
+	writeBin(cfg.GenesisBin())
+	for _, name := range upgrades {
+		writeBin(cfg.UpgradeBin(name))
+	}
+	require.NoError(t, config.NewSymlinkManager(cfg).LinkToGenesis())
+
+	return &MockConfigManager{config: cfg}
+}
+
 // GetConfig implements ConfigManager interface.
 func (m *MockConfigManager) GetConfig() *config.Config {
 	m.mu.Lock()
@@ -212,6 +274,50 @@ func (m *MockUpgradeWatcher) GetClearCalls() int {
 	return m.clearCalls
 }
 
+// MockNotifyingWatcher is a MockUpgradeWatcher that also implements
+// UpgradeNotifier.
+type MockNotifyingWatcher struct {
+	*MockUpgradeWatcher
+	updated chan struct{}
+}
+
+// NewMockNotifyingWatcher creates a new MockNotifyingWatcher.
+func NewMockNotifyingWatcher() *MockNotifyingWatcher {
+	return &MockNotifyingWatcher{
+		MockUpgradeWatcher: NewMockUpgradeWatcher(),
+		updated:            make(chan struct{}),
+	}
+}
+
+// Updated implements UpgradeNotifier interface.
+func (m *MockNotifyingWatcher) Updated() <-chan struct{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.updated
+}
+
+// SetUpgrade sets a new upgrade and signals it.
+func (m *MockNotifyingWatcher) SetUpgrade(upgrade *types.UpgradeInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.upgrade = upgrade
+	if !m.needsUpdate {
+		m.needsUpdate = true
+		close(m.updated)
+	}
+}
+
+// ClearUpdateFlag implements UpgradeWatcher interface.
+func (m *MockNotifyingWatcher) ClearUpdateFlag() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.needsUpdate {
+		m.updated = make(chan struct{})
+	}
+	m.needsUpdate = false
+	m.clearCalls++
+}
+
 // MockHeightProvider is a mock implementation for testing HeightMonitor.
 type MockHeightProvider struct {
 	mu     sync.Mutex
@@ -247,6 +353,28 @@ func (m *MockHeightProvider) SetError(err error) {
 	m.err = err
 }
 
+// MockBlockTimeProvider is a MockHeightProvider that also reports block
+// timestamps, implementing height.BlockTimeProvider.
+type MockBlockTimeProvider struct {
+	*MockHeightProvider
+	blockTime time.Time
+}
+
+// GetBlockTime implements height.BlockTimeProvider interface.
+func (m *MockBlockTimeProvider) GetBlockTime(height int64) (time.Time, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.blockTime, nil
+}
+
+// SetBlock updates the mock height and the timestamp of its block.
+func (m *MockBlockTimeProvider) SetBlock(height int64, blockTime time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.height = height
+	m.blockTime = blockTime
+}
+
 // Test helper function to create a test logger
 func newTestLogger() *logger.Logger {
 	log, err := logger.New(false, false, "")
@@ -453,6 +581,65 @@ func TestUpgradeOrchestrator_ScheduleUpgrade_Success(t *testing.T) {
 	assert.Equal(t, int64(2000), status.PendingUpgrade.Height, "upgrade height should match")
 }
 
+// MockStager records the upgrades handed to it for staging.
+type MockStager struct {
+	mu     sync.Mutex
+	staged []string
+}
+
+// Stage implements UpgradeStager interface.
+func (m *MockStager) Stage(upgrade *types.UpgradeInfo) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.staged = append(m.staged, upgrade.Name)
+}
+
+// GetStaged returns the names of the upgrades staged.
+func (m *MockStager) GetStaged() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string(nil), m.staged...)
+}
+
+func TestUpgradeOrchestrator_ScheduleUpgrade_Stages(t *testing.T) {
+	// Arrange
+	store := state.NewStore(&config.Config{Home: t.TempDir()})
+	heightMonitor := height.NewHeightMonitor(NewMockHeightProvider(1000), time.Second, newTestLogger())
+
+	first := NewUpgradeOrchestrator(
+		NewMockNodeManager(),
+		NewMockConfigManager(),
+		heightMonitor,
+		NewMockUpgradeWatcher(),
+		newTestLogger(),
+	)
+	firstStager := &MockStager{}
+	first.SetStager(firstStager)
+	first.SetStateStore(store)
+
+	// Act
+	require.NoError(t, first.ScheduleUpgrade(&types.UpgradeInfo{Name: "v1.2.0", Height: 2000}))
+
+	// Assert
+	assert.Equal(t, []string{"v1.2.0"}, firstStager.GetStaged(), "a scheduled upgrade should be staged")
+
+	// A pending upgrade restored on start is staged too
+	second := NewUpgradeOrchestrator(
+		NewMockNodeManager(),
+		NewMockConfigManager(),
+		heightMonitor,
+		NewMockUpgradeWatcher(),
+		newTestLogger(),
+	)
+	secondStager := &MockStager{}
+	second.SetStager(secondStager)
+	second.SetStateStore(store)
+	require.NoError(t, second.Start())
+	defer second.Stop()
+
+	assert.Equal(t, []string{"v1.2.0"}, secondStager.GetStaged(), "a restored upgrade should be staged")
+}
+
 func TestUpgradeOrchestrator_ScheduleUpgrade_ReplacesPending(t *testing.T) {
 	// Arrange
 	nodeManager := NewMockNodeManager()
@@ -552,7 +739,7 @@ func TestUpgradeOrchestrator_ScheduleUpgrade_Concurrent(t *testing.T) {
 func TestUpgradeOrchestrator_TriggersAtExactHeight(t *testing.T) {
 	// Arrange
 	nodeManager := NewMockNodeManager()
-	configManager := NewMockConfigManager()
+	configManager := newHomeConfigManager(t, "v1.2.0")
 	heightProvider := NewMockHeightProvider(1000)
 	heightMonitor := height.NewHeightMonitor(heightProvider, 50*time.Millisecond, newTestLogger())
 	upgradeWatcher := NewMockUpgradeWatcher()
@@ -640,10 +827,172 @@ func TestUpgradeOrchestrator_DoesNotTriggerBeforeHeight(t *testing.T) {
 	assert.Equal(t, 0, nodeManager.GetStopCalls(), "node should not be stopped before target height")
 }
 
+func TestUpgradeOrchestrator_TriggersAtTime(t *testing.T) {
+	// Arrange
+	nodeManager := NewMockNodeManager()
+	configManager := newHomeConfigManager(t, "v1.2.0")
+	configManager.config.PollInterval = 50 * time.Millisecond
+	heightProvider := NewMockHeightProvider(1000)
+	heightMonitor := height.NewHeightMonitor(heightProvider, 50*time.Millisecond, newTestLogger())
+
+	orchestrator := NewUpgradeOrchestrator(
+		nodeManager,
+		configManager,
+		heightMonitor,
+		NewMockUpgradeWatcher(),
+		newTestLogger(),
+	)
+
+	activation := time.Now().Add(300 * time.Millisecond)
+	upgrade := &types.UpgradeInfo{
+		Name: "v1.2.0",
+		Time: &activation,
+	}
+
+	// Act
+	require.NoError(t, orchestrator.ScheduleUpgrade(upgrade))
+	require.NoError(t, heightMonitor.Start())
+	defer heightMonitor.Stop()
+	require.NoError(t, orchestrator.Start())
+	defer orchestrator.Stop()
+
+	// Assert: the height never changes, the clock alone triggers the upgrade
+	time.Sleep(150 * time.Millisecond)
+	assert.Equal(t, 0, nodeManager.GetStopCalls(), "node should not be stopped before the activation time")
+
+	require.Eventually(t, func() bool {
+		return orchestrator.GetStatus().LastUpgrade != nil
+	}, 2*time.Second, 20*time.Millisecond, "upgrade should complete after the activation time")
+	assert.GreaterOrEqual(t, nodeManager.GetStartCalls(), 1, "node should be restarted after upgrade")
+	assert.Equal(t, int64(1000), orchestrator.GetStatus().LastUpgrade.Height,
+		"a time-based upgrade should be recorded at the height it activated")
+}
+
+func TestUpgradeOrchestrator_NodeHalted(t *testing.T) {
+	// Arrange
+	nodeManager := NewMockNodeManager()
+	nodeManager.SetState(node.StateUpgrading)
+	configManager := newHomeConfigManager(t, "v1.2.0")
+	heightProvider := NewMockHeightProvider(1000)
+	heightMonitor := height.NewHeightMonitor(heightProvider, 50*time.Millisecond, newTestLogger())
+
+	orchestrator := NewUpgradeOrchestrator(
+		nodeManager,
+		configManager,
+		heightMonitor,
+		NewMockUpgradeWatcher(),
+		newTestLogger(),
+	)
+
+	require.NoError(t, heightMonitor.Start())
+	defer heightMonitor.Stop()
+	require.NoError(t, orchestrator.Start())
+	defer orchestrator.Stop()
+
+	// Act: the node halts at 1500 although the monitor is still at 1000
+	orchestrator.NodeHalted(&types.UpgradeInfo{Name: "v1.2.0", Height: 1500})
+
+	// Assert
+	require.Eventually(t, func() bool {
+		return orchestrator.GetStatus().LastUpgrade != nil
+	}, 2*time.Second, 20*time.Millisecond, "a halt should trigger the upgrade at once")
+	assert.Equal(t, int64(1500), orchestrator.GetStatus().LastUpgrade.Height)
+	assert.Equal(t, 0, nodeManager.GetStopCalls(), "a halted node should not be stopped")
+	assert.GreaterOrEqual(t, nodeManager.GetStartCalls(), 1, "node should be started on the new binary")
+
+	// Announcing the applied upgrade again does not repeat it
+	require.NoError(t, orchestrator.ScheduleUpgrade(&types.UpgradeInfo{Name: "v1.2.0", Height: 1500}))
+	assert.Nil(t, orchestrator.GetStatus().PendingUpgrade)
+}
+
+func TestUpgradeOrchestrator_WaitsOnUpgradeNotifier(t *testing.T) {
+	// Arrange
+	nodeManager := NewMockNodeManager()
+	configManager := newHomeConfigManager(t, "v1.2.0")
+	// Polling alone would not pick the upgrade up during the test
+	configManager.config.PollInterval = time.Hour
+	heightProvider := NewMockHeightProvider(1000)
+	heightMonitor := height.NewHeightMonitor(heightProvider, 50*time.Millisecond, newTestLogger())
+	watcher := NewMockNotifyingWatcher()
+
+	orchestrator := NewUpgradeOrchestrator(
+		nodeManager,
+		configManager,
+		heightMonitor,
+		watcher,
+		newTestLogger(),
+	)
+
+	require.NoError(t, heightMonitor.Start())
+	defer heightMonitor.Stop()
+	require.NoError(t, orchestrator.Start())
+	defer orchestrator.Stop()
+
+	// Act
+	watcher.SetUpgrade(&types.UpgradeInfo{Name: "v1.2.0", Height: 2000})
+
+	// Assert
+	require.Eventually(t, func() bool {
+		return orchestrator.GetStatus().PendingUpgrade != nil
+	}, time.Second, 10*time.Millisecond, "a notified upgrade should be scheduled at once")
+	assert.Equal(t, "v1.2.0", orchestrator.GetStatus().PendingUpgrade.Name)
+	assert.Eventually(t, func() bool {
+		return !watcher.NeedsUpdate() && watcher.GetClearCalls() == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestUpgradeOrchestrator_TriggersAtBlockTime(t *testing.T) {
+	// Arrange
+	nodeManager := NewMockNodeManager()
+	configManager := newHomeConfigManager(t, "v1.2.0")
+	configManager.config.PollInterval = 50 * time.Millisecond
+	activation := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	heightProvider := &MockBlockTimeProvider{
+		MockHeightProvider: NewMockHeightProvider(1000),
+		blockTime:          activation.Add(-time.Minute),
+	}
+	heightMonitor := height.NewHeightMonitor(heightProvider, 50*time.Millisecond, newTestLogger())
+
+	orchestrator := NewUpgradeOrchestrator(
+		nodeManager,
+		configManager,
+		heightMonitor,
+		NewMockUpgradeWatcher(),
+		newTestLogger(),
+	)
+
+	// The activation time has long passed on the host clock, but only the
+	// block timestamp counts
+	upgrade := &types.UpgradeInfo{
+		Name:      "v1.2.0",
+		BlockTime: &activation,
+	}
+
+	// Act
+	require.NoError(t, orchestrator.ScheduleUpgrade(upgrade))
+	require.NoError(t, heightMonitor.Start())
+	defer heightMonitor.Stop()
+	require.NoError(t, orchestrator.Start())
+	defer orchestrator.Stop()
+
+	time.Sleep(100 * time.Millisecond)
+	heightProvider.SetBlock(1001, activation.Add(-time.Second))
+	time.Sleep(200 * time.Millisecond)
+
+	// Assert
+	assert.Equal(t, 0, nodeManager.GetStopCalls(), "node should not be stopped before the block time")
+
+	heightProvider.SetBlock(1002, activation)
+	require.Eventually(t, func() bool {
+		return orchestrator.GetStatus().LastUpgrade != nil
+	}, 2*time.Second, 20*time.Millisecond, "upgrade should complete once a block reaches the activation time")
+	assert.Equal(t, int64(1002), orchestrator.GetStatus().LastUpgrade.Height)
+}
+
 func TestUpgradeOrchestrator_TriggersOnlyOnce(t *testing.T) {
 	// Arrange
 	nodeManager := NewMockNodeManager()
-	configManager := NewMockConfigManager()
+	configManager := newHomeConfigManager(t, "v1.2.0")
 	heightProvider := NewMockHeightProvider(1000)
 	heightMonitor := height.NewHeightMonitor(heightProvider, 30*time.Millisecond, newTestLogger())
 	upgradeWatcher := NewMockUpgradeWatcher()
@@ -783,3 +1132,970 @@ func TestValidateUpgrade_HeightAlreadyPassed(t *testing.T) {
 	assert.Error(t, err, "past height should fail validation")
 	assert.Contains(t, err.Error(), "exceeded", "error should mention exceeded")
 }
+
+func TestValidateUpgrade_TimeWithoutHeight(t *testing.T) {
+	// Arrange
+	orchestrator := &UpgradeOrchestrator{}
+	activation := time.Now()
+
+	upgrade := &types.UpgradeInfo{
+		Name: "v1.2.0",
+		Time: &activation,
+	}
+	currentHeight := int64(1500)
+
+	// Act
+	err := orchestrator.validateUpgrade(upgrade, currentHeight)
+
+	// Assert
+	assert.NoError(t, err, "time-based upgrade should not need a height")
+}
+
+// =============================================================================
+// Test: Readiness
+// =============================================================================
+
+func TestExecuteUpgrade_WaitsForReadiness(t *testing.T) {
+	// Arrange
+	nodeManager := &MockReadyNodeManager{MockNodeManager: NewMockNodeManager()}
+	heightMonitor := height.NewHeightMonitor(NewMockHeightProvider(1000), time.Second, newTestLogger())
+	orchestrator := NewUpgradeOrchestrator(
+		nodeManager,
+		newHomeConfigManager(t, "v1.2.0"),
+		heightMonitor,
+		NewMockUpgradeWatcher(),
+		newTestLogger(),
+	)
+
+	upgrade := &types.UpgradeInfo{Name: "v1.2.0", Height: 1000}
+
+	// Act
+	err := orchestrator.executeUpgrade(upgrade, 1000)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 1, nodeManager.GetReadyCalls(), "upgrade should wait for node readiness")
+}
+
+func TestExecuteUpgrade_FailsWhenNodeNotReady(t *testing.T) {
+	// Arrange
+	nodeManager := &MockReadyNodeManager{
+		MockNodeManager: NewMockNodeManager(),
+		readyErr:        errors.New("startup timeout"),
+	}
+	heightMonitor := height.NewHeightMonitor(NewMockHeightProvider(1000), time.Second, newTestLogger())
+	orchestrator := NewUpgradeOrchestrator(
+		nodeManager,
+		newHomeConfigManager(t, "v1.2.0"),
+		heightMonitor,
+		NewMockUpgradeWatcher(),
+		newTestLogger(),
+	)
+
+	upgrade := &types.UpgradeInfo{Name: "v1.2.0", Height: 1000}
+
+	// Act
+	err := orchestrator.executeUpgrade(upgrade, 1000)
+
+	// Assert
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "did not become ready")
+	assert.Contains(t, err.Error(), "startup timeout")
+}
+
+// =============================================================================
+// Test: Binary Switching
+// =============================================================================
+
+func TestExecuteUpgrade_SwitchesCurrentLink(t *testing.T) {
+	// Arrange
+	configManager := newHomeConfigManager(t, "v1.2.0")
+	cfg := configManager.GetConfig()
+	heightMonitor := height.NewHeightMonitor(NewMockHeightProvider(1000), time.Second, newTestLogger())
+	orchestrator := NewUpgradeOrchestrator(
+		NewMockNodeManager(),
+		configManager,
+		heightMonitor,
+		NewMockUpgradeWatcher(),
+		newTestLogger(),
+	)
+
+	// Act
+	err := orchestrator.executeUpgrade(&types.UpgradeInfo{Name: "v1.2.0", Height: 1000}, 1000)
+
+	// Assert
+	require.NoError(t, err)
+	target, err := config.NewSymlinkManager(cfg).CurrentTarget()
+	require.NoError(t, err)
+	assert.Equal(t, cfg.UpgradeDir("v1.2.0"), target)
+	assert.FileExists(t, cfg.CurrentBin())
+}
+
+func TestExecuteUpgrade_FailsWithoutExecutableBinary(t *testing.T) {
+	tests := []struct {
+		name    string
+		prepare func(cfg *config.Config)
+		errMsg  string
+	}{
+		{
+			name:    "missing binary",
+			prepare: func(cfg *config.Config) { require.NoError(t, os.Remove(cfg.UpgradeBin("v1.2.0"))) },
+			errMsg:  "binary not found",
+		},
+		{
+			name:    "not executable",
+			prepare: func(cfg *config.Config) { require.NoError(t, os.Chmod(cfg.UpgradeBin("v1.2.0"), 0644)) },
+			errMsg:  "not executable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			nodeManager := NewMockNodeManager()
+			configManager := newHomeConfigManager(t, "v1.2.0")
+			cfg := configManager.GetConfig()
+			tt.prepare(cfg)
+			heightMonitor := height.NewHeightMonitor(NewMockHeightProvider(1000), time.Second, newTestLogger())
+			orchestrator := NewUpgradeOrchestrator(
+				nodeManager,
+				configManager,
+				heightMonitor,
+				NewMockUpgradeWatcher(),
+				newTestLogger(),
+			)
+
+			// Act
+			err := orchestrator.executeUpgrade(&types.UpgradeInfo{Name: "v1.2.0", Height: 1000}, 1000)
+
+			// Assert
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "failed to switch binary")
+			assert.Contains(t, err.Error(), tt.errMsg)
+			assert.Equal(t, 0, nodeManager.GetStartCalls(), "node should not start without a usable binary")
+
+			target, err := config.NewSymlinkManager(cfg).CurrentTarget()
+			require.NoError(t, err)
+			assert.Equal(t, cfg.GenesisDir(), target, "current should be left untouched")
+		})
+	}
+}
+
+func TestRollback_RestoresPreviousTarget(t *testing.T) {
+	// Arrange
+	nodeManager := &MockReadyNodeManager{
+		MockNodeManager: NewMockNodeManager(),
+		readyErr:        errors.New("startup timeout"),
+	}
+	configManager := newHomeConfigManager(t, "v1.1.0", "v1.2.0")
+	cfg := configManager.GetConfig()
+	require.NoError(t, config.NewSymlinkManager(cfg).LinkToUpgrade("v1.1.0"))
+	heightMonitor := height.NewHeightMonitor(NewMockHeightProvider(1000), time.Second, newTestLogger())
+	orchestrator := NewUpgradeOrchestrator(
+		nodeManager,
+		configManager,
+		heightMonitor,
+		NewMockUpgradeWatcher(),
+		newTestLogger(),
+	)
+
+	err := orchestrator.executeUpgrade(&types.UpgradeInfo{Name: "v1.2.0", Height: 1000}, 1000)
+	require.Error(t, err)
+	nodeManager.mu.Lock()
+	nodeManager.readyErr = nil
+	nodeManager.mu.Unlock()
+
+	// Act
+	err = orchestrator.rollback()
+
+	// Assert
+	require.NoError(t, err)
+	link, err := os.Readlink(cfg.CurrentDir())
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(config.UpgradesDirName, "v1.1.0"), link, "current should point at the prior upgrade, not genesis")
+	assert.Equal(t, 2, nodeManager.GetStartCalls())
+}
+
+func TestRollback_KeepsLinkWhenNotSwitched(t *testing.T) {
+	// Arrange
+	nodeManager := NewMockNodeManager()
+	configManager := newHomeConfigManager(t, "v1.1.0", "v1.2.0")
+	cfg := configManager.GetConfig()
+	require.NoError(t, config.NewSymlinkManager(cfg).LinkToUpgrade("v1.1.0"))
+	heightMonitor := height.NewHeightMonitor(NewMockHeightProvider(1000), time.Second, newTestLogger())
+	orchestrator := NewUpgradeOrchestrator(
+		nodeManager,
+		configManager,
+		heightMonitor,
+		NewMockUpgradeWatcher(),
+		newTestLogger(),
+	)
+	orchestrator.SetUpgradePreparer(&MockUpgradePreparer{
+		nodeManager: nodeManager,
+		err:         errors.New("backup failed"),
+	})
+
+	err := orchestrator.executeUpgrade(&types.UpgradeInfo{Name: "v1.2.0", Height: 1000}, 1000)
+	require.Error(t, err)
+
+	// Act
+	err = orchestrator.rollback()
+
+	// Assert
+	require.NoError(t, err)
+	target, err := config.NewSymlinkManager(cfg).CurrentTarget()
+	require.NoError(t, err)
+	assert.Equal(t, cfg.UpgradeDir("v1.1.0"), target)
+	assert.Equal(t, 1, nodeManager.GetStartCalls(), "node should restart on the unchanged binary")
+}
+
+func TestRollback_LeavesNodeRunningAfterValidationFailure(t *testing.T) {
+	// Arrange
+	nodeManager := NewMockNodeManager()
+	require.NoError(t, nodeManager.Start(nil))
+	pid := nodeManager.GetStatus().PID
+	heightMonitor := height.NewHeightMonitor(NewMockHeightProvider(2000), time.Second, newTestLogger())
+	orchestrator := NewUpgradeOrchestrator(
+		nodeManager,
+		newHomeConfigManager(t, "v1.2.0"),
+		heightMonitor,
+		NewMockUpgradeWatcher(),
+		newTestLogger(),
+	)
+	upgrade := &types.UpgradeInfo{Name: "v1.2.0", Height: 1000}
+
+	err := orchestrator.executeUpgrade(upgrade, 2000)
+	require.Error(t, err)
+
+	// Act
+	orchestrator.failUpgrade(upgrade, err)
+
+	// Assert
+	assert.Equal(t, node.StateRunning, nodeManager.GetState())
+	assert.Equal(t, pid, nodeManager.GetStatus().PID, "node should keep running as it was")
+	assert.Equal(t, 0, nodeManager.GetStopCalls())
+	assert.Equal(t, 1, nodeManager.GetStartCalls())
+}
+
+// =============================================================================
+// Test: Upgrade Preparation
+// =============================================================================
+
+// MockUpgradePreparer is a mock implementation of UpgradePreparer for testing.
+type MockUpgradePreparer struct {
+	nodeManager *MockNodeManager
+	err         error
+	prepared    []string
+	stopCalls   int
+}
+
+// PrepareUpgrade implements UpgradePreparer interface.
+func (m *MockUpgradePreparer) PrepareUpgrade(upgrade *types.UpgradeInfo) error {
+	m.prepared = append(m.prepared, upgrade.Name)
+	m.stopCalls = m.nodeManager.GetStopCalls()
+	return m.err
+}
+
+func TestExecuteUpgrade_PreparesAfterStoppingNode(t *testing.T) {
+	// Arrange
+	nodeManager := NewMockNodeManager()
+	preparer := &MockUpgradePreparer{nodeManager: nodeManager}
+	heightMonitor := height.NewHeightMonitor(NewMockHeightProvider(1000), time.Second, newTestLogger())
+	orchestrator := NewUpgradeOrchestrator(
+		nodeManager,
+		newHomeConfigManager(t, "v1.2.0"),
+		heightMonitor,
+		NewMockUpgradeWatcher(),
+		newTestLogger(),
+	)
+	orchestrator.SetUpgradePreparer(preparer)
+
+	upgrade := &types.UpgradeInfo{Name: "v1.2.0", Height: 1000}
+
+	// Act
+	err := orchestrator.executeUpgrade(upgrade, 1000)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v1.2.0"}, preparer.prepared)
+	assert.Equal(t, 1, preparer.stopCalls, "node should be stopped before preparing")
+	assert.Equal(t, 1, nodeManager.GetStartCalls())
+}
+
+func TestExecuteUpgrade_FailsWhenPreparationFails(t *testing.T) {
+	// Arrange
+	nodeManager := NewMockNodeManager()
+	preparer := &MockUpgradePreparer{
+		nodeManager: nodeManager,
+		err:         errors.New("backup failed"),
+	}
+	heightMonitor := height.NewHeightMonitor(NewMockHeightProvider(1000), time.Second, newTestLogger())
+	orchestrator := NewUpgradeOrchestrator(
+		nodeManager,
+		NewMockConfigManager(),
+		heightMonitor,
+		NewMockUpgradeWatcher(),
+		newTestLogger(),
+	)
+	orchestrator.SetUpgradePreparer(preparer)
+
+	upgrade := &types.UpgradeInfo{Name: "v1.2.0", Height: 1000}
+
+	// Act
+	err := orchestrator.executeUpgrade(upgrade, 1000)
+
+	// Assert
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to prepare upgrade")
+	assert.Equal(t, 0, nodeManager.GetStartCalls(), "node should not start with an unprepared upgrade")
+}
+
+// =============================================================================
+// Test: Persisted Upgrade State
+// =============================================================================
+
+func TestUpgradeOrchestrator_PendingUpgradeSurvivesRestart(t *testing.T) {
+	// Arrange
+	store := state.NewStore(&config.Config{Home: t.TempDir()})
+	heightMonitor := height.NewHeightMonitor(NewMockHeightProvider(1000), time.Second, newTestLogger())
+
+	first := NewUpgradeOrchestrator(
+		NewMockNodeManager(),
+		NewMockConfigManager(),
+		heightMonitor,
+		NewMockUpgradeWatcher(),
+		newTestLogger(),
+	)
+	first.SetStateStore(store)
+	require.NoError(t, first.ScheduleUpgrade(&types.UpgradeInfo{Name: "v1.2.0", Height: 2000}))
+
+	// Act
+	second := NewUpgradeOrchestrator(
+		NewMockNodeManager(),
+		NewMockConfigManager(),
+		heightMonitor,
+		NewMockUpgradeWatcher(),
+		newTestLogger(),
+	)
+	second.SetStateStore(store)
+	require.NoError(t, second.Start())
+	defer second.Stop()
+
+	// Assert
+	status := second.GetStatus()
+	require.NotNil(t, status.PendingUpgrade, "pending upgrade should be restored")
+	assert.Equal(t, "v1.2.0", status.PendingUpgrade.Name)
+	assert.Equal(t, int64(2000), status.PendingUpgrade.Height)
+}
+
+func TestUpgradeOrchestrator_CompletedUpgradeIsPersisted(t *testing.T) {
+	// Arrange
+	store := state.NewStore(&config.Config{Home: t.TempDir()})
+	heightProvider := NewMockHeightProvider(1000)
+	heightMonitor := height.NewHeightMonitor(heightProvider, 50*time.Millisecond, newTestLogger())
+
+	orchestrator := NewUpgradeOrchestrator(
+		NewMockNodeManager(),
+		newHomeConfigManager(t, "v1.2.0"),
+		heightMonitor,
+		NewMockUpgradeWatcher(),
+		newTestLogger(),
+	)
+	orchestrator.SetStateStore(store)
+	require.NoError(t, orchestrator.ScheduleUpgrade(&types.UpgradeInfo{Name: "v1.2.0", Height: 1500}))
+
+	require.NoError(t, heightMonitor.Start())
+	defer heightMonitor.Stop()
+	require.NoError(t, orchestrator.Start())
+	defer orchestrator.Stop()
+
+	// Act
+	heightProvider.SetHeight(1500)
+
+	// Assert
+	require.Eventually(t, func() bool {
+		return orchestrator.GetStatus().LastUpgrade != nil
+	}, 2*time.Second, 20*time.Millisecond)
+
+	st, err := store.Load()
+	require.NoError(t, err)
+	assert.Nil(t, st.Upgrade.Pending, "completed upgrade should no longer be pending")
+	require.NotNil(t, st.Upgrade.Last)
+	assert.Equal(t, "v1.2.0", st.Upgrade.Last.Name)
+	assert.Equal(t, int64(1500), st.Upgrade.Last.Height)
+
+	require.Eventually(t, func() bool {
+		st, err := store.Load()
+		return err == nil && len(st.Upgrade.History) == 1
+	}, 2*time.Second, 20*time.Millisecond, "completed upgrade should be added to the history")
+	st, err = store.Load()
+	require.NoError(t, err)
+	entry := st.Upgrade.History[0]
+	assert.Equal(t, "v1.2.0", entry.Name)
+	assert.Equal(t, int64(1500), entry.Height)
+	assert.Equal(t, state.HistoryApplied, entry.Status)
+	assert.Len(t, entry.BinaryHash, 64)
+}
+
+// =============================================================================
+// Test: Upgrade Journal
+// =============================================================================
+
+func TestExecuteUpgrade_JournalsSteps(t *testing.T) {
+	// Arrange
+	configManager := newHomeConfigManager(t, "v1.2.0")
+	cfg := configManager.GetConfig()
+	j := journal.New(cfg)
+	heightMonitor := height.NewHeightMonitor(NewMockHeightProvider(1000), time.Second, newTestLogger())
+	orchestrator := NewUpgradeOrchestrator(
+		NewMockNodeManager(),
+		configManager,
+		heightMonitor,
+		NewMockUpgradeWatcher(),
+		newTestLogger(),
+	)
+	orchestrator.SetJournal(j)
+	upgrade := &types.UpgradeInfo{Name: "v1.2.0", Height: 1000}
+
+	// Act
+	err := orchestrator.executeUpgrade(upgrade, 1000)
+
+	// Assert
+	require.NoError(t, err)
+	pending, err := j.Incomplete()
+	require.NoError(t, err)
+	require.NotNil(t, pending, "the upgrade is committed by the caller")
+	assert.Equal(t, upgrade, pending.Info)
+	assert.Equal(t, cfg.GenesisDir(), pending.PreviousTarget)
+	assert.Equal(t, []string{
+		journal.StepBegin,
+		journal.StepNodeStopped,
+		journal.StepBinarySwitched,
+		journal.StepNodeStarted,
+		journal.StepVerified,
+	}, pending.Steps)
+}
+
+func TestUpgradeOrchestrator_JournalsFailedUpgrade(t *testing.T) {
+	// Arrange
+	nodeManager := &MockReadyNodeManager{
+		MockNodeManager: NewMockNodeManager(),
+		readyErr:        errors.New("startup timeout"),
+	}
+	configManager := newHomeConfigManager(t, "v1.2.0")
+	j := journal.New(configManager.GetConfig())
+	heightProvider := NewMockHeightProvider(1000)
+	heightMonitor := height.NewHeightMonitor(heightProvider, 50*time.Millisecond, newTestLogger())
+	orchestrator := NewUpgradeOrchestrator(
+		nodeManager,
+		configManager,
+		heightMonitor,
+		NewMockUpgradeWatcher(),
+		newTestLogger(),
+	)
+	orchestrator.SetJournal(j)
+	require.NoError(t, orchestrator.ScheduleUpgrade(&types.UpgradeInfo{Name: "v1.2.0", Height: 1500}))
+
+	require.NoError(t, heightMonitor.Start())
+	defer heightMonitor.Stop()
+	require.NoError(t, orchestrator.Start())
+	defer orchestrator.Stop()
+
+	// Act
+	heightProvider.SetHeight(1500)
+
+	// Assert - the rollback fails too, so the journal is left for recovery
+	require.Eventually(t, func() bool {
+		pending, err := j.Incomplete()
+		return err == nil && pending != nil && pending.Done(journal.StepFailed)
+	}, 2*time.Second, 20*time.Millisecond)
+	pending, err := j.Incomplete()
+	require.NoError(t, err)
+	assert.Contains(t, pending.Error, "startup timeout")
+	assert.True(t, pending.Done(journal.StepBinarySwitched))
+}
+
+// =============================================================================
+// Test: Post-Upgrade Verification
+// =============================================================================
+
+// MockUpgradeCheck is a mock implementation of UpgradeCheck for testing.
+type MockUpgradeCheck struct {
+	mu     sync.Mutex
+	err    error
+	checks []int64
+	height *height.HeightMonitor
+}
+
+// Name implements UpgradeCheck interface.
+func (m *MockUpgradeCheck) Name() string {
+	return "mock"
+}
+
+// Check implements UpgradeCheck interface, recording the height it ran at.
+func (m *MockUpgradeCheck) Check(ctx context.Context, upgrade *types.UpgradeInfo) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checks = append(m.checks, m.height.GetCurrentHeight())
+	return m.err
+}
+
+// MockBackupPreparer is a MockUpgradePreparer that records a backup in the
+// journal and implements BackupRestorer.
+type MockBackupPreparer struct {
+	*MockUpgradePreparer
+	journal  *journal.Journal
+	mu       sync.Mutex
+	restored []string
+}
+
+// PrepareUpgrade implements UpgradePreparer interface.
+func (m *MockBackupPreparer) PrepareUpgrade(upgrade *types.UpgradeInfo) error {
+	if err := m.journal.BackedUp("/backups/pre-upgrade-" + upgrade.Name); err != nil {
+		return err
+	}
+	return m.MockUpgradePreparer.PrepareUpgrade(upgrade)
+}
+
+// RestoreBackup implements BackupRestorer interface.
+func (m *MockBackupPreparer) RestoreBackup(backupPath string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.restored = append(m.restored, backupPath)
+	return nil
+}
+
+// MockFailureHandler is a mock implementation of UpgradeFailureHandler for
+// testing.
+type MockFailureHandler struct {
+	mu       sync.Mutex
+	failures []*UpgradeFailure
+}
+
+// UpgradeFailed implements UpgradeFailureHandler interface.
+func (m *MockFailureHandler) UpgradeFailed(failure *UpgradeFailure) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.failures = append(m.failures, failure)
+}
+
+// GetFailures returns the failures reported so far.
+func (m *MockFailureHandler) GetFailures() []*UpgradeFailure {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]*UpgradeFailure(nil), m.failures...)
+}
+
+func TestExecuteUpgrade_VerifiesBlocksPastUpgradeHeight(t *testing.T) {
+	// Arrange
+	configManager := newHomeConfigManager(t, "v1.2.0")
+	cfg := configManager.GetConfig()
+	cfg.UpgradeVerifyBlocks = 3
+	cfg.UpgradeVerifyTimeout = 2 * time.Second
+	cfg.HeightPollInterval = 20 * time.Millisecond
+	heightProvider := NewMockHeightProvider(1000)
+	heightMonitor := height.NewHeightMonitor(heightProvider, 20*time.Millisecond, newTestLogger())
+	require.NoError(t, heightMonitor.Start())
+	defer heightMonitor.Stop()
+	check := &MockUpgradeCheck{height: heightMonitor}
+	orchestrator := NewUpgradeOrchestrator(
+		NewMockNodeManager(),
+		configManager,
+		heightMonitor,
+		NewMockUpgradeWatcher(),
+		newTestLogger(),
+	)
+	orchestrator.AddUpgradeCheck(check)
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		heightProvider.SetHeight(1002)
+		time.Sleep(100 * time.Millisecond)
+		heightProvider.SetHeight(1003)
+	}()
+
+	// Act
+	err := orchestrator.executeUpgrade(&types.UpgradeInfo{Name: "v1.2.0", Height: 1000}, 1000)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1003}, check.checks, "checks should run once the node advanced past the upgrade")
+}
+
+func TestExecuteUpgrade_FailsWhenVerificationFails(t *testing.T) {
+	tests := []struct {
+		name     string
+		checkErr error
+		height   int64
+		errMsg   string
+	}{
+		{
+			name:   "height does not advance",
+			height: 1001,
+			errMsg: "did not reach height 1002",
+		},
+		{
+			name:     "custom check fails",
+			checkErr: errors.New("peer count too low"),
+			height:   1002,
+			errMsg:   "check mock failed: peer count too low",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			configManager := newHomeConfigManager(t, "v1.2.0")
+			cfg := configManager.GetConfig()
+			cfg.UpgradeVerifyBlocks = 2
+			cfg.UpgradeVerifyTimeout = 200 * time.Millisecond
+			cfg.HeightPollInterval = 20 * time.Millisecond
+			heightMonitor := height.NewHeightMonitor(NewMockHeightProvider(tt.height), 20*time.Millisecond, newTestLogger())
+			require.NoError(t, heightMonitor.Start())
+			defer heightMonitor.Stop()
+			orchestrator := NewUpgradeOrchestrator(
+				NewMockNodeManager(),
+				configManager,
+				heightMonitor,
+				NewMockUpgradeWatcher(),
+				newTestLogger(),
+			)
+			orchestrator.AddUpgradeCheck(&MockUpgradeCheck{err: tt.checkErr, height: heightMonitor})
+
+			// Act
+			err := orchestrator.executeUpgrade(&types.UpgradeInfo{Name: "v1.2.0", Height: 1000}, 1000)
+
+			// Assert
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "upgrade verification failed")
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestUpgradeOrchestrator_VerificationCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		errMsg  string
+	}{
+		{
+			name:    "command succeeds",
+			command: `test "$UPGRADE_NAME" = v1.2.0 && test "$UPGRADE_HEIGHT" = 1000`,
+		},
+		{
+			name:    "command fails",
+			command: "echo node not synced; exit 2",
+			errMsg:  "check command failed: verification command failed: exit status 2: node not synced",
+		},
+		{
+			name:    "command times out",
+			command: "sleep 5",
+			errMsg:  "check command failed: verification command timed out after 200ms",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			configManager := newHomeConfigManager(t, "v1.2.0")
+			cfg := configManager.GetConfig()
+			cfg.UpgradeVerifyCommand = tt.command
+			cfg.UpgradeVerifyCommandTimeout = 200 * time.Millisecond
+			heightMonitor := height.NewHeightMonitor(NewMockHeightProvider(1000), time.Second, newTestLogger())
+			orchestrator := NewUpgradeOrchestrator(
+				NewMockNodeManager(),
+				configManager,
+				heightMonitor,
+				NewMockUpgradeWatcher(),
+				newTestLogger(),
+			)
+			checks := ConfiguredChecks(cfg)
+			require.Len(t, checks, 1)
+			orchestrator.AddUpgradeCheck(checks[0])
+
+			// Act
+			err := orchestrator.executeUpgrade(&types.UpgradeInfo{Name: "v1.2.0", Height: 1000}, 1000)
+
+			// Assert
+			if tt.errMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), "upgrade verification failed")
+			assert.Contains(t, err.Error(), tt.errMsg)
+		})
+	}
+}
+
+func TestUpgradeOrchestrator_RollsBackFailedVerification(t *testing.T) {
+	// Arrange
+	nodeManager := NewMockNodeManager()
+	configManager := newHomeConfigManager(t, "v1.2.0")
+	cfg := configManager.GetConfig()
+	cfg.UpgradeVerifyBlocks = 10
+	cfg.UpgradeVerifyTimeout = 200 * time.Millisecond
+	cfg.HeightPollInterval = 20 * time.Millisecond
+	cfg.UpgradeRestoreBackup = true
+	j := journal.New(cfg)
+	preparer := &MockBackupPreparer{
+		MockUpgradePreparer: &MockUpgradePreparer{nodeManager: nodeManager},
+		journal:             j,
+	}
+	handler := &MockFailureHandler{}
+	heightProvider := NewMockHeightProvider(1000)
+	heightMonitor := height.NewHeightMonitor(heightProvider, 20*time.Millisecond, newTestLogger())
+	orchestrator := NewUpgradeOrchestrator(
+		nodeManager,
+		configManager,
+		heightMonitor,
+		NewMockUpgradeWatcher(),
+		newTestLogger(),
+	)
+	orchestrator.SetUpgradePreparer(preparer)
+	orchestrator.SetJournal(j)
+	orchestrator.SetFailureHandler(handler)
+	require.NoError(t, nodeManager.Start(nil))
+	require.NoError(t, orchestrator.ScheduleUpgrade(&types.UpgradeInfo{Name: "v1.2.0", Height: 1500}))
+
+	require.NoError(t, heightMonitor.Start())
+	defer heightMonitor.Stop()
+	require.NoError(t, orchestrator.Start())
+	defer orchestrator.Stop()
+
+	// Act
+	heightProvider.SetHeight(1500)
+
+	// Assert
+	require.Eventually(t, func() bool {
+		return len(handler.GetFailures()) == 1
+	}, 2*time.Second, 20*time.Millisecond)
+	failure := handler.GetFailures()[0]
+	assert.Equal(t, "v1.2.0", failure.Upgrade.Name)
+	assert.True(t, failure.RolledBack)
+	assert.NoError(t, failure.RollbackErr)
+	assert.Contains(t, failure.Err.Error(), "did not reach height 1510")
+
+	target, err := config.NewSymlinkManager(cfg).CurrentTarget()
+	require.NoError(t, err)
+	assert.Equal(t, cfg.GenesisDir(), target, "current should point at the previous binary")
+	assert.Equal(t, []string{"/backups/pre-upgrade-v1.2.0"}, preparer.restored)
+	assert.Equal(t, 2, nodeManager.GetStopCalls(), "the upgraded node should be stopped before rolling back")
+	assert.Equal(t, 3, nodeManager.GetStartCalls())
+	assert.Equal(t, node.StateRunning, nodeManager.GetState())
+
+	pending, err := j.Incomplete()
+	require.NoError(t, err)
+	assert.Nil(t, pending, "a rolled back upgrade is aborted in the journal")
+	assert.Nil(t, orchestrator.GetStatus().LastUpgrade)
+}
+
+// =============================================================================
+// Test: Upgrade History and Rollback
+// =============================================================================
+
+// recordHistory appends applied upgrades with the hashes of their binaries
+// to the history in store
+func recordHistory(t *testing.T, cfg *config.Config, store *state.Store, names ...string) {
+	t.Helper()
+	for i, name := range names {
+		hash, err := version.NewCache(cfg, newTestLogger()).Hash(cfg.UpgradeBin(name))
+		require.NoError(t, err)
+		require.NoError(t, store.Update(func(st *state.State) {
+			st.Upgrade.RecordApplied(name, int64(i+1)*100, hash, time.Now())
+		}))
+	}
+}
+
+func TestRollback_PrefersPreviousTargetOverHistory(t *testing.T) {
+	// Arrange
+	nodeManager := &MockReadyNodeManager{
+		MockNodeManager: NewMockNodeManager(),
+		readyErr:        errors.New("startup timeout"),
+	}
+	configManager := newHomeConfigManager(t, "v1.1.0", "v1.2.0", "v1.3.0", "v1.4.0")
+	cfg := configManager.GetConfig()
+	store := state.NewStore(cfg)
+	recordHistory(t, cfg, store, "v1.1.0", "v1.2.0", "v1.3.0")
+	// The node runs v1.2.0, which the history does not name as last
+	// known-good
+	require.NoError(t, config.NewSymlinkManager(cfg).LinkToUpgrade("v1.2.0"))
+	heightMonitor := height.NewHeightMonitor(NewMockHeightProvider(1000), time.Second, newTestLogger())
+	orchestrator := NewUpgradeOrchestrator(
+		nodeManager,
+		configManager,
+		heightMonitor,
+		NewMockUpgradeWatcher(),
+		newTestLogger(),
+	)
+	orchestrator.SetStateStore(store)
+
+	err := orchestrator.executeUpgrade(&types.UpgradeInfo{Name: "v1.4.0", Height: 1000}, 1000)
+	require.Error(t, err)
+	nodeManager.mu.Lock()
+	nodeManager.readyErr = nil
+	nodeManager.mu.Unlock()
+
+	// Act
+	err = orchestrator.rollback()
+
+	// Assert
+	require.NoError(t, err)
+	target, err := config.NewSymlinkManager(cfg).CurrentTarget()
+	require.NoError(t, err)
+	assert.Equal(t, cfg.UpgradeDir("v1.2.0"), target, "rollback should return to the binary the node ran")
+}
+
+func TestRollback_TargetsLastKnownGood(t *testing.T) {
+	// Arrange
+	nodeManager := &MockReadyNodeManager{
+		MockNodeManager: NewMockNodeManager(),
+		readyErr:        errors.New("startup timeout"),
+	}
+	configManager := newHomeConfigManager(t, "v1.1.0", "v1.2.0", "v1.3.0", "v1.4.0")
+	cfg := configManager.GetConfig()
+	store := state.NewStore(cfg)
+	recordHistory(t, cfg, store, "v1.1.0", "v1.2.0", "v1.3.0")
+	// Without a current link no previous binary is recorded, so only the
+	// history knows v1.3.0
+	require.NoError(t, os.Remove(cfg.CurrentDir()))
+	heightMonitor := height.NewHeightMonitor(NewMockHeightProvider(1000), time.Second, newTestLogger())
+	orchestrator := NewUpgradeOrchestrator(
+		nodeManager,
+		configManager,
+		heightMonitor,
+		NewMockUpgradeWatcher(),
+		newTestLogger(),
+	)
+	orchestrator.SetStateStore(store)
+
+	err := orchestrator.executeUpgrade(&types.UpgradeInfo{Name: "v1.4.0", Height: 1000}, 1000)
+	require.Error(t, err)
+	nodeManager.mu.Lock()
+	nodeManager.readyErr = nil
+	nodeManager.mu.Unlock()
+
+	// Act
+	err = orchestrator.rollback()
+
+	// Assert
+	require.NoError(t, err)
+	target, err := config.NewSymlinkManager(cfg).CurrentTarget()
+	require.NoError(t, err)
+	assert.Equal(t, cfg.UpgradeDir("v1.3.0"), target, "rollback should target the last known-good upgrade, not genesis")
+
+	st, err := store.Load()
+	require.NoError(t, err)
+	require.Len(t, st.Upgrade.History, 3, "a failed upgrade is not recorded")
+	for _, entry := range st.Upgrade.History {
+		assert.Equal(t, state.HistoryApplied, entry.Status)
+	}
+}
+
+func TestRollbackBinary(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		history []string
+		prepare func(t *testing.T, cfg *config.Config)
+		want    string
+		errMsg  string
+	}{
+		{name: "last known-good", history: []string{"v1.1.0", "v1.2.0"}, want: "v1.1.0"},
+		{name: "named upgrade", target: "v1.1.0", history: []string{"v1.1.0", "v1.2.0"}, want: "v1.1.0"},
+		{name: "genesis", target: GenesisTarget, history: []string{"v1.1.0", "v1.2.0"}, want: GenesisTarget},
+		{name: "empty history", history: []string{"v1.2.0"}, errMsg: ErrNoKnownGood.Error()},
+		{name: "unknown upgrade", target: "v0.9.0", history: []string{"v1.1.0", "v1.2.0"}, errMsg: "not an applied upgrade"},
+		{name: "current upgrade", target: "v1.2.0", history: []string{"v1.1.0", "v1.2.0"}, errMsg: "already points at v1.2.0"},
+		{
+			name:    "changed binary",
+			history: []string{"v1.1.0", "v1.2.0"},
+			prepare: func(t *testing.T, cfg *config.Config) {
+				require.NoError(t, os.WriteFile(cfg.UpgradeBin("v1.1.0"), []byte("#!/bin/sh\nexit 1\n"), 0755))
+			},
+			errMsg: "changed since it was applied",
+		},
+		{
+			name:    "missing binary",
+			history: []string{"v1.1.0", "v1.2.0"},
+			prepare: func(t *testing.T, cfg *config.Config) {
+				require.NoError(t, os.Remove(cfg.UpgradeBin("v1.1.0")))
+			},
+			errMsg: "binary not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			cfg := newHomeConfigManager(t, "v1.1.0", "v1.2.0").GetConfig()
+			store := state.NewStore(cfg)
+			recordHistory(t, cfg, store, tt.history...)
+			require.NoError(t, config.NewSymlinkManager(cfg).LinkToUpgrade("v1.2.0"))
+			if tt.prepare != nil {
+				tt.prepare(t, cfg)
+			}
+
+			// Act
+			result, err := RollbackBinary(cfg, store, newTestLogger(), tt.target)
+
+			// Assert
+			current, linkErr := config.NewSymlinkManager(cfg).CurrentTarget()
+			require.NoError(t, linkErr)
+			if tt.errMsg != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.errMsg)
+				assert.Equal(t, cfg.UpgradeDir("v1.2.0"), current, "current should be left untouched")
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, &RollbackResult{From: "v1.2.0", To: tt.want}, result)
+			assert.Equal(t, filepath.Base(current), tt.want)
+
+			st, err := store.Load()
+			require.NoError(t, err)
+			assert.Equal(t, state.HistoryRolledBack, st.Upgrade.History[len(st.Upgrade.History)-1].Status)
+		})
+	}
+}
+
+func TestUpgradeOrchestrator_Rollback(t *testing.T) {
+	// Arrange
+	nodeManager := NewMockNodeManager()
+	configManager := newHomeConfigManager(t, "v1.1.0", "v1.2.0")
+	cfg := configManager.GetConfig()
+	store := state.NewStore(cfg)
+	recordHistory(t, cfg, store, "v1.1.0", "v1.2.0")
+	require.NoError(t, config.NewSymlinkManager(cfg).LinkToUpgrade("v1.2.0"))
+	heightMonitor := height.NewHeightMonitor(NewMockHeightProvider(1000), time.Second, newTestLogger())
+	orchestrator := NewUpgradeOrchestrator(
+		nodeManager,
+		configManager,
+		heightMonitor,
+		NewMockUpgradeWatcher(),
+		newTestLogger(),
+	)
+
+	_, err := orchestrator.Rollback("")
+	require.Error(t, err, "rollback requires the upgrade history")
+	orchestrator.SetStateStore(store)
+
+	// Act
+	result, err := orchestrator.Rollback("")
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, &RollbackResult{From: "v1.2.0", To: "v1.1.0"}, result)
+	assert.Equal(t, 1, nodeManager.GetStopCalls())
+	assert.Equal(t, 1, nodeManager.GetStartCalls())
+
+	// A failed rollback restarts the node on the binary it was running
+	_, err = orchestrator.Rollback("v0.9.0")
+	require.Error(t, err)
+	assert.Equal(t, 2, nodeManager.GetStartCalls())
+	target, err := config.NewSymlinkManager(cfg).CurrentTarget()
+	require.NoError(t, err)
+	assert.Equal(t, cfg.UpgradeDir("v1.1.0"), target)
+}
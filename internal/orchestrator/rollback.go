@@ -0,0 +1,122 @@
+package orchestrator
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/state"
+	"github.com/wemix/wemixvisor/internal/version"
+	"github.com/wemix/wemixvisor/pkg/logger"
+)
+
+// GenesisTarget names the genesis binary as a rollback target.
+const GenesisTarget = config.GenesisDirName
+
+// ErrNoKnownGood is returned when the upgrade history holds no upgrade to
+// roll back to.
+var ErrNoKnownGood = errors.New("no known-good upgrade in the upgrade history")
+
+// RollbackResult describes a completed binary rollback.
+type RollbackResult struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// RollbackBinary points the current symlink at an earlier binary and marks
+// the upgrades applied after it as rolled back in the upgrade history.
+//
+// With an empty target, the last known-good upgrade other than the one
+// current points at is chosen. Otherwise the target must be GenesisTarget or
+// an applied upgrade in the history. The target binary must be executable
+// and, for upgrades, match the hash recorded when it was applied.
+//
+// The node must not be running the current binary while it is switched.
+func RollbackBinary(cfg *config.Config, store *state.Store, log *logger.Logger, target string) (*RollbackResult, error) {
+	symlinks := config.NewSymlinkManager(cfg)
+	currentDir, err := symlinks.CurrentTarget()
+	if err != nil {
+		return nil, err
+	}
+	from := targetName(cfg, currentDir)
+
+	st, err := store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load upgrade history: %w", err)
+	}
+
+	index := -1
+	switch target {
+	case "":
+		index = st.Upgrade.LastKnownGood(from)
+		if index < 0 {
+			return nil, ErrNoKnownGood
+		}
+		target = st.Upgrade.History[index].Name
+	case GenesisTarget:
+	default:
+		index = st.Upgrade.LastApplied(target)
+		if index < 0 {
+			return nil, fmt.Errorf("upgrade %s is not an applied upgrade in the upgrade history", target)
+		}
+	}
+
+	if target == from {
+		return nil, fmt.Errorf("current already points at %s", target)
+	}
+
+	binary := cfg.GenesisBin()
+	if target != GenesisTarget {
+		binary = cfg.UpgradeBin(target)
+	}
+	if err := config.CheckExecutable(binary); err != nil {
+		return nil, fmt.Errorf("rollback binary is not usable: %w", err)
+	}
+
+	if index >= 0 {
+		if recorded := st.Upgrade.History[index].BinaryHash; recorded != "" {
+			hash, err := version.NewCache(cfg, log).Hash(binary)
+			if err != nil {
+				return nil, err
+			}
+			if hash != recorded {
+				return nil, fmt.Errorf("binary of %s changed since it was applied (sha256 %s, recorded %s)",
+					target, hash, recorded)
+			}
+		}
+	}
+
+	if target == GenesisTarget {
+		err = symlinks.LinkToGenesis()
+	} else {
+		err = symlinks.LinkToUpgrade(target)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := store.Update(func(st *state.State) {
+		st.Upgrade.RollBackAfter(index, time.Now())
+	}); err != nil {
+		log.Warn("failed to save upgrade history", "error", err)
+	}
+
+	log.Info("binary rolled back", "from", from, "to", target)
+	return &RollbackResult{From: from, To: target}, nil
+}
+
+// targetName returns the upgrade name of a directory current points at:
+// GenesisTarget, the name of an upgrade, or the directory itself if it is
+// neither.
+func targetName(cfg *config.Config, dir string) string {
+	switch {
+	case dir == cfg.GenesisDir():
+		return GenesisTarget
+	case filepath.Dir(dir) == cfg.UpgradesDir():
+		return filepath.Base(dir)
+	default:
+		return dir
+	}
+}
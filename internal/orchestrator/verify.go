@@ -0,0 +1,233 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/journal"
+	"github.com/wemix/wemixvisor/pkg/types"
+)
+
+// UpgradeFailure describes a failed upgrade and the rollback that followed.
+type UpgradeFailure struct {
+	Upgrade *types.UpgradeInfo
+	Err     error
+	// RolledBack reports whether the node was restored and restarted on its
+	// previous binary; RollbackErr holds the reason if it was not.
+	RolledBack  bool
+	RollbackErr error
+	Time        time.Time
+}
+
+// AddUpgradeCheck adds a custom check the upgraded node must pass before an
+// upgrade is declared successful. Checks run in the order they were added.
+// It must be called before Start.
+func (uo *UpgradeOrchestrator) AddUpgradeCheck(check UpgradeCheck) {
+	uo.mu.Lock()
+	defer uo.mu.Unlock()
+	uo.checks = append(uo.checks, check)
+}
+
+// ConfiguredChecks returns the upgrade checks set up by cfg: the
+// verification command, if one is configured
+func ConfiguredChecks(cfg *config.Config) []UpgradeCheck {
+	var checks []UpgradeCheck
+	if cfg.UpgradeVerifyCommand != "" {
+		checks = append(checks, NewCommandCheck(cfg))
+	}
+	return checks
+}
+
+// CommandCheck runs the configured verification command with /bin/sh -c in
+// the node home and passes if it succeeds within the command timeout. The
+// command gets the upgrade in UPGRADE_NAME and UPGRADE_HEIGHT and the node
+// RPC endpoint in NODE_RPC_URL.
+type CommandCheck struct {
+	cfg *config.Config
+}
+
+// NewCommandCheck creates a check running the verification command of cfg
+func NewCommandCheck(cfg *config.Config) *CommandCheck {
+	return &CommandCheck{cfg: cfg}
+}
+
+func (c *CommandCheck) Name() string {
+	return "command"
+}
+
+func (c *CommandCheck) Check(ctx context.Context, upgrade *types.UpgradeInfo) error {
+	timeout := c.cfg.UpgradeVerifyCommandTimeout
+	if timeout <= 0 {
+		timeout = config.DefaultVerifyCommandTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "/bin/sh", "-c", c.cfg.UpgradeVerifyCommand)
+	cmd.Dir = c.cfg.Home
+	cmd.Env = append(os.Environ(),
+		"DAEMON_HOME="+c.cfg.Home,
+		"DAEMON_NAME="+c.cfg.Name,
+		"UPGRADE_NAME="+upgrade.Name,
+		fmt.Sprintf("UPGRADE_HEIGHT=%d", upgrade.Height),
+		"NODE_RPC_URL=http://"+c.cfg.RPCAddress)
+	// Do not wait on children of the shell still holding its output
+	cmd.WaitDelay = time.Second
+
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("verification command timed out after %v", timeout)
+	}
+
+	msg := strings.TrimSpace(string(output))
+	if msg == "" {
+		return fmt.Errorf("verification command failed: %w", err)
+	}
+	return fmt.Errorf("verification command failed: %w: %s", err, msg)
+}
+
+// SetFailureHandler sets the handler told about failed upgrades. It must be
+// called before Start.
+func (uo *UpgradeOrchestrator) SetFailureHandler(handler UpgradeFailureHandler) {
+	uo.mu.Lock()
+	defer uo.mu.Unlock()
+	uo.failureHandler = handler
+}
+
+// verifyUpgrade checks that the node started on the upgrade binary works.
+//
+// The node must advance UpgradeVerifyBlocks past the upgrade height, as seen
+// by the height monitor, and then pass every custom check, all within
+// UpgradeVerifyTimeout. Without blocks to wait for or checks to run, a
+// node that became ready is verified.
+func (uo *UpgradeOrchestrator) verifyUpgrade(upgrade *types.UpgradeInfo) error {
+	cfg := uo.configManager.GetConfig()
+	uo.mu.RLock()
+	checks := uo.checks
+	uo.mu.RUnlock()
+
+	if cfg.UpgradeVerifyBlocks <= 0 && len(checks) == 0 {
+		return nil
+	}
+
+	timeout := cfg.UpgradeVerifyTimeout
+	if timeout <= 0 {
+		timeout = config.DefaultUpgradeVerifyTimeout
+	}
+	ctx, cancel := context.WithTimeout(uo.ctx, timeout)
+	defer cancel()
+
+	uo.logger.Info("verifying upgrade",
+		"upgrade_name", upgrade.Name,
+		"blocks", cfg.UpgradeVerifyBlocks,
+		"checks", len(checks),
+		"timeout", timeout)
+
+	if cfg.UpgradeVerifyBlocks > 0 {
+		interval := cfg.HeightPollInterval
+		if interval <= 0 {
+			interval = config.DefaultHeightPollInterval
+		}
+		if err := uo.waitForHeight(ctx, upgrade.Height+cfg.UpgradeVerifyBlocks, interval); err != nil {
+			return err
+		}
+	}
+
+	for _, check := range checks {
+		if err := check.Check(ctx, upgrade); err != nil {
+			return fmt.Errorf("check %s failed: %w", check.Name(), err)
+		}
+	}
+
+	uo.logger.Info("upgrade verified", "upgrade_name", upgrade.Name)
+	return nil
+}
+
+// waitForHeight waits until the height monitor reports at least target,
+// checking every interval until ctx is done.
+func (uo *UpgradeOrchestrator) waitForHeight(ctx context.Context, target int64, interval time.Duration) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		current := uo.heightMonitor.GetCurrentHeight()
+		if current >= target {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("node did not reach height %d, last height %d: %w", target, current, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// failUpgrade records a failed upgrade, rolls it back and tells the failure
+// handler, if one is set.
+func (uo *UpgradeOrchestrator) failUpgrade(upgrade *types.UpgradeInfo, err error) {
+	uo.logger.Error("upgrade failed, attempting rollback",
+		"error", err,
+		"upgrade_name", upgrade.Name)
+	uo.journalRecord(func(j *journal.Journal) error { return j.Fail(err) })
+
+	// A failed rollback leaves the journal incomplete, to be rolled back
+	// again on the next start
+	rollbackErr := uo.rollback()
+	if rollbackErr != nil {
+		uo.logger.Error("rollback failed", "error", rollbackErr)
+	} else {
+		uo.journalRecord((*journal.Journal).Abort)
+	}
+
+	uo.mu.RLock()
+	handler := uo.failureHandler
+	uo.mu.RUnlock()
+	if handler != nil {
+		handler.UpgradeFailed(&UpgradeFailure{
+			Upgrade:     upgrade,
+			Err:         err,
+			RolledBack:  rollbackErr == nil,
+			RollbackErr: rollbackErr,
+			Time:        time.Now(),
+		})
+	}
+}
+
+// restoreBackup restores the pre-upgrade backup recorded in the upgrade
+// journal when the configuration asks for it. A preparer that cannot
+// restore backups, or an upgrade without a backup, leaves the data as it is.
+func (uo *UpgradeOrchestrator) restoreBackup() error {
+	if !uo.configManager.GetConfig().UpgradeRestoreBackup {
+		return nil
+	}
+
+	uo.mu.RLock()
+	restorer, ok := uo.preparer.(BackupRestorer)
+	j := uo.journal
+	uo.mu.RUnlock()
+	if !ok || j == nil {
+		uo.logger.Warn("pre-upgrade backup cannot be restored without a backup restorer and journal")
+		return nil
+	}
+
+	pending, err := j.Incomplete()
+	if err != nil {
+		return err
+	}
+	if pending == nil || pending.BackupPath == "" {
+		uo.logger.Warn("no pre-upgrade backup recorded, leaving node data as it is")
+		return nil
+	}
+
+	uo.logger.Info("restoring pre-upgrade backup", "backup_path", pending.BackupPath)
+	return restorer.RestoreBackup(pending.BackupPath)
+}
@@ -0,0 +1,216 @@
+// Package procstat reads resource usage of a process group from /proc
+package procstat
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ClockTicks is the unit of the CPU times in /proc/<pid>/stat. The kernel
+// reports them in USER_HZ, which is 100 on all supported architectures.
+const ClockTicks = 100
+
+// procRoot is the mount point of procfs, replaced in tests
+var procRoot = "/proc"
+
+// Stats is the resource usage of a process group, summed over its members.
+// MaxFDs is the open file limit of the group leader.
+type Stats struct {
+	PGID                int     `json:"pgid"`
+	Processes           int     `json:"processes"`
+	RSSBytes            uint64  `json:"rss_bytes"`
+	CPUSeconds          float64 `json:"cpu_seconds"`
+	OpenFDs             int     `json:"open_fds"`
+	MaxFDs              uint64  `json:"max_fds"`
+	Threads             int     `json:"threads"`
+	ReadBytes           uint64  `json:"read_bytes"`
+	WriteBytes          uint64  `json:"write_bytes"`
+	VoluntarySwitches   uint64  `json:"voluntary_ctx_switches"`
+	InvoluntarySwitches uint64  `json:"involuntary_ctx_switches"`
+}
+
+// FDUsage returns the open file descriptors as a fraction of the limit, or
+// 0 if the limit is unknown or unlimited
+func (s *Stats) FDUsage() float64 {
+	if s.MaxFDs == 0 {
+		return 0
+	}
+	return float64(s.OpenFDs) / float64(s.MaxFDs)
+}
+
+// ReadGroup returns the resource usage of the processes in process group
+// pgid. Processes that exit while they are read are skipped, and the I/O
+// counters are left out for processes whose io file is not readable.
+func ReadGroup(pgid int) (*Stats, error) {
+	if pgid <= 0 {
+		return nil, fmt.Errorf("invalid process group %d", pgid)
+	}
+
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", procRoot, err)
+	}
+
+	stats := &Stats{PGID: pgid}
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := readStat(pid)
+		if err != nil || stat.pgrp != pgid {
+			continue
+		}
+
+		stats.Processes++
+		stats.RSSBytes += stat.rssPages * uint64(os.Getpagesize())
+		stats.CPUSeconds += float64(stat.utime+stat.stime) / ClockTicks
+		stats.Threads += stat.threads
+		stats.OpenFDs += countFDs(pid)
+		addIO(stats, pid)
+		addContextSwitches(stats, pid)
+
+		if pid == pgid {
+			stats.MaxFDs = readMaxFDs(pid)
+		}
+	}
+
+	if stats.Processes == 0 {
+		return nil, fmt.Errorf("no processes in group %d", pgid)
+	}
+	return stats, nil
+}
+
+// procStat holds the fields of /proc/<pid>/stat used here
+type procStat struct {
+	pgrp     int
+	utime    uint64
+	stime    uint64
+	threads  int
+	rssPages uint64
+}
+
+// readStat parses /proc/<pid>/stat. Zombies are reported as errors since
+// they hold no resources.
+func readStat(pid int) (*procStat, error) {
+	data, err := os.ReadFile(procPath(pid, "stat"))
+	if err != nil {
+		return nil, err
+	}
+
+	// The command name may contain spaces, so fields are counted from the
+	// closing parenthesis: state is field 3, pgrp 5, utime 14, stime 15,
+	// num_threads 20 and rss 24
+	stat := string(data)
+	end := strings.LastIndexByte(stat, ')')
+	if end < 0 {
+		return nil, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	fields := strings.Fields(stat[end+1:])
+	if len(fields) < 22 {
+		return nil, fmt.Errorf("malformed stat for pid %d", pid)
+	}
+	if fields[0] == "Z" || fields[0] == "X" {
+		return nil, fmt.Errorf("process %d has exited", pid)
+	}
+
+	var s procStat
+	var errs [5]error
+	s.pgrp, errs[0] = strconv.Atoi(fields[2])
+	s.utime, errs[1] = strconv.ParseUint(fields[11], 10, 64)
+	s.stime, errs[2] = strconv.ParseUint(fields[12], 10, 64)
+	s.threads, errs[3] = strconv.Atoi(fields[17])
+	s.rssPages, errs[4] = strconv.ParseUint(fields[21], 10, 64)
+	for _, err := range errs {
+		if err != nil {
+			return nil, fmt.Errorf("malformed stat for pid %d: %w", pid, err)
+		}
+	}
+	return &s, nil
+}
+
+// countFDs returns the number of open file descriptors of pid
+func countFDs(pid int) int {
+	entries, err := os.ReadDir(procPath(pid, "fd"))
+	if err != nil {
+		return 0
+	}
+	return len(entries)
+}
+
+// readMaxFDs returns the soft open file limit of pid, or 0 if it is
+// unlimited or unknown
+func readMaxFDs(pid int) uint64 {
+	file, err := os.Open(procPath(pid, "limits"))
+	if err != nil {
+		return 0
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "Max open files") {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, "Max open files"))
+		if len(fields) == 0 {
+			return 0
+		}
+		limit, err := strconv.ParseUint(fields[0], 10, 64)
+		if err != nil {
+			return 0
+		}
+		return limit
+	}
+	return 0
+}
+
+// addIO adds the storage I/O of pid from /proc/<pid>/io
+func addIO(stats *Stats, pid int) {
+	values := readKeyValues(procPath(pid, "io"))
+	stats.ReadBytes += values["read_bytes"]
+	stats.WriteBytes += values["write_bytes"]
+}
+
+// addContextSwitches adds the context switches of pid from /proc/<pid>/status
+func addContextSwitches(stats *Stats, pid int) {
+	values := readKeyValues(procPath(pid, "status"))
+	stats.VoluntarySwitches += values["voluntary_ctxt_switches"]
+	stats.InvoluntarySwitches += values["nonvoluntary_ctxt_switches"]
+}
+
+// readKeyValues reads the numeric "key: value" lines of a proc file,
+// skipping other lines. A missing or unreadable file yields no values.
+func readKeyValues(path string) map[string]uint64 {
+	values := make(map[string]uint64)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return values
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseUint(strings.TrimSpace(value), 10, 64)
+		if err != nil {
+			continue
+		}
+		values[strings.TrimSpace(key)] = n
+	}
+	return values
+}
+
+// procPath returns the path of a file in the proc directory of pid
+func procPath(pid int, name string) string {
+	return filepath.Join(procRoot, strconv.Itoa(pid), name)
+}
@@ -0,0 +1,102 @@
+package procstat
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFakeProcess creates the proc files of a process in a fake proc tree
+func writeFakeProcess(t *testing.T, root string, pid, pgrp int, files map[string]string) {
+	t.Helper()
+	dir := filepath.Join(root, fmt.Sprint(pid))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "fd"), 0755))
+
+	// utime 150, stime 50, 3 threads and 10 resident pages
+	stat := fmt.Sprintf("%d (wemix d) S 1 %d %d 0 -1 0 0 0 0 0 150 50 0 0 20 0 3 0 100 0 10 0", pid, pgrp, pgrp)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "stat"), []byte(stat), 0644))
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0644))
+	}
+}
+
+func TestReadGroup_FakeProc(t *testing.T) {
+	root := t.TempDir()
+	procRoot = root
+	defer func() { procRoot = "/proc" }()
+
+	writeFakeProcess(t, root, 100, 100, map[string]string{
+		"limits": "Limit                     Soft Limit           Hard Limit           Units\n" +
+			"Max open files            4096                 8192                 files\n",
+		"io":     "rchar: 1\nread_bytes: 4096\nwrite_bytes: 1024\n",
+		"status": "Name:\twemixd\nvoluntary_ctxt_switches:\t7\nnonvoluntary_ctxt_switches:\t2\n",
+	})
+	for i := 0; i < 3; i++ {
+		require.NoError(t, os.WriteFile(filepath.Join(root, "100", "fd", fmt.Sprint(i)), nil, 0644))
+	}
+	// A child in the same group without a readable io file
+	writeFakeProcess(t, root, 101, 100, map[string]string{
+		"status": "voluntary_ctxt_switches:\t1\nnonvoluntary_ctxt_switches:\t1\n",
+	})
+	// An unrelated process
+	writeFakeProcess(t, root, 200, 200, nil)
+
+	stats, err := ReadGroup(100)
+	require.NoError(t, err)
+
+	page := uint64(os.Getpagesize())
+	assert.Equal(t, &Stats{
+		PGID:                100,
+		Processes:           2,
+		RSSBytes:            20 * page,
+		CPUSeconds:          4,
+		OpenFDs:             3,
+		MaxFDs:              4096,
+		Threads:             6,
+		ReadBytes:           4096,
+		WriteBytes:          1024,
+		VoluntarySwitches:   8,
+		InvoluntarySwitches: 3,
+	}, stats)
+	assert.InDelta(t, 3.0/4096, stats.FDUsage(), 1e-9)
+
+	_, err = ReadGroup(300)
+	assert.Error(t, err)
+}
+
+func TestReadGroup_LiveProcess(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("procfs not available")
+	}
+
+	cmd := exec.Command("/bin/sh", "-c", "sleep 10 & sleep 10")
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	require.NoError(t, cmd.Start())
+	defer func() {
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		cmd.Wait()
+	}()
+
+	require.Eventually(t, func() bool {
+		stats, err := ReadGroup(cmd.Process.Pid)
+		return err == nil && stats.Processes >= 3
+	}, 5*time.Second, 20*time.Millisecond)
+
+	stats, err := ReadGroup(cmd.Process.Pid)
+	require.NoError(t, err)
+	assert.Greater(t, stats.RSSBytes, uint64(0))
+	assert.GreaterOrEqual(t, stats.Threads, 3)
+	assert.Greater(t, stats.OpenFDs, 0)
+}
+
+func TestReadGroup_InvalidGroup(t *testing.T) {
+	_, err := ReadGroup(0)
+	assert.Error(t, err)
+}
@@ -0,0 +1,329 @@
+// Package staging makes upgrade binaries ready ahead of their upgrade. As
+// soon as an upgrade is known, its binary is downloaded, verified and
+// smoke-tested in the background, so that the upgrade itself does not wait
+// on a download while the node is stopped.
+package staging
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/download"
+	"github.com/wemix/wemixvisor/internal/hooks"
+	"github.com/wemix/wemixvisor/internal/metrics"
+	"github.com/wemix/wemixvisor/internal/version"
+	"github.com/wemix/wemixvisor/pkg/logger"
+	"github.com/wemix/wemixvisor/pkg/types"
+)
+
+// States of a staged upgrade binary
+const (
+	StateStaging = "staging"
+	StateStaged  = "staged"
+	StateFailed  = "failed"
+)
+
+// RetryInterval is how long a binary whose staging failed is left alone
+// before it is staged again when its upgrade is announced again
+const RetryInterval = time.Minute
+
+// Status reports the staging of one upgrade binary
+type Status struct {
+	Name        string     `json:"name"`
+	Height      int64      `json:"height,omitempty"`
+	State       string     `json:"state"`
+	Error       string     `json:"error,omitempty"`
+	Binary      string     `json:"binary"`
+	Version     string     `json:"version,omitempty"`
+	SHA256      string     `json:"sha256,omitempty"`
+	StartedAt   time.Time  `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Alerted     bool       `json:"alerted,omitempty"`
+}
+
+// Alerter receives the alert raised for an upgrade that is not staged in
+// time
+type Alerter interface {
+	GenerateAlert(alert *metrics.Alert)
+}
+
+// entry is the staging of one upgrade binary
+type entry struct {
+	status  Status
+	upgrade *types.UpgradeInfo
+	done    chan struct{}
+}
+
+// Stager stages upgrade binaries in the background. An upgrade whose
+// binary is not staged StagingAlertBlocks before its height raises an
+// alert. It is safe for concurrent use.
+type Stager struct {
+	cfg        *config.Config
+	logger     *logger.Logger
+	downloader *download.Downloader
+	versions   *version.Cache
+	validator  *hooks.PreUpgradeHook
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	alerter Alerter
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewStager creates a stager for the upgrades of the configured node
+func NewStager(cfg *config.Config, log *logger.Logger) *Stager {
+	return &Stager{
+		cfg:        cfg,
+		logger:     log,
+		downloader: download.NewDownloader(cfg, log),
+		versions:   version.NewCache(cfg, log),
+		validator:  hooks.NewPreUpgradeHook(cfg, log),
+		entries:    make(map[string]*entry),
+		stopCh:     make(chan struct{}),
+	}
+}
+
+// SetAlerter sets where the alert for an upgrade not staged in time is
+// raised
+func (s *Stager) SetAlerter(alerter Alerter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.alerter = alerter
+}
+
+// Start checks every height received on heights against the staging
+// deadline of the known upgrades, until Stop
+func (s *Stager) Start(heights <-chan int64) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			select {
+			case <-s.stopCh:
+				return
+			case height, ok := <-heights:
+				if !ok {
+					return
+				}
+				s.CheckDeadlines(height)
+			}
+		}
+	}()
+}
+
+// Stop stops checking staging deadlines. Staging in progress is not
+// interrupted.
+func (s *Stager) Stop() {
+	select {
+	case <-s.stopCh:
+	default:
+		close(s.stopCh)
+	}
+	s.wg.Wait()
+}
+
+// Stage starts staging the binary of upgrade in the background, unless it
+// is staged or being staged already. A binary whose staging failed is
+// staged again once RetryInterval has passed.
+func (s *Stager) Stage(upgrade *types.UpgradeInfo) {
+	if upgrade == nil || upgrade.Name == "" {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	height, alerted := upgrade.Height, false
+	if e, ok := s.entries[upgrade.Name]; ok {
+		if upgrade.Height > 0 && upgrade.Height != e.status.Height {
+			e.status.Height = upgrade.Height
+			e.status.Alerted = false
+		}
+		if e.status.State != StateFailed || time.Since(*e.status.CompletedAt) < RetryInterval {
+			return
+		}
+		height, alerted = e.status.Height, e.status.Alerted
+	}
+
+	e := &entry{
+		status: Status{
+			Name:      upgrade.Name,
+			Height:    height,
+			State:     StateStaging,
+			Binary:    s.cfg.UpgradeBin(upgrade.Name),
+			StartedAt: time.Now(),
+			Alerted:   alerted,
+		},
+		upgrade: upgrade,
+		done:    make(chan struct{}),
+	}
+	s.entries[upgrade.Name] = e
+
+	s.logger.Info("staging upgrade binary",
+		zap.String("name", upgrade.Name),
+		zap.Int64("height", height))
+
+	go s.run(e)
+}
+
+// Wait blocks until staging in progress for the named upgrade, if any, has
+// finished
+func (s *Stager) Wait(name string) {
+	s.mu.Lock()
+	e, ok := s.entries[name]
+	s.mu.Unlock()
+
+	if ok {
+		<-e.done
+	}
+}
+
+// Status returns the staging of every known upgrade, ordered by height
+func (s *Stager) Status() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]Status, 0, len(s.entries))
+	for _, e := range s.entries {
+		statuses = append(statuses, e.status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		if statuses[i].Height != statuses[j].Height {
+			return statuses[i].Height < statuses[j].Height
+		}
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// CheckDeadlines raises an alert, once per upgrade, for every upgrade whose
+// binary is not staged although the chain is within StagingAlertBlocks of
+// its height
+func (s *Stager) CheckDeadlines(currentHeight int64) {
+	if s.cfg.StagingAlertBlocks <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, e := range s.entries {
+		st := &e.status
+		if st.State == StateStaged || st.Alerted || st.Height <= 0 {
+			continue
+		}
+		if currentHeight < st.Height-s.cfg.StagingAlertBlocks {
+			continue
+		}
+
+		st.Alerted = true
+		s.alertNotStaged(st, currentHeight)
+	}
+}
+
+// alertNotStaged logs and raises the alert for an upgrade not staged in
+// time. Caller must hold mu.
+func (s *Stager) alertNotStaged(st *Status, currentHeight int64) {
+	message := fmt.Sprintf("upgrade %s at height %d is not staged %d blocks ahead: %s",
+		st.Name, st.Height, st.Height-currentHeight, st.State)
+	if st.Error != "" {
+		message += ": " + st.Error
+	}
+
+	s.logger.Warn("upgrade binary not staged in time",
+		zap.String("name", st.Name),
+		zap.Int64("height", st.Height),
+		zap.Int64("current_height", currentHeight),
+		zap.String("state", st.State),
+		zap.String("error", st.Error))
+
+	if s.alerter == nil {
+		return
+	}
+
+	now := time.Now()
+	s.alerter.GenerateAlert(&metrics.Alert{
+		ID:        fmt.Sprintf("upgrade-not-staged-%s-%d", st.Name, now.Unix()),
+		Name:      "UpgradeNotStaged",
+		Level:     metrics.AlertLevelWarning,
+		Message:   message,
+		Source:    "staging",
+		Metric:    "upgrade_blocks_remaining",
+		Value:     float64(st.Height - currentHeight),
+		Threshold: float64(s.cfg.StagingAlertBlocks),
+		Labels:    map[string]string{"upgrade": st.Name, "state": st.State},
+		Timestamp: now,
+	})
+}
+
+// run stages the binary of e and records the outcome
+func (s *Stager) run(e *entry) {
+	defer close(e.done)
+
+	info, hash, err := s.stage(e.upgrade)
+	completed := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e.status.CompletedAt = &completed
+	if err != nil {
+		e.status.State = StateFailed
+		e.status.Error = err.Error()
+		s.logger.Error("failed to stage upgrade binary",
+			zap.String("name", e.status.Name),
+			zap.Error(err))
+		return
+	}
+
+	e.status.State = StateStaged
+	e.status.Version = info.String()
+	e.status.SHA256 = hash
+	s.logger.Info("upgrade binary staged",
+		zap.String("name", e.status.Name),
+		zap.String("version", e.status.Version),
+		zap.Duration("duration", completed.Sub(e.status.StartedAt)))
+}
+
+// stage makes the binary of upgrade available, downloading and verifying
+// it if it is not installed, checks it against the checksum in the upgrade
+// plan, if any, runs it once for its version as a smoke test and puts it
+// through the checks it must pass before the node is switched to it
+func (s *Stager) stage(upgrade *types.UpgradeInfo) (*version.Info, string, error) {
+	if err := s.downloader.EnsureUpgradeBinary(upgrade.Name); err != nil {
+		return nil, "", err
+	}
+
+	binary := s.cfg.UpgradeBin(upgrade.Name)
+	if err := config.CheckExecutable(binary); err != nil {
+		return nil, "", err
+	}
+
+	if checksum, _ := upgrade.Info["checksum"].(string); checksum != "" {
+		if err := s.downloader.VerifyChecksum(binary, checksum); err != nil {
+			return nil, "", err
+		}
+	}
+
+	info, err := s.versions.Lookup(binary)
+	if err != nil {
+		return nil, "", fmt.Errorf("smoke test failed: %w", err)
+	}
+
+	if err := s.validator.ValidateBinary(binary, upgrade); err != nil {
+		return nil, "", err
+	}
+
+	hash, err := s.versions.Hash(binary)
+	if err != nil {
+		return nil, "", err
+	}
+	return info, hash, nil
+}
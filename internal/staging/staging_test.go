@@ -0,0 +1,205 @@
+package staging
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/metrics"
+	"github.com/wemix/wemixvisor/pkg/logger"
+	"github.com/wemix/wemixvisor/pkg/types"
+)
+
+// versionScript is an upgrade binary that reports its version
+const versionScript = "#!/bin/sh\necho \"wemixd version v2.0.0\"\n"
+
+// mockAlerter records the alerts raised
+type mockAlerter struct {
+	mu     sync.Mutex
+	alerts []*metrics.Alert
+}
+
+func (m *mockAlerter) GenerateAlert(alert *metrics.Alert) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.alerts = append(m.alerts, alert)
+}
+
+func (m *mockAlerter) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.alerts)
+}
+
+func newTestStager(t *testing.T) (*Stager, *config.Config) {
+	t.Helper()
+	cfg := &config.Config{
+		Home:               t.TempDir(),
+		Name:               "wemixd",
+		StagingAlertBlocks: 100,
+	}
+	return NewStager(cfg, logger.NewTestLogger()), cfg
+}
+
+func installBinary(t *testing.T, cfg *config.Config, name, script string) {
+	t.Helper()
+	bin := cfg.UpgradeBin(name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(bin), 0755))
+	require.NoError(t, os.WriteFile(bin, []byte(script), 0755))
+}
+
+// stageAndWait stages upgrade and returns its status once staging is done
+func stageAndWait(t *testing.T, stager *Stager, upgrade *types.UpgradeInfo) Status {
+	t.Helper()
+	stager.Stage(upgrade)
+	stager.Wait(upgrade.Name)
+
+	for _, st := range stager.Status() {
+		if st.Name == upgrade.Name {
+			return st
+		}
+	}
+	t.Fatalf("upgrade %s is not staged", upgrade.Name)
+	return Status{}
+}
+
+func TestStager_StagesInstalledBinary(t *testing.T) {
+	stager, cfg := newTestStager(t)
+	installBinary(t, cfg, "v2.0.0", versionScript)
+
+	st := stageAndWait(t, stager, &types.UpgradeInfo{Name: "v2.0.0", Height: 1000})
+
+	assert.Equal(t, StateStaged, st.State)
+	assert.Empty(t, st.Error)
+	assert.Equal(t, "wemixd version v2.0.0", st.Version)
+	assert.Len(t, st.SHA256, 64)
+	assert.Equal(t, cfg.UpgradeBin("v2.0.0"), st.Binary)
+	require.NotNil(t, st.CompletedAt)
+}
+
+func TestStager_DownloadsBinary(t *testing.T) {
+	sum := sha256.Sum256([]byte(versionScript))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/wemixd":
+			w.Write([]byte(versionScript))
+		case "/wemixd.sha256":
+			w.Write([]byte(hex.EncodeToString(sum[:]) + "  wemixd\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	stager, cfg := newTestStager(t)
+	cfg.AllowDownloadBinaries = true
+	cfg.DownloadURLs = map[string]string{"v2.0.0": server.URL + "/wemixd"}
+
+	st := stageAndWait(t, stager, &types.UpgradeInfo{Name: "v2.0.0", Height: 1000})
+
+	assert.Equal(t, StateStaged, st.State, st.Error)
+	assert.Equal(t, hex.EncodeToString(sum[:]), st.SHA256)
+	assert.FileExists(t, cfg.UpgradeBin("v2.0.0"))
+}
+
+func TestStager_Failures(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		info    map[string]interface{}
+		wantErr string
+	}{
+		{"missing binary", "", nil, "downloads disabled"},
+		{"checksum mismatch", versionScript, map[string]interface{}{"checksum": hex.EncodeToString(make([]byte, 32))}, "checksum mismatch"},
+		{"smoke test", "#!/bin/sh\nexit 1\n", nil, "smoke test failed"},
+		{"version mismatch", versionScript, map[string]interface{}{"version": "v3.0.0"}, "version check failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stager, cfg := newTestStager(t)
+			if tt.script != "" {
+				installBinary(t, cfg, "v2.0.0", tt.script)
+			}
+
+			st := stageAndWait(t, stager, &types.UpgradeInfo{Name: "v2.0.0", Height: 1000, Info: tt.info})
+
+			assert.Equal(t, StateFailed, st.State)
+			assert.Contains(t, st.Error, tt.wantErr)
+		})
+	}
+}
+
+func TestStager_StageIsIdempotent(t *testing.T) {
+	stager, cfg := newTestStager(t)
+	installBinary(t, cfg, "v2.0.0", versionScript)
+
+	first := stageAndWait(t, stager, &types.UpgradeInfo{Name: "v2.0.0", Height: 1000})
+	second := stageAndWait(t, stager, &types.UpgradeInfo{Name: "v2.0.0", Height: 1200})
+
+	assert.Equal(t, first.StartedAt, second.StartedAt, "a staged binary is not staged again")
+	assert.Equal(t, int64(1200), second.Height, "the upgrade height follows the latest announcement")
+	assert.Len(t, stager.Status(), 1)
+}
+
+func TestStager_CheckDeadlines(t *testing.T) {
+	stager, cfg := newTestStager(t)
+	alerter := &mockAlerter{}
+	stager.SetAlerter(alerter)
+
+	installBinary(t, cfg, "v2.0.0", versionScript)
+	stageAndWait(t, stager, &types.UpgradeInfo{Name: "v2.0.0", Height: 1000})
+	stageAndWait(t, stager, &types.UpgradeInfo{Name: "v3.0.0", Height: 2000})
+
+	// Far from both upgrades
+	stager.CheckDeadlines(1800)
+	assert.Equal(t, 0, alerter.count())
+
+	// Within the alert window of v3.0.0, whose binary is missing; v2.0.0 is
+	// staged
+	stager.CheckDeadlines(1950)
+	require.Equal(t, 1, alerter.count())
+	assert.Equal(t, "UpgradeNotStaged", alerter.alerts[0].Name)
+	assert.Equal(t, "v3.0.0", alerter.alerts[0].Labels["upgrade"])
+	assert.Equal(t, float64(50), alerter.alerts[0].Value)
+
+	// Alerted once only
+	stager.CheckDeadlines(1960)
+	assert.Equal(t, 1, alerter.count())
+}
+
+func TestStager_CheckDeadlinesDisabled(t *testing.T) {
+	stager, _ := newTestStager(t)
+	stager.cfg.StagingAlertBlocks = 0
+	alerter := &mockAlerter{}
+	stager.SetAlerter(alerter)
+
+	stageAndWait(t, stager, &types.UpgradeInfo{Name: "v2.0.0", Height: 1000})
+	stager.CheckDeadlines(999)
+
+	assert.Equal(t, 0, alerter.count())
+}
+
+func TestStager_StartFollowsHeights(t *testing.T) {
+	stager, _ := newTestStager(t)
+	alerter := &mockAlerter{}
+	stager.SetAlerter(alerter)
+	stageAndWait(t, stager, &types.UpgradeInfo{Name: "v2.0.0", Height: 1000})
+
+	heights := make(chan int64, 1)
+	stager.Start(heights)
+	defer stager.Stop()
+
+	heights <- 950
+	assert.Eventually(t, func() bool { return alerter.count() == 1 },
+		time.Second, 10*time.Millisecond)
+}
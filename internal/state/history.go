@@ -0,0 +1,64 @@
+package state
+
+import "time"
+
+// Upgrade history entry statuses
+const (
+	HistoryApplied    = "applied"
+	HistoryRolledBack = "rolled_back"
+)
+
+// HistoryEntry records an upgrade applied to the node. Entries are kept in
+// the order the upgrades were applied.
+type HistoryEntry struct {
+	Name         string     `json:"name"`
+	Height       int64      `json:"height"`
+	BinaryHash   string     `json:"binary_sha256,omitempty"`
+	AppliedAt    time.Time  `json:"applied_at"`
+	Status       string     `json:"status"`
+	RolledBackAt *time.Time `json:"rolled_back_at,omitempty"`
+}
+
+// RecordApplied appends an applied upgrade to the history
+func (u *UpgradeState) RecordApplied(name string, height int64, binaryHash string, at time.Time) {
+	u.History = append(u.History, HistoryEntry{
+		Name:       name,
+		Height:     height,
+		BinaryHash: binaryHash,
+		AppliedAt:  at,
+		Status:     HistoryApplied,
+	})
+}
+
+// LastApplied returns the index of the latest applied entry for the named
+// upgrade, or -1 if there is none
+func (u *UpgradeState) LastApplied(name string) int {
+	for i := len(u.History) - 1; i >= 0; i-- {
+		if u.History[i].Name == name && u.History[i].Status == HistoryApplied {
+			return i
+		}
+	}
+	return -1
+}
+
+// LastKnownGood returns the index of the latest applied entry for an
+// upgrade other than exclude, or -1 if there is none
+func (u *UpgradeState) LastKnownGood(exclude string) int {
+	for i := len(u.History) - 1; i >= 0; i-- {
+		if u.History[i].Name != exclude && u.History[i].Status == HistoryApplied {
+			return i
+		}
+	}
+	return -1
+}
+
+// RollBackAfter marks every applied entry after index as rolled back. An
+// index of -1 rolls back the whole history.
+func (u *UpgradeState) RollBackAfter(index int, at time.Time) {
+	for i := index + 1; i < len(u.History); i++ {
+		if u.History[i].Status == HistoryApplied {
+			u.History[i].Status = HistoryRolledBack
+			u.History[i].RolledBackAt = &at
+		}
+	}
+}
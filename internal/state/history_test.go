@@ -0,0 +1,57 @@
+package state
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wemix/wemixvisor/internal/config"
+)
+
+func TestUpgradeState_History(t *testing.T) {
+	applied := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var u UpgradeState
+	u.RecordApplied("v1", 100, "aa", applied)
+	u.RecordApplied("v2", 200, "bb", applied.Add(time.Hour))
+	u.RecordApplied("v3", 300, "cc", applied.Add(2*time.Hour))
+
+	assert.Equal(t, 1, u.LastApplied("v2"))
+	assert.Equal(t, -1, u.LastApplied("v4"))
+	assert.Equal(t, 2, u.LastKnownGood("v4"), "a failed upgrade is not in the history")
+	assert.Equal(t, 1, u.LastKnownGood("v3"))
+
+	rolledBack := applied.Add(3 * time.Hour)
+	u.RollBackAfter(0, rolledBack)
+
+	assert.Equal(t, HistoryApplied, u.History[0].Status)
+	for _, entry := range u.History[1:] {
+		assert.Equal(t, HistoryRolledBack, entry.Status)
+		require.NotNil(t, entry.RolledBackAt)
+		assert.True(t, rolledBack.Equal(*entry.RolledBackAt))
+	}
+	assert.Equal(t, 0, u.LastKnownGood("v3"))
+	assert.Equal(t, -1, u.LastApplied("v2"), "rolled back entries are not applied")
+
+	u.RollBackAfter(-1, rolledBack)
+	assert.Equal(t, -1, u.LastKnownGood(""))
+}
+
+func TestStore_HistorySurvivesReload(t *testing.T) {
+	cfg := &config.Config{Home: t.TempDir()}
+	applied := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	require.NoError(t, NewStore(cfg).Update(func(st *State) {
+		st.Upgrade.RecordApplied("v1", 100, "aa", applied)
+	}))
+
+	st, err := NewStore(cfg).Load()
+	require.NoError(t, err)
+	require.Len(t, st.Upgrade.History, 1)
+	entry := st.Upgrade.History[0]
+	assert.Equal(t, "v1", entry.Name)
+	assert.Equal(t, int64(100), entry.Height)
+	assert.Equal(t, "aa", entry.BinaryHash)
+	assert.True(t, applied.Equal(entry.AppliedAt))
+	assert.Equal(t, HistoryApplied, entry.Status)
+}
@@ -0,0 +1,50 @@
+// Package state persists supervisor runtime state, such as restart counts,
+// the last crash and upgrade progress, so it survives wemixvisor restarts.
+package state
+
+import (
+	"time"
+
+	"github.com/wemix/wemixvisor/pkg/types"
+)
+
+// SchemaVersion is the version of the state file layout written by this
+// build. Older files are migrated to it when loaded.
+const SchemaVersion = 1
+
+// State is the supervisor runtime state persisted across restarts
+type State struct {
+	Version   int       `json:"version"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	Node    NodeState    `json:"node"`
+	Upgrade UpgradeState `json:"upgrade"`
+}
+
+// NodeState is the node lifecycle state kept by the node manager
+type NodeState struct {
+	RestartCount int       `json:"restart_count"`
+	StartTime    time.Time `json:"start_time"`
+	LastCrash    *Crash    `json:"last_crash,omitempty"`
+}
+
+// Crash summarizes the most recent unexpected node exit
+type Crash struct {
+	Time   time.Time `json:"time"`
+	Reason string    `json:"reason"`
+}
+
+// UpgradeState is the upgrade progress kept by the upgrade orchestrator and
+// supervisor, including the history of applied upgrades
+type UpgradeState struct {
+	Pending *types.UpgradeInfo `json:"pending,omitempty"`
+	Last    *CompletedUpgrade  `json:"last,omitempty"`
+	History []HistoryEntry     `json:"history,omitempty"`
+}
+
+// CompletedUpgrade records the last upgrade that was applied successfully
+type CompletedUpgrade struct {
+	Name        string    `json:"name"`
+	Height      int64     `json:"height"`
+	CompletedAt time.Time `json:"completed_at"`
+}
@@ -0,0 +1,168 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/pkg/fsutil"
+)
+
+// ErrUnsupportedVersion is returned for a state file written by a newer
+// wemixvisor than this one
+var ErrUnsupportedVersion = errors.New("unsupported state schema version")
+
+// migration upgrades a decoded state document by one schema version
+type migration func(doc map[string]interface{}) error
+
+// migrations[i] upgrades a document from version i+1 to version i+2.
+// Raising SchemaVersion requires appending the matching migration.
+var migrations = []migration{}
+
+// fileLocks holds one lock per state file, shared by all Stores for it
+var fileLocks sync.Map
+
+// Store reads and atomically updates the runtime state file. Components
+// each hold their own Store: Stores for the same file share a lock and
+// every update re-reads the file, so updates to different parts of the
+// state do not overwrite each other.
+type Store struct {
+	path string
+	mu   *sync.Mutex
+}
+
+// NewStore creates a runtime state store for the given configuration
+func NewStore(cfg *config.Config) *Store {
+	path := cfg.RuntimeStatePath()
+	mu, _ := fileLocks.LoadOrStore(path, &sync.Mutex{})
+
+	return &Store{
+		path: path,
+		mu:   mu.(*sync.Mutex),
+	}
+}
+
+// Path returns the path of the state file
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Load returns the persisted state, or an empty state if none has been
+// saved yet
+func (s *Store) Load() (*State, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.load()
+}
+
+// Update applies fn to the persisted state and writes the result back. A
+// state file that cannot be loaded is left untouched and its error
+// returned; see Quarantine.
+func (s *Store) Update(fn func(st *State)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := s.load()
+	if err != nil {
+		return err
+	}
+
+	fn(st)
+	return s.save(st)
+}
+
+// Quarantine moves the state file aside to <path>.corrupt-<timestamp> and
+// returns the new path, so that state is saved afresh while the unreadable
+// file is kept for inspection
+func (s *Store) Quarantine() (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	dest := fmt.Sprintf("%s.corrupt-%s", s.path, time.Now().Format("20060102-150405"))
+	if err := os.Rename(s.path, dest); err != nil {
+		return "", fmt.Errorf("failed to move state file aside: %w", err)
+	}
+	return dest, nil
+}
+
+// load reads and decodes the state file. Caller must hold mu.
+func (s *Store) load() (*State, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &State{Version: SchemaVersion}, nil
+		}
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+	return decode(data)
+}
+
+// save atomically writes the state, so a crash leaves either the old or
+// the new state. Caller must hold mu.
+func (s *Store) save(st *State) error {
+	st.Version = SchemaVersion
+	st.UpdatedAt = time.Now()
+
+	data, err := json.MarshalIndent(st, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	if err := fsutil.WriteFileAtomic(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save state file: %w", err)
+	}
+	return nil
+}
+
+// decode parses a state document, migrating it to the current schema
+func decode(data []byte) (*State, error) {
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse state file: %w", err)
+	}
+
+	version, _ := doc["version"].(float64)
+	if version < 1 {
+		return nil, fmt.Errorf("state file has no schema version")
+	}
+	if int(version) > SchemaVersion {
+		return nil, fmt.Errorf("%w: %d (this build supports up to %d)",
+			ErrUnsupportedVersion, int(version), SchemaVersion)
+	}
+
+	if err := migrate(doc, int(version), migrations); err != nil {
+		return nil, err
+	}
+
+	migrated, err := json.Marshal(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode migrated state: %w", err)
+	}
+
+	var st State
+	if err := json.Unmarshal(migrated, &st); err != nil {
+		return nil, fmt.Errorf("failed to decode state: %w", err)
+	}
+	return &st, nil
+}
+
+// migrate applies the migrations from version onwards to doc, leaving it at
+// the version following the last migration
+func migrate(doc map[string]interface{}, version int, steps []migration) error {
+	for ; version <= len(steps); version++ {
+		if err := steps[version-1](doc); err != nil {
+			return fmt.Errorf("failed to migrate state from version %d: %w", version, err)
+		}
+		doc["version"] = float64(version + 1)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/pkg/types"
+)
+
+func TestStore_LoadWithoutFile(t *testing.T) {
+	store := NewStore(&config.Config{Home: t.TempDir()})
+
+	st, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, SchemaVersion, st.Version)
+	assert.Zero(t, st.Node.RestartCount)
+	assert.Nil(t, st.Upgrade.Pending)
+}
+
+func TestStore_UpdateAndReload(t *testing.T) {
+	cfg := &config.Config{Home: t.TempDir()}
+	store := NewStore(cfg)
+	assert.Equal(t, cfg.RuntimeStatePath(), store.Path())
+
+	started := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	require.NoError(t, store.Update(func(st *State) {
+		st.Node.RestartCount = 3
+		st.Node.StartTime = started
+		st.Node.LastCrash = &Crash{Time: started, Reason: "killed by signal killed"}
+	}))
+	require.NoError(t, store.Update(func(st *State) {
+		st.Upgrade.Pending = &types.UpgradeInfo{Name: "v2", Height: 100}
+	}))
+
+	// A separate store sees both updates
+	st, err := NewStore(cfg).Load()
+	require.NoError(t, err)
+	assert.Equal(t, SchemaVersion, st.Version)
+	assert.False(t, st.UpdatedAt.IsZero())
+	assert.Equal(t, 3, st.Node.RestartCount)
+	assert.True(t, started.Equal(st.Node.StartTime))
+	assert.Equal(t, "killed by signal killed", st.Node.LastCrash.Reason)
+	assert.Equal(t, "v2", st.Upgrade.Pending.Name)
+
+	entries, err := os.ReadDir(filepath.Dir(store.Path()))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1, "temporary file should not be left behind")
+}
+
+func TestStore_ConcurrentUpdatesFromSeparateStores(t *testing.T) {
+	cfg := &config.Config{Home: t.TempDir()}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, NewStore(cfg).Update(func(st *State) {
+				st.Node.RestartCount++
+			}))
+		}()
+	}
+	wg.Wait()
+
+	st, err := NewStore(cfg).Load()
+	require.NoError(t, err)
+	assert.Equal(t, 20, st.Node.RestartCount)
+}
+
+func TestStore_UnreadableFileIsNotOverwritten(t *testing.T) {
+	cfg := &config.Config{Home: t.TempDir()}
+	store := NewStore(cfg)
+	require.NoError(t, os.MkdirAll(cfg.WemixvisorDir(), 0755))
+	corrupt := []byte("{not json")
+	require.NoError(t, os.WriteFile(store.Path(), corrupt, 0644))
+
+	_, err := store.Load()
+	assert.Error(t, err)
+
+	err = store.Update(func(st *State) { st.Node.RestartCount = 1 })
+	assert.Error(t, err)
+
+	data, err := os.ReadFile(store.Path())
+	require.NoError(t, err)
+	assert.Equal(t, corrupt, data)
+}
+
+func TestStore_Quarantine(t *testing.T) {
+	cfg := &config.Config{Home: t.TempDir()}
+	store := NewStore(cfg)
+	require.NoError(t, os.MkdirAll(cfg.WemixvisorDir(), 0755))
+	corrupt := []byte("{not json")
+	require.NoError(t, os.WriteFile(store.Path(), corrupt, 0644))
+
+	moved, err := store.Quarantine()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(moved, store.Path()+".corrupt-"))
+
+	data, err := os.ReadFile(moved)
+	require.NoError(t, err)
+	assert.Equal(t, corrupt, data)
+
+	// State is saved afresh once the file is moved aside
+	require.NoError(t, store.Update(func(st *State) { st.Node.RestartCount = 1 }))
+	st, err := store.Load()
+	require.NoError(t, err)
+	assert.Equal(t, 1, st.Node.RestartCount)
+}
+
+func TestStore_NewerVersionIsNotOverwritten(t *testing.T) {
+	cfg := &config.Config{Home: t.TempDir()}
+	store := NewStore(cfg)
+	require.NoError(t, os.MkdirAll(cfg.WemixvisorDir(), 0755))
+	newer := []byte(`{"version": 99, "node": {"restart_count": 7}}`)
+	require.NoError(t, os.WriteFile(store.Path(), newer, 0644))
+
+	_, err := store.Load()
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	err = store.Update(func(st *State) { st.Node.RestartCount = 1 })
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	data, err := os.ReadFile(store.Path())
+	require.NoError(t, err)
+	assert.Equal(t, newer, data)
+}
+
+func TestMigrate(t *testing.T) {
+	steps := []migration{
+		// v1 -> v2: restart count moved under node
+		func(doc map[string]interface{}) error {
+			doc["node"] = map[string]interface{}{"restart_count": doc["restarts"]}
+			delete(doc, "restarts")
+			return nil
+		},
+		// v2 -> v3: nothing to change
+		func(doc map[string]interface{}) error { return nil },
+	}
+
+	doc := map[string]interface{}{"version": float64(1), "restarts": float64(4)}
+	require.NoError(t, migrate(doc, 1, steps))
+	assert.Equal(t, float64(3), doc["version"])
+	assert.Equal(t, map[string]interface{}{"restart_count": float64(4)}, doc["node"])
+
+	// Documents already at the latest version are left alone
+	doc = map[string]interface{}{"version": float64(3)}
+	require.NoError(t, migrate(doc, 3, steps))
+	assert.Equal(t, float64(3), doc["version"])
+}
@@ -0,0 +1,93 @@
+package supervisor
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+
+	"go.uber.org/zap"
+
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/pkg/logger"
+)
+
+// Group supervises several named node instances from one wemixvisor
+// process. Every instance runs under its own Supervisor, and with it its
+// own node.Manager, HeightMonitor, upgrade tracking and control socket, so
+// instances are started, stopped and upgraded independently.
+type Group struct {
+	cfg         *config.Config
+	logger      *logger.Logger
+	supervisors []*Supervisor
+}
+
+// NewGroup creates a supervisor for every instance in the configuration
+func NewGroup(cfg *config.Config, log *logger.Logger) *Group {
+	g := &Group{
+		cfg:    cfg,
+		logger: log,
+	}
+
+	for _, instCfg := range cfg.InstanceConfigs() {
+		instLog := log.With(zap.String("instance", instCfg.Instance))
+		g.supervisors = append(g.supervisors, New(instCfg, instLog))
+	}
+
+	return g
+}
+
+// Run starts every instance, then blocks until a termination signal is
+// received
+func (g *Group) Run() error {
+	if err := g.Start(); err != nil {
+		return err
+	}
+
+	g.logger.Info("wemixvisor supervising instances",
+		zap.Strings("instances", g.cfg.InstanceNames()))
+
+	// SIGUSR2 restarts only the supervisor, as for a single node
+	if sig := waitForSignal(g.logger); sig == syscall.SIGUSR2 {
+		return g.Detach()
+	}
+	return g.Shutdown()
+}
+
+// Start starts every instance with its configured arguments. If one fails
+// to start, the instances already started are shut down again.
+func (g *Group) Start() error {
+	for i, s := range g.supervisors {
+		if err := s.Start(s.cfg.Args); err != nil {
+			for _, started := range g.supervisors[:i] {
+				started.Shutdown()
+			}
+			return fmt.Errorf("failed to start instance %s: %w", s.cfg.Instance, err)
+		}
+	}
+	return nil
+}
+
+// Shutdown stops every instance
+func (g *Group) Shutdown() error {
+	return g.each(func(s *Supervisor) error { return s.Shutdown() })
+}
+
+// Detach releases every instance, leaving the nodes running
+func (g *Group) Detach() error {
+	return g.each(func(s *Supervisor) error { return s.Detach() })
+}
+
+// each applies fn to every instance, reporting all failures
+func (g *Group) each(fn func(s *Supervisor) error) error {
+	var failures []string
+	for _, s := range g.supervisors {
+		if err := fn(s); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", s.cfg.Instance, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("instances failed: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package supervisor
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/node"
+	"github.com/wemix/wemixvisor/pkg/logger"
+)
+
+// setupGroupTest creates two instances, each with its own genesis binary
+func setupGroupTest(t *testing.T) *config.Config {
+	t.Helper()
+
+	base := t.TempDir()
+	cfg := &config.Config{
+		Home:          base,
+		Name:          "wemixd",
+		PollInterval:  50 * time.Millisecond,
+		ShutdownGrace: time.Second,
+		Instances: []config.InstanceConfig{
+			{Name: "validator", Home: filepath.Join(base, "validator")},
+			{Name: "sentry", Home: filepath.Join(base, "sentry")},
+		},
+	}
+	for _, instCfg := range cfg.InstanceConfigs() {
+		installBinary(t, instCfg.GenesisBin())
+	}
+	return cfg
+}
+
+func TestGroup_StartsInstancesIndependently(t *testing.T) {
+	cfg := setupGroupTest(t)
+
+	g := NewGroup(cfg, logger.NewTestLogger())
+	require.Len(t, g.supervisors, 2)
+	require.NoError(t, g.Start())
+	defer g.Shutdown()
+
+	validator, sentry := g.supervisors[0], g.supervisors[1]
+	assert.Equal(t, "validator", validator.cfg.Instance)
+	assert.Equal(t, "sentry", sentry.cfg.Instance)
+	assert.NotZero(t, validator.manager.GetPID())
+	assert.NotZero(t, sentry.manager.GetPID())
+	assert.NotEqual(t, validator.manager.GetPID(), sentry.manager.GetPID())
+
+	// Stopping one instance leaves the other running
+	require.NoError(t, validator.manager.Stop())
+	assert.Equal(t, node.StateStopped, validator.manager.GetState())
+	assert.Equal(t, node.StateRunning, sentry.manager.GetState())
+	assert.Equal(t, "sentry", sentry.manager.GetStatus().Instance)
+
+	require.NoError(t, g.Shutdown())
+	assert.Equal(t, node.StateStopped, sentry.manager.GetState())
+}
+
+func TestGroup_StartRollsBackOnFailure(t *testing.T) {
+	cfg := setupGroupTest(t)
+	// The second instance has no genesis binary
+	cfg.Instances[1].Home = filepath.Join(cfg.Home, "empty")
+
+	g := NewGroup(cfg, logger.NewTestLogger())
+	err := g.Start()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to start instance sentry")
+	assert.Equal(t, node.StateStopped, g.supervisors[0].manager.GetState())
+}
@@ -0,0 +1,147 @@
+package supervisor
+
+import (
+	"errors"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/journal"
+	"github.com/wemix/wemixvisor/internal/state"
+)
+
+// checkState moves an unreadable runtime state file aside, so that state
+// is saved afresh instead of every update failing. A state file written by
+// a newer wemixvisor is left alone.
+func (s *Supervisor) checkState() {
+	_, err := s.stateStore.Load()
+	if err == nil || errors.Is(err, state.ErrUnsupportedVersion) {
+		return
+	}
+
+	moved, qerr := s.stateStore.Quarantine()
+	if qerr != nil {
+		s.logger.Error("runtime state file is unreadable",
+			zap.String("path", s.stateStore.Path()),
+			zap.Error(err),
+			zap.NamedError("quarantine_error", qerr))
+		return
+	}
+	s.logger.Error("runtime state file is unreadable, moved it aside and starting with empty state",
+		zap.String("path", s.stateStore.Path()),
+		zap.String("moved_to", moved),
+		zap.Error(err))
+}
+
+// recoverUpgrade settles an upgrade that the upgrade journal shows was
+// interrupted, before the node is started.
+//
+// An upgrade whose binary switch was recorded and that did not fail is
+// finished: current is pointed at the upgrade and the upgrade is recorded
+// as completed. An upgrade whose node was started on the new binary but not
+// verified is not, as its verification never passed. It and any other
+// incomplete upgrade are rolled back: a node left running is stopped, the
+// pre-upgrade backup, if one was taken, is restored and current is pointed
+// back at the binary the node ran before. Once the node has run on the new binary, the backup is only
+// restored with UpgradeRestoreBackup, as after a failed verification.
+func (s *Supervisor) recoverUpgrade() error {
+	pending, err := s.journal.Incomplete()
+	if err != nil {
+		return err
+	}
+	if pending == nil {
+		return nil
+	}
+
+	s.logger.Warn("found interrupted upgrade",
+		zap.String("name", pending.Info.Name),
+		zap.Strings("steps", pending.Steps))
+
+	unverified := pending.Done(journal.StepNodeStarted) && !pending.Done(journal.StepVerified)
+	if pending.Error == "" && pending.Done(journal.StepBinarySwitched) && !unverified {
+		return s.finishUpgrade(pending)
+	}
+	if unverified {
+		s.logger.Warn("upgrade was interrupted before its verification passed, rolling back",
+			zap.String("name", pending.Info.Name))
+	}
+	return s.rollbackUpgrade(pending)
+}
+
+// finishUpgrade completes an interrupted upgrade whose binary was switched
+func (s *Supervisor) finishUpgrade(pending *journal.Upgrade) error {
+	info := pending.Info
+	if err := config.NewSymlinkManager(s.cfg).LinkToUpgrade(info.Name); err != nil {
+		return err
+	}
+
+	// The upgrade may have been recorded just before the crash
+	st, err := s.stateStore.Load()
+	if err != nil || !lastApplied(st, info.Name) {
+		s.recordUpgrade(info)
+	}
+	if err := s.stateStore.Update(func(st *state.State) {
+		if st.Upgrade.Pending != nil && st.Upgrade.Pending.Name == info.Name {
+			st.Upgrade.Pending = nil
+		}
+	}); err != nil {
+		s.logger.Warn("failed to save upgrade state", zap.Error(err))
+	}
+
+	if err := s.journal.Commit(); err != nil {
+		return err
+	}
+
+	s.logger.Info("finished interrupted upgrade", zap.String("name", info.Name))
+	return nil
+}
+
+// rollbackUpgrade undoes the recorded steps of an interrupted upgrade. A
+// node left running, possibly on the upgrade binary, is stopped first, so
+// that neither its binary nor its data change underneath it.
+func (s *Supervisor) rollbackUpgrade(pending *journal.Upgrade) error {
+	stopped, err := s.manager.StopRecordedNode()
+	if err != nil {
+		return err
+	}
+	if stopped {
+		s.logger.Warn("stopped node left running by the interrupted upgrade",
+			zap.String("name", pending.Info.Name))
+	}
+
+	restoreBackup := !pending.Done(journal.StepNodeStarted) || s.cfg.UpgradeRestoreBackup
+	if pending.BackupPath != "" && restoreBackup {
+		if err := s.upgrader.RestoreBackup(pending.BackupPath); err != nil {
+			return fmt.Errorf("failed to restore pre-upgrade backup: %w", err)
+		}
+	}
+
+	symlinks := config.NewSymlinkManager(s.cfg)
+	switch {
+	case pending.PreviousTarget != "":
+		if err := symlinks.LinkTo(pending.PreviousTarget); err != nil {
+			return err
+		}
+	case pending.Done(journal.StepBinarySwitched):
+		if err := symlinks.LinkToGenesis(); err != nil {
+			return err
+		}
+	}
+
+	if err := s.journal.Abort(); err != nil {
+		return err
+	}
+
+	s.logger.Info("rolled back interrupted upgrade",
+		zap.String("name", pending.Info.Name),
+		zap.String("current", pending.PreviousTarget))
+	return nil
+}
+
+// lastApplied reports whether name is the latest applied upgrade in the
+// upgrade history
+func lastApplied(st *state.State, name string) bool {
+	history := st.Upgrade.History
+	return len(history) > 0 && st.Upgrade.LastApplied(name) == len(history)-1
+}
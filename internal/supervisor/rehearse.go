@@ -0,0 +1,293 @@
+package supervisor
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"go.uber.org/zap"
+
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/orchestrator"
+	"github.com/wemix/wemixvisor/pkg/types"
+)
+
+// Outcomes of a rehearsed upgrade step
+const (
+	RehearsalOK      = "ok"
+	RehearsalFailed  = "fail"
+	RehearsalSkipped = "skip"
+)
+
+// Rehearsed upgrade steps, in the order they run
+const (
+	RehearseStepPlan     = "validate plan"
+	RehearseStepResolve  = "resolve binary"
+	RehearseStepChecksum = "verify checksum"
+	RehearseStepValidate = "validate binary"
+	RehearseStepHook     = "run pre-upgrade hook"
+	RehearseStepBackup   = "check backup space"
+	RehearseStepSwitch   = "switch binary"
+)
+
+// RehearsalStep is the outcome of one rehearsed upgrade step
+type RehearsalStep struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail"`
+}
+
+// Rehearsal reports, step by step, what an upgrade would do
+type Rehearsal struct {
+	Upgrade *types.UpgradeInfo `json:"upgrade"`
+	Steps   []RehearsalStep    `json:"steps"`
+}
+
+// Failed reports whether any step would fail
+func (r *Rehearsal) Failed() bool {
+	for _, step := range r.Steps {
+		if step.Status == RehearsalFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// add records the outcome of a step
+func (r *Rehearsal) add(name, status, detail string) {
+	r.Steps = append(r.Steps, RehearsalStep{Name: name, Status: status, Detail: detail})
+}
+
+// Rehearse walks through the steps of an upgrade without touching the node
+// or its home: the binary is resolved, downloading it into a scratch
+// directory if it is not installed, checked against its checksum and put
+// through the pre-switch validation checks, the pre-upgrade hook runs
+// against a scratch home, the space for the backup is measured and the
+// symlink switch is tried on a scratch link. A failing step is reported
+// and the rehearsal goes on with the next one.
+//
+// A positive currentHeight is checked against the upgrade height, if it
+// has one.
+func (u *Upgrader) Rehearse(info *types.UpgradeInfo, currentHeight int64) (*Rehearsal, error) {
+	scratch, err := os.MkdirTemp("", "wemixvisor-rehearse-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scratch directory: %w", err)
+	}
+	defer os.RemoveAll(scratch)
+
+	u.logger.Info("rehearsing upgrade",
+		zap.String("name", info.Name),
+		zap.String("scratch", scratch))
+
+	r := &Rehearsal{Upgrade: info}
+	u.rehearsePlan(r, info, currentHeight)
+	binary, binaryURL := u.rehearseResolve(r, info, scratch)
+	u.rehearseChecksum(r, info, binary, binaryURL)
+	u.rehearseValidate(r, info, binary)
+	u.rehearseHook(r, info, filepath.Join(scratch, "home"))
+	u.rehearseBackup(r)
+	u.rehearseSwitch(r, info, binary)
+
+	return r, nil
+}
+
+// rehearsePlan validates the upgrade plan as the orchestrator would
+func (u *Upgrader) rehearsePlan(r *Rehearsal, info *types.UpgradeInfo, currentHeight int64) {
+	if info.Height <= 0 && !info.HasTime() {
+		r.add(RehearseStepPlan, RehearsalSkipped, "upgrade is not scheduled, its height is not checked")
+		return
+	}
+
+	if err := orchestrator.ValidateUpgrade(info, currentHeight); err != nil {
+		r.add(RehearseStepPlan, RehearsalFailed, err.Error())
+		return
+	}
+
+	detail := fmt.Sprintf("upgrade at %s", info.Condition())
+	if currentHeight > 0 {
+		detail += fmt.Sprintf(", current height %d", currentHeight)
+	}
+	r.add(RehearseStepPlan, RehearsalOK, detail)
+}
+
+// rehearseResolve finds the installed upgrade binary or downloads it into
+// the scratch directory. It returns the binary, empty if it could not be
+// resolved, and the URL it was downloaded from, if any.
+func (u *Upgrader) rehearseResolve(r *Rehearsal, info *types.UpgradeInfo, scratch string) (string, string) {
+	binary := u.cfg.UpgradeBin(info.Name)
+	binaryURL := ""
+
+	if _, err := os.Stat(binary); err != nil {
+		if !u.cfg.AllowDownloadBinaries {
+			r.add(RehearseStepResolve, RehearsalFailed,
+				fmt.Sprintf("binary not installed at %s and downloads are disabled", binary))
+			return "", ""
+		}
+
+		binaryURL, err = u.downloader.GetBinaryURL(info.Name)
+		if err != nil {
+			r.add(RehearseStepResolve, RehearsalFailed, err.Error())
+			return "", ""
+		}
+
+		binary = filepath.Join(scratch, u.cfg.Name)
+		if err := u.downloader.DownloadBinary(binaryURL, binary); err != nil {
+			r.add(RehearseStepResolve, RehearsalFailed, err.Error())
+			return "", ""
+		}
+	}
+
+	if err := config.CheckExecutable(binary); err != nil {
+		r.add(RehearseStepResolve, RehearsalFailed, err.Error())
+		return "", ""
+	}
+
+	if binaryURL != "" {
+		r.add(RehearseStepResolve, RehearsalOK, fmt.Sprintf("downloaded from %s into the scratch area", binaryURL))
+	} else {
+		r.add(RehearseStepResolve, RehearsalOK, fmt.Sprintf("installed at %s", binary))
+	}
+	return binary, binaryURL
+}
+
+// rehearseChecksum verifies the binary against the checksum in the upgrade
+// plan or, failing that, the one published next to its download URL
+func (u *Upgrader) rehearseChecksum(r *Rehearsal, info *types.UpgradeInfo, binary, binaryURL string) {
+	if binary == "" {
+		r.add(RehearseStepChecksum, RehearsalSkipped, "no binary to verify")
+		return
+	}
+
+	expected, _ := info.Info["checksum"].(string)
+	source := "the upgrade plan"
+	if expected == "" {
+		if binaryURL == "" && u.cfg.AllowDownloadBinaries {
+			binaryURL, _ = u.downloader.GetBinaryURL(info.Name)
+		}
+		if binaryURL == "" {
+			r.add(RehearseStepChecksum, RehearsalSkipped, "no checksum in the upgrade plan and no download URL")
+			return
+		}
+
+		checksumURL := u.downloader.GetChecksumURL(binaryURL)
+		checksum, err := u.downloader.FetchChecksum(checksumURL)
+		if err != nil {
+			status := RehearsalFailed
+			if u.cfg.UnsafeSkipChecksum {
+				status = RehearsalSkipped
+			}
+			r.add(RehearseStepChecksum, status, fmt.Sprintf("no checksum at %s: %v", checksumURL, err))
+			return
+		}
+		expected, source = checksum, checksumURL
+	}
+
+	if err := u.downloader.VerifyChecksum(binary, expected); err != nil {
+		r.add(RehearseStepChecksum, RehearsalFailed, err.Error())
+		return
+	}
+	r.add(RehearseStepChecksum, RehearsalOK, fmt.Sprintf("matches the checksum from %s", source))
+}
+
+// rehearseValidate puts the binary through the checks it must pass before
+// the node is switched to it
+func (u *Upgrader) rehearseValidate(r *Rehearsal, info *types.UpgradeInfo, binary string) {
+	if binary == "" {
+		r.add(RehearseStepValidate, RehearsalSkipped, "no binary to validate")
+		return
+	}
+
+	if err := u.preHook.ValidateBinary(binary, info); err != nil {
+		r.add(RehearseStepValidate, RehearsalFailed, err.Error())
+		return
+	}
+	r.add(RehearseStepValidate, RehearsalOK, "passed the pre-switch checks")
+}
+
+// rehearseHook runs the pre-upgrade hook against a scratch home
+func (u *Upgrader) rehearseHook(r *Rehearsal, info *types.UpgradeInfo, scratchHome string) {
+	if err := os.MkdirAll(scratchHome, 0755); err != nil {
+		r.add(RehearseStepHook, RehearsalFailed, fmt.Sprintf("failed to create scratch home: %v", err))
+		return
+	}
+
+	script, err := u.preHook.Rehearse(info, scratchHome)
+	switch {
+	case err != nil:
+		r.add(RehearseStepHook, RehearsalFailed, fmt.Sprintf("%s: %v", script, err))
+	case script == "":
+		r.add(RehearseStepHook, RehearsalSkipped, "no pre-upgrade hook")
+	default:
+		r.add(RehearseStepHook, RehearsalOK, fmt.Sprintf("%s succeeded with UPGRADE_DRY_RUN=true", script))
+	}
+}
+
+// rehearseBackup checks that the pre-upgrade backup fits in the backup
+// directory
+func (u *Upgrader) rehearseBackup(r *Rehearsal) {
+	if u.cfg.UnsafeSkipBackup {
+		r.add(RehearseStepBackup, RehearsalSkipped, "backups are disabled (unsafe_skip_backup)")
+		return
+	}
+
+	estimate, err := u.backup.EstimateSpace()
+	if err != nil {
+		r.add(RehearseStepBackup, RehearsalFailed, err.Error())
+		return
+	}
+
+	detail := fmt.Sprintf("data is %s, %s free in %s",
+		megabytes(estimate.DataBytes), megabytes(estimate.FreeBytes), u.cfg.DataBackupPath)
+	if !estimate.Sufficient() {
+		r.add(RehearseStepBackup, RehearsalFailed, detail)
+		return
+	}
+	r.add(RehearseStepBackup, RehearsalOK, detail)
+}
+
+// rehearseSwitch checks that current can be read and relinked, using a
+// scratch link next to it
+func (u *Upgrader) rehearseSwitch(r *Rehearsal, info *types.UpgradeInfo, binary string) {
+	if binary == "" {
+		r.add(RehearseStepSwitch, RehearsalSkipped, "no binary to switch to")
+		return
+	}
+
+	current, err := config.NewSymlinkManager(u.cfg).CurrentTarget()
+	if err != nil {
+		r.add(RehearseStepSwitch, RehearsalFailed, err.Error())
+		return
+	}
+
+	target := u.cfg.UpgradeDir(info.Name)
+	probe := u.cfg.CurrentDir() + ".rehearse"
+	rel, err := filepath.Rel(filepath.Dir(probe), target)
+	if err == nil {
+		os.Remove(probe)
+		err = os.Symlink(rel, probe)
+		os.Remove(probe)
+	}
+	if err != nil {
+		r.add(RehearseStepSwitch, RehearsalFailed, fmt.Sprintf("cannot link current: %v", err))
+		return
+	}
+
+	var detail string
+	switch current {
+	case "":
+		detail = fmt.Sprintf("current would be created pointing at %s", target)
+	case target:
+		detail = fmt.Sprintf("current already points at %s", target)
+	default:
+		detail = fmt.Sprintf("current would switch from %s to %s", current, target)
+	}
+	if binary != u.cfg.UpgradeBin(info.Name) {
+		detail += " once the download installs the binary there"
+	}
+	r.add(RehearseStepSwitch, RehearsalOK, detail)
+}
+
+// megabytes formats a byte count in megabytes
+func megabytes(bytes uint64) string {
+	return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+}
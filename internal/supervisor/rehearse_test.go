@@ -0,0 +1,123 @@
+package supervisor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wemix/wemixvisor/pkg/logger"
+	"github.com/wemix/wemixvisor/pkg/types"
+)
+
+// stepStatuses maps each rehearsed step to its outcome
+func stepStatuses(r *Rehearsal) map[string]string {
+	statuses := make(map[string]string)
+	for _, step := range r.Steps {
+		statuses[step.Name] = step.Status
+	}
+	return statuses
+}
+
+func TestUpgrader_RehearseInstalledUpgrade(t *testing.T) {
+	cfg := setupSupervisorTest(t)
+	cfg.UnsafeSkipBackup = false
+	cfg.DataBackupPath = filepath.Join(cfg.Home, "backups")
+	require.NoError(t, os.MkdirAll(filepath.Join(cfg.Home, "data"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(cfg.Home, "data", "chain.db"), []byte("blocks"), 0644))
+	installBinary(t, cfg.UpgradeBin("v2"))
+	hook := "#!/bin/sh\n[ \"$UPGRADE_DRY_RUN\" = true ] || exit 1\ntouch \"$DAEMON_HOME/hook-ran\"\n"
+	require.NoError(t, os.WriteFile(filepath.Join(cfg.UpgradeDir("v2"), "pre-upgrade"), []byte(hook), 0755))
+	upgrader := NewUpgrader(cfg, logger.NewTestLogger())
+	require.NoError(t, upgrader.EnsureCurrentLink())
+	before := currentTarget(t, cfg)
+
+	sum := sha256.Sum256([]byte(mockNodeScript))
+	info := &types.UpgradeInfo{
+		Name:   "v2",
+		Height: 100,
+		Info:   map[string]interface{}{"checksum": hex.EncodeToString(sum[:])},
+	}
+
+	rehearsal, err := upgrader.Rehearse(info, 90)
+	require.NoError(t, err)
+
+	assert.False(t, rehearsal.Failed(), "%+v", rehearsal.Steps)
+	assert.Equal(t, map[string]string{
+		RehearseStepPlan:     RehearsalOK,
+		RehearseStepResolve:  RehearsalOK,
+		RehearseStepChecksum: RehearsalOK,
+		RehearseStepValidate: RehearsalOK,
+		RehearseStepHook:     RehearsalOK,
+		RehearseStepBackup:   RehearsalOK,
+		RehearseStepSwitch:   RehearsalOK,
+	}, stepStatuses(rehearsal))
+
+	assert.Equal(t, before, currentTarget(t, cfg), "current link should be unchanged")
+	assert.NoFileExists(t, filepath.Join(cfg.Home, "hook-ran"), "the hook should run against a scratch home")
+	assert.NoFileExists(t, cfg.CurrentDir()+".rehearse")
+	assert.NoDirExists(t, cfg.DataBackupPath, "no backup should be taken")
+}
+
+func TestUpgrader_RehearseReportsFailingSteps(t *testing.T) {
+	cfg := setupSupervisorTest(t)
+	cfg.CustomPreUpgrade = filepath.Join(cfg.Home, "pre-upgrade.sh")
+	require.NoError(t, os.WriteFile(cfg.CustomPreUpgrade, []byte("#!/bin/sh\nexit 1\n"), 0755))
+	upgrader := NewUpgrader(cfg, logger.NewTestLogger())
+	require.NoError(t, upgrader.EnsureCurrentLink())
+
+	rehearsal, err := upgrader.Rehearse(&types.UpgradeInfo{Name: "v2", Height: 100}, 150)
+	require.NoError(t, err)
+
+	assert.True(t, rehearsal.Failed())
+	assert.Equal(t, map[string]string{
+		RehearseStepPlan:     RehearsalFailed,
+		RehearseStepResolve:  RehearsalFailed,
+		RehearseStepChecksum: RehearsalSkipped,
+		RehearseStepValidate: RehearsalSkipped,
+		RehearseStepHook:     RehearsalFailed,
+		RehearseStepBackup:   RehearsalSkipped,
+		RehearseStepSwitch:   RehearsalSkipped,
+	}, stepStatuses(rehearsal))
+	assert.Contains(t, rehearsal.Steps[1].Detail, "downloads are disabled")
+}
+
+func TestUpgrader_RehearseDownloadsIntoScratch(t *testing.T) {
+	sum := sha256.Sum256([]byte(mockNodeScript))
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/wemixd":
+			w.Write([]byte(mockNodeScript))
+		case "/wemixd.sha256":
+			w.Write([]byte(hex.EncodeToString(sum[:]) + "  wemixd\n"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	cfg := setupSupervisorTest(t)
+	cfg.AllowDownloadBinaries = true
+	cfg.DownloadURLs = map[string]string{"v2": server.URL + "/wemixd"}
+	upgrader := NewUpgrader(cfg, logger.NewTestLogger())
+	require.NoError(t, upgrader.EnsureCurrentLink())
+	before := currentTarget(t, cfg)
+
+	rehearsal, err := upgrader.Rehearse(&types.UpgradeInfo{Name: "v2"}, 0)
+	require.NoError(t, err)
+
+	assert.False(t, rehearsal.Failed(), "%+v", rehearsal.Steps)
+	statuses := stepStatuses(rehearsal)
+	assert.Equal(t, RehearsalSkipped, statuses[RehearseStepPlan], "an unscheduled upgrade has no height to check")
+	assert.Equal(t, RehearsalOK, statuses[RehearseStepResolve])
+	assert.Equal(t, RehearsalOK, statuses[RehearseStepChecksum])
+	assert.Equal(t, RehearsalOK, statuses[RehearseStepSwitch])
+	assert.NoDirExists(t, cfg.UpgradeDir("v2"), "the binary should only be downloaded into the scratch area")
+	assert.Equal(t, before, currentTarget(t, cfg))
+}
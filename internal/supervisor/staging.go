@@ -0,0 +1,118 @@
+package supervisor
+
+import (
+	"errors"
+	"io/fs"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wemix/wemixvisor/internal/batch"
+	"github.com/wemix/wemixvisor/pkg/types"
+)
+
+// PlanScanInterval is how often the batch plans directory and the
+// announced upgrades file are scanned for upgrades to be staged
+const PlanScanInterval = time.Minute
+
+// startStaging starts staging upgrades ahead of time: upgrades scheduled on
+// the orchestrator are staged as they are scheduled, those of batch plans
+// and those announced by the governance scheduler as they appear. Staging
+// deadlines are checked against the chain height when the height monitor
+// is running.
+func (s *Supervisor) startStaging() {
+	s.stager.SetAlerter(s.manager)
+
+	var heights <-chan int64
+	if s.heightMonitor != nil {
+		heights = s.heightMonitor.Subscribe()
+	}
+	s.stager.Start(heights)
+
+	s.wg.Add(1)
+	go s.watchBatchPlans()
+}
+
+// watchBatchPlans stages the upgrades of every batch plan and the announced
+// upgrades, scanning for new ones every PlanScanInterval
+func (s *Supervisor) watchBatchPlans() {
+	defer s.wg.Done()
+
+	plans := batch.NewBatchManager(s.cfg, s.logger)
+	scanned := make(map[string]bool)
+
+	ticker := time.NewTicker(PlanScanInterval)
+	defer ticker.Stop()
+
+	for {
+		s.stageBatchPlans(plans, scanned)
+		s.stageAnnouncedUpgrades()
+
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// stageBatchPlans stages the upgrades still ahead in the batch plans not
+// scanned yet
+func (s *Supervisor) stageBatchPlans(plans *batch.BatchManager, scanned map[string]bool) {
+	files, err := plans.ListPlans()
+	if err != nil {
+		s.logger.Warn("failed to list batch plans", zap.Error(err))
+		return
+	}
+
+	var currentHeight int64
+	if s.heightMonitor != nil {
+		currentHeight = s.heightMonitor.GetCurrentHeight()
+	}
+
+	for _, file := range files {
+		if scanned[file] {
+			continue
+		}
+		scanned[file] = true
+
+		plan, err := plans.LoadPlan(file)
+		if err != nil {
+			s.logger.Warn("failed to load batch plan",
+				zap.String("plan", file),
+				zap.Error(err))
+			continue
+		}
+
+		for i := range plan.Upgrades {
+			if upgrade := &plan.Upgrades[i]; upgrade.Height > currentHeight {
+				s.stager.Stage(upgrade)
+			}
+		}
+	}
+}
+
+// stageAnnouncedUpgrades stages the upgrades still ahead in the announced
+// upgrades file written by the governance scheduler. Upgrades already
+// staged or being staged are left alone by the stager.
+func (s *Supervisor) stageAnnouncedUpgrades() {
+	announced, err := types.ParseUpgradeInfoList(s.cfg.AnnouncedUpgradesPath())
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			s.logger.Warn("failed to load announced upgrades", zap.Error(err))
+		}
+		return
+	}
+
+	var currentHeight int64
+	if s.heightMonitor != nil {
+		currentHeight = s.heightMonitor.GetCurrentHeight()
+	}
+
+	for _, upgrade := range announced {
+		// Upgrades activated by time only have no height to compare
+		if upgrade != nil && (upgrade.Height == 0 || upgrade.Height > currentHeight) {
+			s.stager.Stage(upgrade)
+		}
+	}
+}
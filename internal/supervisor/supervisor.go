@@ -0,0 +1,459 @@
+// Package supervisor runs the managed node together with the services
+// around it. It is the single supervisor behind every CLI front end.
+package supervisor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/control"
+	"github.com/wemix/wemixvisor/internal/governance"
+	"github.com/wemix/wemixvisor/internal/height"
+	"github.com/wemix/wemixvisor/internal/journal"
+	"github.com/wemix/wemixvisor/internal/metrics"
+	"github.com/wemix/wemixvisor/internal/node"
+	"github.com/wemix/wemixvisor/internal/orchestrator"
+	"github.com/wemix/wemixvisor/internal/staging"
+	"github.com/wemix/wemixvisor/internal/state"
+	"github.com/wemix/wemixvisor/internal/upgrade"
+	"github.com/wemix/wemixvisor/internal/version"
+	"github.com/wemix/wemixvisor/pkg/logger"
+	"github.com/wemix/wemixvisor/pkg/types"
+)
+
+// Supervisor owns the live node.Manager, which provides the restart policy,
+// health monitoring and metrics, and adds upgrade-info watching, upgrades
+// with backups and hooks, staging of upgrade binaries ahead of time, the
+// stalled-chain watchdog, and the control socket through which other CLI
+// invocations reach it.
+//
+// With upgrade automation enabled, upgrades are carried out at their height
+// by the UpgradeOrchestrator. Otherwise an upgrade is applied as soon as
+// upgrade-info.json appears, which is when the node has halted for it. In
+// both cases a node that halts itself printing a halt marker is upgraded at
+// once rather than restarted as crashed.
+type Supervisor struct {
+	cfg    *config.Config
+	logger *logger.Logger
+
+	manager       *node.Manager
+	control       *control.Server
+	upgrader      *Upgrader
+	watcher       *upgrade.FileWatcher
+	heightMonitor *height.HeightMonitor
+	watchdog      *node.StallWatchdog
+	orchestrator  *orchestrator.UpgradeOrchestrator
+	stager        *staging.Stager
+	stateStore    *state.Store
+	journal       *journal.Journal
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// staticConfigManager adapts a plain config to orchestrator.ConfigManager
+type staticConfigManager struct {
+	cfg *config.Config
+}
+
+// GetConfig returns the wrapped configuration
+func (s *staticConfigManager) GetConfig() *config.Config {
+	return s.cfg
+}
+
+// New creates a supervisor for the given configuration
+func New(cfg *config.Config, log *logger.Logger) *Supervisor {
+	manager := node.NewManager(cfg, log)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	stager := staging.NewStager(cfg, log)
+	upgrader := NewUpgrader(cfg, log)
+	upgrader.SetStager(stager)
+
+	server := control.NewServer(cfg, manager, log)
+	server.SetStager(stager)
+
+	return &Supervisor{
+		cfg:        cfg,
+		logger:     log,
+		manager:    manager,
+		control:    server,
+		upgrader:   upgrader,
+		stager:     stager,
+		stateStore: state.NewStore(cfg),
+		journal:    journal.New(cfg),
+		ctx:        ctx,
+		cancel:     cancel,
+	}
+}
+
+// Run starts the supervisor, then blocks until a termination signal is
+// received
+func (s *Supervisor) Run(args []string) error {
+	if err := s.Start(args); err != nil {
+		return err
+	}
+
+	s.logger.Info("wemixvisor supervisor running",
+		zap.Int("pid", s.manager.GetPID()),
+		zap.String("socket", s.control.SocketPath()))
+
+	// SIGUSR2 restarts only the supervisor: the node keeps running and is
+	// adopted by the next wemixvisor started with the same home
+	if sig := waitForSignal(s.logger); sig == syscall.SIGUSR2 {
+		return s.Detach()
+	}
+	return s.Shutdown()
+}
+
+// waitForSignal blocks until a termination or detach signal is received
+func waitForSignal(log *logger.Logger) os.Signal {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT, syscall.SIGUSR2)
+	defer signal.Stop(sigCh)
+
+	sig := <-sigCh
+	log.Info("received signal", zap.String("signal", sig.String()))
+	return sig
+}
+
+// Start starts the node and the supporting services. An upgrade left
+// incomplete by a crash is finished or rolled back first.
+func (s *Supervisor) Start(args []string) error {
+	s.checkState()
+
+	if err := s.recoverUpgrade(); err != nil {
+		return fmt.Errorf("failed to recover interrupted upgrade: %w", err)
+	}
+
+	if err := s.upgrader.EnsureCurrentLink(); err != nil {
+		return fmt.Errorf("failed to ensure current link: %w", err)
+	}
+
+	// The watcher starts first so that a failure leaves nothing running
+	s.watcher = upgrade.NewFileWatcher(s.cfg, s.logger)
+	if err := s.watcher.Start(); err != nil {
+		return fmt.Errorf("failed to start upgrade watcher: %w", err)
+	}
+
+	if err := s.control.Start(); err != nil {
+		s.watcher.Stop()
+		return fmt.Errorf("failed to start control server: %w", err)
+	}
+
+	if err := s.manager.Start(args); err != nil {
+		s.control.Stop()
+		s.watcher.Stop()
+		return fmt.Errorf("failed to start node: %w", err)
+	}
+
+	if s.cfg.UpgradeEnabled || s.cfg.StallTimeout > 0 {
+		if err := s.startHeightMonitor(); err != nil {
+			s.logger.Error("failed to start height monitor", zap.Error(err))
+		}
+	}
+
+	if s.cfg.StallTimeout > 0 && s.heightMonitor != nil {
+		watchdog := node.NewStallWatchdog(s.manager, s.heightMonitor, s.logger)
+		if err := watchdog.Start(); err != nil {
+			s.logger.Error("failed to start stall watchdog", zap.Error(err))
+		} else {
+			s.watchdog = watchdog
+		}
+	}
+
+	s.startStaging()
+
+	if s.cfg.UpgradeEnabled {
+		if err := s.startOrchestrator(); err != nil {
+			s.logger.Error("failed to start upgrade automation, applying upgrades when upgrade-info.json appears",
+				zap.Error(err))
+		}
+	}
+
+	s.wg.Add(1)
+	if s.orchestrator != nil {
+		go s.forwardUpgradeHalts()
+	} else {
+		go s.watchUpgradeInfo()
+	}
+
+	return nil
+}
+
+// startHeightMonitor starts following the chain height over RPC
+func (s *Supervisor) startHeightMonitor() error {
+	provider, err := governance.NewWBFTClient(fmt.Sprintf("http://%s", s.cfg.RPCAddress), s.logger)
+	if err != nil {
+		return err
+	}
+
+	heightMonitor := height.NewHeightMonitor(provider, s.cfg.HeightPollInterval, s.logger)
+	if err := heightMonitor.Start(); err != nil {
+		return err
+	}
+
+	s.heightMonitor = heightMonitor
+	return nil
+}
+
+// startOrchestrator wires the height monitor and orchestrator to the
+// upgrade watcher
+func (s *Supervisor) startOrchestrator() error {
+	if s.heightMonitor == nil {
+		return fmt.Errorf("height monitor is not running")
+	}
+
+	orch := orchestrator.NewUpgradeOrchestrator(
+		s.manager, &staticConfigManager{cfg: s.cfg}, s.heightMonitor, s.watcher, s.logger)
+	orch.SetUpgradePreparer(s.upgrader)
+	orch.SetStateStore(s.stateStore)
+	orch.SetJournal(s.journal)
+	orch.SetFailureHandler(s)
+	orch.SetStager(s.stager)
+	for _, check := range orchestrator.ConfiguredChecks(s.cfg) {
+		orch.AddUpgradeCheck(check)
+	}
+
+	if err := orch.Start(); err != nil {
+		return err
+	}
+
+	s.orchestrator = orch
+	s.control.SetUpgradeController(orch)
+	return nil
+}
+
+// UpgradeFailed logs an upgrade the orchestrator could not complete and
+// raises an alert for it. It satisfies orchestrator.UpgradeFailureHandler.
+func (s *Supervisor) UpgradeFailed(failure *orchestrator.UpgradeFailure) {
+	name := failure.Upgrade.Name
+	level := metrics.AlertLevelWarning
+	message := fmt.Sprintf("upgrade %s failed and was rolled back: %v", name, failure.Err)
+	if !failure.RolledBack {
+		level = metrics.AlertLevelCritical
+		message = fmt.Sprintf("upgrade %s failed and could not be rolled back: %v", name, failure.RollbackErr)
+	}
+
+	s.logger.Error("upgrade failed",
+		zap.String("name", name),
+		zap.Error(failure.Err),
+		zap.Bool("rolled_back", failure.RolledBack))
+
+	s.manager.GenerateAlert(&metrics.Alert{
+		ID:        fmt.Sprintf("upgrade-failed-%s-%d", name, failure.Time.Unix()),
+		Name:      "UpgradeFailed",
+		Level:     level,
+		Message:   message,
+		Source:    "orchestrator",
+		Labels:    map[string]string{"upgrade": name, "rolled_back": strconv.FormatBool(failure.RolledBack)},
+		Timestamp: failure.Time,
+	})
+}
+
+// forwardUpgradeHalts hands upgrades the node halted for to the
+// orchestrator, which executes them at once
+func (s *Supervisor) forwardUpgradeHalts() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case info := <-s.manager.UpgradeHalts():
+			s.orchestrator.NodeHalted(info)
+		}
+	}
+}
+
+// watchUpgradeInfo applies upgrades announced through upgrade-info.json,
+// or by the node halting for them, when no orchestrator is scheduling them
+func (s *Supervisor) watchUpgradeInfo() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case info := <-s.manager.UpgradeHalts():
+			s.applyUpgrade(info)
+		case <-s.watcher.Updated():
+			info := s.watcher.GetCurrentUpgrade()
+			s.watcher.ClearUpdateFlag()
+			if info == nil {
+				continue
+			}
+			// A node that halted for an upgrade may also write its
+			// upgrade-info.json
+			if s.alreadyApplied(info) {
+				s.logger.Info("upgrade already applied, ignoring upgrade-info.json",
+					zap.String("name", info.Name))
+				continue
+			}
+			s.applyUpgrade(info)
+		}
+	}
+}
+
+// alreadyApplied reports whether current already points at the binary of
+// the upgrade
+func (s *Supervisor) alreadyApplied(info *types.UpgradeInfo) bool {
+	current, err := config.NewSymlinkManager(s.cfg).CurrentTarget()
+	return err == nil && current == filepath.Clean(s.cfg.UpgradeDir(info.Name))
+}
+
+// applyUpgrade stops the node, switches it to the upgrade binary and
+// starts it again. If the upgrade cannot be applied, the node is restarted
+// on the binary it was running.
+func (s *Supervisor) applyUpgrade(info *types.UpgradeInfo) {
+	s.logger.Info("upgrade detected, stopping node",
+		zap.String("name", info.Name),
+		zap.Int64("height", info.Height))
+
+	previous, err := config.NewSymlinkManager(s.cfg).CurrentTarget()
+	if err == nil {
+		err = s.journal.Begin(info, previous)
+	}
+	if err != nil {
+		s.logger.Error("failed to journal upgrade", zap.Error(err))
+		return
+	}
+
+	if state := s.manager.GetState(); state == node.StateRunning || state == node.StateStarting {
+		if err := s.manager.Stop(); err != nil {
+			s.logger.Error("failed to stop node for upgrade", zap.Error(err))
+			s.abortJournal(err)
+			return
+		}
+	}
+	if err := s.journal.Step(journal.StepNodeStopped); err != nil {
+		s.logger.Error("failed to journal upgrade", zap.Error(err))
+		s.restartNode()
+		return
+	}
+
+	if err := s.upgrader.Apply(info); err != nil {
+		s.logger.Error("upgrade failed, restarting node on the current binary",
+			zap.String("name", info.Name),
+			zap.Error(err))
+		s.abortJournal(err)
+		s.restartNode()
+		return
+	}
+	s.recordUpgrade(info)
+	if err := s.journal.Commit(); err != nil {
+		s.logger.Warn("failed to journal upgrade", zap.Error(err))
+	}
+
+	if !s.cfg.RestartAfterUpgrade {
+		s.logger.Info("upgrade applied, node left stopped (restart_after_upgrade=false)",
+			zap.String("name", info.Name))
+		return
+	}
+
+	if s.cfg.RestartDelay > 0 {
+		s.logger.Info("waiting before restart", zap.Duration("delay", s.cfg.RestartDelay))
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(s.cfg.RestartDelay):
+		}
+	}
+
+	s.restartNode()
+}
+
+// recordUpgrade saves info as the last completed upgrade and appends it to
+// the upgrade history
+func (s *Supervisor) recordUpgrade(info *types.UpgradeInfo) {
+	completed := &state.CompletedUpgrade{
+		Name:        info.Name,
+		Height:      info.Height,
+		CompletedAt: time.Now(),
+	}
+	hash, err := version.NewCache(s.cfg, s.logger).Hash(s.cfg.UpgradeBin(info.Name))
+	if err != nil {
+		s.logger.Warn("failed to hash upgrade binary", zap.Error(err))
+	}
+	if err := s.stateStore.Update(func(st *state.State) {
+		st.Upgrade.Last = completed
+		st.Upgrade.RecordApplied(info.Name, info.Height, hash, completed.CompletedAt)
+	}); err != nil {
+		s.logger.Warn("failed to save upgrade state", zap.Error(err))
+	}
+}
+
+// abortJournal records that the upgrade failed with cause and that its
+// steps were undone
+func (s *Supervisor) abortJournal(cause error) {
+	err := s.journal.Fail(cause)
+	if err == nil {
+		err = s.journal.Abort()
+	}
+	if err != nil {
+		s.logger.Warn("failed to journal upgrade", zap.Error(err))
+	}
+}
+
+// restartNode starts the node with its previous arguments, clearing a
+// crashed or failed state first
+func (s *Supervisor) restartNode() {
+	var err error
+	if state := s.manager.GetState(); state == node.StateStopped || state == node.StateUpgrading {
+		err = s.manager.Start(nil)
+	} else {
+		err = s.manager.Restart()
+	}
+	if err != nil {
+		s.logger.Error("failed to start node", zap.Error(err))
+	}
+}
+
+// Shutdown stops upgrade automation, the node and the control server
+func (s *Supervisor) Shutdown() error {
+	s.stopUpgrades()
+	err := s.manager.Close()
+	s.control.Stop()
+	return err
+}
+
+// Detach stops upgrade automation and the control server, leaving the node
+// running
+func (s *Supervisor) Detach() error {
+	s.stopUpgrades()
+	err := s.manager.Detach()
+	s.control.Stop()
+	return err
+}
+
+// stopUpgrades stops the upgrade loop, orchestrator, upgrade watcher,
+// stager, stall watchdog and height monitor
+func (s *Supervisor) stopUpgrades() {
+	s.cancel()
+	s.wg.Wait()
+
+	s.stager.Stop()
+	if s.orchestrator != nil {
+		s.orchestrator.Stop()
+	}
+	if s.watchdog != nil {
+		s.watchdog.Stop()
+	}
+	if s.watcher != nil {
+		s.watcher.Stop()
+	}
+	if s.heightMonitor != nil {
+		s.heightMonitor.Stop()
+	}
+}
@@ -0,0 +1,403 @@
+package supervisor
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/wemix/wemixvisor/internal/batch"
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/governance"
+	"github.com/wemix/wemixvisor/internal/journal"
+	"github.com/wemix/wemixvisor/internal/node"
+	"github.com/wemix/wemixvisor/internal/staging"
+	"github.com/wemix/wemixvisor/internal/state"
+	"github.com/wemix/wemixvisor/pkg/logger"
+	"github.com/wemix/wemixvisor/pkg/types"
+)
+
+// mockNodeScript answers version queries and runs until signalled
+const mockNodeScript = `#!/bin/sh
+if [ "$1" = "version" ]; then
+  echo "v1.0.0"
+  exit 0
+fi
+trap 'exit 0' TERM INT
+while true; do
+  sleep 0.1
+done
+`
+
+// setupSupervisorTest creates a home with a genesis binary
+func setupSupervisorTest(t *testing.T) *config.Config {
+	t.Helper()
+
+	cfg := &config.Config{
+		Home:                t.TempDir(),
+		Name:                "wemixd",
+		PollInterval:        50 * time.Millisecond,
+		ShutdownGrace:       time.Second,
+		RestartAfterUpgrade: true,
+		UnsafeSkipBackup:    true,
+	}
+	installBinary(t, cfg.GenesisBin())
+	return cfg
+}
+
+// installBinary writes the mock node script to path
+func installBinary(t *testing.T, path string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(mockNodeScript), 0755))
+}
+
+// currentTarget returns the directory the current symlink points at
+func currentTarget(t *testing.T, cfg *config.Config) string {
+	t.Helper()
+	target, err := filepath.EvalSymlinks(cfg.CurrentDir())
+	require.NoError(t, err)
+	return target
+}
+
+func TestUpgrader_EnsureCurrentLink(t *testing.T) {
+	cfg := setupSupervisorTest(t)
+	upgrader := NewUpgrader(cfg, logger.NewTestLogger())
+
+	require.NoError(t, upgrader.EnsureCurrentLink())
+
+	genesis, err := filepath.EvalSymlinks(cfg.GenesisDir())
+	require.NoError(t, err)
+	assert.Equal(t, genesis, currentTarget(t, cfg))
+	assert.FileExists(t, cfg.CurrentBin())
+}
+
+func TestUpgrader_EnsureCurrentLinkWithoutGenesis(t *testing.T) {
+	cfg := &config.Config{Home: t.TempDir(), Name: "wemixd"}
+	upgrader := NewUpgrader(cfg, logger.NewTestLogger())
+
+	err := upgrader.EnsureCurrentLink()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "genesis binary not found")
+}
+
+func TestUpgrader_ApplySwitchesLink(t *testing.T) {
+	cfg := setupSupervisorTest(t)
+	installBinary(t, cfg.UpgradeBin("v2"))
+	upgrader := NewUpgrader(cfg, logger.NewTestLogger())
+	require.NoError(t, upgrader.EnsureCurrentLink())
+
+	require.NoError(t, upgrader.Apply(&types.UpgradeInfo{Name: "v2", Height: 100}))
+
+	upgradeDir, err := filepath.EvalSymlinks(cfg.UpgradeDir("v2"))
+	require.NoError(t, err)
+	assert.Equal(t, upgradeDir, currentTarget(t, cfg))
+}
+
+func TestUpgrader_ApplyFailsWithoutBinary(t *testing.T) {
+	cfg := setupSupervisorTest(t)
+	upgrader := NewUpgrader(cfg, logger.NewTestLogger())
+	require.NoError(t, upgrader.EnsureCurrentLink())
+	before := currentTarget(t, cfg)
+
+	err := upgrader.Apply(&types.UpgradeInfo{Name: "v2", Height: 100})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "downloads disabled")
+	assert.Equal(t, before, currentTarget(t, cfg), "current link should be unchanged")
+}
+
+func TestSupervisor_AppliesUpgradeFromUpgradeInfo(t *testing.T) {
+	cfg := setupSupervisorTest(t)
+	installBinary(t, cfg.UpgradeBin("v2"))
+
+	s := New(cfg, logger.NewTestLogger())
+	require.NoError(t, s.Start([]string{"--flag"}))
+	defer s.Shutdown()
+
+	oldPID := s.manager.GetPID()
+	require.NotZero(t, oldPID)
+
+	// The node announces the upgrade it halted for
+	infoPath := cfg.UpgradeInfoFilePath()
+	require.NoError(t, os.MkdirAll(filepath.Dir(infoPath), 0755))
+	require.NoError(t, os.WriteFile(infoPath, []byte(`{"name":"v2","height":100}`), 0644))
+
+	upgradeDir, err := filepath.EvalSymlinks(cfg.UpgradeDir("v2"))
+	require.NoError(t, err)
+
+	assert.Eventually(t, func() bool {
+		pid := s.manager.GetPID()
+		return s.manager.GetState() == node.StateRunning && pid != 0 && pid != oldPID
+	}, 10*time.Second, 50*time.Millisecond, "node should restart after the upgrade")
+	assert.Equal(t, upgradeDir, currentTarget(t, cfg))
+
+	data, err := os.ReadFile(cfg.UpgradeJournalPath())
+	require.NoError(t, err)
+	for _, step := range []string{journal.StepBegin, journal.StepNodeStopped, journal.StepBinaryReady,
+		journal.StepBackedUp, journal.StepHooksRun, journal.StepBinarySwitched, journal.StepCommitted} {
+		assert.Contains(t, string(data), `"step":"`+step+`"`)
+	}
+}
+
+func TestSupervisor_FinishesInterruptedUpgrade(t *testing.T) {
+	cfg := setupSupervisorTest(t)
+	installBinary(t, cfg.UpgradeBin("v2"))
+	info := &types.UpgradeInfo{Name: "v2", Height: 100}
+
+	// Crashed after switching the binary, before starting the node
+	j := journal.New(cfg)
+	require.NoError(t, j.Begin(info, cfg.GenesisDir()))
+	for _, step := range []string{journal.StepNodeStopped, journal.StepBinaryReady, journal.StepHooksRun, journal.StepBinarySwitched} {
+		require.NoError(t, j.Step(step))
+	}
+	require.NoError(t, config.NewSymlinkManager(cfg).LinkToGenesis())
+	require.NoError(t, state.NewStore(cfg).Update(func(st *state.State) {
+		st.Upgrade.Pending = info
+	}))
+
+	s := New(cfg, logger.NewTestLogger())
+	require.NoError(t, s.Start(nil))
+	defer s.Shutdown()
+
+	upgradeDir, err := filepath.EvalSymlinks(cfg.UpgradeDir("v2"))
+	require.NoError(t, err)
+	assert.Equal(t, upgradeDir, currentTarget(t, cfg))
+	assert.Equal(t, node.StateRunning, s.manager.GetState())
+
+	pending, err := j.Incomplete()
+	require.NoError(t, err)
+	assert.Nil(t, pending, "journal should be committed")
+
+	st, err := state.NewStore(cfg).Load()
+	require.NoError(t, err)
+	assert.Nil(t, st.Upgrade.Pending)
+	require.NotNil(t, st.Upgrade.Last)
+	assert.Equal(t, "v2", st.Upgrade.Last.Name)
+	require.Len(t, st.Upgrade.History, 1)
+	assert.Equal(t, "v2", st.Upgrade.History[0].Name)
+}
+
+func TestSupervisor_RollsBackInterruptedUpgrade(t *testing.T) {
+	cfg := setupSupervisorTest(t)
+	cfg.UnsafeSkipBackup = false
+	cfg.DataBackupPath = filepath.Join(cfg.Home, "backups")
+	installBinary(t, cfg.UpgradeBin("v1"))
+	installBinary(t, cfg.UpgradeBin("v2"))
+	require.NoError(t, config.NewSymlinkManager(cfg).LinkToUpgrade("v1"))
+
+	marker := filepath.Join(cfg.Home, "data", "marker")
+	require.NoError(t, os.MkdirAll(filepath.Dir(marker), 0755))
+	require.NoError(t, os.WriteFile(marker, []byte("before"), 0644))
+	backupPath, err := NewUpgrader(cfg, logger.NewTestLogger()).backup.CreateBackup("pre-upgrade-v2")
+	require.NoError(t, err)
+
+	// Crashed while the pre-upgrade hook was changing the data
+	j := journal.New(cfg)
+	require.NoError(t, j.Begin(&types.UpgradeInfo{Name: "v2", Height: 100}, cfg.UpgradeDir("v1")))
+	require.NoError(t, j.Step(journal.StepNodeStopped))
+	require.NoError(t, j.Step(journal.StepBinaryReady))
+	require.NoError(t, j.BackedUp(backupPath))
+	require.NoError(t, os.WriteFile(marker, []byte("after"), 0644))
+
+	s := New(cfg, logger.NewTestLogger())
+	require.NoError(t, s.Start(nil))
+	defer s.Shutdown()
+
+	upgradeDir, err := filepath.EvalSymlinks(cfg.UpgradeDir("v1"))
+	require.NoError(t, err)
+	assert.Equal(t, upgradeDir, currentTarget(t, cfg), "node should run the binary it ran before")
+
+	data, err := os.ReadFile(marker)
+	require.NoError(t, err)
+	assert.Equal(t, "before", string(data), "pre-upgrade backup should be restored")
+
+	pending, err := j.Incomplete()
+	require.NoError(t, err)
+	assert.Nil(t, pending, "journal should be aborted")
+}
+
+func TestSupervisor_RollsBackUnverifiedUpgrade(t *testing.T) {
+	cfg := setupSupervisorTest(t)
+	installBinary(t, cfg.UpgradeBin("v1"))
+	installBinary(t, cfg.UpgradeBin("v2"))
+	require.NoError(t, config.NewSymlinkManager(cfg).LinkToUpgrade("v1"))
+	info := &types.UpgradeInfo{Name: "v2", Height: 100}
+
+	// Crashed while verifying the node started on the new binary
+	j := journal.New(cfg)
+	require.NoError(t, j.Begin(info, cfg.UpgradeDir("v1")))
+	for _, step := range []string{journal.StepNodeStopped, journal.StepBinaryReady, journal.StepHooksRun,
+		journal.StepBinarySwitched, journal.StepNodeStarted} {
+		require.NoError(t, j.Step(step))
+	}
+	require.NoError(t, config.NewSymlinkManager(cfg).LinkToUpgrade("v2"))
+
+	s := New(cfg, logger.NewTestLogger())
+	require.NoError(t, s.Start(nil))
+	defer s.Shutdown()
+
+	upgradeDir, err := filepath.EvalSymlinks(cfg.UpgradeDir("v1"))
+	require.NoError(t, err)
+	assert.Equal(t, upgradeDir, currentTarget(t, cfg), "node should run the binary it ran before")
+
+	pending, err := j.Incomplete()
+	require.NoError(t, err)
+	assert.Nil(t, pending, "journal should be aborted")
+
+	st, err := state.NewStore(cfg).Load()
+	require.NoError(t, err)
+	assert.Empty(t, st.Upgrade.History, "an unverified upgrade is not recorded as applied")
+}
+
+func TestSupervisor_StopsNodeLeftRunningByUnverifiedUpgrade(t *testing.T) {
+	cfg := setupSupervisorTest(t)
+	installBinary(t, cfg.UpgradeBin("v1"))
+	installBinary(t, cfg.UpgradeBin("v2"))
+	info := &types.UpgradeInfo{Name: "v2", Height: 100}
+
+	// The upgrade started the node on the new binary, then the supervisor
+	// died before verifying it, leaving the node running
+	j := journal.New(cfg)
+	require.NoError(t, j.Begin(info, cfg.UpgradeDir("v1")))
+	for _, step := range []string{journal.StepNodeStopped, journal.StepBinaryReady, journal.StepHooksRun,
+		journal.StepBinarySwitched, journal.StepNodeStarted} {
+		require.NoError(t, j.Step(step))
+	}
+	require.NoError(t, config.NewSymlinkManager(cfg).LinkToUpgrade("v2"))
+
+	first := node.NewManager(cfg, logger.NewTestLogger())
+	require.NoError(t, first.Start(nil))
+	upgradedPID := first.GetPID()
+	require.NoError(t, first.Detach())
+
+	s := New(cfg, logger.NewTestLogger())
+	require.NoError(t, s.Start(nil))
+	defer s.Shutdown()
+
+	upgradeDir, err := filepath.EvalSymlinks(cfg.UpgradeDir("v1"))
+	require.NoError(t, err)
+	assert.Equal(t, upgradeDir, currentTarget(t, cfg))
+
+	status := s.manager.GetStatus()
+	assert.False(t, status.Adopted, "the node on the unverified binary should not be adopted")
+	assert.NotEqual(t, upgradedPID, status.PID)
+	assert.False(t, processAlive(upgradedPID), "the node on the unverified binary should be stopped")
+}
+
+// processAlive reports whether pid names a live, non-zombie process
+func processAlive(pid int) bool {
+	data, err := os.ReadFile(filepath.Join("/proc", strconv.Itoa(pid), "stat"))
+	if err != nil {
+		return false
+	}
+	stat := string(data)
+	fields := strings.Fields(stat[strings.LastIndexByte(stat, ')')+1:])
+	return len(fields) > 0 && fields[0] != "Z" && fields[0] != "X"
+}
+
+func TestSupervisor_RollsBackFailedUpgrade(t *testing.T) {
+	cfg := setupSupervisorTest(t)
+	installBinary(t, cfg.UpgradeBin("v2"))
+
+	// The binary was switched, but the upgrade failed and its rollback did
+	// not complete
+	j := journal.New(cfg)
+	require.NoError(t, j.Begin(&types.UpgradeInfo{Name: "v2", Height: 100}, ""))
+	require.NoError(t, j.Step(journal.StepBinarySwitched))
+	require.NoError(t, j.Fail(errors.New("node did not become ready")))
+	require.NoError(t, config.NewSymlinkManager(cfg).LinkToUpgrade("v2"))
+
+	s := New(cfg, logger.NewTestLogger())
+	require.NoError(t, s.Start(nil))
+	defer s.Shutdown()
+
+	genesis, err := filepath.EvalSymlinks(cfg.GenesisDir())
+	require.NoError(t, err)
+	assert.Equal(t, genesis, currentTarget(t, cfg))
+}
+
+func TestSupervisor_MovesUnreadableStateAside(t *testing.T) {
+	cfg := setupSupervisorTest(t)
+	store := state.NewStore(cfg)
+	require.NoError(t, os.MkdirAll(filepath.Dir(store.Path()), 0755))
+	require.NoError(t, os.WriteFile(store.Path(), []byte("{not json"), 0644))
+
+	s := New(cfg, logger.NewTestLogger())
+	require.NoError(t, s.Start(nil))
+	defer s.Shutdown()
+
+	moved, err := filepath.Glob(store.Path() + ".corrupt-*")
+	require.NoError(t, err)
+	require.Len(t, moved, 1)
+	data, err := os.ReadFile(moved[0])
+	require.NoError(t, err)
+	assert.Equal(t, "{not json", string(data))
+
+	_, err = store.Load()
+	assert.NoError(t, err)
+}
+
+func TestSupervisor_StagesBatchPlanUpgrades(t *testing.T) {
+	cfg := setupSupervisorTest(t)
+	installBinary(t, cfg.UpgradeBin("v2"))
+
+	log := logger.NewTestLogger()
+	_, err := batch.NewBatchManager(cfg, log).CreatePlan("next", "", []types.UpgradeInfo{
+		{Name: "v2", Height: 100},
+		{Name: "v3", Height: 200},
+	})
+	require.NoError(t, err)
+
+	s := New(cfg, log)
+	require.NoError(t, s.Start(nil))
+	defer s.Shutdown()
+
+	var statuses []staging.Status
+	require.Eventually(t, func() bool {
+		statuses = s.stager.Status()
+		return len(statuses) == 2 &&
+			statuses[0].State != staging.StateStaging && statuses[1].State != staging.StateStaging
+	}, 10*time.Second, 50*time.Millisecond, "plan upgrades should be staged")
+
+	assert.Equal(t, "v2", statuses[0].Name)
+	assert.Equal(t, staging.StateStaged, statuses[0].State)
+	assert.Equal(t, "v1.0.0", statuses[0].Version)
+	assert.Equal(t, "v3", statuses[1].Name)
+	assert.Equal(t, staging.StateFailed, statuses[1].State, "v3 has no binary and downloads are disabled")
+}
+
+func TestSupervisor_StagesAnnouncedUpgrades(t *testing.T) {
+	cfg := setupSupervisorTest(t)
+	installBinary(t, cfg.UpgradeBin("v2"))
+
+	// The governance scheduler announces the upgrades it schedules
+	log := logger.NewTestLogger()
+	scheduler := governance.NewUpgradeScheduler(cfg, log)
+	scheduler.SetValidationEnabled(false)
+	require.NoError(t, scheduler.ScheduleUpgrade(&governance.Proposal{
+		ID:            "1",
+		Type:          governance.ProposalTypeUpgrade,
+		UpgradeHeight: 100,
+		UpgradeInfo:   &governance.UpgradeInfo{Name: "v2", Height: 100},
+	}))
+
+	s := New(cfg, log)
+	require.NoError(t, s.Start(nil))
+	defer s.Shutdown()
+
+	var statuses []staging.Status
+	require.Eventually(t, func() bool {
+		statuses = s.stager.Status()
+		return len(statuses) == 1 && statuses[0].State != staging.StateStaging
+	}, 10*time.Second, 50*time.Millisecond, "announced upgrade should be staged")
+
+	assert.Equal(t, "v2", statuses[0].Name)
+	assert.Equal(t, int64(100), statuses[0].Height)
+	assert.Equal(t, staging.StateStaged, statuses[0].State)
+}
@@ -0,0 +1,196 @@
+package supervisor
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wemix/wemixvisor/internal/backup"
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/internal/download"
+	"github.com/wemix/wemixvisor/internal/hooks"
+	"github.com/wemix/wemixvisor/internal/journal"
+	"github.com/wemix/wemixvisor/internal/staging"
+	"github.com/wemix/wemixvisor/pkg/logger"
+	"github.com/wemix/wemixvisor/pkg/types"
+)
+
+// DefaultBackupRetention is how long pre-upgrade backups are kept
+const DefaultBackupRetention = 7 * 24 * time.Hour
+
+// Upgrader prepares the node home for an upgrade while the node is stopped:
+// it makes sure the upgrade binary is available, backs up the data
+// directory and runs the pre-upgrade hooks, restoring the backup if a later
+// step fails. Each completed step is recorded in the upgrade journal.
+type Upgrader struct {
+	cfg        *config.Config
+	logger     *logger.Logger
+	backup     *backup.Manager
+	preHook    *hooks.PreUpgradeHook
+	downloader *download.Downloader
+	journal    *journal.Journal
+	stager     *staging.Stager
+}
+
+// NewUpgrader creates a new upgrader
+func NewUpgrader(cfg *config.Config, log *logger.Logger) *Upgrader {
+	return &Upgrader{
+		cfg:        cfg,
+		logger:     log,
+		backup:     backup.NewManager(cfg, log),
+		preHook:    hooks.NewPreUpgradeHook(cfg, log),
+		downloader: download.NewDownloader(cfg, log),
+		journal:    journal.New(cfg),
+	}
+}
+
+// SetStager sets the stager whose staging of an upgrade binary, if in
+// progress, is waited for before the binary is made available
+func (u *Upgrader) SetStager(stager *staging.Stager) {
+	u.stager = stager
+}
+
+// EnsureCurrentLink makes sure the current symlink points at a binary,
+// linking it to genesis if it is missing or broken
+func (u *Upgrader) EnsureCurrentLink() error {
+	if _, err := os.Stat(u.cfg.CurrentDir()); err == nil {
+		if _, err := os.Stat(u.cfg.CurrentBin()); err == nil {
+			return nil
+		}
+		u.logger.Warn("current link exists but binary not found, recreating")
+	}
+
+	if _, err := os.Stat(u.cfg.GenesisBin()); err != nil {
+		return fmt.Errorf("genesis binary not found: %w", err)
+	}
+
+	if err := config.NewSymlinkManager(u.cfg).LinkToGenesis(); err != nil {
+		return fmt.Errorf("failed to create genesis link: %w", err)
+	}
+
+	u.logger.Info("created symlink to genesis")
+	return nil
+}
+
+// PrepareUpgrade makes the node home ready to switch to the upgrade binary.
+// It satisfies orchestrator.UpgradePreparer.
+func (u *Upgrader) PrepareUpgrade(info *types.UpgradeInfo) error {
+	if _, err := u.prepare(info); err != nil {
+		return err
+	}
+
+	u.cleanupOldBackups()
+	return nil
+}
+
+// Apply prepares the upgrade and points the current symlink at the upgrade
+// binary
+func (u *Upgrader) Apply(info *types.UpgradeInfo) error {
+	backupPath, err := u.prepare(info)
+	if err != nil {
+		return err
+	}
+
+	if err := config.NewSymlinkManager(u.cfg).LinkToUpgrade(info.Name); err != nil {
+		u.restoreBackupOnFailure(backupPath, "symlink failure")
+		return fmt.Errorf("failed to update symlink: %w", err)
+	}
+	// Without the record, recovery after a crash rolls the switch back
+	if err := u.journal.Step(journal.StepBinarySwitched); err != nil {
+		u.logger.Warn("failed to journal upgrade", zap.Error(err))
+	}
+
+	u.cleanupOldBackups()
+
+	u.logger.Info("upgrade applied", zap.String("name", info.Name))
+	return nil
+}
+
+// prepare runs the preparation steps and returns the path of the backup
+// taken, if any
+func (u *Upgrader) prepare(info *types.UpgradeInfo) (string, error) {
+	u.logger.Info("preparing upgrade",
+		zap.String("name", info.Name),
+		zap.Int64("height", info.Height))
+
+	// Don't download the binary alongside the stager
+	if u.stager != nil {
+		u.stager.Wait(info.Name)
+	}
+	if err := u.downloader.EnsureUpgradeBinary(info.Name); err != nil {
+		return "", fmt.Errorf("failed to ensure upgrade binary: %w", err)
+	}
+	if err := u.journal.Step(journal.StepBinaryReady); err != nil {
+		return "", err
+	}
+
+	if err := u.preHook.ValidateUpgrade(info); err != nil {
+		return "", fmt.Errorf("upgrade validation failed: %w", err)
+	}
+
+	backupPath, err := u.createPreUpgradeBackup(info.Name)
+	if err != nil {
+		return "", err
+	}
+	if err := u.journal.BackedUp(backupPath); err != nil {
+		return "", err
+	}
+
+	if err := u.preHook.Execute(info); err != nil {
+		u.restoreBackupOnFailure(backupPath, "hook failure")
+		return "", fmt.Errorf("pre-upgrade hook failed: %w", err)
+	}
+	if err := u.journal.Step(journal.StepHooksRun); err != nil {
+		u.restoreBackupOnFailure(backupPath, "journal failure")
+		return "", err
+	}
+
+	return backupPath, nil
+}
+
+// createPreUpgradeBackup creates a backup before upgrade
+func (u *Upgrader) createPreUpgradeBackup(upgradeName string) (string, error) {
+	backupPath, err := u.backup.CreateBackup(fmt.Sprintf("pre-upgrade-%s", upgradeName))
+	if err != nil {
+		u.logger.Error("backup failed", zap.Error(err))
+		if !u.cfg.UnsafeSkipBackup {
+			return "", fmt.Errorf("backup failed and unsafe_skip_backup is false: %w", err)
+		}
+		u.logger.Warn("continuing upgrade without backup (unsafe_skip_backup=true)")
+		return "", nil
+	}
+
+	if backupPath != "" {
+		u.logger.Info("backup created", zap.String("path", backupPath))
+	}
+	return backupPath, nil
+}
+
+// restoreBackupOnFailure attempts to restore backup after a failure
+func (u *Upgrader) restoreBackupOnFailure(backupPath, reason string) {
+	if backupPath == "" {
+		return
+	}
+
+	u.logger.Info("attempting to restore backup after "+reason,
+		zap.String("backup_path", backupPath))
+
+	if err := u.backup.RestoreBackup(backupPath); err != nil {
+		u.logger.Error("backup restore failed", zap.Error(err))
+	}
+}
+
+// RestoreBackup restores the pre-upgrade backup at backupPath
+func (u *Upgrader) RestoreBackup(backupPath string) error {
+	u.logger.Info("restoring pre-upgrade backup", zap.String("backup_path", backupPath))
+	return u.backup.RestoreBackup(backupPath)
+}
+
+// cleanupOldBackups removes old backups
+func (u *Upgrader) cleanupOldBackups() {
+	if err := u.backup.CleanOldBackups(DefaultBackupRetention); err != nil {
+		u.logger.Warn("failed to clean old backups", zap.Error(err))
+	}
+}
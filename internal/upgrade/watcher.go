@@ -1,31 +1,54 @@
 package upgrade
 
 import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sync"
 	"time"
 
+	"github.com/fsnotify/fsnotify"
 	"github.com/wemix/wemixvisor/internal/config"
 	"github.com/wemix/wemixvisor/pkg/logger"
 	"github.com/wemix/wemixvisor/pkg/types"
 	"go.uber.org/zap"
 )
 
-// FileWatcher monitors the upgrade-info.json file for changes
+// DebounceInterval is how long the upgrade info file must be left alone
+// after a change before it is parsed, so that a file still being written
+// is not read
+const DebounceInterval = 100 * time.Millisecond
+
+// ErrWatcherStopped is returned by WaitForUpgrade when the watcher is
+// stopped before an upgrade is detected
+var ErrWatcherStopped = errors.New("upgrade watcher stopped")
+
+// FileWatcher monitors the upgrade-info.json file for changes.
+//
+// The directory of the file is watched for file system events, so that the
+// file is picked up whether it is written in place or renamed over. The
+// file is parsed once it has been left alone for DebounceInterval and is
+// compared by content, not modification time. Only while the directory
+// cannot be watched, e.g. before it is created, is the file polled every
+// PollInterval.
 type FileWatcher struct {
-	cfg          *config.Config
-	logger       *logger.Logger
-	filename     string
-	interval     time.Duration
-	lastModTime  time.Time
-	currentInfo  *types.UpgradeInfo
-	needsUpdate  bool
-	initialized  bool
-	mu           sync.RWMutex
-	stopChan     chan struct{}
-	stoppedChan  chan struct{}
+	cfg         *config.Config
+	logger      *logger.Logger
+	filename    string
+	interval    time.Duration
+	debounce    time.Duration
+	lastSum     []byte
+	currentInfo *types.UpgradeInfo
+	needsUpdate bool
+	updated     chan struct{}
+	initialized bool
+	mu          sync.RWMutex
+	stopChan    chan struct{}
+	stoppedChan chan struct{}
 }
 
 // NewFileWatcher creates a new FileWatcher instance
@@ -35,6 +58,8 @@ func NewFileWatcher(cfg *config.Config, logger *logger.Logger) *FileWatcher {
 		logger:      logger,
 		filename:    cfg.UpgradeInfoFilePath(),
 		interval:    cfg.PollInterval,
+		debounce:    DebounceInterval,
+		updated:     make(chan struct{}),
 		stopChan:    make(chan struct{}),
 		stoppedChan: make(chan struct{}),
 	}
@@ -58,13 +83,19 @@ func (fw *FileWatcher) Start() error {
 	}
 
 	fw.initialized = true
+	watcher := fw.watch()
 
 	// Start monitoring in background
-	go fw.monitor()
-
-	fw.logger.Info("started upgrade file watcher",
-		zap.String("file", fw.filename),
-		zap.Duration("interval", fw.interval))
+	go fw.monitor(watcher)
+
+	if watcher != nil {
+		fw.logger.Info("started upgrade file watcher",
+			zap.String("file", fw.filename))
+	} else {
+		fw.logger.Info("started upgrade file watcher, polling until the directory can be watched",
+			zap.String("file", fw.filename),
+			zap.Duration("interval", fw.interval))
+	}
 
 	return nil
 }
@@ -85,27 +116,127 @@ func (fw *FileWatcher) Stop() {
 	fw.logger.Info("stopped upgrade file watcher")
 }
 
-// monitor continuously checks for upgrade file changes
-func (fw *FileWatcher) monitor() {
+// watch starts watching the directory of the upgrade info file, returning
+// nil if it cannot be watched
+func (fw *FileWatcher) watch() *fsnotify.Watcher {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		fw.logger.Debug("failed to create file system watcher", zap.Error(err))
+		return nil
+	}
+
+	if err := watcher.Add(filepath.Dir(fw.filename)); err != nil {
+		fw.logger.Debug("cannot watch upgrade info directory",
+			zap.String("dir", filepath.Dir(fw.filename)),
+			zap.Error(err))
+		watcher.Close()
+		return nil
+	}
+
+	return watcher
+}
+
+// monitor handles the file system events of watcher on the upgrade info
+// directory, falling back to polling while the directory is not watched
+func (fw *FileWatcher) monitor(watcher *fsnotify.Watcher) {
 	defer close(fw.stoppedChan)
 
-	ticker := time.NewTicker(fw.interval)
-	defer ticker.Stop()
+	defer func() {
+		if watcher != nil {
+			watcher.Close()
+		}
+	}()
+
+	interval := fw.interval
+	if interval <= 0 {
+		interval = config.DefaultPollInterval
+	}
+	poll := time.NewTicker(interval)
+	defer poll.Stop()
+
+	// Catch changes made before the directory was watched
+	settle := time.NewTimer(0)
+	defer settle.Stop()
 
 	for {
+		var (
+			events   <-chan fsnotify.Event
+			errs     <-chan error
+			pollTick <-chan time.Time
+		)
+		if watcher != nil {
+			events, errs = watcher.Events, watcher.Errors
+		} else {
+			pollTick = poll.C
+		}
+
 		select {
 		case <-fw.stopChan:
 			return
-		case <-ticker.C:
-			if fw.checkForUpdate() {
-				fw.logger.Info("upgrade detected",
-					zap.String("name", fw.currentInfo.Name),
-					zap.Int64("height", fw.currentInfo.Height))
+
+		case <-pollTick:
+			if watcher = fw.watch(); watcher != nil {
+				fw.logger.Info("watching upgrade info directory, stopped polling",
+					zap.String("dir", filepath.Dir(fw.filename)))
+			}
+			fw.update()
+
+		case event, ok := <-events:
+			if !ok {
+				watcher = fw.fallBack(watcher, nil)
+				continue
+			}
+
+			if filepath.Clean(event.Name) == filepath.Dir(fw.filename) &&
+				event.Has(fsnotify.Remove|fsnotify.Rename) {
+				watcher = fw.fallBack(watcher, fmt.Errorf("directory removed"))
+				continue
+			}
+			if filepath.Clean(event.Name) != fw.filename || event.Op == fsnotify.Chmod {
+				continue
+			}
+
+			// Parse once the file has settled
+			settle.Reset(fw.debounce)
+
+		case <-settle.C:
+			fw.update()
+
+		case err, ok := <-errs:
+			if !ok {
+				watcher = fw.fallBack(watcher, nil)
+				continue
+			}
+			fw.logger.Warn("upgrade info watcher error", zap.Error(err))
+			if errors.Is(err, fsnotify.ErrEventOverflow) {
+				// Events were lost, look at the file again
+				settle.Reset(fw.debounce)
 			}
 		}
 	}
 }
 
+// fallBack closes watcher and returns nil, so that the file is polled
+// until its directory can be watched again
+func (fw *FileWatcher) fallBack(watcher *fsnotify.Watcher, reason error) *fsnotify.Watcher {
+	watcher.Close()
+	fw.logger.Warn("stopped watching upgrade info directory, polling",
+		zap.String("dir", filepath.Dir(fw.filename)),
+		zap.Duration("interval", fw.interval),
+		zap.Error(reason))
+	return nil
+}
+
+// update checks the upgrade file and logs the upgrade it announces, if new
+func (fw *FileWatcher) update() {
+	if fw.checkForUpdate() {
+		info := fw.GetCurrentUpgrade()
+		fw.logger.Info("upgrade detected",
+			zap.String("name", info.Name),
+			zap.Int64("height", info.Height))
+	}
+}
+
 // checkForUpdate checks if the upgrade file has been updated
 func (fw *FileWatcher) checkForUpdate() bool {
 	fw.mu.Lock()
@@ -113,7 +244,9 @@ func (fw *FileWatcher) checkForUpdate() bool {
 
 	info, err := fw.checkFile()
 	if err != nil {
-		// File doesn't exist or is invalid - not an error condition
+		// File is being written or is invalid - it is read again when it
+		// changes
+		fw.logger.Debug("ignoring upgrade file", zap.Error(err))
 		return false
 	}
 
@@ -122,53 +255,67 @@ func (fw *FileWatcher) checkForUpdate() bool {
 		return false
 	}
 
-	// Check if this is a new upgrade
-	if fw.currentInfo == nil {
-		// First upgrade detected
-		fw.currentInfo = info
-		fw.needsUpdate = true
-		return true
+	// Check if this is a new upgrade or the upgrade has changed
+	if fw.currentInfo != nil && sameUpgrade(info, fw.currentInfo) {
+		return false
 	}
 
-	// Check if upgrade has changed
-	if info.Name != fw.currentInfo.Name ||
-		info.Height != fw.currentInfo.Height {
-		fw.currentInfo = info
+	fw.currentInfo = info
+	if !fw.needsUpdate {
 		fw.needsUpdate = true
-		return true
+		close(fw.updated)
 	}
+	return true
+}
+
+// sameUpgrade reports whether a and b announce the same upgrade at the
+// same activation condition
+func sameUpgrade(a, b *types.UpgradeInfo) bool {
+	return a.Name == b.Name && a.Height == b.Height &&
+		sameTime(a.Time, b.Time) && sameTime(a.BlockTime, b.BlockTime)
+}
 
-	return false
+func sameTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
 }
 
-// checkFile checks the upgrade info file
+// checkFile reads the upgrade info file and parses it if its contents
+// have changed since it was last parsed. Caller must hold mu.
 func (fw *FileWatcher) checkFile() (*types.UpgradeInfo, error) {
-	// Check if file exists
-	stat, err := os.Stat(fw.filename)
+	data, err := os.ReadFile(fw.filename)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return nil, nil // File doesn't exist yet
+			// No upgrade is announced, or it was withdrawn: forget it, so
+			// that announcing it again is detected
+			fw.lastSum = nil
+			fw.currentInfo = nil
+			fw.clearUpdateFlag()
+			return nil, nil
 		}
-		return nil, fmt.Errorf("failed to stat upgrade file: %w", err)
+		return nil, fmt.Errorf("failed to read upgrade file: %w", err)
 	}
 
 	// Check if file is empty
-	if stat.Size() == 0 {
+	if len(data) == 0 {
 		return nil, fmt.Errorf("upgrade file is empty")
 	}
 
-	// Check modification time
-	if !stat.ModTime().After(fw.lastModTime) {
+	// Check contents
+	sum := sha256.Sum256(data)
+	if bytes.Equal(sum[:], fw.lastSum) {
 		return nil, nil // File hasn't been modified
 	}
 
 	// Parse the file
-	info, err := types.ParseUpgradeInfoFile(fw.filename)
+	info, err := types.ParseUpgradeInfo(data)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse upgrade file: %w", err)
 	}
 
-	fw.lastModTime = stat.ModTime()
+	fw.lastSum = sum[:]
 	return info, nil
 }
 
@@ -190,24 +337,48 @@ func (fw *FileWatcher) NeedsUpdate() bool {
 func (fw *FileWatcher) ClearUpdateFlag() {
 	fw.mu.Lock()
 	defer fw.mu.Unlock()
-	fw.needsUpdate = false
+	fw.clearUpdateFlag()
 }
 
-// WaitForUpgrade blocks until an upgrade is detected or context is cancelled
-func (fw *FileWatcher) WaitForUpgrade() *types.UpgradeInfo {
-	ticker := time.NewTicker(fw.interval)
-	defer ticker.Stop()
+// clearUpdateFlag clears the update flag.
+// Caller must hold mu.
+func (fw *FileWatcher) clearUpdateFlag() {
+	if fw.needsUpdate {
+		fw.needsUpdate = false
+		fw.updated = make(chan struct{})
+	}
+}
 
+// Updated returns a channel that is closed once an upgrade is detected.
+// It is closed already if an upgrade is detected and the update flag not
+// cleared yet; a fresh channel must be obtained after clearing the flag.
+func (fw *FileWatcher) Updated() <-chan struct{} {
+	fw.mu.RLock()
+	defer fw.mu.RUnlock()
+	return fw.updated
+}
+
+// WaitForUpgrade blocks until an upgrade is detected and returns it,
+// clearing the update flag. It returns the error of ctx if ctx is done, or
+// ErrWatcherStopped if the watcher is stopped, first.
+func (fw *FileWatcher) WaitForUpgrade(ctx context.Context) (*types.UpgradeInfo, error) {
 	for {
+		fw.mu.Lock()
+		if fw.needsUpdate {
+			info := fw.currentInfo
+			fw.clearUpdateFlag()
+			fw.mu.Unlock()
+			return info, nil
+		}
+		updated := fw.updated
+		fw.mu.Unlock()
+
 		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
 		case <-fw.stopChan:
-			return nil
-		case <-ticker.C:
-			if fw.NeedsUpdate() {
-				info := fw.GetCurrentUpgrade()
-				fw.ClearUpdateFlag()
-				return info
-			}
+			return nil, ErrWatcherStopped
+		case <-updated:
 		}
 	}
 }
@@ -229,4 +400,4 @@ func CreateUpgradeDir(cfg *config.Config, name string) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
@@ -1,6 +1,8 @@
 package upgrade
 
 import (
+	"context"
+	"errors"
 	"os"
 	"path/filepath"
 	"testing"
@@ -279,6 +281,7 @@ func TestFileWatcherWaitForUpgrade(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to start watcher: %v", err)
 	}
+	defer watcher.Stop()
 
 	// Create upgrade file in background
 	go func() {
@@ -289,23 +292,204 @@ func TestFileWatcherWaitForUpgrade(t *testing.T) {
 	}()
 
 	// Wait for upgrade with timeout
-	done := make(chan *types.UpgradeInfo)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	info, err := watcher.WaitForUpgrade(ctx)
+	if err != nil {
+		t.Fatalf("failed waiting for upgrade: %v", err)
+	}
+	if info == nil || info.Name != "v5.0.0" {
+		t.Fatalf("expected upgrade v5.0.0, got %v", info)
+	}
+	if watcher.NeedsUpdate() {
+		t.Error("expected update flag to be cleared")
+	}
+}
+
+func TestFileWatcherWaitForUpgradeCancelled(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &config.Config{
+		Home:         tmpDir,
+		PollInterval: 50 * time.Millisecond,
+	}
+
+	logger, _ := logger.New(false, true, "")
+	watcher := NewFileWatcher(cfg, logger)
+	if err := watcher.Start(); err != nil {
+		t.Fatalf("failed to start watcher: %v", err)
+	}
+
+	// Context done first
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if _, err := watcher.WaitForUpgrade(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	// Watcher stopped first
 	go func() {
-		info := watcher.WaitForUpgrade()
-		done <- info
+		time.Sleep(50 * time.Millisecond)
+		watcher.Stop()
 	}()
+	if _, err := watcher.WaitForUpgrade(context.Background()); !errors.Is(err, ErrWatcherStopped) {
+		t.Errorf("expected watcher stopped, got %v", err)
+	}
+}
 
+// waitUpdated waits for the watcher to detect an upgrade
+func waitUpdated(t *testing.T, watcher *FileWatcher, timeout time.Duration) *types.UpgradeInfo {
+	t.Helper()
 	select {
-	case info := <-done:
-		if info == nil {
-			t.Fatal("expected upgrade info to be non-nil")
-		}
-		if info.Name != "v5.0.0" {
-			t.Errorf("expected upgrade name v5.0.0, got %s", info.Name)
-		}
-	case <-time.After(500 * time.Millisecond):
+	case <-watcher.Updated():
+		return watcher.GetCurrentUpgrade()
+	case <-time.After(timeout):
 		t.Fatal("timeout waiting for upgrade")
+		return nil
 	}
+}
 
-	watcher.Stop()
-}
\ No newline at end of file
+func TestFileWatcherAtomicRename(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &config.Config{
+		Home: tmpDir,
+		// Polling would not detect the upgrade in time
+		PollInterval: time.Hour,
+	}
+	os.MkdirAll(filepath.Join(tmpDir, "data"), 0755)
+
+	logger, _ := logger.New(false, true, "")
+	watcher := NewFileWatcher(cfg, logger)
+	if err := watcher.Start(); err != nil {
+		t.Fatalf("failed to start watcher: %v", err)
+	}
+	defer watcher.Stop()
+
+	info := &types.UpgradeInfo{Name: "v6.0.0", Height: 4000000}
+	if err := types.WriteUpgradeInfoFile(cfg.UpgradeInfoFilePath(), info); err != nil {
+		t.Fatalf("failed to write upgrade file: %v", err)
+	}
+
+	current := waitUpdated(t, watcher, time.Second)
+	if current == nil || current.Name != "v6.0.0" {
+		t.Errorf("expected v6.0.0 upgrade to be detected, got %v", current)
+	}
+}
+
+func TestFileWatcherRescheduleAfterCancel(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &config.Config{
+		Home: tmpDir,
+		// Polling would not detect the upgrade in time
+		PollInterval: time.Hour,
+	}
+	os.MkdirAll(filepath.Join(tmpDir, "data"), 0755)
+
+	logger, _ := logger.New(false, true, "")
+	watcher := NewFileWatcher(cfg, logger)
+	if err := watcher.Start(); err != nil {
+		t.Fatalf("failed to start watcher: %v", err)
+	}
+	defer watcher.Stop()
+
+	info := &types.UpgradeInfo{Name: "v7.0.0", Height: 5000000}
+	if err := types.WriteUpgradeInfoFile(cfg.UpgradeInfoFilePath(), info); err != nil {
+		t.Fatalf("failed to write upgrade file: %v", err)
+	}
+	if current := waitUpdated(t, watcher, time.Second); current == nil || current.Name != "v7.0.0" {
+		t.Fatalf("expected v7.0.0 upgrade to be detected, got %v", current)
+	}
+	watcher.ClearUpdateFlag()
+
+	// Cancel the upgrade
+	if err := os.Remove(cfg.UpgradeInfoFilePath()); err != nil {
+		t.Fatalf("failed to remove upgrade file: %v", err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for watcher.GetCurrentUpgrade() != nil {
+		if time.Now().After(deadline) {
+			t.Fatal("cancelled upgrade is still current")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// Schedule the same upgrade again
+	if err := types.WriteUpgradeInfoFile(cfg.UpgradeInfoFilePath(), info); err != nil {
+		t.Fatalf("failed to write upgrade file: %v", err)
+	}
+	if current := waitUpdated(t, watcher, time.Second); current == nil || current.Name != "v7.0.0" {
+		t.Errorf("expected rescheduled v7.0.0 upgrade to be detected, got %v", current)
+	}
+}
+
+func TestFileWatcherDebouncesPartialWrites(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &config.Config{
+		Home:         tmpDir,
+		PollInterval: time.Hour,
+	}
+	os.MkdirAll(filepath.Join(tmpDir, "data"), 0755)
+
+	logger, _ := logger.New(false, true, "")
+	watcher := NewFileWatcher(cfg, logger)
+	watcher.debounce = 200 * time.Millisecond
+	if err := watcher.Start(); err != nil {
+		t.Fatalf("failed to start watcher: %v", err)
+	}
+	defer watcher.Stop()
+
+	// Write the file in two halves, the second within the debounce interval
+	upgradeFile := cfg.UpgradeInfoFilePath()
+	content := `{"name": "v7.0.0", "height": 5000000}`
+	file, err := os.Create(upgradeFile)
+	if err != nil {
+		t.Fatalf("failed to create upgrade file: %v", err)
+	}
+	file.WriteString(content[:10])
+	time.Sleep(50 * time.Millisecond)
+	file.WriteString(content[10:])
+	file.Close()
+
+	current := waitUpdated(t, watcher, time.Second)
+	if current == nil || current.Name != "v7.0.0" {
+		t.Errorf("expected v7.0.0 upgrade to be detected, got %v", current)
+	}
+
+	// Rewriting the same upgrade is not a new upgrade
+	watcher.ClearUpdateFlag()
+	os.WriteFile(upgradeFile, []byte(content+"\n"), 0644)
+	time.Sleep(400 * time.Millisecond)
+	if watcher.NeedsUpdate() {
+		t.Error("expected no update when the upgrade has not changed")
+	}
+}
+
+func TestFileWatcherPollsUntilDirectoryExists(t *testing.T) {
+	tmpDir := t.TempDir()
+	cfg := &config.Config{
+		Home:         tmpDir,
+		PollInterval: 50 * time.Millisecond,
+	}
+
+	logger, _ := logger.New(false, true, "")
+	watcher := NewFileWatcher(cfg, logger)
+	if err := watcher.Start(); err != nil {
+		t.Fatalf("failed to start watcher: %v", err)
+	}
+	defer watcher.Stop()
+
+	// The data directory appears after the watcher started
+	time.Sleep(100 * time.Millisecond)
+	os.MkdirAll(filepath.Join(tmpDir, "data"), 0755)
+	time.Sleep(150 * time.Millisecond)
+
+	info := &types.UpgradeInfo{Name: "v8.0.0", Height: 6000000}
+	if err := types.WriteUpgradeInfoFile(cfg.UpgradeInfoFilePath(), info); err != nil {
+		t.Fatalf("failed to write upgrade file: %v", err)
+	}
+
+	current := waitUpdated(t, watcher, time.Second)
+	if current == nil || current.Name != "v8.0.0" {
+		t.Errorf("expected v8.0.0 upgrade to be detected, got %v", current)
+	}
+}
@@ -0,0 +1,236 @@
+package version
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/pkg/fsutil"
+	"github.com/wemix/wemixvisor/pkg/logger"
+)
+
+// FailureRetryInterval is how long a binary that could not report its
+// version is left alone before it is run again
+const FailureRetryInterval = time.Minute
+
+// caches holds one Cache per cache file, so that every component of a
+// supervisor shares the entries and the file
+var caches sync.Map
+
+// cacheFile is the on-disk layout of the version cache
+type cacheFile struct {
+	Binaries map[string]*Info `json:"binaries"`
+}
+
+// fileStamp remembers the hash of a binary until its size or modification
+// time changes, so an unchanged binary is not hashed again
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+	hash    string
+}
+
+// failure is a version probe that failed
+type failure struct {
+	err  error
+	time time.Time
+}
+
+// Cache resolves binary versions, running each distinct binary once. It is
+// safe for concurrent use.
+type Cache struct {
+	path    string
+	timeout time.Duration
+	logger  *logger.Logger
+
+	mu       sync.Mutex
+	loaded   bool
+	entries  map[string]*Info // by SHA-256 of the binary
+	failures map[string]failure
+	probing  map[string]chan struct{} // closed when the probe ends
+	stamps   map[string]fileStamp     // by binary path
+}
+
+// NewCache returns the version cache stored under the wemixvisor directory
+// of the given configuration
+func NewCache(cfg *config.Config, log *logger.Logger) *Cache {
+	path := cfg.VersionCachePath()
+	cache, _ := caches.LoadOrStore(path, newCache(path, log))
+	return cache.(*Cache)
+}
+
+// newCache creates an unshared cache backed by the file at path
+func newCache(path string, log *logger.Logger) *Cache {
+	return &Cache{
+		path:     path,
+		timeout:  DefaultProbeTimeout,
+		logger:   log,
+		entries:  make(map[string]*Info),
+		failures: make(map[string]failure),
+		probing:  make(map[string]chan struct{}),
+		stamps:   make(map[string]fileStamp),
+	}
+}
+
+// Lookup returns the version of the binary at path, following symlinks. The
+// binary is only run if no binary with the same content was seen before.
+func (c *Cache) Lookup(binary string) (*Info, error) {
+	resolved, err := filepath.EvalSymlinks(binary)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve binary: %w", err)
+	}
+
+	c.mu.Lock()
+	c.load()
+
+	hash, err := c.hash(resolved)
+	if err != nil {
+		c.mu.Unlock()
+		return nil, err
+	}
+
+	for {
+		if info, ok := c.entries[hash]; ok {
+			c.mu.Unlock()
+			return info, nil
+		}
+		if f, ok := c.failures[hash]; ok && time.Since(f.time) < FailureRetryInterval {
+			c.mu.Unlock()
+			return nil, f.err
+		}
+
+		// Wait for a lookup already running the same binary
+		done, ok := c.probing[hash]
+		if !ok {
+			break
+		}
+		c.mu.Unlock()
+		<-done
+		c.mu.Lock()
+	}
+
+	done := make(chan struct{})
+	c.probing[hash] = done
+	c.mu.Unlock()
+
+	// The binary runs without holding mu, so that a slow binary does not
+	// block lookups of other binaries
+	info, err := Probe(resolved, c.timeout)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.probing, hash)
+	close(done)
+
+	if err != nil {
+		c.failures[hash] = failure{err: err, time: time.Now()}
+		return nil, err
+	}
+	delete(c.failures, hash)
+
+	info.SHA256 = hash
+	info.ProbedAt = time.Now()
+	c.entries[hash] = info
+
+	if err := c.save(); err != nil {
+		c.logger.Warn("failed to save version cache", zap.Error(err))
+	}
+
+	c.logger.Info("resolved binary version",
+		zap.String("binary", resolved),
+		zap.String("version", info.Raw),
+		zap.String("sha256", hash))
+
+	return info, nil
+}
+
+// Hash returns the SHA-256 of the binary at path, following symlinks
+func (c *Cache) Hash(binary string) (string, error) {
+	resolved, err := filepath.EvalSymlinks(binary)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve binary: %w", err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hash(resolved)
+}
+
+// hash returns the SHA-256 of the file at path, reusing the previous hash
+// while the file is unchanged. Caller must hold mu.
+func (c *Cache) hash(path string) (string, error) {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to stat binary: %w", err)
+	}
+
+	if stamp, ok := c.stamps[path]; ok && stamp.size == stat.Size() && stamp.modTime.Equal(stat.ModTime()) {
+		return stamp.hash, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open binary: %w", err)
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", fmt.Errorf("failed to hash binary: %w", err)
+	}
+	hash := hex.EncodeToString(h.Sum(nil))
+
+	c.stamps[path] = fileStamp{size: stat.Size(), modTime: stat.ModTime(), hash: hash}
+	return hash, nil
+}
+
+// load reads the cache file once. A missing or unreadable file leaves the
+// cache empty, to be rebuilt by probing. Caller must hold mu.
+func (c *Cache) load() {
+	if c.loaded {
+		return
+	}
+	c.loaded = true
+
+	data, err := os.ReadFile(c.path)
+	if err != nil {
+		return
+	}
+
+	var file cacheFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		c.logger.Warn("ignoring unreadable version cache", zap.String("path", c.path), zap.Error(err))
+		return
+	}
+	for hash, info := range file.Binaries {
+		if info != nil {
+			c.entries[hash] = info
+		}
+	}
+}
+
+// save atomically writes the cache file. Caller must hold mu.
+func (c *Cache) save() error {
+	data, err := json.MarshalIndent(&cacheFile{Binaries: c.entries}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal version cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.path), 0755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	if err := fsutil.WriteFileAtomic(c.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to save version cache: %w", err)
+	}
+	return nil
+}
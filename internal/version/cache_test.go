@@ -0,0 +1,158 @@
+package version
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/wemix/wemixvisor/internal/config"
+	"github.com/wemix/wemixvisor/pkg/logger"
+)
+
+// writeCountingBinary writes a binary that reports version and records
+// each invocation in calls.log next to it
+func writeCountingBinary(t *testing.T, path, version string) {
+	t.Helper()
+	script := fmt.Sprintf(`#!/bin/sh
+echo run >> "$(dirname "$0")/calls.log"
+echo "wemixd %s"
+`, version)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(script), 0755))
+}
+
+// probeCount returns how often the binary in dir was run
+func probeCount(t *testing.T, dir string) int {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(dir, "calls.log"))
+	if os.IsNotExist(err) {
+		return 0
+	}
+	require.NoError(t, err)
+	return strings.Count(string(data), "run")
+}
+
+func TestCache_LookupRunsBinaryOnce(t *testing.T) {
+	cfg := &config.Config{Home: t.TempDir(), Name: "wemixd"}
+	binary := filepath.Join(t.TempDir(), "bin", "wemixd")
+	writeCountingBinary(t, binary, "v1.0.0")
+
+	cache := NewCache(cfg, logger.NewTestLogger())
+	for i := 0; i < 3; i++ {
+		info, err := cache.Lookup(binary)
+		require.NoError(t, err)
+		assert.Equal(t, "wemixd v1.0.0", info.Raw)
+		assert.Equal(t, "1.0.0", info.Semver)
+		assert.Len(t, info.SHA256, 64)
+	}
+	assert.Equal(t, 1, probeCount(t, filepath.Dir(binary)))
+
+	// Components created from the same configuration share the cache
+	assert.Same(t, cache, NewCache(cfg, logger.NewTestLogger()))
+}
+
+func TestCache_ChangedBinaryIsProbedAgain(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "bin", "wemixd")
+	writeCountingBinary(t, binary, "v1.0.0")
+
+	cache := newCache(filepath.Join(dir, "versions.json"), logger.NewTestLogger())
+	first, err := cache.Lookup(binary)
+	require.NoError(t, err)
+
+	writeCountingBinary(t, binary, "v1.10.0")
+	second, err := cache.Lookup(binary)
+	require.NoError(t, err)
+
+	assert.Equal(t, "1.10.0", second.Semver)
+	assert.NotEqual(t, first.SHA256, second.SHA256)
+	assert.Equal(t, 2, probeCount(t, filepath.Dir(binary)))
+}
+
+func TestCache_LookupFollowsSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "upgrades", "v1", "wemixd")
+	writeCountingBinary(t, binary, "v1.0.0")
+	link := filepath.Join(dir, "current")
+	require.NoError(t, os.Symlink(binary, link))
+
+	cache := newCache(filepath.Join(dir, "versions.json"), logger.NewTestLogger())
+	viaLink, err := cache.Lookup(link)
+	require.NoError(t, err)
+	direct, err := cache.Lookup(binary)
+	require.NoError(t, err)
+
+	assert.Same(t, viaLink, direct)
+	assert.Equal(t, 1, probeCount(t, filepath.Dir(binary)))
+}
+
+func TestCache_PersistsAcrossRestarts(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "versions.json")
+	binary := filepath.Join(dir, "bin", "wemixd")
+	writeCountingBinary(t, binary, "v1.0.0")
+
+	_, err := newCache(path, logger.NewTestLogger()).Lookup(binary)
+	require.NoError(t, err)
+	require.FileExists(t, path)
+
+	// A new supervisor finds the version without running the binary
+	info, err := newCache(path, logger.NewTestLogger()).Lookup(binary)
+	require.NoError(t, err)
+	assert.Equal(t, "1.0.0", info.Semver)
+	assert.Equal(t, 1, probeCount(t, filepath.Dir(binary)))
+}
+
+func TestCache_FailedProbeIsNotRepeated(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "bin", "wemixd")
+	require.NoError(t, os.MkdirAll(filepath.Dir(binary), 0755))
+	script := "#!/bin/sh\necho run >> \"$(dirname \"$0\")/calls.log\"\nexit 1\n"
+	require.NoError(t, os.WriteFile(binary, []byte(script), 0755))
+
+	cache := newCache(filepath.Join(dir, "versions.json"), logger.NewTestLogger())
+	_, err := cache.Lookup(binary)
+	require.Error(t, err)
+	_, err = cache.Lookup(binary)
+	require.Error(t, err)
+
+	// All probe arguments are tried once, then the failure is remembered
+	assert.Equal(t, len(probeArgs), probeCount(t, filepath.Dir(binary)))
+}
+
+func TestCache_LookupMissingBinary(t *testing.T) {
+	cache := newCache(filepath.Join(t.TempDir(), "versions.json"), logger.NewTestLogger())
+	_, err := cache.Lookup("/nonexistent/wemixd")
+	assert.Error(t, err)
+}
+
+func TestCache_SlowProbeDoesNotBlockOtherBinaries(t *testing.T) {
+	dir := t.TempDir()
+	slow := filepath.Join(dir, "slow", "wemixd")
+	require.NoError(t, os.MkdirAll(filepath.Dir(slow), 0755))
+	require.NoError(t, os.WriteFile(slow, []byte("#!/bin/sh\nsleep 2\necho \"wemixd v1.0.0\"\n"), 0755))
+	fast := filepath.Join(dir, "fast", "wemixd")
+	writeCountingBinary(t, fast, "v2.0.0")
+
+	cache := newCache(filepath.Join(dir, "versions.json"), logger.NewTestLogger())
+
+	probed := make(chan error, 1)
+	go func() {
+		_, err := cache.Lookup(slow)
+		probed <- err
+	}()
+	time.Sleep(200 * time.Millisecond)
+
+	start := time.Now()
+	info, err := cache.Lookup(fast)
+	require.NoError(t, err)
+	assert.Equal(t, "wemixd v2.0.0", info.Raw)
+	assert.Less(t, time.Since(start), time.Second, "lookup waited for the slow probe")
+
+	require.NoError(t, <-probed)
+}
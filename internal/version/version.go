@@ -0,0 +1,119 @@
+// Package version identifies node binaries. A binary is run to report its
+// version once per content hash; the result is cached in memory and on
+// disk, so status polling and upgrade checks do not fork the binary again.
+package version
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultProbeTimeout bounds all attempts to run a binary for its version
+	DefaultProbeTimeout = 5 * time.Second
+	// ProbeWaitDelay bounds the wait for the output of a probed binary once
+	// it has exited or was killed
+	ProbeWaitDelay = time.Second
+)
+
+// probeArgs are the version invocations tried in order
+var probeArgs = [][]string{
+	{"version"},
+	{"--version"},
+	{"-version"},
+	{"-v"},
+}
+
+var (
+	versionLineRe = regexp.MustCompile(`(?im)^\s*version\s*:\s*v?(\d+\.\d+\.\d+[0-9A-Za-z.+-]*)`)
+	semverRe      = regexp.MustCompile(`\bv?(\d+\.\d+\.\d+(?:-[0-9A-Za-z.-]+)?(?:\+[0-9A-Za-z.-]+)?)`)
+	commitRe      = regexp.MustCompile(`(?i)commit\s*(?:hash)?\s*[:=]?\s*([0-9a-f]{7,40})\b`)
+	goVersionRe   = regexp.MustCompile(`\bgo(\d+\.\d+(?:\.\d+)?(?:[a-z]+\d*)?)\b`)
+)
+
+// Info is the version reported by a node binary
+type Info struct {
+	// Raw is the first line of the version output
+	Raw       string    `json:"raw"`
+	Semver    string    `json:"semver,omitempty"`
+	Commit    string    `json:"commit,omitempty"`
+	GoVersion string    `json:"go_version,omitempty"`
+	SHA256    string    `json:"sha256"`
+	ProbedAt  time.Time `json:"probed_at"`
+}
+
+// String returns the version as reported by the binary
+func (i *Info) String() string {
+	return i.Raw
+}
+
+// Parse extracts the version fields from the output of a version command
+func Parse(output string) *Info {
+	output = strings.TrimSpace(output)
+	info := &Info{}
+	if output == "" {
+		return info
+	}
+
+	info.Raw = strings.TrimSpace(strings.SplitN(output, "\n", 2)[0])
+
+	if m := goVersionRe.FindStringSubmatch(output); m != nil {
+		info.GoVersion = "go" + m[1]
+	}
+	if m := commitRe.FindStringSubmatch(output); m != nil {
+		info.Commit = m[1]
+	}
+
+	// An explicit "Version:" line wins over any other version-like text,
+	// which could be the Go version
+	if m := versionLineRe.FindStringSubmatch(output); m != nil {
+		info.Semver = m[1]
+	} else if m := semverRe.FindStringSubmatch(goVersionRe.ReplaceAllString(output, "")); m != nil {
+		info.Semver = m[1]
+	}
+
+	return info
+}
+
+// Probe runs the binary with the usual version arguments and parses the
+// first non-empty output
+func Probe(binary string, timeout time.Duration) (*Info, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for _, args := range probeArgs {
+		output, err := probeCommand(ctx, binary, args).Output()
+		// Output still held open by children of an exited binary is cut
+		// off after the wait delay; what was read is good
+		if err == nil || errors.Is(err, exec.ErrWaitDelay) {
+			if info := Parse(string(output)); info.Raw != "" {
+				return info, nil
+			}
+		}
+
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("timeout getting version: %w", ctx.Err())
+		}
+	}
+
+	return nil, fmt.Errorf("unable to determine binary version")
+}
+
+// probeCommand returns the command running binary with args in its own
+// process group. When ctx is done the whole group is killed, and output
+// still held open by children is not waited on past ProbeWaitDelay.
+func probeCommand(ctx context.Context, binary string, args []string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, binary, args...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	cmd.WaitDelay = ProbeWaitDelay
+	return cmd
+}
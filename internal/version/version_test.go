@@ -0,0 +1,105 @@
+package version
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		output string
+		want   Info
+	}{
+		{
+			name: "geth style",
+			output: `Wemix
+Version: 0.10.7-stable
+Git Commit: 1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b
+Architecture: amd64
+Go Version: go1.21.5
+Operating System: linux`,
+			want: Info{
+				Raw:       "Wemix",
+				Semver:    "0.10.7-stable",
+				Commit:    "1a2b3c4d5e6f7a8b9c0d1e2f3a4b5c6d7e8f9a0b",
+				GoVersion: "go1.21.5",
+			},
+		},
+		{
+			name:   "single line",
+			output: "wemixd version v2.0.0-rc1 (commit abc1234, go1.22.1)\n",
+			want: Info{
+				Raw:       "wemixd version v2.0.0-rc1 (commit abc1234, go1.22.1)",
+				Semver:    "2.0.0-rc1",
+				Commit:    "abc1234",
+				GoVersion: "go1.22.1",
+			},
+		},
+		{
+			name:   "go version only",
+			output: "custom build go1.21.0",
+			want:   Info{Raw: "custom build go1.21.0", GoVersion: "go1.21.0"},
+		},
+		{
+			name:   "free text",
+			output: "test",
+			want:   Info{Raw: "test"},
+		},
+		{
+			name:   "empty",
+			output: "  \n",
+			want:   Info{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, *Parse(tt.output))
+		})
+	}
+}
+
+func TestProbe(t *testing.T) {
+	binary := filepath.Join(t.TempDir(), "wemixd")
+	script := `#!/bin/sh
+if [ "$1" = "-v" ]; then
+  echo "wemixd 1.4.0"
+  exit 0
+fi
+exit 1
+`
+	require.NoError(t, os.WriteFile(binary, []byte(script), 0755))
+
+	info, err := Probe(binary, time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, "wemixd 1.4.0", info.Raw)
+	assert.Equal(t, "1.4.0", info.Semver)
+}
+
+func TestProbe_NoVersion(t *testing.T) {
+	binary := filepath.Join(t.TempDir(), "wemixd")
+	require.NoError(t, os.WriteFile(binary, []byte("#!/bin/sh\nexit 1\n"), 0755))
+
+	_, err := Probe(binary, time.Second)
+	assert.Error(t, err)
+}
+
+func TestProbe_KillsChildrenHoldingOutput(t *testing.T) {
+	binary := filepath.Join(t.TempDir(), "wemixd")
+	script := `#!/bin/sh
+sleep 20 &
+sleep 20
+`
+	require.NoError(t, os.WriteFile(binary, []byte(script), 0755))
+
+	start := time.Now()
+	_, err := Probe(binary, 500*time.Millisecond)
+	assert.Error(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond+2*ProbeWaitDelay)
+}
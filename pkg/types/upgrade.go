@@ -4,14 +4,84 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 	"time"
+
+	"github.com/wemix/wemixvisor/pkg/fsutil"
 )
 
-// UpgradeInfo represents upgrade information
+// UpgradeInfo represents upgrade information.
+//
+// An upgrade activates at a block height, a wall-clock time, a block
+// timestamp, or whichever of the declared conditions is reached first.
+// Time is compared with the host clock, BlockTime with the timestamp of
+// the latest block so that every node activates on the same block.
 type UpgradeInfo struct {
-	Name   string                 `json:"name"`
-	Height int64                  `json:"height"`
-	Info   map[string]interface{} `json:"info,omitempty"`
+	Name      string                 `json:"name"`
+	Height    int64                  `json:"height"`
+	Time      *time.Time             `json:"time,omitempty"`
+	BlockTime *time.Time             `json:"block_time,omitempty"`
+	Info      map[string]interface{} `json:"info,omitempty"`
+}
+
+// Validate checks that the upgrade has a name and at least one activation
+// condition
+func (info *UpgradeInfo) Validate() error {
+	if info.Name == "" {
+		return fmt.Errorf("upgrade name is empty")
+	}
+
+	if info.Height < 0 {
+		return fmt.Errorf("upgrade height must be positive")
+	}
+
+	if info.Height == 0 && !info.HasTime() {
+		return fmt.Errorf("upgrade height must be positive when no activation time is set")
+	}
+
+	return nil
+}
+
+// HasTime reports whether the upgrade declares a wall-clock or block
+// timestamp activation condition
+func (info *UpgradeInfo) HasTime() bool {
+	return (info.Time != nil && !info.Time.IsZero()) ||
+		(info.BlockTime != nil && !info.BlockTime.IsZero())
+}
+
+// Reached reports whether any declared activation condition holds at the
+// given height, latest block timestamp and host time. A zero blockTime
+// means the block timestamp is unknown and never satisfies BlockTime.
+func (info *UpgradeInfo) Reached(height int64, blockTime, now time.Time) bool {
+	if info.Height > 0 && height >= info.Height {
+		return true
+	}
+
+	if info.Time != nil && !info.Time.IsZero() && !now.Before(*info.Time) {
+		return true
+	}
+
+	if info.BlockTime != nil && !info.BlockTime.IsZero() &&
+		!blockTime.IsZero() && !blockTime.Before(*info.BlockTime) {
+		return true
+	}
+
+	return false
+}
+
+// Condition describes the activation conditions of the upgrade
+func (info *UpgradeInfo) Condition() string {
+	var conditions []string
+	if info.Height > 0 {
+		conditions = append(conditions, fmt.Sprintf("height %d", info.Height))
+	}
+	if info.Time != nil && !info.Time.IsZero() {
+		conditions = append(conditions, "time "+info.Time.Format(time.RFC3339))
+	}
+	if info.BlockTime != nil && !info.BlockTime.IsZero() {
+		conditions = append(conditions, "block time "+info.BlockTime.Format(time.RFC3339))
+	}
+	return strings.Join(conditions, " or ")
 }
 
 // UpgradePlan represents a planned upgrade
@@ -29,36 +99,70 @@ func ParseUpgradeInfoFile(filename string) (*UpgradeInfo, error) {
 		return nil, fmt.Errorf("failed to read upgrade info file: %w", err)
 	}
 
+	return ParseUpgradeInfo(data)
+}
+
+// ParseUpgradeInfo parses the contents of an upgrade-info.json file
+func ParseUpgradeInfo(data []byte) (*UpgradeInfo, error) {
 	var info UpgradeInfo
 	if err := json.Unmarshal(data, &info); err != nil {
 		return nil, fmt.Errorf("failed to parse upgrade info: %w", err)
 	}
 
-	if info.Name == "" {
-		return nil, fmt.Errorf("upgrade name is empty")
-	}
-
-	if info.Height <= 0 {
-		return nil, fmt.Errorf("upgrade height must be positive")
+	if err := info.Validate(); err != nil {
+		return nil, err
 	}
 
 	return &info, nil
 }
 
-// WriteUpgradeInfoFile writes upgrade info to file
+// WriteUpgradeInfoFile writes upgrade info to file. The file is written
+// next to its destination and renamed over it, so that a watcher never
+// reads it half-written.
 func WriteUpgradeInfoFile(filename string, info *UpgradeInfo) error {
 	data, err := json.MarshalIndent(info, "", "  ")
 	if err != nil {
 		return fmt.Errorf("failed to marshal upgrade info: %w", err)
 	}
 
-	if err := os.WriteFile(filename, data, 0644); err != nil {
+	if err := fsutil.WriteFileAtomic(filename, data, 0644); err != nil {
 		return fmt.Errorf("failed to write upgrade info file: %w", err)
 	}
 
 	return nil
 }
 
+// ParseUpgradeInfoList reads a JSON list of upgrades, such as the upgrades
+// announced by governance
+func ParseUpgradeInfoList(filename string) ([]*UpgradeInfo, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upgrade list: %w", err)
+	}
+
+	var infos []*UpgradeInfo
+	if err := json.Unmarshal(data, &infos); err != nil {
+		return nil, fmt.Errorf("failed to parse upgrade list: %w", err)
+	}
+	return infos, nil
+}
+
+// WriteUpgradeInfoList atomically writes a JSON list of upgrades
+func WriteUpgradeInfoList(filename string, infos []*UpgradeInfo) error {
+	if infos == nil {
+		infos = []*UpgradeInfo{}
+	}
+	data, err := json.MarshalIndent(infos, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal upgrade list: %w", err)
+	}
+
+	if err := fsutil.WriteFileAtomic(filename, data, 0644); err != nil {
+		return fmt.Errorf("failed to write upgrade list: %w", err)
+	}
+	return nil
+}
+
 // BinaryInfo contains information about binaries for different platforms
 type BinaryInfo struct {
 	Binaries map[string]string `json:"binaries"`
@@ -6,9 +6,12 @@ import (
 	"path/filepath"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestParseUpgradeInfoFile(t *testing.T) {
+	activation := time.Date(2026, 1, 2, 15, 4, 5, 0, time.UTC)
+
 	tests := []struct {
 		name    string
 		content string
@@ -61,6 +64,38 @@ func TestParseUpgradeInfoFile(t *testing.T) {
 			}`,
 			wantErr: true,
 		},
+		{
+			name: "time without height",
+			content: `{
+				"name": "v2.0.0",
+				"time": "2026-01-02T15:04:05Z"
+			}`,
+			wantErr: false,
+			want: &UpgradeInfo{
+				Name: "v2.0.0",
+				Time: &activation,
+			},
+		},
+		{
+			name: "block time without height",
+			content: `{
+				"name": "v2.0.0",
+				"block_time": "2026-01-02T15:04:05Z"
+			}`,
+			wantErr: false,
+			want: &UpgradeInfo{
+				Name:      "v2.0.0",
+				BlockTime: &activation,
+			},
+		},
+		{
+			name: "zero time without height",
+			content: `{
+				"name": "v2.0.0",
+				"time": "0001-01-01T00:00:00Z"
+			}`,
+			wantErr: true,
+		},
 		{
 			name:    "invalid json",
 			content: `{invalid json}`,
@@ -106,11 +141,67 @@ func TestParseUpgradeInfoFile(t *testing.T) {
 				if got.Height != tt.want.Height {
 					t.Errorf("Height = %v, want %v", got.Height, tt.want.Height)
 				}
+				if !equalTime(got.Time, tt.want.Time) {
+					t.Errorf("Time = %v, want %v", got.Time, tt.want.Time)
+				}
+				if !equalTime(got.BlockTime, tt.want.BlockTime) {
+					t.Errorf("BlockTime = %v, want %v", got.BlockTime, tt.want.BlockTime)
+				}
+			}
+		})
+	}
+}
+
+// equalTime reports whether a and b are both unset or the same instant
+func equalTime(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+func TestUpgradeInfoReached(t *testing.T) {
+	activation := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	before := activation.Add(-time.Minute)
+	after := activation.Add(time.Minute)
+
+	tests := []struct {
+		name      string
+		info      UpgradeInfo
+		height    int64
+		blockTime time.Time
+		now       time.Time
+		want      bool
+	}{
+		{"height not reached", UpgradeInfo{Height: 100}, 99, after, after, false},
+		{"height reached", UpgradeInfo{Height: 100}, 100, time.Time{}, before, true},
+		{"time not reached", UpgradeInfo{Time: &activation}, 1000, after, before, false},
+		{"time reached", UpgradeInfo{Time: &activation}, 0, time.Time{}, activation, true},
+		{"block time not reached", UpgradeInfo{BlockTime: &activation}, 1000, before, after, false},
+		{"block time reached", UpgradeInfo{BlockTime: &activation}, 0, after, before, true},
+		{"block time unknown", UpgradeInfo{BlockTime: &activation}, 0, time.Time{}, after, false},
+		{"either condition", UpgradeInfo{Height: 100, Time: &activation}, 50, time.Time{}, after, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.info.Reached(tt.height, tt.blockTime, tt.now); got != tt.want {
+				t.Errorf("Reached() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
+func TestUpgradeInfoCondition(t *testing.T) {
+	activation := time.Date(2026, 1, 2, 15, 0, 0, 0, time.UTC)
+	info := &UpgradeInfo{Height: 100, BlockTime: &activation}
+
+	want := "height 100 or block time 2026-01-02T15:00:00Z"
+	if got := info.Condition(); got != want {
+		t.Errorf("Condition() = %q, want %q", got, want)
+	}
+}
+
 func TestParseUpgradeInfoFileNotExist(t *testing.T) {
 	tmpFile := filepath.Join(t.TempDir(), "non-existent.json")
 	_, err := ParseUpgradeInfoFile(tmpFile)